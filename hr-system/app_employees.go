@@ -20,6 +20,12 @@ type EmployeeListResponse struct {
 	Data    bootstrap.EmployeeListResult `json:"data"`
 }
 
+type SalaryHistoryResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    []bootstrap.SalaryHistoryView `json:"data"`
+}
+
 type DepartmentListResponse struct {
 	Success bool                         `json:"success"`
 	Message string                       `json:"message"`
@@ -30,11 +36,12 @@ func (a *App) CreateEmployee(accessToken string, input bootstrap.EmployeeInput)
 	if a.employees == nil || a.auth == nil {
 		return EmployeeResponse{}, fmt.Errorf("employee service unavailable")
 	}
-	if _, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer"); err != nil {
+	actor, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer")
+	if err != nil {
 		return EmployeeResponse{}, errors.New(formatEmployeeError(err))
 	}
 
-	employee, err := a.employees.CreateEmployee(a.ctx, input)
+	employee, err := a.employees.CreateEmployee(a.ctx, actor, input)
 	if err != nil {
 		return EmployeeResponse{}, errors.New(formatEmployeeError(err))
 	}
@@ -50,11 +57,12 @@ func (a *App) UpdateEmployee(accessToken string, employeeID int64, input bootstr
 	if a.employees == nil || a.auth == nil {
 		return EmployeeResponse{}, fmt.Errorf("employee service unavailable")
 	}
-	if _, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer"); err != nil {
+	actor, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer")
+	if err != nil {
 		return EmployeeResponse{}, errors.New(formatEmployeeError(err))
 	}
 
-	employee, err := a.employees.UpdateEmployee(a.ctx, employeeID, input)
+	employee, err := a.employees.UpdateEmployee(a.ctx, actor, employeeID, input)
 	if err != nil {
 		return EmployeeResponse{}, errors.New(formatEmployeeError(err))
 	}
@@ -120,6 +128,28 @@ func (a *App) ListEmployees(accessToken string, query bootstrap.EmployeeListQuer
 	}, nil
 }
 
+// ListEmployeeSalaryHistory is open to Finance Officers as well, since payroll
+// is generated from this history.
+func (a *App) ListEmployeeSalaryHistory(accessToken string, employeeID int64) (SalaryHistoryResponse, error) {
+	if a.employees == nil || a.auth == nil {
+		return SalaryHistoryResponse{}, fmt.Errorf("employee service unavailable")
+	}
+	if _, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer", "Finance Officer"); err != nil {
+		return SalaryHistoryResponse{}, errors.New(formatEmployeeError(err))
+	}
+
+	result, err := a.employees.ListSalaryHistory(a.ctx, employeeID)
+	if err != nil {
+		return SalaryHistoryResponse{}, errors.New(formatEmployeeError(err))
+	}
+
+	return SalaryHistoryResponse{
+		Success: true,
+		Message: "salary history fetched",
+		Data:    result,
+	}, nil
+}
+
 func (a *App) ListEmployeeDepartments(accessToken string) (DepartmentListResponse, error) {
 	if a.employees == nil || a.auth == nil {
 		return DepartmentListResponse{}, fmt.Errorf("employee service unavailable")
@@ -150,6 +180,8 @@ func formatEmployeeError(err error) string {
 		return "account inactive"
 	case bootstrap.IsEmployeeInvalidInput(err):
 		return "invalid employee input"
+	case bootstrap.IsSalaryReasonRequired(err):
+		return "salary change reason is required"
 	case bootstrap.IsDepartmentNotFound(err):
 		return "department not found"
 	case bootstrap.IsEmployeeNotFound(err):
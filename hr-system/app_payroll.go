@@ -38,8 +38,236 @@ type PayrollCSVResponse struct {
 	Data    string `json:"data"`
 }
 
+type PayrollFileResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    bootstrap.PayrollFile `json:"data"`
+}
+
+type PayrollEmployeeEntryListResponse struct {
+	Success bool                             `json:"success"`
+	Message string                           `json:"message"`
+	Data    []bootstrap.PayrollEmployeeEntry `json:"data"`
+}
+
+type PayrollBankFormatListResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    []bootstrap.PayrollBankFormat `json:"data"`
+}
+
+type PayrollBankValidationResponse struct {
+	Success bool                                   `json:"success"`
+	Message string                                 `json:"message"`
+	Data    []bootstrap.PayrollBankValidationIssue `json:"data"`
+}
+
+type PayrollSettingsResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    bootstrap.PayrollSettings `json:"data"`
+}
+
+type PayrollComponentListResponse struct {
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+	Data    []bootstrap.PayrollComponent `json:"data"`
+}
+
+type PayrollComponentResponse struct {
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+	Data    bootstrap.PayrollComponent `json:"data"`
+}
+
+type PayrollTaxTableListResponse struct {
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    []bootstrap.PayrollTaxTable `json:"data"`
+}
+
+type PayrollTaxTableResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    bootstrap.PayrollTaxTable `json:"data"`
+}
+
+type PayrollContributionSchemeListResponse struct {
+	Success bool                                  `json:"success"`
+	Message string                                `json:"message"`
+	Data    []bootstrap.PayrollContributionScheme `json:"data"`
+}
+
+type PayrollContributionSchemeResponse struct {
+	Success bool                                `json:"success"`
+	Message string                              `json:"message"`
+	Data    bootstrap.PayrollContributionScheme `json:"data"`
+}
+
+type PayrollContributionMemberListResponse struct {
+	Success bool                                  `json:"success"`
+	Message string                                `json:"message"`
+	Data    []bootstrap.PayrollContributionMember `json:"data"`
+}
+
+type PayrollContributionMemberResponse struct {
+	Success bool                                `json:"success"`
+	Message string                              `json:"message"`
+	Data    bootstrap.PayrollContributionMember `json:"data"`
+}
+
+type PayrollRemittanceScheduleResponse struct {
+	Success bool                                `json:"success"`
+	Message string                              `json:"message"`
+	Data    bootstrap.PayrollRemittanceSchedule `json:"data"`
+}
+
+type PayrollImportResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    bootstrap.PayrollImportResult `json:"data"`
+}
+
+type PayrollApprovalStageListResponse struct {
+	Success bool                             `json:"success"`
+	Message string                           `json:"message"`
+	Data    []bootstrap.PayrollApprovalStage `json:"data"`
+}
+
+type PayrollRecurringItemListResponse struct {
+	Success bool                             `json:"success"`
+	Message string                           `json:"message"`
+	Data    []bootstrap.PayrollRecurringItem `json:"data"`
+}
+
+type PayrollRecurringItemResponse struct {
+	Success bool                           `json:"success"`
+	Message string                         `json:"message"`
+	Data    bootstrap.PayrollRecurringItem `json:"data"`
+}
+
+type PayrollLoanListResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []bootstrap.PayrollLoan `json:"data"`
+}
+
+type PayrollLoanResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    bootstrap.PayrollLoan `json:"data"`
+}
+
+type PayrollLoanDetailResponse struct {
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    bootstrap.PayrollLoanDetail `json:"data"`
+}
+
+type PayrollLoanBalanceReportResponse struct {
+	Success bool                               `json:"success"`
+	Message string                             `json:"message"`
+	Data    bootstrap.PayrollLoanBalanceReport `json:"data"`
+}
+
+type PayrollYTDTotalsResponse struct {
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+	Data    []bootstrap.PayrollYTDTotals `json:"data"`
+}
+
+type PayrollAnnualCertificateResponse struct {
+	Success bool                               `json:"success"`
+	Message string                             `json:"message"`
+	Data    bootstrap.PayrollAnnualCertificate `json:"data"`
+}
+
+type PayrollAnnualReturnResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    bootstrap.PayrollAnnualReturn `json:"data"`
+}
+
+type PayrollProjectListResponse struct {
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+	Data    []bootstrap.PayrollProject `json:"data"`
+}
+
+type PayrollProjectResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    bootstrap.PayrollProject `json:"data"`
+}
+
+type PayrollCostAllocationListResponse struct {
+	Success bool                              `json:"success"`
+	Message string                            `json:"message"`
+	Data    []bootstrap.PayrollCostAllocation `json:"data"`
+}
+
+type PayrollCostAllocationReportResponse struct {
+	Success bool                                  `json:"success"`
+	Message string                                `json:"message"`
+	Data    bootstrap.PayrollCostAllocationReport `json:"data"`
+}
+
+type PayrollGLAccountListResponse struct {
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+	Data    []bootstrap.PayrollGLAccount `json:"data"`
+}
+
+type PayrollJournalLayoutResponse struct {
+	Success bool                           `json:"success"`
+	Message string                         `json:"message"`
+	Data    bootstrap.PayrollJournalLayout `json:"data"`
+}
+
+type PayrollJournalResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    bootstrap.PayrollJournal `json:"data"`
+}
+
+type PayrollExchangeRateListResponse struct {
+	Success bool                            `json:"success"`
+	Message string                          `json:"message"`
+	Data    []bootstrap.PayrollExchangeRate `json:"data"`
+}
+
+type PayrollExchangeRateResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    bootstrap.PayrollExchangeRate `json:"data"`
+}
+
+type PayrollCurrencyReportResponse struct {
+	Success bool                            `json:"success"`
+	Message string                          `json:"message"`
+	Data    bootstrap.PayrollCurrencyReport `json:"data"`
+}
+
+type PayrollBatchVerificationResponse struct {
+	Success bool                               `json:"success"`
+	Message string                             `json:"message"`
+	Data    bootstrap.PayrollBatchVerification `json:"data"`
+}
+
+type PayrollSimulationResponse struct {
+	Success bool                              `json:"success"`
+	Message string                            `json:"message"`
+	Data    bootstrap.PayrollSimulationReport `json:"data"`
+}
+
+type PayrollVarianceReportResponse struct {
+	Success bool                            `json:"success"`
+	Message string                          `json:"message"`
+	Data    bootstrap.PayrollVarianceReport `json:"data"`
+}
+
 func (a *App) ListPayrollBatches(accessToken string, filter bootstrap.PayrollBatchFilter) (PayrollBatchListResponse, error) {
-	actor, err := a.authorizePayroll(accessToken)
+	actor, err := a.authorizePayrollApprover(accessToken)
 	if err != nil {
 		return PayrollBatchListResponse{}, err
 	}
@@ -51,7 +279,7 @@ func (a *App) ListPayrollBatches(accessToken string, filter bootstrap.PayrollBat
 }
 
 func (a *App) GetPayrollBatch(accessToken string, batchID int64) (PayrollBatchDetailResponse, error) {
-	actor, err := a.authorizePayroll(accessToken)
+	actor, err := a.authorizePayrollApprover(accessToken)
 	if err != nil {
 		return PayrollBatchDetailResponse{}, err
 	}
@@ -97,16 +325,85 @@ func (a *App) UpdatePayrollEntryAmounts(accessToken string, entryID int64, input
 	return PayrollEntryResponse{Success: true, Message: "payroll entry updated", Data: result}, nil
 }
 
-func (a *App) ApprovePayrollBatch(accessToken string, batchID int64) (PayrollBatchResponse, error) {
+// ImportPayrollEntryAmounts validates a CSV/XLSX sheet of entry amounts and,
+// unless it is a dry run, applies it. A sheet with errors is reported row by
+// row and nothing is applied.
+func (a *App) ImportPayrollEntryAmounts(accessToken string, batchID int64, input bootstrap.PayrollImportEntriesInput) (PayrollImportResponse, error) {
 	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollImportResponse{}, err
+	}
+	result, execErr := a.payroll.ImportEntryAmounts(a.ctx, actor, batchID, input)
+	if execErr != nil {
+		return PayrollImportResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	switch {
+	case result.Applied:
+		return PayrollImportResponse{Success: true, Message: "payroll import applied", Data: result}, nil
+	case result.ErrorCount > 0:
+		return PayrollImportResponse{Success: false, Message: "payroll import has errors; nothing was applied", Data: result}, nil
+	default:
+		return PayrollImportResponse{Success: true, Message: "payroll import previewed", Data: result}, nil
+	}
+}
+
+// ApprovePayrollBatch signs the batch's next approval stage; the batch is
+// Approved once the last stage is signed.
+func (a *App) ApprovePayrollBatch(accessToken string, batchID int64) (PayrollBatchResponse, error) {
+	return a.SignOffPayrollBatch(accessToken, batchID, bootstrap.PayrollSignOffInput{})
+}
+
+func (a *App) SignOffPayrollBatch(accessToken string, batchID int64, input bootstrap.PayrollSignOffInput) (PayrollBatchResponse, error) {
+	actor, err := a.authorizePayrollApprover(accessToken)
+	if err != nil {
+		return PayrollBatchResponse{}, err
+	}
+	result, execErr := a.payroll.SignOffBatch(a.ctx, actor, batchID, input)
+	if execErr != nil {
+		return PayrollBatchResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	message := "payroll batch signed off"
+	if result.Status == bootstrap.PayrollStatusApproved {
+		message = "payroll batch approved"
+	}
+	return PayrollBatchResponse{Success: true, Message: message, Data: result}, nil
+}
+
+// RejectPayrollBatch returns a batch in approval to Draft with the comment.
+func (a *App) RejectPayrollBatch(accessToken string, batchID int64, input bootstrap.PayrollSignOffInput) (PayrollBatchResponse, error) {
+	actor, err := a.authorizePayrollApprover(accessToken)
 	if err != nil {
 		return PayrollBatchResponse{}, err
 	}
-	result, execErr := a.payroll.ApproveBatch(a.ctx, actor, batchID)
+	result, execErr := a.payroll.RejectBatch(a.ctx, actor, batchID, input)
 	if execErr != nil {
 		return PayrollBatchResponse{}, errors.New(formatPayrollError(execErr))
 	}
-	return PayrollBatchResponse{Success: true, Message: "payroll batch approved", Data: result}, nil
+	return PayrollBatchResponse{Success: true, Message: "payroll batch rejected", Data: result}, nil
+}
+
+func (a *App) ListPayrollApprovalStages(accessToken string) (PayrollApprovalStageListResponse, error) {
+	actor, err := a.authorizePayrollApprover(accessToken)
+	if err != nil {
+		return PayrollApprovalStageListResponse{}, err
+	}
+	result, execErr := a.payroll.ListApprovalStages(a.ctx, actor)
+	if execErr != nil {
+		return PayrollApprovalStageListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollApprovalStageListResponse{Success: true, Message: "approval stages fetched", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollApprovalStages(accessToken string, stages []bootstrap.PayrollApprovalStageInput) (PayrollApprovalStageListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollApprovalStageListResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateApprovalStages(a.ctx, actor, stages)
+	if execErr != nil {
+		return PayrollApprovalStageListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollApprovalStageListResponse{Success: true, Message: "approval stages updated", Data: result}, nil
 }
 
 func (a *App) LockPayrollBatch(accessToken string, batchID int64) (PayrollBatchResponse, error) {
@@ -121,6 +418,44 @@ func (a *App) LockPayrollBatch(accessToken string, batchID int64) (PayrollBatchR
 	return PayrollBatchResponse{Success: true, Message: "payroll batch locked", Data: result}, nil
 }
 
+// DeletePayrollBatch removes a Draft batch and its entries.
+func (a *App) DeletePayrollBatch(accessToken string, batchID int64) error {
+	actor, err := a.authorizePayrollMaster(accessToken)
+	if err != nil {
+		return err
+	}
+	if execErr := a.payroll.DeleteBatch(a.ctx, actor, batchID); execErr != nil {
+		return errors.New(formatPayrollError(execErr))
+	}
+	return nil
+}
+
+func (a *App) ReopenPayrollBatch(accessToken string, batchID int64, input bootstrap.PayrollBatchTransitionInput) (PayrollBatchResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollBatchResponse{}, err
+	}
+	result, execErr := a.payroll.ReopenBatch(a.ctx, actor, batchID, input)
+	if execErr != nil {
+		return PayrollBatchResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollBatchResponse{Success: true, Message: "payroll batch reopened", Data: result}, nil
+}
+
+// ReversePayrollBatch returns the correcting Draft batch created in place of
+// the reversed one.
+func (a *App) ReversePayrollBatch(accessToken string, batchID int64, input bootstrap.PayrollBatchTransitionInput) (PayrollBatchResponse, error) {
+	actor, err := a.authorizePayrollMaster(accessToken)
+	if err != nil {
+		return PayrollBatchResponse{}, err
+	}
+	result, execErr := a.payroll.ReverseBatch(a.ctx, actor, batchID, input)
+	if execErr != nil {
+		return PayrollBatchResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollBatchResponse{Success: true, Message: "payroll batch reversed", Data: result}, nil
+}
+
 func (a *App) ExportPayrollBatchCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
 	actor, err := a.authorizePayroll(accessToken)
 	if err != nil {
@@ -133,37 +468,885 @@ func (a *App) ExportPayrollBatchCSV(accessToken string, batchID int64) (PayrollC
 	return PayrollCSVResponse{Success: true, Message: "payroll csv exported", Data: result}, nil
 }
 
-func (a *App) authorizePayroll(accessToken string) (bootstrap.AuthUser, error) {
-	if a.payroll == nil || a.auth == nil {
-		return bootstrap.AuthUser{}, fmt.Errorf("payroll service unavailable")
+func (a *App) ExportPayrollPayslipPDF(accessToken string, entryID int64) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollFileResponse{}, err
 	}
-	actor, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "Finance Officer")
+	result, execErr := a.payroll.RenderPayslip(a.ctx, actor, entryID)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "payslip exported", Data: result}, nil
+}
+
+// ExportPayrollBatchPayslips returns every payslip in the batch; format is
+// "zip" for one PDF per employee or "pdf" for a single merged document.
+func (a *App) ExportPayrollBatchPayslips(accessToken string, batchID int64, format string) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
 	if err != nil {
-		return bootstrap.AuthUser{}, errors.New(formatPayrollError(err))
+		return PayrollFileResponse{}, err
 	}
-	return actor, nil
+	result, execErr := a.payroll.RenderBatchPayslips(a.ctx, actor, batchID, format)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "payslips exported", Data: result}, nil
 }
 
-func formatPayrollError(err error) string {
-	switch {
-	case bootstrap.IsUnauthorized(err):
-		return "unauthorized"
-	case bootstrap.IsForbidden(err), bootstrap.IsPayrollForbidden(err):
-		return "forbidden"
-	case bootstrap.IsInactiveUser(err):
-		return "account inactive"
-	case bootstrap.IsPayrollInvalidInput(err):
-		return "invalid payroll input"
-	case bootstrap.IsPayrollBatchNotFound(err):
-		return "payroll batch not found"
-	case bootstrap.IsPayrollEntryNotFound(err):
-		return "payroll entry not found"
-	case bootstrap.IsPayrollBatchExists(err):
-		return "payroll batch already exists for month"
-	case bootstrap.IsPayrollStatusTransition(err):
-		return "invalid payroll status transition"
-	case bootstrap.IsPayrollImmutable(err):
-		return "payroll batch is immutable"
+func (a *App) ListMyPayrollEntries(accessToken string) (PayrollEmployeeEntryListResponse, error) {
+	actor, err := a.authorizePayrollSelfService(accessToken)
+	if err != nil {
+		return PayrollEmployeeEntryListResponse{}, err
+	}
+	result, execErr := a.payroll.ListMyEntries(a.ctx, actor)
+	if execErr != nil {
+		return PayrollEmployeeEntryListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollEmployeeEntryListResponse{Success: true, Message: "payroll entries fetched", Data: result}, nil
+}
+
+func (a *App) ExportMyPayslipPDF(accessToken string, entryID int64) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayrollSelfService(accessToken)
+	if err != nil {
+		return PayrollFileResponse{}, err
+	}
+	result, execErr := a.payroll.RenderMyPayslip(a.ctx, actor, entryID)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "payslip exported", Data: result}, nil
+}
+
+func (a *App) ListPayrollBankFormats(accessToken string) (PayrollBankFormatListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollBankFormatListResponse{}, err
+	}
+	result, execErr := a.payroll.ListBankFormats(a.ctx, actor)
+	if execErr != nil {
+		return PayrollBankFormatListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollBankFormatListResponse{Success: true, Message: "bank formats fetched", Data: result}, nil
+}
+
+func (a *App) ValidatePayrollBankFile(accessToken string, batchID int64, format string) (PayrollBankValidationResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollBankValidationResponse{}, err
+	}
+	result, execErr := a.payroll.ValidateBankFile(a.ctx, actor, batchID, format)
+	if execErr != nil {
+		return PayrollBankValidationResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollBankValidationResponse{Success: len(result) == 0, Message: "bank details validated", Data: result}, nil
+}
+
+func (a *App) ExportPayrollBankFile(accessToken string, batchID int64, format string) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollFileResponse{}, err
+	}
+	result, execErr := a.payroll.ExportBankFile(a.ctx, actor, batchID, format)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "bank file exported", Data: result}, nil
+}
+
+func (a *App) GetPayrollSettings(accessToken string) (PayrollSettingsResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollSettingsResponse{}, err
+	}
+	result, execErr := a.payroll.GetSettings(a.ctx, actor)
+	if execErr != nil {
+		return PayrollSettingsResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollSettingsResponse{Success: true, Message: "payroll settings fetched", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollSettings(accessToken string, input bootstrap.PayrollSettingsInput) (PayrollSettingsResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollSettingsResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateSettings(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollSettingsResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollSettingsResponse{Success: true, Message: "payroll settings updated", Data: result}, nil
+}
+
+func (a *App) ListPayrollComponents(accessToken string) (PayrollComponentListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollComponentListResponse{}, err
+	}
+	result, execErr := a.payroll.ListComponents(a.ctx, actor)
+	if execErr != nil {
+		return PayrollComponentListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollComponentListResponse{Success: true, Message: "payroll components fetched", Data: result}, nil
+}
+
+func (a *App) CreatePayrollComponent(accessToken string, input bootstrap.PayrollComponentInput) (PayrollComponentResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollComponentResponse{}, err
+	}
+	result, execErr := a.payroll.CreateComponent(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollComponentResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollComponentResponse{Success: true, Message: "payroll component created", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollComponent(accessToken string, componentID int64, input bootstrap.PayrollComponentInput) (PayrollComponentResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollComponentResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateComponent(a.ctx, actor, componentID, input)
+	if execErr != nil {
+		return PayrollComponentResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollComponentResponse{Success: true, Message: "payroll component updated", Data: result}, nil
+}
+
+func (a *App) ListPayrollTaxTables(accessToken string) (PayrollTaxTableListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollTaxTableListResponse{}, err
+	}
+	result, execErr := a.payroll.ListTaxTables(a.ctx, actor)
+	if execErr != nil {
+		return PayrollTaxTableListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollTaxTableListResponse{Success: true, Message: "payroll tax tables fetched", Data: result}, nil
+}
+
+func (a *App) CreatePayrollTaxTable(accessToken string, input bootstrap.PayrollTaxTableInput) (PayrollTaxTableResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollTaxTableResponse{}, err
+	}
+	result, execErr := a.payroll.CreateTaxTable(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollTaxTableResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollTaxTableResponse{Success: true, Message: "payroll tax table created", Data: result}, nil
+}
+
+func (a *App) ListPayrollContributionSchemes(accessToken string) (PayrollContributionSchemeListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollContributionSchemeListResponse{}, err
+	}
+	result, execErr := a.payroll.ListContributionSchemes(a.ctx, actor)
+	if execErr != nil {
+		return PayrollContributionSchemeListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollContributionSchemeListResponse{Success: true, Message: "contribution schemes fetched", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollContributionScheme(accessToken string, schemeID int64, input bootstrap.PayrollContributionSchemeInput) (PayrollContributionSchemeResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollContributionSchemeResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateContributionScheme(a.ctx, actor, schemeID, input)
+	if execErr != nil {
+		return PayrollContributionSchemeResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollContributionSchemeResponse{Success: true, Message: "contribution scheme updated", Data: result}, nil
+}
+
+func (a *App) ListPayrollContributionMembers(accessToken string, schemeID int64) (PayrollContributionMemberListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollContributionMemberListResponse{}, err
+	}
+	result, execErr := a.payroll.ListContributionMembers(a.ctx, actor, schemeID)
+	if execErr != nil {
+		return PayrollContributionMemberListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollContributionMemberListResponse{Success: true, Message: "contribution members fetched", Data: result}, nil
+}
+
+func (a *App) SetPayrollContributionMember(accessToken string, schemeID int64, input bootstrap.PayrollContributionMemberInput) (PayrollContributionMemberResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollContributionMemberResponse{}, err
+	}
+	result, execErr := a.payroll.SetContributionMember(a.ctx, actor, schemeID, input)
+	if execErr != nil {
+		return PayrollContributionMemberResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollContributionMemberResponse{Success: true, Message: "contribution member saved", Data: result}, nil
+}
+
+func (a *App) RemovePayrollContributionMember(accessToken string, schemeID int64, employeeID int64) error {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return err
+	}
+	if execErr := a.payroll.RemoveContributionMember(a.ctx, actor, schemeID, employeeID); execErr != nil {
+		return errors.New(formatPayrollError(execErr))
+	}
+	return nil
+}
+
+func (a *App) GetPayrollRemittanceSchedule(accessToken string, batchID int64) (PayrollRemittanceScheduleResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollRemittanceScheduleResponse{}, err
+	}
+	result, execErr := a.payroll.GetRemittanceSchedule(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollRemittanceScheduleResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollRemittanceScheduleResponse{Success: true, Message: "remittance schedule fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollRemittanceCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportRemittanceCSV(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "remittance csv exported", Data: result}, nil
+}
+
+// GetPayrollVarianceReport compares the batch with the previous Locked batch
+// of the same type, flagging net pay changes above the configured threshold.
+func (a *App) GetPayrollVarianceReport(accessToken string, batchID int64) (PayrollVarianceReportResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollVarianceReportResponse{}, err
+	}
+	result, execErr := a.payroll.GetVarianceReport(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollVarianceReportResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollVarianceReportResponse{Success: true, Message: "variance report fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollVarianceCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportVarianceCSV(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "variance csv exported", Data: result}, nil
+}
+
+func (a *App) ListPayrollRecurringItems(accessToken string, filter bootstrap.PayrollRecurringItemFilter) (PayrollRecurringItemListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollRecurringItemListResponse{}, err
+	}
+	result, execErr := a.payroll.ListRecurringItems(a.ctx, actor, filter)
+	if execErr != nil {
+		return PayrollRecurringItemListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollRecurringItemListResponse{Success: true, Message: "recurring items fetched", Data: result}, nil
+}
+
+// CreatePayrollRecurringItem assigns a standing earning or deduction that
+// regular batches post automatically for the months it covers.
+func (a *App) CreatePayrollRecurringItem(accessToken string, input bootstrap.PayrollRecurringItemInput) (PayrollRecurringItemResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollRecurringItemResponse{}, err
+	}
+	result, execErr := a.payroll.CreateRecurringItem(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollRecurringItemResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollRecurringItemResponse{Success: true, Message: "recurring item created", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollRecurringItem(accessToken string, itemID int64, input bootstrap.PayrollRecurringItemInput) (PayrollRecurringItemResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollRecurringItemResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateRecurringItem(a.ctx, actor, itemID, input)
+	if execErr != nil {
+		return PayrollRecurringItemResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollRecurringItemResponse{Success: true, Message: "recurring item updated", Data: result}, nil
+}
+
+func (a *App) DeletePayrollRecurringItem(accessToken string, itemID int64) error {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return err
+	}
+	if execErr := a.payroll.DeleteRecurringItem(a.ctx, actor, itemID); execErr != nil {
+		return errors.New(formatPayrollError(execErr))
+	}
+	return nil
+}
+
+func (a *App) ListPayrollLoans(accessToken string, filter bootstrap.PayrollLoanFilter) (PayrollLoanListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollLoanListResponse{}, err
+	}
+	result, execErr := a.payroll.ListLoans(a.ctx, actor, filter)
+	if execErr != nil {
+		return PayrollLoanListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollLoanListResponse{Success: true, Message: "payroll loans fetched", Data: result}, nil
+}
+
+// GetPayrollLoan returns the loan with its repayment history.
+func (a *App) GetPayrollLoan(accessToken string, loanID int64) (PayrollLoanDetailResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollLoanDetailResponse{}, err
+	}
+	result, execErr := a.payroll.GetLoan(a.ctx, actor, loanID)
+	if execErr != nil {
+		return PayrollLoanDetailResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollLoanDetailResponse{Success: true, Message: "payroll loan fetched", Data: result}, nil
+}
+
+// CreatePayrollLoan records a staff loan or salary advance; its installments
+// are deducted from regular batches generated from the start month.
+func (a *App) CreatePayrollLoan(accessToken string, input bootstrap.PayrollLoanInput) (PayrollLoanResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollLoanResponse{}, err
+	}
+	result, execErr := a.payroll.CreateLoan(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollLoanResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollLoanResponse{Success: true, Message: "payroll loan created", Data: result}, nil
+}
+
+func (a *App) CancelPayrollLoan(accessToken string, loanID int64, input bootstrap.PayrollCancelLoanInput) (PayrollLoanResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollLoanResponse{}, err
+	}
+	result, execErr := a.payroll.CancelLoan(a.ctx, actor, loanID, input)
+	if execErr != nil {
+		return PayrollLoanResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollLoanResponse{Success: true, Message: "payroll loan cancelled", Data: result}, nil
+}
+
+func (a *App) GetPayrollLoanBalanceReport(accessToken string) (PayrollLoanBalanceReportResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollLoanBalanceReportResponse{}, err
+	}
+	result, execErr := a.payroll.GetLoanBalanceReport(a.ctx, actor)
+	if execErr != nil {
+		return PayrollLoanBalanceReportResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollLoanBalanceReportResponse{Success: true, Message: "loan balances fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollLoanBalancesCSV(accessToken string) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportLoanBalancesCSV(a.ctx, actor)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "loan balances csv exported", Data: result}, nil
+}
+
+// ListPayrollYTDTotals returns every employee's year-to-date totals for a
+// fiscal year, named by the calendar year it starts in.
+func (a *App) ListPayrollYTDTotals(accessToken string, fiscalYear int) (PayrollYTDTotalsResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollYTDTotalsResponse{}, err
+	}
+	result, execErr := a.payroll.ListYTDTotals(a.ctx, actor, fiscalYear)
+	if execErr != nil {
+		return PayrollYTDTotalsResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollYTDTotalsResponse{Success: true, Message: "year-to-date totals fetched", Data: result}, nil
+}
+
+func (a *App) RebuildPayrollYTDTotals(accessToken string, fiscalYear int) (PayrollYTDTotalsResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollYTDTotalsResponse{}, err
+	}
+	result, execErr := a.payroll.RebuildYTDTotals(a.ctx, actor, fiscalYear)
+	if execErr != nil {
+		return PayrollYTDTotalsResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollYTDTotalsResponse{Success: true, Message: "year-to-date totals rebuilt", Data: result}, nil
+}
+
+func (a *App) GetPayrollAnnualCertificate(accessToken string, employeeID int64, fiscalYear int) (PayrollAnnualCertificateResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollAnnualCertificateResponse{}, err
+	}
+	result, execErr := a.payroll.GetAnnualCertificate(a.ctx, actor, employeeID, fiscalYear)
+	if execErr != nil {
+		return PayrollAnnualCertificateResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollAnnualCertificateResponse{Success: true, Message: "annual certificate fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollAnnualCertificatePDF(accessToken string, employeeID int64, fiscalYear int) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollFileResponse{}, err
+	}
+	result, execErr := a.payroll.RenderAnnualCertificate(a.ctx, actor, employeeID, fiscalYear)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "annual certificate exported", Data: result}, nil
+}
+
+func (a *App) ExportPayrollAnnualCertificateCSV(accessToken string, employeeID int64, fiscalYear int) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportAnnualCertificateCSV(a.ctx, actor, employeeID, fiscalYear)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "annual certificate csv exported", Data: result}, nil
+}
+
+func (a *App) ExportMyAnnualCertificatePDF(accessToken string, fiscalYear int) (PayrollFileResponse, error) {
+	actor, err := a.authorizePayrollSelfService(accessToken)
+	if err != nil {
+		return PayrollFileResponse{}, err
+	}
+	result, execErr := a.payroll.RenderMyAnnualCertificate(a.ctx, actor, fiscalYear)
+	if execErr != nil {
+		return PayrollFileResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollFileResponse{Success: true, Message: "annual certificate exported", Data: result}, nil
+}
+
+func (a *App) GetPayrollAnnualReturn(accessToken string, fiscalYear int) (PayrollAnnualReturnResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollAnnualReturnResponse{}, err
+	}
+	result, execErr := a.payroll.GetAnnualReturn(a.ctx, actor, fiscalYear)
+	if execErr != nil {
+		return PayrollAnnualReturnResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollAnnualReturnResponse{Success: true, Message: "annual return fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollAnnualReturnCSV(accessToken string, fiscalYear int) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportAnnualReturnCSV(a.ctx, actor, fiscalYear)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "annual return csv exported", Data: result}, nil
+}
+
+func (a *App) ListPayrollProjects(accessToken string) (PayrollProjectListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollProjectListResponse{}, err
+	}
+	result, execErr := a.payroll.ListProjects(a.ctx, actor)
+	if execErr != nil {
+		return PayrollProjectListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollProjectListResponse{Success: true, Message: "payroll projects fetched", Data: result}, nil
+}
+
+func (a *App) CreatePayrollProject(accessToken string, input bootstrap.PayrollProjectInput) (PayrollProjectResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollProjectResponse{}, err
+	}
+	result, execErr := a.payroll.CreateProject(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollProjectResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollProjectResponse{Success: true, Message: "payroll project created", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollProject(accessToken string, projectID int64, input bootstrap.PayrollProjectInput) (PayrollProjectResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollProjectResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateProject(a.ctx, actor, projectID, input)
+	if execErr != nil {
+		return PayrollProjectResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollProjectResponse{Success: true, Message: "payroll project updated", Data: result}, nil
+}
+
+func (a *App) ListPayrollCostAllocations(accessToken string, employeeID int64) (PayrollCostAllocationListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCostAllocationListResponse{}, err
+	}
+	result, execErr := a.payroll.ListCostAllocations(a.ctx, actor, employeeID)
+	if execErr != nil {
+		return PayrollCostAllocationListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCostAllocationListResponse{Success: true, Message: "payroll cost allocations fetched", Data: result}, nil
+}
+
+// SetPayrollCostAllocation records an employee's project split from a month,
+// replacing any split starting in the same month.
+func (a *App) SetPayrollCostAllocation(accessToken string, employeeID int64, input bootstrap.PayrollCostAllocationInput) (PayrollCostAllocationListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCostAllocationListResponse{}, err
+	}
+	result, execErr := a.payroll.SetCostAllocation(a.ctx, actor, employeeID, input)
+	if execErr != nil {
+		return PayrollCostAllocationListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCostAllocationListResponse{Success: true, Message: "payroll cost allocation saved", Data: result}, nil
+}
+
+func (a *App) DeletePayrollCostAllocation(accessToken string, employeeID int64, effectiveFrom string) error {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return err
+	}
+	if execErr := a.payroll.DeleteCostAllocation(a.ctx, actor, employeeID, effectiveFrom); execErr != nil {
+		return errors.New(formatPayrollError(execErr))
+	}
+	return nil
+}
+
+func (a *App) GetPayrollCostAllocationReport(accessToken string, batchID int64) (PayrollCostAllocationReportResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCostAllocationReportResponse{}, err
+	}
+	result, execErr := a.payroll.GetCostAllocationReport(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCostAllocationReportResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCostAllocationReportResponse{Success: true, Message: "cost allocation report fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollCostAllocationCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportCostAllocationCSV(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "cost allocation csv exported", Data: result}, nil
+}
+
+func (a *App) ListPayrollGLAccounts(accessToken string) (PayrollGLAccountListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollGLAccountListResponse{}, err
+	}
+	result, execErr := a.payroll.ListGLAccounts(a.ctx, actor)
+	if execErr != nil {
+		return PayrollGLAccountListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollGLAccountListResponse{Success: true, Message: "payroll gl accounts fetched", Data: result}, nil
+}
+
+// UpdatePayrollGLAccounts replaces the whole chart-of-accounts mapping.
+func (a *App) UpdatePayrollGLAccounts(accessToken string, accounts []bootstrap.PayrollGLAccountInput) (PayrollGLAccountListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollGLAccountListResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateGLAccounts(a.ctx, actor, accounts)
+	if execErr != nil {
+		return PayrollGLAccountListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollGLAccountListResponse{Success: true, Message: "payroll gl accounts updated", Data: result}, nil
+}
+
+func (a *App) GetPayrollJournalLayout(accessToken string) (PayrollJournalLayoutResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollJournalLayoutResponse{}, err
+	}
+	result, execErr := a.payroll.GetJournalLayout(a.ctx, actor)
+	if execErr != nil {
+		return PayrollJournalLayoutResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollJournalLayoutResponse{Success: true, Message: "payroll journal layout fetched", Data: result}, nil
+}
+
+func (a *App) UpdatePayrollJournalLayout(accessToken string, input bootstrap.PayrollJournalLayoutInput) (PayrollJournalLayoutResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollJournalLayoutResponse{}, err
+	}
+	result, execErr := a.payroll.UpdateJournalLayout(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollJournalLayoutResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollJournalLayoutResponse{Success: true, Message: "payroll journal layout updated", Data: result}, nil
+}
+
+func (a *App) GetPayrollJournal(accessToken string, batchID int64) (PayrollJournalResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollJournalResponse{}, err
+	}
+	result, execErr := a.payroll.GetJournal(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollJournalResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollJournalResponse{Success: true, Message: "payroll journal fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollJournalCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportJournalCSV(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "payroll journal csv exported", Data: result}, nil
+}
+
+func (a *App) ListPayrollExchangeRates(accessToken string, month string) (PayrollExchangeRateListResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollExchangeRateListResponse{}, err
+	}
+	result, execErr := a.payroll.ListExchangeRates(a.ctx, actor, month)
+	if execErr != nil {
+		return PayrollExchangeRateListResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollExchangeRateListResponse{Success: true, Message: "payroll exchange rates fetched", Data: result}, nil
+}
+
+// SetPayrollExchangeRate records or replaces a currency's rate for a month.
+func (a *App) SetPayrollExchangeRate(accessToken string, input bootstrap.PayrollExchangeRateInput) (PayrollExchangeRateResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollExchangeRateResponse{}, err
+	}
+	result, execErr := a.payroll.SetExchangeRate(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollExchangeRateResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollExchangeRateResponse{Success: true, Message: "payroll exchange rate saved", Data: result}, nil
+}
+
+func (a *App) DeletePayrollExchangeRate(accessToken string, month string, currency string) error {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return err
+	}
+	if execErr := a.payroll.DeleteExchangeRate(a.ctx, actor, month, currency); execErr != nil {
+		return errors.New(formatPayrollError(execErr))
+	}
+	return nil
+}
+
+func (a *App) GetPayrollCurrencyReport(accessToken string, batchID int64) (PayrollCurrencyReportResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCurrencyReportResponse{}, err
+	}
+	result, execErr := a.payroll.GetCurrencyReport(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCurrencyReportResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCurrencyReportResponse{Success: true, Message: "currency report fetched", Data: result}, nil
+}
+
+func (a *App) ExportPayrollCurrencyCSV(accessToken string, batchID int64) (PayrollCSVResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollCSVResponse{}, err
+	}
+	result, execErr := a.payroll.ExportCurrencyCSV(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollCSVResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollCSVResponse{Success: true, Message: "currency csv exported", Data: result}, nil
+}
+
+// VerifyPayrollBatch checks a Locked or Reversed batch against the digest
+// recorded when it was locked.
+func (a *App) VerifyPayrollBatch(accessToken string, batchID int64) (PayrollBatchVerificationResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollBatchVerificationResponse{}, err
+	}
+	result, execErr := a.payroll.VerifyBatch(a.ctx, actor, batchID)
+	if execErr != nil {
+		return PayrollBatchVerificationResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	message := "payroll batch verified"
+	switch {
+	case !result.Sealed:
+		message = "payroll batch was locked before digests were recorded"
+	case !result.Intact:
+		message = "payroll batch has changed since it was locked"
+	}
+	return PayrollBatchVerificationResponse{Success: true, Message: message, Data: result}, nil
+}
+
+// SimulatePayroll prices a what-if regular batch against the latest Locked
+// one; nothing is saved.
+func (a *App) SimulatePayroll(accessToken string, input bootstrap.PayrollSimulationInput) (PayrollSimulationResponse, error) {
+	actor, err := a.authorizePayroll(accessToken)
+	if err != nil {
+		return PayrollSimulationResponse{}, err
+	}
+	result, execErr := a.payroll.SimulatePayroll(a.ctx, actor, input)
+	if execErr != nil {
+		return PayrollSimulationResponse{}, errors.New(formatPayrollError(execErr))
+	}
+	return PayrollSimulationResponse{Success: true, Message: "payroll simulation completed", Data: result}, nil
+}
+
+func (a *App) authorizePayroll(accessToken string) (bootstrap.AuthUser, error) {
+	if a.payroll == nil || a.auth == nil {
+		return bootstrap.AuthUser{}, fmt.Errorf("payroll service unavailable")
+	}
+	actor, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "Finance Officer")
+	if err != nil {
+		return bootstrap.AuthUser{}, errors.New(formatPayrollError(err))
+	}
+	return actor, nil
+}
+
+// authorizePayrollMaster guards the corrective operations reserved for
+// the Master and Master Admin roles.
+func (a *App) authorizePayrollMaster(accessToken string) (bootstrap.AuthUser, error) {
+	if a.payroll == nil || a.auth == nil {
+		return bootstrap.AuthUser{}, fmt.Errorf("payroll service unavailable")
+	}
+	actor, err := a.auth.Authorize(a.ctx, accessToken, "Master", "Master Admin")
+	if err != nil {
+		return bootstrap.AuthUser{}, errors.New(formatPayrollError(err))
+	}
+	return actor, nil
+}
+
+// authorizePayrollSelfService admits every role; the service then limits the
+// caller to the employee record linked to their user account.
+func (a *App) authorizePayrollSelfService(accessToken string) (bootstrap.AuthUser, error) {
+	if a.payroll == nil || a.auth == nil {
+		return bootstrap.AuthUser{}, fmt.Errorf("payroll service unavailable")
+	}
+	actor, err := a.auth.Authorize(a.ctx, accessToken, "Admin", "HR Officer", "Finance Officer", "Viewer", "Master", "Master Admin")
+	if err != nil {
+		return bootstrap.AuthUser{}, errors.New(formatPayrollError(err))
+	}
+	return actor, nil
+}
+
+// authorizePayrollApprover admits any signed-in user, since approval stages
+// may name any role; the service checks the role against the chain.
+func (a *App) authorizePayrollApprover(accessToken string) (bootstrap.AuthUser, error) {
+	if a.payroll == nil || a.auth == nil {
+		return bootstrap.AuthUser{}, fmt.Errorf("payroll service unavailable")
+	}
+	actor, err := a.auth.Me(a.ctx, accessToken)
+	if err != nil {
+		return bootstrap.AuthUser{}, errors.New(formatPayrollError(err))
+	}
+	return actor, nil
+}
+
+func formatPayrollError(err error) string {
+	switch {
+	case bootstrap.IsUnauthorized(err):
+		return "unauthorized"
+	case bootstrap.IsForbidden(err), bootstrap.IsPayrollForbidden(err):
+		return "forbidden"
+	case bootstrap.IsInactiveUser(err):
+		return "account inactive"
+	case bootstrap.IsPayrollInvalidInput(err):
+		return "invalid payroll input"
+	case bootstrap.IsPayrollBatchNotFound(err):
+		return "payroll batch not found"
+	case bootstrap.IsPayrollEntryNotFound(err):
+		return "payroll entry not found"
+	case bootstrap.IsPayrollBatchExists(err):
+		return "a regular payroll batch already exists for month"
+	case bootstrap.IsPayrollStatusTransition(err):
+		return "invalid payroll status transition"
+	case bootstrap.IsPayrollImmutable(err):
+		return "payroll batch is immutable"
+	case bootstrap.IsPayrollComponentNotFound(err):
+		return "payroll component not found"
+	case bootstrap.IsPayrollComponentExists(err):
+		return "payroll component code already exists"
+	case bootstrap.IsPayrollTaxTableNotFound(err):
+		return "no tax table in force for payroll month"
+	case bootstrap.IsPayrollTaxOverrideReason(err):
+		return "tax override requires a reason"
+	case bootstrap.IsPayrollContributionSchemeNotFound(err):
+		return "contribution scheme not found"
+	case bootstrap.IsPayrollContributionMemberNotFound(err):
+		return "contribution scheme member not found"
+	case bootstrap.IsPayrollJustificationRequired(err):
+		return "a justification is required"
+	case bootstrap.IsPayrollSegregationOfDuties(err):
+		return "segregation of duties: the approver must not have created, edited or already signed off the payroll batch"
+	case bootstrap.IsPayrollRecurringItemNotFound(err):
+		return "payroll recurring item not found"
+	case bootstrap.IsPayrollLoanNotFound(err):
+		return "payroll loan not found"
+	case bootstrap.IsPayrollProjectNotFound(err):
+		return "payroll project not found"
+	case bootstrap.IsPayrollProjectExists(err):
+		return "payroll project code already exists"
+	case bootstrap.IsPayrollCostAllocationNotFound(err):
+		return "payroll cost allocation not found"
+	case bootstrap.IsPayrollEmployeeNotFound(err):
+		return "employee not found"
+	case bootstrap.IsPayrollNoAnnualEarnings(err):
+		return "no locked payroll for the employee in this fiscal year"
+	case bootstrap.IsPayrollApprovalInProgress(err):
+		return "approval stages cannot change while a payroll batch is in approval"
+	case bootstrap.IsPayrollMonthBatchPending(err):
+		return "another payroll batch of the month is in approval or approved; reject or lock it before reopening this one"
+	case bootstrap.IsPayrollBankDetailsInvalid(err):
+		// The message lists each employee whose details need fixing.
+		return strings.TrimSpace(err.Error())
+	case bootstrap.IsPayrollExchangeRateNotFound(err):
+		// From generation the message names the employee and currency.
+		return strings.TrimSpace(err.Error())
+	case bootstrap.IsPayrollGLAccountMissing(err):
+		// The message lists each posting that still needs an account.
+		return strings.TrimSpace(err.Error())
 	default:
 		return strings.TrimSpace(err.Error())
 	}
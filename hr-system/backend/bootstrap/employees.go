@@ -19,6 +19,7 @@ type EmployeeListQuery = employees.EmployeeListFilter
 type EmployeeView = employees.EmployeeView
 type EmployeeListResult = employees.EmployeeListResult
 type DepartmentOption = employees.DepartmentOption
+type SalaryHistoryView = employees.SalaryHistoryView
 
 func NewEmployeesFacade(db *sqlx.DB) (*EmployeesFacade, error) {
 	repo := employees.NewRepository(db)
@@ -29,12 +30,16 @@ func NewEmployeesFacade(db *sqlx.DB) (*EmployeesFacade, error) {
 	return &EmployeesFacade{service: service}, nil
 }
 
-func (f *EmployeesFacade) CreateEmployee(ctx context.Context, input EmployeeInput) (EmployeeView, error) {
-	return f.service.CreateEmployee(ctx, input)
+func (f *EmployeesFacade) CreateEmployee(ctx context.Context, actor AuthUser, input EmployeeInput) (EmployeeView, error) {
+	return f.service.CreateEmployee(ctx, employees.Actor{UserID: actor.ID, Role: actor.Role}, input)
 }
 
-func (f *EmployeesFacade) UpdateEmployee(ctx context.Context, employeeID int64, input EmployeeInput) (EmployeeView, error) {
-	return f.service.UpdateEmployee(ctx, employeeID, input)
+func (f *EmployeesFacade) UpdateEmployee(ctx context.Context, actor AuthUser, employeeID int64, input EmployeeInput) (EmployeeView, error) {
+	return f.service.UpdateEmployee(ctx, employees.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, input)
+}
+
+func (f *EmployeesFacade) ListSalaryHistory(ctx context.Context, employeeID int64) ([]SalaryHistoryView, error) {
+	return f.service.ListSalaryHistory(ctx, employeeID)
 }
 
 func (f *EmployeesFacade) DeleteEmployee(ctx context.Context, employeeID int64) error {
@@ -61,6 +66,10 @@ func IsEmployeeNotFound(err error) bool {
 	return errors.Is(err, employees.ErrEmployeeNotFound)
 }
 
+func IsSalaryReasonRequired(err error) bool {
+	return errors.Is(err, employees.ErrSalaryReasonRequired)
+}
+
 func IsDepartmentNotFound(err error) bool {
 	return errors.Is(err, employees.ErrDepartmentNotFound)
 }
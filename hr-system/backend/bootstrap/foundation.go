@@ -59,7 +59,13 @@ func Initialize(ctx context.Context) (*Runtime, error) {
 		return nil, fmt.Errorf("initialize leave: %w", err)
 	}
 
-	payrollFacade, err := NewPayrollFacade(conn)
+	payrollFacade, err := NewPayrollFacade(conn, PayrollEmployer{
+		Name:        cfg.EmployerName,
+		Address:     cfg.EmployerAddress,
+		TIN:         cfg.EmployerTIN,
+		BankCode:    cfg.EmployerBankCode,
+		BankAccount: cfg.EmployerBankAccount,
+	})
 	if err != nil {
 		_ = conn.Close()
 		return nil, fmt.Errorf("initialize payroll: %w", err)
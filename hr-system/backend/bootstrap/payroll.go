@@ -21,10 +21,63 @@ type PayrollBatchDetail = payroll.BatchDetail
 type PayrollBatchListResult = payroll.BatchListResult
 type PayrollCreateBatchInput = payroll.CreateBatchInput
 type PayrollUpdateEntryAmountsInput = payroll.UpdateEntryAmountsInput
+type PayrollComponent = payroll.Component
+type PayrollComponentInput = payroll.ComponentInput
+type PayrollTaxTable = payroll.TaxTable
+type PayrollTaxTableInput = payroll.TaxTableInput
+type PayrollContributionScheme = payroll.ContributionScheme
+type PayrollContributionSchemeInput = payroll.ContributionSchemeInput
+type PayrollContributionMember = payroll.ContributionMember
+type PayrollContributionMemberInput = payroll.ContributionMemberInput
+type PayrollRemittanceSchedule = payroll.RemittanceSchedule
+type PayrollVarianceReport = payroll.VarianceReport
+type PayrollEmployer = payroll.Employer
+type PayrollFile = payroll.File
+type PayrollEmployeeEntry = payroll.EmployeeEntry
+type PayrollBankFormat = payroll.BankFormat
+type PayrollSettings = payroll.Settings
+type PayrollSettingsInput = payroll.SettingsInput
+type PayrollBankValidationIssue = payroll.BankValidationIssue
+type PayrollBatchTransitionInput = payroll.BatchTransitionInput
+type PayrollApprovalStage = payroll.ApprovalStage
+type PayrollApprovalStageInput = payroll.ApprovalStageInput
+type PayrollSignOffInput = payroll.SignOffInput
+type PayrollImportEntriesInput = payroll.ImportEntriesInput
+type PayrollImportResult = payroll.ImportResult
+type PayrollRecurringItem = payroll.RecurringItem
+type PayrollRecurringItemInput = payroll.RecurringItemInput
+type PayrollRecurringItemFilter = payroll.RecurringItemFilter
+type PayrollLoan = payroll.Loan
+type PayrollLoanInput = payroll.LoanInput
+type PayrollLoanFilter = payroll.LoanFilter
+type PayrollLoanDetail = payroll.LoanDetail
+type PayrollCancelLoanInput = payroll.CancelLoanInput
+type PayrollLoanBalanceReport = payroll.LoanBalanceReport
+type PayrollYTDTotals = payroll.YTDTotals
+type PayrollAnnualCertificate = payroll.AnnualCertificate
+type PayrollAnnualReturn = payroll.AnnualReturn
+type PayrollProject = payroll.Project
+type PayrollProjectInput = payroll.ProjectInput
+type PayrollCostAllocation = payroll.CostAllocation
+type PayrollCostAllocationInput = payroll.CostAllocationInput
+type PayrollCostAllocationReport = payroll.CostAllocationReport
+type PayrollGLAccount = payroll.GLAccount
+type PayrollGLAccountInput = payroll.GLAccountInput
+type PayrollJournalLayout = payroll.JournalLayout
+type PayrollJournalLayoutInput = payroll.JournalLayoutInput
+type PayrollJournal = payroll.Journal
+type PayrollExchangeRate = payroll.ExchangeRate
+type PayrollExchangeRateInput = payroll.ExchangeRateInput
+type PayrollCurrencyReport = payroll.CurrencyReport
+type PayrollBatchVerification = payroll.BatchVerification
+type PayrollSimulationInput = payroll.SimulationInput
+type PayrollSimulationReport = payroll.SimulationReport
 
-func NewPayrollFacade(db *sqlx.DB) (*PayrollFacade, error) {
+const PayrollStatusApproved = payroll.StatusApproved
+
+func NewPayrollFacade(db *sqlx.DB, employer PayrollEmployer) (*PayrollFacade, error) {
 	repo := payroll.NewRepository(db)
-	service, err := payroll.NewService(repo)
+	service, err := payroll.NewService(repo, employer)
 	if err != nil {
 		return nil, fmt.Errorf("create payroll service: %w", err)
 	}
@@ -55,14 +108,294 @@ func (f *PayrollFacade) ApproveBatch(ctx context.Context, actor AuthUser, batchI
 	return f.service.ApproveBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
 }
 
+func (f *PayrollFacade) ImportEntryAmounts(ctx context.Context, actor AuthUser, batchID int64, input PayrollImportEntriesInput) (PayrollImportResult, error) {
+	return f.service.ImportEntryAmounts(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, input)
+}
+
+func (f *PayrollFacade) SignOffBatch(ctx context.Context, actor AuthUser, batchID int64, input PayrollSignOffInput) (PayrollBatch, error) {
+	return f.service.SignOffBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, input)
+}
+
+func (f *PayrollFacade) RejectBatch(ctx context.Context, actor AuthUser, batchID int64, input PayrollSignOffInput) (PayrollBatch, error) {
+	return f.service.RejectBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, input)
+}
+
+func (f *PayrollFacade) ListApprovalStages(ctx context.Context, actor AuthUser) ([]PayrollApprovalStage, error) {
+	return f.service.ListApprovalStages(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) UpdateApprovalStages(ctx context.Context, actor AuthUser, stages []PayrollApprovalStageInput) ([]PayrollApprovalStage, error) {
+	return f.service.UpdateApprovalStages(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, stages)
+}
+
 func (f *PayrollFacade) LockBatch(ctx context.Context, actor AuthUser, batchID int64) (PayrollBatch, error) {
 	return f.service.LockBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
 }
 
+func (f *PayrollFacade) DeleteBatch(ctx context.Context, actor AuthUser, batchID int64) error {
+	return f.service.DeleteBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ReopenBatch(ctx context.Context, actor AuthUser, batchID int64, input PayrollBatchTransitionInput) (PayrollBatch, error) {
+	return f.service.ReopenBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, input)
+}
+
+func (f *PayrollFacade) ReverseBatch(ctx context.Context, actor AuthUser, batchID int64, input PayrollBatchTransitionInput) (PayrollBatch, error) {
+	return f.service.ReverseBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, input)
+}
+
 func (f *PayrollFacade) ExportBatchCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
 	return f.service.ExportBatchCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
 }
 
+func (f *PayrollFacade) RenderPayslip(ctx context.Context, actor AuthUser, entryID int64) (PayrollFile, error) {
+	return f.service.RenderPayslip(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, entryID)
+}
+
+func (f *PayrollFacade) RenderBatchPayslips(ctx context.Context, actor AuthUser, batchID int64, format string) (PayrollFile, error) {
+	return f.service.RenderBatchPayslips(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, format)
+}
+
+func (f *PayrollFacade) ListMyEntries(ctx context.Context, actor AuthUser) ([]PayrollEmployeeEntry, error) {
+	return f.service.ListMyEntries(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) RenderMyPayslip(ctx context.Context, actor AuthUser, entryID int64) (PayrollFile, error) {
+	return f.service.RenderMyPayslip(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, entryID)
+}
+
+func (f *PayrollFacade) ListBankFormats(ctx context.Context, actor AuthUser) ([]PayrollBankFormat, error) {
+	return f.service.ListBankFormats(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) ValidateBankFile(ctx context.Context, actor AuthUser, batchID int64, format string) ([]PayrollBankValidationIssue, error) {
+	return f.service.ValidateBankFile(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, format)
+}
+
+func (f *PayrollFacade) ExportBankFile(ctx context.Context, actor AuthUser, batchID int64, format string) (PayrollFile, error) {
+	return f.service.ExportBankFile(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID, format)
+}
+
+func (f *PayrollFacade) GetSettings(ctx context.Context, actor AuthUser) (PayrollSettings, error) {
+	return f.service.GetSettings(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) UpdateSettings(ctx context.Context, actor AuthUser, input PayrollSettingsInput) (PayrollSettings, error) {
+	return f.service.UpdateSettings(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) ListComponents(ctx context.Context, actor AuthUser) ([]PayrollComponent, error) {
+	return f.service.ListComponents(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) CreateComponent(ctx context.Context, actor AuthUser, input PayrollComponentInput) (PayrollComponent, error) {
+	return f.service.CreateComponent(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) UpdateComponent(ctx context.Context, actor AuthUser, componentID int64, input PayrollComponentInput) (PayrollComponent, error) {
+	return f.service.UpdateComponent(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, componentID, input)
+}
+
+func (f *PayrollFacade) ListTaxTables(ctx context.Context, actor AuthUser) ([]PayrollTaxTable, error) {
+	return f.service.ListTaxTables(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) CreateTaxTable(ctx context.Context, actor AuthUser, input PayrollTaxTableInput) (PayrollTaxTable, error) {
+	return f.service.CreateTaxTable(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) ListContributionSchemes(ctx context.Context, actor AuthUser) ([]PayrollContributionScheme, error) {
+	return f.service.ListContributionSchemes(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) UpdateContributionScheme(ctx context.Context, actor AuthUser, schemeID int64, input PayrollContributionSchemeInput) (PayrollContributionScheme, error) {
+	return f.service.UpdateContributionScheme(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, schemeID, input)
+}
+
+func (f *PayrollFacade) ListContributionMembers(ctx context.Context, actor AuthUser, schemeID int64) ([]PayrollContributionMember, error) {
+	return f.service.ListContributionMembers(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, schemeID)
+}
+
+func (f *PayrollFacade) SetContributionMember(ctx context.Context, actor AuthUser, schemeID int64, input PayrollContributionMemberInput) (PayrollContributionMember, error) {
+	return f.service.SetContributionMember(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, schemeID, input)
+}
+
+func (f *PayrollFacade) RemoveContributionMember(ctx context.Context, actor AuthUser, schemeID int64, employeeID int64) error {
+	return f.service.RemoveContributionMember(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, schemeID, employeeID)
+}
+
+func (f *PayrollFacade) GetRemittanceSchedule(ctx context.Context, actor AuthUser, batchID int64) (PayrollRemittanceSchedule, error) {
+	return f.service.GetRemittanceSchedule(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ExportRemittanceCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
+	return f.service.ExportRemittanceCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) GetVarianceReport(ctx context.Context, actor AuthUser, batchID int64) (PayrollVarianceReport, error) {
+	return f.service.GetVarianceReport(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ExportVarianceCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
+	return f.service.ExportVarianceCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ListRecurringItems(ctx context.Context, actor AuthUser, filter PayrollRecurringItemFilter) ([]PayrollRecurringItem, error) {
+	return f.service.ListRecurringItems(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, filter)
+}
+
+func (f *PayrollFacade) CreateRecurringItem(ctx context.Context, actor AuthUser, input PayrollRecurringItemInput) (PayrollRecurringItem, error) {
+	return f.service.CreateRecurringItem(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) UpdateRecurringItem(ctx context.Context, actor AuthUser, itemID int64, input PayrollRecurringItemInput) (PayrollRecurringItem, error) {
+	return f.service.UpdateRecurringItem(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, itemID, input)
+}
+
+func (f *PayrollFacade) DeleteRecurringItem(ctx context.Context, actor AuthUser, itemID int64) error {
+	return f.service.DeleteRecurringItem(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, itemID)
+}
+
+func (f *PayrollFacade) ListLoans(ctx context.Context, actor AuthUser, filter PayrollLoanFilter) ([]PayrollLoan, error) {
+	return f.service.ListLoans(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, filter)
+}
+
+func (f *PayrollFacade) GetLoan(ctx context.Context, actor AuthUser, loanID int64) (PayrollLoanDetail, error) {
+	return f.service.GetLoan(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, loanID)
+}
+
+func (f *PayrollFacade) CreateLoan(ctx context.Context, actor AuthUser, input PayrollLoanInput) (PayrollLoan, error) {
+	return f.service.CreateLoan(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) CancelLoan(ctx context.Context, actor AuthUser, loanID int64, input PayrollCancelLoanInput) (PayrollLoan, error) {
+	return f.service.CancelLoan(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, loanID, input)
+}
+
+func (f *PayrollFacade) GetLoanBalanceReport(ctx context.Context, actor AuthUser) (PayrollLoanBalanceReport, error) {
+	return f.service.GetLoanBalanceReport(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) ExportLoanBalancesCSV(ctx context.Context, actor AuthUser) (string, error) {
+	return f.service.ExportLoanBalancesCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) ListYTDTotals(ctx context.Context, actor AuthUser, fiscalYear int) ([]PayrollYTDTotals, error) {
+	return f.service.ListYTDTotals(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, fiscalYear)
+}
+
+func (f *PayrollFacade) RebuildYTDTotals(ctx context.Context, actor AuthUser, fiscalYear int) ([]PayrollYTDTotals, error) {
+	return f.service.RebuildYTDTotals(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, fiscalYear)
+}
+
+func (f *PayrollFacade) GetAnnualCertificate(ctx context.Context, actor AuthUser, employeeID int64, fiscalYear int) (PayrollAnnualCertificate, error) {
+	return f.service.GetAnnualCertificate(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, fiscalYear)
+}
+
+func (f *PayrollFacade) RenderAnnualCertificate(ctx context.Context, actor AuthUser, employeeID int64, fiscalYear int) (PayrollFile, error) {
+	return f.service.RenderAnnualCertificate(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, fiscalYear)
+}
+
+func (f *PayrollFacade) ExportAnnualCertificateCSV(ctx context.Context, actor AuthUser, employeeID int64, fiscalYear int) (string, error) {
+	return f.service.ExportAnnualCertificateCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, fiscalYear)
+}
+
+func (f *PayrollFacade) RenderMyAnnualCertificate(ctx context.Context, actor AuthUser, fiscalYear int) (PayrollFile, error) {
+	return f.service.RenderMyAnnualCertificate(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, fiscalYear)
+}
+
+func (f *PayrollFacade) GetAnnualReturn(ctx context.Context, actor AuthUser, fiscalYear int) (PayrollAnnualReturn, error) {
+	return f.service.GetAnnualReturn(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, fiscalYear)
+}
+
+func (f *PayrollFacade) ExportAnnualReturnCSV(ctx context.Context, actor AuthUser, fiscalYear int) (string, error) {
+	return f.service.ExportAnnualReturnCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, fiscalYear)
+}
+
+func (f *PayrollFacade) ListProjects(ctx context.Context, actor AuthUser) ([]PayrollProject, error) {
+	return f.service.ListProjects(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) CreateProject(ctx context.Context, actor AuthUser, input PayrollProjectInput) (PayrollProject, error) {
+	return f.service.CreateProject(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) UpdateProject(ctx context.Context, actor AuthUser, projectID int64, input PayrollProjectInput) (PayrollProject, error) {
+	return f.service.UpdateProject(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, projectID, input)
+}
+
+func (f *PayrollFacade) ListCostAllocations(ctx context.Context, actor AuthUser, employeeID int64) ([]PayrollCostAllocation, error) {
+	return f.service.ListCostAllocations(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID)
+}
+
+func (f *PayrollFacade) SetCostAllocation(ctx context.Context, actor AuthUser, employeeID int64, input PayrollCostAllocationInput) ([]PayrollCostAllocation, error) {
+	return f.service.SetCostAllocation(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, input)
+}
+
+func (f *PayrollFacade) DeleteCostAllocation(ctx context.Context, actor AuthUser, employeeID int64, effectiveFrom string) error {
+	return f.service.DeleteCostAllocation(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, employeeID, effectiveFrom)
+}
+
+func (f *PayrollFacade) GetCostAllocationReport(ctx context.Context, actor AuthUser, batchID int64) (PayrollCostAllocationReport, error) {
+	return f.service.GetCostAllocationReport(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ExportCostAllocationCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
+	return f.service.ExportCostAllocationCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ListGLAccounts(ctx context.Context, actor AuthUser) ([]PayrollGLAccount, error) {
+	return f.service.ListGLAccounts(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) UpdateGLAccounts(ctx context.Context, actor AuthUser, accounts []PayrollGLAccountInput) ([]PayrollGLAccount, error) {
+	return f.service.UpdateGLAccounts(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, accounts)
+}
+
+func (f *PayrollFacade) GetJournalLayout(ctx context.Context, actor AuthUser) (PayrollJournalLayout, error) {
+	return f.service.GetJournalLayout(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role})
+}
+
+func (f *PayrollFacade) UpdateJournalLayout(ctx context.Context, actor AuthUser, input PayrollJournalLayoutInput) (PayrollJournalLayout, error) {
+	return f.service.UpdateJournalLayout(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) GetJournal(ctx context.Context, actor AuthUser, batchID int64) (PayrollJournal, error) {
+	return f.service.GetJournal(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ExportJournalCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
+	return f.service.ExportJournalCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ListExchangeRates(ctx context.Context, actor AuthUser, month string) ([]PayrollExchangeRate, error) {
+	return f.service.ListExchangeRates(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, month)
+}
+
+func (f *PayrollFacade) SetExchangeRate(ctx context.Context, actor AuthUser, input PayrollExchangeRateInput) (PayrollExchangeRate, error) {
+	return f.service.SetExchangeRate(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
+func (f *PayrollFacade) DeleteExchangeRate(ctx context.Context, actor AuthUser, month, currency string) error {
+	return f.service.DeleteExchangeRate(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, month, currency)
+}
+
+func (f *PayrollFacade) GetCurrencyReport(ctx context.Context, actor AuthUser, batchID int64) (PayrollCurrencyReport, error) {
+	return f.service.GetCurrencyReport(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) ExportCurrencyCSV(ctx context.Context, actor AuthUser, batchID int64) (string, error) {
+	return f.service.ExportCurrencyCSV(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) VerifyBatch(ctx context.Context, actor AuthUser, batchID int64) (PayrollBatchVerification, error) {
+	return f.service.VerifyBatch(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, batchID)
+}
+
+func (f *PayrollFacade) SimulatePayroll(ctx context.Context, actor AuthUser, input PayrollSimulationInput) (PayrollSimulationReport, error) {
+	return f.service.SimulatePayroll(ctx, payroll.Actor{UserID: actor.ID, Role: actor.Role}, input)
+}
+
 func IsPayrollInvalidInput(err error) bool {
 	return errors.Is(err, payroll.ErrInvalidInput)
 }
@@ -90,3 +423,83 @@ func IsPayrollStatusTransition(err error) bool {
 func IsPayrollImmutable(err error) bool {
 	return errors.Is(err, payroll.ErrBatchImmutable)
 }
+
+func IsPayrollComponentNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrComponentNotFound)
+}
+
+func IsPayrollComponentExists(err error) bool {
+	return errors.Is(err, payroll.ErrComponentAlreadyExists)
+}
+
+func IsPayrollTaxTableNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrTaxTableNotFound)
+}
+
+func IsPayrollTaxOverrideReason(err error) bool {
+	return errors.Is(err, payroll.ErrTaxOverrideReason)
+}
+
+func IsPayrollContributionSchemeNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrContributionSchemeNotFound)
+}
+
+func IsPayrollContributionMemberNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrContributionMemberNotFound)
+}
+
+func IsPayrollBankDetailsInvalid(err error) bool {
+	return errors.Is(err, payroll.ErrBankDetailsInvalid)
+}
+
+func IsPayrollSegregationOfDuties(err error) bool {
+	return errors.Is(err, payroll.ErrSegregationOfDuties)
+}
+
+func IsPayrollApprovalInProgress(err error) bool {
+	return errors.Is(err, payroll.ErrApprovalInProgress)
+}
+
+func IsPayrollMonthBatchPending(err error) bool {
+	return errors.Is(err, payroll.ErrMonthBatchPending)
+}
+
+func IsPayrollJustificationRequired(err error) bool {
+	return errors.Is(err, payroll.ErrJustificationRequired)
+}
+
+func IsPayrollLoanNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrLoanNotFound)
+}
+
+func IsPayrollRecurringItemNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrRecurringItemNotFound)
+}
+
+func IsPayrollEmployeeNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrEmployeeNotFound)
+}
+
+func IsPayrollNoAnnualEarnings(err error) bool {
+	return errors.Is(err, payroll.ErrNoAnnualEarnings)
+}
+
+func IsPayrollProjectNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrProjectNotFound)
+}
+
+func IsPayrollProjectExists(err error) bool {
+	return errors.Is(err, payroll.ErrProjectAlreadyExists)
+}
+
+func IsPayrollCostAllocationNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrCostAllocationNotFound)
+}
+
+func IsPayrollGLAccountMissing(err error) bool {
+	return errors.Is(err, payroll.ErrGLAccountMissing)
+}
+
+func IsPayrollExchangeRateNotFound(err error) bool {
+	return errors.Is(err, payroll.ErrExchangeRateNotFound)
+}
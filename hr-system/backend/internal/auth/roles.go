@@ -0,0 +1,30 @@
+package auth
+
+// The roles a user account can hold.
+const (
+	RoleAdmin          = "Admin"
+	RoleHROfficer      = "HR Officer"
+	RoleFinanceOfficer = "Finance Officer"
+	RoleViewer         = "Viewer"
+	RoleMaster         = "Master"
+	RoleMasterAdmin    = "Master Admin"
+)
+
+// Roles lists every role the application authorizes.
+var Roles = []string{RoleAdmin, RoleHROfficer, RoleFinanceOfficer, RoleViewer, RoleMaster, RoleMasterAdmin}
+
+// IsKnownRole reports whether role is one of Roles.
+func IsKnownRole(role string) bool {
+	for _, known := range Roles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMasterRole reports whether role holds master rights; "Master" and
+// "Master Admin" are the same role under two names.
+func IsMasterRole(role string) bool {
+	return role == RoleMaster || role == RoleMasterAdmin
+}
@@ -24,6 +24,11 @@ type Config struct {
 	InitialAdminUsername string
 	InitialAdminPassword string
 	InitialAdminRole     string
+	EmployerName         string
+	EmployerAddress      string
+	EmployerTIN          string
+	EmployerBankCode     string
+	EmployerBankAccount  string
 }
 
 func Load() (Config, error) {
@@ -61,6 +66,11 @@ func Load() (Config, error) {
 		InitialAdminUsername: parseString("APP_INITIAL_ADMIN_USERNAME", ""),
 		InitialAdminPassword: parseString("APP_INITIAL_ADMIN_PASSWORD", ""),
 		InitialAdminRole:     parseString("APP_INITIAL_ADMIN_ROLE", "admin"),
+		EmployerName:         parseString("APP_EMPLOYER_NAME", "HISP Uganda"),
+		EmployerAddress:      parseString("APP_EMPLOYER_ADDRESS", ""),
+		EmployerTIN:          parseString("APP_EMPLOYER_TIN", ""),
+		EmployerBankCode:     parseString("APP_EMPLOYER_BANK_CODE", ""),
+		EmployerBankAccount:  parseString("APP_EMPLOYER_BANK_ACCOUNT", ""),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -3,7 +3,8 @@ package employees
 import "errors"
 
 var (
-	ErrInvalidInput       = errors.New("invalid employee input")
-	ErrEmployeeNotFound   = errors.New("employee not found")
-	ErrDepartmentNotFound = errors.New("department not found")
+	ErrInvalidInput         = errors.New("invalid employee input")
+	ErrEmployeeNotFound     = errors.New("employee not found")
+	ErrDepartmentNotFound   = errors.New("department not found")
+	ErrSalaryReasonRequired = errors.New("salary change reason is required")
 )
@@ -3,44 +3,121 @@ package employees
 import (
 	"database/sql"
 	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+const (
+	TaxResidencyResident    = "Resident"
+	TaxResidencyNonResident = "Non-Resident"
 )
 
+// DefaultSalaryCurrency is the currency payroll is paid in; salaries
+// contracted in another currency are converted when payroll is generated.
+const DefaultSalaryCurrency = "UGX"
+
 type Employee struct {
-	ID               int64          `db:"id" json:"id"`
-	FirstName        string         `db:"first_name" json:"first_name"`
-	LastName         string         `db:"last_name" json:"last_name"`
-	OtherName        sql.NullString `db:"other_name" json:"-"`
-	Gender           string         `db:"gender" json:"gender"`
-	DOB              time.Time      `db:"dob" json:"-"`
-	Phone            string         `db:"phone" json:"phone"`
-	Email            sql.NullString `db:"email" json:"-"`
-	NationalID       sql.NullString `db:"national_id" json:"-"`
-	Address          sql.NullString `db:"address" json:"-"`
-	DepartmentID     sql.NullInt64  `db:"department_id" json:"-"`
-	DepartmentName   sql.NullString `db:"department_name" json:"-"`
-	Position         string         `db:"position" json:"position"`
-	EmploymentStatus string         `db:"employment_status" json:"employment_status"`
-	HireDate         time.Time      `db:"hire_date" json:"-"`
-	BaseSalary       float64        `db:"base_salary" json:"base_salary"`
-	CreatedAt        time.Time      `db:"created_at" json:"-"`
-	UpdatedAt        time.Time      `db:"updated_at" json:"-"`
+	ID                int64          `db:"id" json:"id"`
+	FirstName         string         `db:"first_name" json:"first_name"`
+	LastName          string         `db:"last_name" json:"last_name"`
+	OtherName         sql.NullString `db:"other_name" json:"-"`
+	Gender            string         `db:"gender" json:"gender"`
+	DOB               time.Time      `db:"dob" json:"-"`
+	Phone             string         `db:"phone" json:"phone"`
+	Email             sql.NullString `db:"email" json:"-"`
+	NationalID        sql.NullString `db:"national_id" json:"-"`
+	Address           sql.NullString `db:"address" json:"-"`
+	DepartmentID      sql.NullInt64  `db:"department_id" json:"-"`
+	DepartmentName    sql.NullString `db:"department_name" json:"-"`
+	Position          string         `db:"position" json:"position"`
+	EmploymentStatus  string         `db:"employment_status" json:"employment_status"`
+	HireDate          time.Time      `db:"hire_date" json:"-"`
+	TerminationDate   sql.NullTime   `db:"termination_date" json:"-"`
+	BaseSalary        money.Amount   `db:"base_salary" json:"base_salary"`
+	SalaryCurrency    string         `db:"salary_currency" json:"salary_currency"`
+	TaxResidency      string         `db:"tax_residency" json:"tax_residency"`
+	BankName          sql.NullString `db:"bank_name" json:"-"`
+	BankBranch        sql.NullString `db:"bank_branch" json:"-"`
+	BankCode          sql.NullString `db:"bank_code" json:"-"`
+	BankAccountName   sql.NullString `db:"bank_account_name" json:"-"`
+	BankAccountNumber sql.NullString `db:"bank_account_number" json:"-"`
+	CreatedAt         time.Time      `db:"created_at" json:"-"`
+	UpdatedAt         time.Time      `db:"updated_at" json:"-"`
 }
 
 type UpsertEmployeeInput struct {
-	FirstName        string  `json:"first_name"`
-	LastName         string  `json:"last_name"`
-	OtherName        string  `json:"other_name"`
-	Gender           string  `json:"gender"`
-	DOB              string  `json:"dob"`
-	Phone            string  `json:"phone"`
-	Email            string  `json:"email"`
-	NationalID       string  `json:"national_id"`
-	Address          string  `json:"address"`
-	DepartmentID     *int64  `json:"department_id"`
-	Position         string  `json:"position"`
-	EmploymentStatus string  `json:"employment_status"`
-	HireDate         string  `json:"hire_date"`
-	BaseSalary       float64 `json:"base_salary"`
+	FirstName         string       `json:"first_name"`
+	LastName          string       `json:"last_name"`
+	OtherName         string       `json:"other_name"`
+	Gender            string       `json:"gender"`
+	DOB               string       `json:"dob"`
+	Phone             string       `json:"phone"`
+	Email             string       `json:"email"`
+	NationalID        string       `json:"national_id"`
+	Address           string       `json:"address"`
+	DepartmentID      *int64       `json:"department_id"`
+	Position          string       `json:"position"`
+	EmploymentStatus  string       `json:"employment_status"`
+	HireDate          string       `json:"hire_date"`
+	TerminationDate   string       `json:"termination_date"`
+	BaseSalary        money.Amount `json:"base_salary"`
+	SalaryCurrency    string       `json:"salary_currency"`
+	TaxResidency      string       `json:"tax_residency"`
+	BankName          string       `json:"bank_name"`
+	BankBranch        string       `json:"bank_branch"`
+	BankCode          string       `json:"bank_code"`
+	BankAccountName   string       `json:"bank_account_name"`
+	BankAccountNumber string       `json:"bank_account_number"`
+
+	// A base salary or currency change is recorded in the salary history. The
+	// reason is required when the salary changes on update; the effective date
+	// defaults to today and the approver to the acting user.
+	SalaryEffectiveFrom string `json:"salary_effective_from"`
+	SalaryChangeReason  string `json:"salary_change_reason"`
+	SalaryApprovedBy    *int64 `json:"salary_approved_by"`
+}
+
+type Actor struct {
+	UserID int64
+	Role   string
+}
+
+// SalaryChange is one row appended to an employee's salary history.
+type SalaryChange struct {
+	BaseSalary    money.Amount
+	Currency      string
+	EffectiveFrom string
+	Reason        string
+	ApprovedBy    int64
+	RecordedBy    int64
+}
+
+type SalaryHistory struct {
+	ID             int64          `db:"id"`
+	EmployeeID     int64          `db:"employee_id"`
+	BaseSalary     money.Amount   `db:"base_salary"`
+	Currency       string         `db:"currency"`
+	EffectiveFrom  time.Time      `db:"effective_from"`
+	Reason         string         `db:"reason"`
+	ApprovedBy     sql.NullInt64  `db:"approved_by"`
+	ApprovedByName sql.NullString `db:"approved_by_name"`
+	RecordedBy     sql.NullInt64  `db:"recorded_by"`
+	RecordedByName sql.NullString `db:"recorded_by_name"`
+	CreatedAt      time.Time      `db:"created_at"`
+}
+
+type SalaryHistoryView struct {
+	ID             int64        `json:"id"`
+	EmployeeID     int64        `json:"employee_id"`
+	BaseSalary     money.Amount `json:"base_salary"`
+	Currency       string       `json:"currency"`
+	EffectiveFrom  string       `json:"effective_from"`
+	Reason         string       `json:"reason"`
+	ApprovedBy     *int64       `json:"approved_by"`
+	ApprovedByName string       `json:"approved_by_name"`
+	RecordedBy     *int64       `json:"recorded_by"`
+	RecordedByName string       `json:"recorded_by_name"`
+	CreatedAt      string       `json:"created_at"`
 }
 
 type EmployeeListFilter struct {
@@ -59,24 +136,32 @@ type EmployeeListResult struct {
 }
 
 type EmployeeView struct {
-	ID               int64   `json:"id"`
-	FirstName        string  `json:"first_name"`
-	LastName         string  `json:"last_name"`
-	OtherName        string  `json:"other_name"`
-	Gender           string  `json:"gender"`
-	DOB              string  `json:"dob"`
-	Phone            string  `json:"phone"`
-	Email            string  `json:"email"`
-	NationalID       string  `json:"national_id"`
-	Address          string  `json:"address"`
-	DepartmentID     *int64  `json:"department_id"`
-	DepartmentName   string  `json:"department_name"`
-	Position         string  `json:"position"`
-	EmploymentStatus string  `json:"employment_status"`
-	HireDate         string  `json:"hire_date"`
-	BaseSalary       float64 `json:"base_salary"`
-	CreatedAt        string  `json:"created_at"`
-	UpdatedAt        string  `json:"updated_at"`
+	ID                int64        `json:"id"`
+	FirstName         string       `json:"first_name"`
+	LastName          string       `json:"last_name"`
+	OtherName         string       `json:"other_name"`
+	Gender            string       `json:"gender"`
+	DOB               string       `json:"dob"`
+	Phone             string       `json:"phone"`
+	Email             string       `json:"email"`
+	NationalID        string       `json:"national_id"`
+	Address           string       `json:"address"`
+	DepartmentID      *int64       `json:"department_id"`
+	DepartmentName    string       `json:"department_name"`
+	Position          string       `json:"position"`
+	EmploymentStatus  string       `json:"employment_status"`
+	HireDate          string       `json:"hire_date"`
+	TerminationDate   string       `json:"termination_date"`
+	BaseSalary        money.Amount `json:"base_salary"`
+	SalaryCurrency    string       `json:"salary_currency"`
+	TaxResidency      string       `json:"tax_residency"`
+	BankName          string       `json:"bank_name"`
+	BankBranch        string       `json:"bank_branch"`
+	BankCode          string       `json:"bank_code"`
+	BankAccountName   string       `json:"bank_account_name"`
+	BankAccountNumber string       `json:"bank_account_number"`
+	CreatedAt         string       `json:"created_at"`
+	UpdatedAt         string       `json:"updated_at"`
 }
 
 type Department struct {
@@ -97,24 +182,48 @@ func ToEmployeeView(row Employee) EmployeeView {
 	}
 
 	return EmployeeView{
-		ID:               row.ID,
-		FirstName:        row.FirstName,
-		LastName:         row.LastName,
-		OtherName:        nullString(row.OtherName),
-		Gender:           row.Gender,
-		DOB:              row.DOB.Format("2006-01-02"),
-		Phone:            row.Phone,
-		Email:            nullString(row.Email),
-		NationalID:       nullString(row.NationalID),
-		Address:          nullString(row.Address),
-		DepartmentID:     departmentID,
-		DepartmentName:   nullString(row.DepartmentName),
-		Position:         row.Position,
-		EmploymentStatus: row.EmploymentStatus,
-		HireDate:         row.HireDate.Format("2006-01-02"),
-		BaseSalary:       row.BaseSalary,
-		CreatedAt:        row.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:        row.UpdatedAt.UTC().Format(time.RFC3339),
+		ID:                row.ID,
+		FirstName:         row.FirstName,
+		LastName:          row.LastName,
+		OtherName:         nullString(row.OtherName),
+		Gender:            row.Gender,
+		DOB:               row.DOB.Format("2006-01-02"),
+		Phone:             row.Phone,
+		Email:             nullString(row.Email),
+		NationalID:        nullString(row.NationalID),
+		Address:           nullString(row.Address),
+		DepartmentID:      departmentID,
+		DepartmentName:    nullString(row.DepartmentName),
+		Position:          row.Position,
+		EmploymentStatus:  row.EmploymentStatus,
+		HireDate:          row.HireDate.Format("2006-01-02"),
+		TerminationDate:   nullDate(row.TerminationDate),
+		BaseSalary:        row.BaseSalary,
+		SalaryCurrency:    row.SalaryCurrency,
+		TaxResidency:      row.TaxResidency,
+		BankName:          nullString(row.BankName),
+		BankBranch:        nullString(row.BankBranch),
+		BankCode:          nullString(row.BankCode),
+		BankAccountName:   nullString(row.BankAccountName),
+		BankAccountNumber: nullString(row.BankAccountNumber),
+		CreatedAt:         row.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:         row.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func ToSalaryHistoryView(row SalaryHistory) SalaryHistoryView {
+	return SalaryHistoryView{
+		ID:             row.ID,
+		EmployeeID:     row.EmployeeID,
+		BaseSalary:     row.BaseSalary,
+		Currency:       row.Currency,
+		EffectiveFrom:  row.EffectiveFrom.Format("2006-01-02"),
+		Reason:         row.Reason,
+		ApprovedBy:     nullInt64(row.ApprovedBy),
+		ApprovedByName: nullString(row.ApprovedByName),
+		RecordedBy:     nullInt64(row.RecordedBy),
+		RecordedByName: nullString(row.RecordedByName),
+		CreatedAt:      row.CreatedAt.UTC().Format(time.RFC3339),
 	}
 }
 
@@ -124,3 +233,18 @@ func nullString(value sql.NullString) string {
 	}
 	return value.String
 }
+
+func nullInt64(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	id := value.Int64
+	return &id
+}
+
+func nullDate(value sql.NullTime) string {
+	if !value.Valid {
+		return ""
+	}
+	return value.Time.Format("2006-01-02")
+}
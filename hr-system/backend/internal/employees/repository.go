@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -18,7 +19,7 @@ func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) CreateEmployee(ctx context.Context, input UpsertEmployeeInput) (Employee, error) {
+func (r *Repository) CreateEmployee(ctx context.Context, input UpsertEmployeeInput, change SalaryChange) (Employee, error) {
 	const query = `
 		INSERT INTO employees (
 			first_name,
@@ -34,7 +35,15 @@ func (r *Repository) CreateEmployee(ctx context.Context, input UpsertEmployeeInp
 			position,
 			employment_status,
 			hire_date,
-			base_salary
+			termination_date,
+			base_salary,
+			salary_currency,
+			tax_residency,
+			bank_name,
+			bank_branch,
+			bank_code,
+			bank_account_name,
+			bank_account_number
 		)
 		VALUES (
 			:first_name,
@@ -50,7 +59,15 @@ func (r *Repository) CreateEmployee(ctx context.Context, input UpsertEmployeeInp
 			:position,
 			:employment_status,
 			:hire_date,
-			:base_salary
+			:termination_date,
+			:base_salary,
+			:salary_currency,
+			:tax_residency,
+			:bank_name,
+			:bank_branch,
+			:bank_code,
+			:bank_account_name,
+			:bank_account_number
 		)
 		RETURNING
 			id,
@@ -68,30 +85,43 @@ func (r *Repository) CreateEmployee(ctx context.Context, input UpsertEmployeeInp
 			position,
 			employment_status,
 			hire_date,
+			termination_date,
 			base_salary,
+			salary_currency,
+			tax_residency,
+			bank_name,
+			bank_branch,
+			bank_code,
+			bank_account_name,
+			bank_account_number,
 			created_at,
 			updated_at
 	`
 
-	row := mapToNamedParams(input)
-	rows, err := r.db.NamedQueryContext(ctx, query, row)
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return Employee{}, fmt.Errorf("create employee: %w", err)
+		return Employee{}, fmt.Errorf("begin create employee tx: %w", err)
 	}
-	defer rows.Close()
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	if !rows.Next() {
-		return Employee{}, ErrEmployeeNotFound
+	employee, err := namedGetEmployee(ctx, tx, query, mapToNamedParams(input))
+	if err != nil {
+		return Employee{}, fmt.Errorf("create employee: %w", err)
 	}
-
-	var employee Employee
-	if err := rows.StructScan(&employee); err != nil {
-		return Employee{}, fmt.Errorf("scan created employee: %w", err)
+	if err := insertSalaryChange(ctx, tx, employee.ID, change); err != nil {
+		return Employee{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Employee{}, fmt.Errorf("commit create employee tx: %w", err)
 	}
 	return employee, nil
 }
 
-func (r *Repository) UpdateEmployee(ctx context.Context, employeeID int64, input UpsertEmployeeInput) (Employee, error) {
+// UpdateEmployee saves the employee and, when change is set, appends it to the
+// salary history in the same transaction.
+func (r *Repository) UpdateEmployee(ctx context.Context, employeeID int64, input UpsertEmployeeInput, change *SalaryChange) (Employee, error) {
 	const query = `
 		UPDATE employees
 		SET
@@ -108,7 +138,15 @@ func (r *Repository) UpdateEmployee(ctx context.Context, employeeID int64, input
 			position = :position,
 			employment_status = :employment_status,
 			hire_date = :hire_date,
+			termination_date = :termination_date,
 			base_salary = :base_salary,
+			salary_currency = :salary_currency,
+			tax_residency = :tax_residency,
+			bank_name = :bank_name,
+			bank_branch = :bank_branch,
+			bank_code = :bank_code,
+			bank_account_name = :bank_account_name,
+			bank_account_number = :bank_account_number,
 			updated_at = NOW()
 		WHERE id = :id
 		RETURNING
@@ -127,30 +165,113 @@ func (r *Repository) UpdateEmployee(ctx context.Context, employeeID int64, input
 			position,
 			employment_status,
 			hire_date,
+			termination_date,
 			base_salary,
+			salary_currency,
+			tax_residency,
+			bank_name,
+			bank_branch,
+			bank_code,
+			bank_account_name,
+			bank_account_number,
 			created_at,
 			updated_at
 	`
 
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Employee{}, fmt.Errorf("begin update employee tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	row := mapToNamedParams(input)
 	row["id"] = employeeID
-	rows, err := r.db.NamedQueryContext(ctx, query, row)
+	employee, err := namedGetEmployee(ctx, tx, query, row)
 	if err != nil {
+		if errors.Is(err, ErrEmployeeNotFound) {
+			return Employee{}, err
+		}
 		return Employee{}, fmt.Errorf("update employee: %w", err)
 	}
+	if change != nil {
+		if err := insertSalaryChange(ctx, tx, employeeID, *change); err != nil {
+			return Employee{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Employee{}, fmt.Errorf("commit update employee tx: %w", err)
+	}
+	return employee, nil
+}
+
+func (r *Repository) ListSalaryHistory(ctx context.Context, employeeID int64) ([]SalaryHistory, error) {
+	const query = `
+		SELECT
+			h.id,
+			h.employee_id,
+			h.base_salary,
+			h.currency,
+			h.effective_from,
+			h.reason,
+			h.approved_by,
+			approver.username AS approved_by_name,
+			h.recorded_by,
+			recorder.username AS recorded_by_name,
+			h.created_at
+		FROM employee_salary_history h
+		LEFT JOIN users approver ON approver.id = h.approved_by
+		LEFT JOIN users recorder ON recorder.id = h.recorded_by
+		WHERE h.employee_id = $1
+		ORDER BY h.effective_from DESC, h.id DESC
+	`
+	rows := make([]SalaryHistory, 0)
+	if err := r.db.SelectContext(ctx, &rows, query, employeeID); err != nil {
+		return nil, fmt.Errorf("list salary history: %w", err)
+	}
+	return rows, nil
+}
+
+func namedGetEmployee(ctx context.Context, tx *sqlx.Tx, query string, params map[string]any) (Employee, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, params)
+	if err != nil {
+		return Employee{}, err
+	}
 	defer rows.Close()
 
 	if !rows.Next() {
 		return Employee{}, ErrEmployeeNotFound
 	}
-
 	var employee Employee
 	if err := rows.StructScan(&employee); err != nil {
-		return Employee{}, fmt.Errorf("scan updated employee: %w", err)
+		return Employee{}, fmt.Errorf("scan employee: %w", err)
 	}
 	return employee, nil
 }
 
+func insertSalaryChange(ctx context.Context, tx *sqlx.Tx, employeeID int64, change SalaryChange) error {
+	const query = `
+		INSERT INTO employee_salary_history (employee_id, base_salary, currency, effective_from, reason, approved_by, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		employeeID,
+		change.BaseSalary,
+		change.Currency,
+		change.EffectiveFrom,
+		change.Reason,
+		nullableInt64(&change.ApprovedBy),
+		nullableInt64(&change.RecordedBy),
+	); err != nil {
+		if isForeignKeyViolation(err, "employee_salary_history_approved_by_fkey") {
+			return ErrInvalidInput
+		}
+		return fmt.Errorf("record salary change: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) DeleteEmployee(ctx context.Context, employeeID int64) error {
 	const query = `DELETE FROM employees WHERE id = $1`
 	res, err := r.db.ExecContext(ctx, query, employeeID)
@@ -185,7 +306,15 @@ func (r *Repository) GetEmployee(ctx context.Context, employeeID int64) (Employe
 			e.position,
 			e.employment_status,
 			e.hire_date,
+			e.termination_date,
 			e.base_salary,
+			e.salary_currency,
+			e.tax_residency,
+			e.bank_name,
+			e.bank_branch,
+			e.bank_code,
+			e.bank_account_name,
+			e.bank_account_number,
 			e.created_at,
 			e.updated_at
 		FROM employees e
@@ -234,7 +363,15 @@ func (r *Repository) ListEmployees(ctx context.Context, filter EmployeeListFilte
 			e.position,
 			e.employment_status,
 			e.hire_date,
+			e.termination_date,
 			e.base_salary,
+			e.salary_currency,
+			e.tax_residency,
+			e.bank_name,
+			e.bank_branch,
+			e.bank_code,
+			e.bank_account_name,
+			e.bank_account_number,
 			e.created_at,
 			e.updated_at
 		FROM employees e
@@ -313,20 +450,28 @@ func buildEmployeeFilters(filter EmployeeListFilter) (string, []any) {
 
 func mapToNamedParams(input UpsertEmployeeInput) map[string]any {
 	return map[string]any{
-		"first_name":        input.FirstName,
-		"last_name":         input.LastName,
-		"other_name":        nullableString(input.OtherName),
-		"gender":            input.Gender,
-		"dob":               input.DOB,
-		"phone":             input.Phone,
-		"email":             nullableString(input.Email),
-		"national_id":       nullableString(input.NationalID),
-		"address":           nullableString(input.Address),
-		"department_id":     nullableInt64(input.DepartmentID),
-		"position":          input.Position,
-		"employment_status": input.EmploymentStatus,
-		"hire_date":         input.HireDate,
-		"base_salary":       input.BaseSalary,
+		"first_name":          input.FirstName,
+		"last_name":           input.LastName,
+		"other_name":          nullableString(input.OtherName),
+		"gender":              input.Gender,
+		"dob":                 input.DOB,
+		"phone":               input.Phone,
+		"email":               nullableString(input.Email),
+		"national_id":         nullableString(input.NationalID),
+		"address":             nullableString(input.Address),
+		"department_id":       nullableInt64(input.DepartmentID),
+		"position":            input.Position,
+		"employment_status":   input.EmploymentStatus,
+		"hire_date":           input.HireDate,
+		"termination_date":    nullableString(input.TerminationDate),
+		"base_salary":         input.BaseSalary,
+		"salary_currency":     input.SalaryCurrency,
+		"tax_residency":       input.TaxResidency,
+		"bank_name":           nullableString(input.BankName),
+		"bank_branch":         nullableString(input.BankBranch),
+		"bank_code":           nullableString(input.BankCode),
+		"bank_account_name":   nullableString(input.BankAccountName),
+		"bank_account_number": nullableString(input.BankAccountNumber),
 	}
 }
 
@@ -344,3 +489,11 @@ func nullableInt64(value *int64) any {
 	}
 	return *value
 }
+
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23503" && pqErr.Constraint == constraint
+}
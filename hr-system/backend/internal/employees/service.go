@@ -19,7 +19,7 @@ func NewService(repo *Repository) (*Service, error) {
 	return &Service{repo: repo}, nil
 }
 
-func (s *Service) CreateEmployee(ctx context.Context, input UpsertEmployeeInput) (EmployeeView, error) {
+func (s *Service) CreateEmployee(ctx context.Context, actor Actor, input UpsertEmployeeInput) (EmployeeView, error) {
 	normalized, err := s.normalizeInput(input)
 	if err != nil {
 		return EmployeeView{}, err
@@ -28,7 +28,14 @@ func (s *Service) CreateEmployee(ctx context.Context, input UpsertEmployeeInput)
 		return EmployeeView{}, err
 	}
 
-	row, err := s.repo.CreateEmployee(ctx, normalized)
+	// The starting salary applies from the hire date.
+	reason := normalized.SalaryChangeReason
+	if reason == "" {
+		reason = "Starting salary"
+	}
+	change := newSalaryChange(actor, normalized, normalized.HireDate, reason)
+
+	row, err := s.repo.CreateEmployee(ctx, normalized, change)
 	if err != nil {
 		return EmployeeView{}, err
 	}
@@ -40,7 +47,7 @@ func (s *Service) CreateEmployee(ctx context.Context, input UpsertEmployeeInput)
 	return ToEmployeeView(created), nil
 }
 
-func (s *Service) UpdateEmployee(ctx context.Context, employeeID int64, input UpsertEmployeeInput) (EmployeeView, error) {
+func (s *Service) UpdateEmployee(ctx context.Context, actor Actor, employeeID int64, input UpsertEmployeeInput) (EmployeeView, error) {
 	if employeeID <= 0 {
 		return EmployeeView{}, ErrInvalidInput
 	}
@@ -53,7 +60,27 @@ func (s *Service) UpdateEmployee(ctx context.Context, employeeID int64, input Up
 		return EmployeeView{}, err
 	}
 
-	_, err = s.repo.UpdateEmployee(ctx, employeeID, normalized)
+	current, err := s.repo.GetEmployee(ctx, employeeID)
+	if err != nil {
+		return EmployeeView{}, err
+	}
+	var change *SalaryChange
+	if normalized.BaseSalary != current.BaseSalary || normalized.SalaryCurrency != current.SalaryCurrency {
+		if normalized.SalaryChangeReason == "" {
+			return EmployeeView{}, ErrSalaryReasonRequired
+		}
+		effectiveFrom := normalized.SalaryEffectiveFrom
+		if effectiveFrom == "" {
+			effectiveFrom = time.Now().UTC().Format("2006-01-02")
+		}
+		if effectiveFrom < normalized.HireDate {
+			return EmployeeView{}, ErrInvalidInput
+		}
+		salaryChange := newSalaryChange(actor, normalized, effectiveFrom, normalized.SalaryChangeReason)
+		change = &salaryChange
+	}
+
+	_, err = s.repo.UpdateEmployee(ctx, employeeID, normalized, change)
 	if err != nil {
 		return EmployeeView{}, err
 	}
@@ -83,6 +110,26 @@ func (s *Service) GetEmployee(ctx context.Context, employeeID int64) (EmployeeVi
 	return ToEmployeeView(row), nil
 }
 
+// ListSalaryHistory returns an employee's salary changes, newest first.
+func (s *Service) ListSalaryHistory(ctx context.Context, employeeID int64) ([]SalaryHistoryView, error) {
+	if employeeID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.GetEmployee(ctx, employeeID); err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListSalaryHistory(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]SalaryHistoryView, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ToSalaryHistoryView(row))
+	}
+	return items, nil
+}
+
 func (s *Service) ListEmployees(ctx context.Context, filter EmployeeListFilter) (EmployeeListResult, error) {
 	normalizedFilter := filter
 	if normalizedFilter.Page <= 0 {
@@ -149,6 +196,22 @@ func (s *Service) normalizeInput(input UpsertEmployeeInput) (UpsertEmployeeInput
 	normalized.EmploymentStatus = strings.TrimSpace(input.EmploymentStatus)
 	normalized.DOB = strings.TrimSpace(input.DOB)
 	normalized.HireDate = strings.TrimSpace(input.HireDate)
+	normalized.TerminationDate = strings.TrimSpace(input.TerminationDate)
+	normalized.SalaryCurrency = strings.ToUpper(strings.TrimSpace(input.SalaryCurrency))
+	if normalized.SalaryCurrency == "" {
+		normalized.SalaryCurrency = DefaultSalaryCurrency
+	}
+	normalized.TaxResidency = strings.TrimSpace(input.TaxResidency)
+	if normalized.TaxResidency == "" {
+		normalized.TaxResidency = TaxResidencyResident
+	}
+	normalized.BankName = strings.TrimSpace(input.BankName)
+	normalized.BankBranch = strings.TrimSpace(input.BankBranch)
+	normalized.BankCode = strings.ToUpper(strings.TrimSpace(input.BankCode))
+	normalized.BankAccountName = strings.TrimSpace(input.BankAccountName)
+	normalized.BankAccountNumber = normalizeAccountNumber(input.BankAccountNumber)
+	normalized.SalaryEffectiveFrom = strings.TrimSpace(input.SalaryEffectiveFrom)
+	normalized.SalaryChangeReason = strings.TrimSpace(input.SalaryChangeReason)
 
 	if normalized.FirstName == "" || normalized.LastName == "" {
 		return UpsertEmployeeInput{}, ErrInvalidInput
@@ -156,15 +219,28 @@ func (s *Service) normalizeInput(input UpsertEmployeeInput) (UpsertEmployeeInput
 	if normalized.Gender == "" || normalized.Phone == "" || normalized.Position == "" || normalized.EmploymentStatus == "" {
 		return UpsertEmployeeInput{}, ErrInvalidInput
 	}
-	if normalized.BaseSalary < 0 {
+	if normalized.BaseSalary.IsNegative() {
+		return UpsertEmployeeInput{}, ErrInvalidInput
+	}
+	if !IsCurrencyCode(normalized.SalaryCurrency) {
+		return UpsertEmployeeInput{}, ErrInvalidInput
+	}
+	if normalized.TaxResidency != TaxResidencyResident && normalized.TaxResidency != TaxResidencyNonResident {
 		return UpsertEmployeeInput{}, ErrInvalidInput
 	}
 	if _, err := time.Parse("2006-01-02", normalized.DOB); err != nil {
 		return UpsertEmployeeInput{}, ErrInvalidInput
 	}
-	if _, err := time.Parse("2006-01-02", normalized.HireDate); err != nil {
+	hireDate, err := time.Parse("2006-01-02", normalized.HireDate)
+	if err != nil {
 		return UpsertEmployeeInput{}, ErrInvalidInput
 	}
+	if normalized.TerminationDate != "" {
+		terminationDate, err := time.Parse("2006-01-02", normalized.TerminationDate)
+		if err != nil || terminationDate.Before(hireDate) {
+			return UpsertEmployeeInput{}, ErrInvalidInput
+		}
+	}
 	if normalized.Email != "" {
 		if _, err := mail.ParseAddress(normalized.Email); err != nil {
 			return UpsertEmployeeInput{}, ErrInvalidInput
@@ -173,10 +249,78 @@ func (s *Service) normalizeInput(input UpsertEmployeeInput) (UpsertEmployeeInput
 	if normalized.DepartmentID != nil && *normalized.DepartmentID <= 0 {
 		return UpsertEmployeeInput{}, ErrInvalidInput
 	}
+	if normalized.BankAccountNumber != "" && (normalized.BankName == "" || !IsAccountNumber(normalized.BankAccountNumber)) {
+		return UpsertEmployeeInput{}, ErrInvalidInput
+	}
+	if normalized.BankCode != "" && !isAlphanumeric(normalized.BankCode, 11) {
+		return UpsertEmployeeInput{}, ErrInvalidInput
+	}
+	if normalized.SalaryEffectiveFrom != "" {
+		if _, err := time.Parse("2006-01-02", normalized.SalaryEffectiveFrom); err != nil {
+			return UpsertEmployeeInput{}, ErrInvalidInput
+		}
+	}
+	if normalized.SalaryApprovedBy != nil && *normalized.SalaryApprovedBy <= 0 {
+		return UpsertEmployeeInput{}, ErrInvalidInput
+	}
 
 	return normalized, nil
 }
 
+func newSalaryChange(actor Actor, input UpsertEmployeeInput, effectiveFrom, reason string) SalaryChange {
+	approvedBy := actor.UserID
+	if input.SalaryApprovedBy != nil {
+		approvedBy = *input.SalaryApprovedBy
+	}
+	return SalaryChange{
+		BaseSalary:    input.BaseSalary,
+		Currency:      input.SalaryCurrency,
+		EffectiveFrom: effectiveFrom,
+		Reason:        reason,
+		ApprovedBy:    approvedBy,
+		RecordedBy:    actor.UserID,
+	}
+}
+
+// normalizeAccountNumber drops the spaces and dashes people type when copying
+// account numbers from bank letters.
+func normalizeAccountNumber(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, " ", "")
+	return strings.ToUpper(strings.ReplaceAll(value, "-", ""))
+}
+
+// IsAccountNumber reports whether value, already normalized, is a bank
+// account number every payroll bank export accepts: 5-34 letters or digits.
+func IsAccountNumber(value string) bool {
+	return len(value) >= 5 && isAlphanumeric(value, 34)
+}
+
+func isAlphanumeric(value string, maxLength int) bool {
+	if len(value) > maxLength {
+		return false
+	}
+	for _, char := range value {
+		if !(char >= 'A' && char <= 'Z') && !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsCurrencyCode reports whether value is a three-letter ISO 4217 code.
+func IsCurrencyCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, char := range value {
+		if char < 'A' || char > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Service) ensureDepartmentIntegrity(ctx context.Context, departmentID *int64) error {
 	if departmentID == nil {
 		return nil
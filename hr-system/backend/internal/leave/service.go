@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"hr-system/backend/internal/auth"
 )
 
 type Actor struct {
@@ -387,7 +389,7 @@ func isHR(role string) bool {
 }
 
 func isMaster(role string) bool {
-	return role == "Master" || role == "Master Admin"
+	return auth.IsMasterRole(role)
 }
 
 func isStaff(role string) bool {
@@ -0,0 +1,273 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount = errors.New("invalid money amount")
+	ErrOutOfRange    = errors.New("money amount out of range")
+)
+
+// Amount is a fixed-point currency value held as a whole number of cents, so
+// sums of amounts never drift the way float64 sums do. Conversions from
+// decimal text, floats and rate multiplication round half-up (halves move away
+// from zero) to the nearest cent.
+type Amount struct {
+	cents int64
+}
+
+var (
+	bigOne     = big.NewInt(1)
+	bigTwo     = big.NewInt(2)
+	bigHundred = big.NewRat(100, 1)
+)
+
+func FromCents(cents int64) Amount {
+	return Amount{cents: cents}
+}
+
+// FromInt returns an amount of whole currency units.
+func FromInt(units int64) Amount {
+	return Amount{cents: units * 100}
+}
+
+// FromFloat converts using the float's shortest decimal representation, so
+// 1.005 becomes 1.01 rather than the 1.00 a binary round would give.
+func FromFloat(value float64) (Amount, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Amount{}, ErrInvalidAmount
+	}
+	return Parse(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Parse reads a decimal string such as "1250.5" or "-3.125".
+func Parse(value string) (Amount, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return Amount{}, ErrInvalidAmount
+	}
+	rat, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	return fromRat(new(big.Rat).Mul(rat, bigHundred))
+}
+
+// MustParse is Parse for constants known to be valid.
+func MustParse(value string) Amount {
+	amount, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, amount := range amounts {
+		total.cents += amount.cents
+	}
+	return total
+}
+
+func Min(a, b Amount) Amount {
+	if a.cents < b.cents {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Amount) Amount {
+	if a.cents > b.cents {
+		return a
+	}
+	return b
+}
+
+func (a Amount) Cents() int64 {
+	return a.cents
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{cents: a.cents + b.cents}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{cents: a.cents - b.cents}
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{cents: -a.cents}
+}
+
+// MulRate multiplies by a decimal rate (0.3 for 30%) and rounds half-up to
+// cents. The rate is taken at its shortest decimal form, which is exact for the
+// NUMERIC rates stored in the database.
+func (a Amount) MulRate(rate float64) (Amount, error) {
+	ratio, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: rate %v", ErrInvalidAmount, rate)
+	}
+	return fromRat(new(big.Rat).Mul(new(big.Rat).SetInt64(a.cents), ratio))
+}
+
+// MulFrac multiplies by numerator/denominator and rounds half-up to cents.
+func (a Amount) MulFrac(numerator, denominator int64) (Amount, error) {
+	if denominator == 0 {
+		return Amount{}, fmt.Errorf("%w: zero denominator", ErrInvalidAmount)
+	}
+	return fromRat(new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(a.cents), big.NewInt(numerator)),
+		big.NewInt(denominator),
+	))
+}
+
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.cents < b.cents:
+		return -1
+	case a.cents > b.cents:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (a Amount) IsZero() bool {
+	return a.cents == 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a.cents < 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a.cents > 0
+}
+
+func (a Amount) LessThan(b Amount) bool {
+	return a.cents < b.cents
+}
+
+func (a Amount) GreaterThan(b Amount) bool {
+	return a.cents > b.cents
+}
+
+// Float64 is for display and ratio maths only; never feed it back into sums.
+func (a Amount) Float64() float64 {
+	return float64(a.cents) / 100
+}
+
+// String renders the amount with exactly two decimals, e.g. "-1250.05".
+func (a Amount) String() string {
+	cents := a.cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// FormatThousands renders the amount for documents, e.g. "1,250,000.00".
+func (a Amount) FormatThousands() string {
+	plain := a.String()
+	sign := ""
+	if strings.HasPrefix(plain, "-") {
+		sign = "-"
+		plain = plain[1:]
+	}
+	whole, cents, _ := strings.Cut(plain, ".")
+	var sb strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	return sign + sb.String() + "." + cents
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func (a *Amount) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*a = Amount{}
+		return nil
+	case []byte:
+		parsed, err := Parse(string(value))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case string:
+		parsed, err := Parse(value)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case int64:
+		*a = FromInt(value)
+		return nil
+	case float64:
+		parsed, err := FromFloat(value)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+}
+
+// MarshalJSON emits a plain JSON number so API consumers keep reading numbers.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string and parses the
+// literal text, so no binary float rounding happens on the way in.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		return nil
+	}
+	text = strings.Trim(text, `"`)
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// fromRat rounds a value expressed in cents half-up to a whole cent.
+func fromRat(cents *big.Rat) (Amount, error) {
+	num := cents.Num()
+	den := cents.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), bigTwo).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quotient.Sub(quotient, bigOne)
+		} else {
+			quotient.Add(quotient, bigOne)
+		}
+	}
+	if !quotient.IsInt64() {
+		return Amount{}, ErrOutOfRange
+	}
+	return Amount{cents: quotient.Int64()}, nil
+}
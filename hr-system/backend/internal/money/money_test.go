@@ -0,0 +1,144 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseRoundsHalfUpToCents(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "0", want: "0.00"},
+		{in: "1250.5", want: "1250.50"},
+		{in: "0.125", want: "0.13"},
+		{in: "0.124", want: "0.12"},
+		{in: "-0.125", want: "-0.13"},
+		{in: "-0.005", want: "-0.01"},
+		{in: "1.005", want: "1.01"},
+		{in: "1e3", want: "1000.00"},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.in, err)
+		}
+		if got.String() != tc.want {
+			t.Fatalf("parse %q: expected %s, got %s", tc.in, tc.want, got)
+		}
+	}
+
+	if _, err := Parse("abc"); err == nil {
+		t.Fatalf("expected invalid amount error")
+	}
+}
+
+func TestFromFloatUsesShortestDecimal(t *testing.T) {
+	// 1.005 is 1.00499999... in binary; money treats it as the decimal the caller typed.
+	got, err := FromFloat(1.005)
+	if err != nil {
+		t.Fatalf("from float: %v", err)
+	}
+	if got != FromCents(101) {
+		t.Fatalf("expected 1.01, got %s", got)
+	}
+}
+
+func TestMulRateRoundsHalfUp(t *testing.T) {
+	cases := []struct {
+		got  func() (Amount, error)
+		want Amount
+	}{
+		{func() (Amount, error) { return MustParse("0.25").MulRate(0.5) }, FromCents(13)},
+		{func() (Amount, error) { return FromInt(65000).MulRate(0.1) }, FromInt(6500)},
+		{func() (Amount, error) { return FromInt(100).MulFrac(1, 3) }, MustParse("33.33")},
+		{func() (Amount, error) { return FromInt(100).MulFrac(2, 3) }, MustParse("66.67")},
+	}
+	for i, tc := range cases {
+		got, err := tc.got()
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if got != tc.want {
+			t.Fatalf("case %d: expected %s, got %s", i, tc.want, got)
+		}
+	}
+}
+
+func TestMulRateAndMulFracReportErrors(t *testing.T) {
+	if _, err := FromInt(100).MulRate(math.NaN()); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected invalid rate error, got %v", err)
+	}
+	if _, err := FromInt(100).MulFrac(1, 0); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected zero denominator error, got %v", err)
+	}
+	if _, err := FromCents(math.MaxInt64).MulRate(2); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("expected overflow error, got %v", err)
+	}
+}
+
+func TestSumHasNoDrift(t *testing.T) {
+	tenth := MustParse("0.10")
+	values := make([]Amount, 0, 1000)
+	floatTotal := 0.0
+	for i := 0; i < 1000; i++ {
+		values = append(values, tenth)
+		floatTotal += 0.1
+	}
+	if got := Sum(values...); got != FromInt(100) {
+		t.Fatalf("expected 100.00, got %s", got)
+	}
+	if floatTotal == 100 {
+		t.Fatalf("float64 control sum unexpectedly exact")
+	}
+}
+
+func TestFormatThousands(t *testing.T) {
+	cases := map[string]string{
+		"0":          "0.00",
+		"999.5":      "999.50",
+		"1000":       "1,000.00",
+		"1250000.05": "1,250,000.05",
+		"-12345.6":   "-12,345.60",
+	}
+	for in, want := range cases {
+		if got := MustParse(in).FormatThousands(); got != want {
+			t.Fatalf("format %s: expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestScanAndJSON(t *testing.T) {
+	var scanned Amount
+	if err := scanned.Scan([]byte("1234.56")); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if scanned != FromCents(123456) {
+		t.Fatalf("expected 1234.56, got %s", scanned)
+	}
+	value, err := scanned.Value()
+	if err != nil || value != "1234.56" {
+		t.Fatalf("expected driver value 1234.56, got %v (%v)", value, err)
+	}
+
+	var decoded struct {
+		Amount Amount  `json:"amount"`
+		Limit  *Amount `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 10.105, "limit": null}`), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Amount != FromCents(1011) || decoded.Limit != nil {
+		t.Fatalf("unexpected decode: %+v", decoded)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(encoded) != `{"amount":10.11,"limit":null}` {
+		t.Fatalf("unexpected encoding: %s", encoded)
+	}
+}
@@ -0,0 +1,250 @@
+package payroll
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// UnallocatedProjectName labels the cost of employees with no allocation in
+// force for the batch month.
+const UnallocatedProjectName = "Unallocated"
+
+// Project is a project, grant or other funding source that salaries are
+// charged to.
+type Project struct {
+	ID            int64     `db:"id" json:"id"`
+	Code          string    `db:"code" json:"code"`
+	Name          string    `db:"name" json:"name"`
+	FundingSource string    `db:"funding_source" json:"funding_source"`
+	IsActive      bool      `db:"is_active" json:"is_active"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ProjectInput struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	FundingSource string `json:"funding_source"`
+	IsActive      bool   `json:"is_active"`
+}
+
+// CostAllocation is one project's share of an employee's cost from
+// EffectiveFrom (YYYY-MM) until the employee's next allocation. The shares
+// with the same EffectiveFrom add up to 100 percent.
+type CostAllocation struct {
+	EmployeeID    int64     `db:"employee_id" json:"employee_id"`
+	EmployeeName  string    `db:"employee_name" json:"employee_name"`
+	EffectiveFrom string    `db:"effective_from" json:"effective_from"`
+	ProjectID     int64     `db:"project_id" json:"project_id"`
+	ProjectCode   string    `db:"project_code" json:"project_code"`
+	ProjectName   string    `db:"project_name" json:"project_name"`
+	FundingSource string    `db:"funding_source" json:"funding_source"`
+	Percent       float64   `db:"percent" json:"percent"`
+	CreatedBy     int64     `db:"created_by" json:"created_by"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+type CostAllocationShare struct {
+	ProjectID int64   `json:"project_id"`
+	Percent   float64 `json:"percent"`
+}
+
+// CostAllocationInput replaces the employee's allocation taking effect in
+// EffectiveFrom.
+type CostAllocationInput struct {
+	EffectiveFrom string                `json:"effective_from"`
+	Shares        []CostAllocationShare `json:"shares"`
+}
+
+type CostAllocationAmounts struct {
+	GrossPay              money.Amount `json:"gross_pay"`
+	EmployerContributions money.Amount `json:"employer_contributions"`
+	TotalCost             money.Amount `json:"total_cost"`
+}
+
+func (a CostAllocationAmounts) add(gross, employer money.Amount) CostAllocationAmounts {
+	return CostAllocationAmounts{
+		GrossPay:              a.GrossPay.Add(gross),
+		EmployerContributions: a.EmployerContributions.Add(employer),
+		TotalCost:             a.TotalCost.Add(gross).Add(employer),
+	}
+}
+
+// CostAllocationRow is the cost charged to one project from one department.
+// In the per-project and per-department subtotals the other side is empty.
+type CostAllocationRow struct {
+	ProjectID     int64  `json:"project_id"`
+	ProjectCode   string `json:"project_code"`
+	ProjectName   string `json:"project_name"`
+	FundingSource string `json:"funding_source"`
+	Department    string `json:"department"`
+	Employees     int    `json:"employees"`
+	CostAllocationAmounts
+}
+
+// CostAllocationReport splits a Locked batch's gross pay and employer
+// contributions across projects and departments for donor reporting.
+type CostAllocationReport struct {
+	Batch        Batch                 `json:"batch"`
+	Rows         []CostAllocationRow   `json:"rows"`
+	ByProject    []CostAllocationRow   `json:"by_project"`
+	ByDepartment []CostAllocationRow   `json:"by_department"`
+	Totals       CostAllocationAmounts `json:"totals"`
+}
+
+// AllocationFor returns the shares in force for an employee in month: those
+// with the latest EffectiveFrom on or before it. Nil means unallocated.
+func AllocationFor(allocations []CostAllocation, employeeID int64, month string) []CostAllocation {
+	effective := ""
+	for _, allocation := range allocations {
+		if allocation.EmployeeID == employeeID && allocation.EffectiveFrom <= month && allocation.EffectiveFrom > effective {
+			effective = allocation.EffectiveFrom
+		}
+	}
+	if effective == "" {
+		return nil
+	}
+	var shares []CostAllocation
+	for _, allocation := range allocations {
+		if allocation.EmployeeID == employeeID && allocation.EffectiveFrom == effective {
+			shares = append(shares, allocation)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ProjectCode < shares[j].ProjectCode })
+	return shares
+}
+
+// splitByPercent divides amount by the shares' percentages, rounding each
+// share half-up; the last share takes what remains so the parts add up to
+// amount exactly.
+func splitByPercent(amount money.Amount, shares []CostAllocation) ([]money.Amount, error) {
+	parts := make([]money.Amount, len(shares))
+	remaining := amount
+	for i, share := range shares {
+		if i == len(shares)-1 {
+			parts[i] = remaining
+			break
+		}
+		part, err := amount.MulRate(share.Percent / 100)
+		if err != nil {
+			return nil, fmt.Errorf("%s share: %w", share.ProjectCode, err)
+		}
+		parts[i] = part
+		remaining = remaining.Sub(part)
+	}
+	return parts, nil
+}
+
+// BuildCostAllocationReport charges each entry's gross pay and employer
+// contributions to the projects in the employee's allocation for the batch
+// month, under the employee's department.
+func BuildCostAllocationReport(batch Batch, entries []Entry, employees []EmployeeDetails, allocations []CostAllocation) (CostAllocationReport, error) {
+	departments := make(map[int64]string, len(employees))
+	for _, employee := range employees {
+		departments[employee.ID] = employee.Department
+	}
+
+	type rowKey struct {
+		projectID  int64
+		department string
+	}
+	rows := newAllocationTotals[rowKey]()
+	projects := newAllocationTotals[int64]()
+	byDepartment := newAllocationTotals[string]()
+
+	report := CostAllocationReport{Batch: batch}
+	for _, entry := range entries {
+		department := departments[entry.EmployeeID]
+		shares := AllocationFor(allocations, entry.EmployeeID, batch.Month)
+		if len(shares) == 0 {
+			shares = []CostAllocation{{ProjectName: UnallocatedProjectName}}
+		}
+		grossParts, err := splitByPercent(entry.GrossPay, shares)
+		if err != nil {
+			return CostAllocationReport{}, fmt.Errorf("allocate entry %d: %w", entry.ID, err)
+		}
+		employerParts, err := splitByPercent(entry.EmployerContributionsTotal, shares)
+		if err != nil {
+			return CostAllocationReport{}, fmt.Errorf("allocate entry %d: %w", entry.ID, err)
+		}
+		for i, share := range shares {
+			project := CostAllocationRow{ProjectID: share.ProjectID, ProjectCode: share.ProjectCode, ProjectName: share.ProjectName, FundingSource: share.FundingSource}
+			row := project
+			row.Department = department
+			rows.charge(rowKey{share.ProjectID, department}, row, entry.EmployeeID, grossParts[i], employerParts[i])
+			projects.charge(share.ProjectID, project, entry.EmployeeID, grossParts[i], employerParts[i])
+			byDepartment.charge(department, CostAllocationRow{Department: department}, entry.EmployeeID, grossParts[i], employerParts[i])
+		}
+		report.Totals = report.Totals.add(entry.GrossPay, entry.EmployerContributionsTotal)
+	}
+
+	report.Rows = rows.sorted()
+	report.ByProject = projects.sorted()
+	report.ByDepartment = byDepartment.sorted()
+	return report, nil
+}
+
+// allocationTotals accumulates report rows by key, counting each employee
+// once per row however many shares they contribute.
+type allocationTotals[K comparable] struct {
+	rows      map[K]*CostAllocationRow
+	employees map[K]map[int64]struct{}
+}
+
+func newAllocationTotals[K comparable]() allocationTotals[K] {
+	return allocationTotals[K]{rows: make(map[K]*CostAllocationRow), employees: make(map[K]map[int64]struct{})}
+}
+
+func (t allocationTotals[K]) charge(key K, template CostAllocationRow, employeeID int64, gross, employer money.Amount) {
+	row, ok := t.rows[key]
+	if !ok {
+		row = &template
+		t.rows[key] = row
+		t.employees[key] = make(map[int64]struct{})
+	}
+	row.CostAllocationAmounts = row.CostAllocationAmounts.add(gross, employer)
+	t.employees[key][employeeID] = struct{}{}
+	row.Employees = len(t.employees[key])
+}
+
+// sorted orders the rows by project code, unallocated cost last, then by
+// department.
+func (t allocationTotals[K]) sorted() []CostAllocationRow {
+	items := make([]CostAllocationRow, 0, len(t.rows))
+	for _, row := range t.rows {
+		items = append(items, *row)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if (items[i].ProjectID == 0) != (items[j].ProjectID == 0) {
+			return items[j].ProjectID == 0
+		}
+		if items[i].ProjectCode != items[j].ProjectCode {
+			return items[i].ProjectCode < items[j].ProjectCode
+		}
+		return items[i].Department < items[j].Department
+	})
+	return items
+}
+
+func findProjectByID(projects []Project, projectID int64) (Project, bool) {
+	for _, project := range projects {
+		if project.ID == projectID {
+			return project, true
+		}
+	}
+	return Project{}, false
+}
+
+// sharesAddUpToWhole reports whether percentages total 100, allowing for
+// the four decimal places stored.
+func sharesAddUpToWhole(shares []CostAllocationShare) bool {
+	total := 0.0
+	for _, share := range shares {
+		total += share.Percent
+	}
+	return math.Abs(total-100) < 0.00005
+}
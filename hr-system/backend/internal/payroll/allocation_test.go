@@ -0,0 +1,71 @@
+package payroll
+
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestAllocationForPicksLatestEffectiveSplit(t *testing.T) {
+	allocations := []CostAllocation{
+		{EmployeeID: 21, EffectiveFrom: "2025-07", ProjectID: 1, ProjectCode: "A", Percent: 100},
+		{EmployeeID: 21, EffectiveFrom: "2026-01", ProjectID: 2, ProjectCode: "B", Percent: 30},
+		{EmployeeID: 21, EffectiveFrom: "2026-01", ProjectID: 1, ProjectCode: "A", Percent: 70},
+		{EmployeeID: 22, EffectiveFrom: "2025-01", ProjectID: 2, ProjectCode: "B", Percent: 100},
+	}
+	if shares := AllocationFor(allocations, 21, "2025-12"); len(shares) != 1 || shares[0].ProjectID != 1 {
+		t.Fatalf("expected the July split, got %+v", shares)
+	}
+	shares := AllocationFor(allocations, 21, "2026-02")
+	if len(shares) != 2 || shares[0].ProjectCode != "A" || shares[1].ProjectCode != "B" {
+		t.Fatalf("expected the January split ordered by code, got %+v", shares)
+	}
+	if shares := AllocationFor(allocations, 21, "2025-06"); shares != nil {
+		t.Fatalf("expected no split before the first, got %+v", shares)
+	}
+}
+
+func TestBuildCostAllocationReportSplitsExactly(t *testing.T) {
+	batch := Batch{ID: 1, Month: "2026-02", Status: StatusLocked}
+	entries := []Entry{
+		{EmployeeID: 21, GrossPay: money.FromInt(1000), EmployerContributionsTotal: money.FromInt(100)},
+		{EmployeeID: 22, GrossPay: money.FromInt(500), EmployerContributionsTotal: money.FromInt(50)},
+		{EmployeeID: 23, GrossPay: money.FromInt(300)},
+	}
+	employees := []EmployeeDetails{{ID: 21, Department: "Health"}, {ID: 22, Department: "Health"}, {ID: 23, Department: "Finance"}}
+	third := 100.0 / 3
+	allocations := []CostAllocation{
+		{EmployeeID: 21, EffectiveFrom: "2026-01", ProjectID: 1, ProjectCode: "A", ProjectName: "Clinic", Percent: third},
+		{EmployeeID: 21, EffectiveFrom: "2026-01", ProjectID: 2, ProjectCode: "B", ProjectName: "Schools", Percent: third},
+		{EmployeeID: 21, EffectiveFrom: "2026-01", ProjectID: 3, ProjectCode: "C", ProjectName: "Water", Percent: third},
+		{EmployeeID: 22, EffectiveFrom: "2025-07", ProjectID: 1, ProjectCode: "A", ProjectName: "Clinic", Percent: 100},
+	}
+
+	report, err := BuildCostAllocationReport(batch, entries, employees, allocations)
+	if err != nil {
+		t.Fatalf("build cost allocation report: %v", err)
+	}
+	clinic := report.ByProject[0]
+	if clinic.ProjectCode != "A" || clinic.Employees != 2 || clinic.GrossPay != money.FromCents(83333) || clinic.EmployerContributions != money.FromCents(8333) {
+		t.Fatalf("unexpected clinic total: %+v", clinic)
+	}
+	water := report.ByProject[2]
+	if water.GrossPay != money.FromCents(33334) || water.TotalCost != money.FromCents(36668) {
+		t.Fatalf("expected the last share to take the rounding remainder, got %+v", water)
+	}
+	unallocated := report.ByProject[len(report.ByProject)-1]
+	if unallocated.ProjectID != 0 || unallocated.ProjectName != UnallocatedProjectName || unallocated.GrossPay != money.FromInt(300) {
+		t.Fatalf("expected unallocated cost last, got %+v", unallocated)
+	}
+
+	var gross money.Amount
+	for _, row := range report.Rows {
+		gross = gross.Add(row.GrossPay)
+	}
+	if gross != money.FromInt(1800) || report.Totals.TotalCost != money.FromInt(1950) {
+		t.Fatalf("rows must add up to the batch, got %s and %+v", gross, report.Totals)
+	}
+	if len(report.ByDepartment) != 2 || report.ByDepartment[0].Department != "Finance" || report.ByDepartment[1].Employees != 2 {
+		t.Fatalf("unexpected department totals: %+v", report.ByDepartment)
+	}
+}
@@ -0,0 +1,97 @@
+package payroll
+
+import (
+	"sort"
+	"time"
+
+	"hr-system/backend/internal/auth"
+)
+
+const (
+	SignOffSigned   = "Signed"
+	SignOffRejected = "Rejected"
+)
+
+// ApprovalStage is one step of the approval chain. Stages are signed in
+// position order, each by a user holding its role or by an Admin; signing the
+// last stage approves the batch.
+type ApprovalStage struct {
+	ID        int64     `db:"id" json:"id"`
+	Position  int       `db:"position" json:"position"`
+	Name      string    `db:"name" json:"name"`
+	Role      string    `db:"role" json:"role"`
+	UpdatedBy *int64    `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type ApprovalStageInput struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// BatchSignOff records a stage signed or rejected. The stage is copied so the
+// history survives later changes to the chain.
+type BatchSignOff struct {
+	ID            int64      `db:"id" json:"id"`
+	BatchID       int64      `db:"batch_id" json:"batch_id"`
+	StagePosition int        `db:"stage_position" json:"stage_position"`
+	StageName     string     `db:"stage_name" json:"stage_name"`
+	StageRole     string     `db:"stage_role" json:"stage_role"`
+	Decision      string     `db:"decision" json:"decision"`
+	Comment       string     `db:"comment" json:"comment"`
+	ActorUserID   int64      `db:"actor_user_id" json:"actor_user_id"`
+	ActorName     string     `db:"actor_name" json:"actor_name"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	SupersededAt  *time.Time `db:"superseded_at" json:"superseded_at,omitempty"`
+}
+
+type SignOffInput struct {
+	Comment string `json:"comment"`
+}
+
+// nextApprovalStage returns the first stage not signed in the current
+// approval round, and false once every stage has been signed.
+func nextApprovalStage(stages []ApprovalStage, signOffs []BatchSignOff) (ApprovalStage, bool) {
+	signed := make(map[int]bool, len(signOffs))
+	for _, signOff := range currentSignOffs(signOffs) {
+		signed[signOff.StagePosition] = true
+	}
+	ordered := append([]ApprovalStage(nil), stages...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Position < ordered[j].Position })
+	for _, stage := range ordered {
+		if !signed[stage.Position] {
+			return stage, true
+		}
+	}
+	return ApprovalStage{}, false
+}
+
+// currentSignOffs are the signatures of the approval round in progress.
+func currentSignOffs(signOffs []BatchSignOff) []BatchSignOff {
+	current := make([]BatchSignOff, 0, len(signOffs))
+	for _, signOff := range signOffs {
+		if signOff.SupersededAt == nil && signOff.Decision == SignOffSigned {
+			current = append(current, signOff)
+		}
+	}
+	return current
+}
+
+// canSignStage reports whether role may sign or reject stage: the stage's own
+// role, with the two master role names interchangeable, or Admin, who could
+// approve any batch before the chain existed.
+func canSignStage(stage ApprovalStage, role string) bool {
+	if isAdmin(role) || stage.Role == role {
+		return true
+	}
+	return auth.IsMasterRole(stage.Role) && auth.IsMasterRole(role)
+}
+
+func isApproverRole(stages []ApprovalStage, role string) bool {
+	for _, stage := range stages {
+		if canSignStage(stage, role) {
+			return true
+		}
+	}
+	return false
+}
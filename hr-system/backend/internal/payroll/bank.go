@@ -0,0 +1,416 @@
+package payroll
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"hr-system/backend/internal/employees"
+	"hr-system/backend/internal/money"
+)
+
+// BankCurrency is the currency net pay is remitted in.
+const BankCurrency = PaymentCurrency
+
+const (
+	BankFormatCSV        = "csv"
+	BankFormatFixedWidth = "fixed"
+	BankFormatPain001    = "pain001"
+)
+
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// BankPayment is one credit transfer: an employee's net pay and where to send it.
+type BankPayment struct {
+	EntryID           int64        `db:"entry_id" json:"entry_id"`
+	EmployeeID        int64        `db:"employee_id" json:"employee_id"`
+	EmployeeName      string       `db:"employee_name" json:"employee_name"`
+	BankName          string       `db:"bank_name" json:"bank_name"`
+	BankBranch        string       `db:"bank_branch" json:"bank_branch"`
+	BankCode          string       `db:"bank_code" json:"bank_code"`
+	BankAccountName   string       `db:"bank_account_name" json:"bank_account_name"`
+	BankAccountNumber string       `db:"bank_account_number" json:"bank_account_number"`
+	Amount            money.Amount `db:"net_pay" json:"amount"`
+}
+
+// BankTransfer is everything a formatter needs to lay out a bulk-payment file.
+type BankTransfer struct {
+	Batch         Batch
+	Debtor        Employer
+	Currency      string
+	ExecutionDate time.Time
+	CreatedAt     time.Time
+	Payments      []BankPayment
+}
+
+func (t BankTransfer) Total() money.Amount {
+	total := money.Amount{}
+	for _, payment := range t.Payments {
+		total = total.Add(payment.Amount)
+	}
+	return total
+}
+
+// Reference is the payment reference quoted to every beneficiary.
+func (t BankTransfer) Reference() string {
+	return fmt.Sprintf("%s %s", referencePrefix(t.Batch.BatchType), t.Batch.Month)
+}
+
+type BankValidationIssue struct {
+	EmployeeID   int64  `json:"employee_id,omitempty"`
+	EmployeeName string `json:"employee_name,omitempty"`
+	Field        string `json:"field"`
+	Message      string `json:"message"`
+}
+
+// BankValidationError carries every problem found so they can be fixed in one
+// pass instead of one export attempt per employee.
+type BankValidationError struct {
+	Issues []BankValidationIssue
+}
+
+func (e *BankValidationError) Error() string {
+	parts := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.EmployeeName != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", issue.EmployeeName, issue.Message))
+			continue
+		}
+		parts = append(parts, issue.Message)
+	}
+	return ErrBankDetailsInvalid.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *BankValidationError) Unwrap() error {
+	return ErrBankDetailsInvalid
+}
+
+// BankFormatter renders a bank bulk-payment layout. Validate reports
+// layout-specific requirements on top of the checks every layout shares.
+type BankFormatter interface {
+	Code() string
+	Name() string
+	FileExtension() string
+	ContentType() string
+	Validate(transfer BankTransfer) []BankValidationIssue
+	Format(transfer BankTransfer) ([]byte, error)
+}
+
+type BankFormat struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	FileExtension string `json:"file_extension"`
+}
+
+func defaultBankFormatters() []BankFormatter {
+	return []BankFormatter{CSVBankFormatter{}, FixedWidthBankFormatter{}, Pain001BankFormatter{}}
+}
+
+// ValidateBankTransfer applies the checks every layout needs: a positive
+// amount and a usable account for each payment.
+func ValidateBankTransfer(transfer BankTransfer, formatter BankFormatter) []BankValidationIssue {
+	issues := make([]BankValidationIssue, 0)
+	if len(transfer.Payments) == 0 {
+		issues = append(issues, BankValidationIssue{Field: "payments", Message: "batch has no payments"})
+	}
+	for _, payment := range transfer.Payments {
+		issue := func(field, message string) {
+			issues = append(issues, BankValidationIssue{EmployeeID: payment.EmployeeID, EmployeeName: payment.EmployeeName, Field: field, Message: message})
+		}
+		if !payment.Amount.IsPositive() {
+			issue("amount", "net pay must be positive")
+		}
+		if payment.BankName == "" {
+			issue("bank_name", "bank name is missing")
+		}
+		switch {
+		case payment.BankAccountNumber == "":
+			issue("bank_account_number", "account number is missing")
+		case !employees.IsAccountNumber(payment.BankAccountNumber):
+			issue("bank_account_number", "account number must be 5-34 letters or digits")
+		}
+	}
+	if formatter != nil {
+		issues = append(issues, formatter.Validate(transfer)...)
+	}
+	return issues
+}
+
+func accountName(payment BankPayment) string {
+	if payment.BankAccountName != "" {
+		return payment.BankAccountName
+	}
+	return payment.EmployeeName
+}
+
+func sortedFormats(formatters map[string]BankFormatter) []BankFormat {
+	items := make([]BankFormat, 0, len(formatters))
+	for _, formatter := range formatters {
+		items = append(items, BankFormat{Code: formatter.Code(), Name: formatter.Name(), FileExtension: formatter.FileExtension()})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+	return items
+}
+
+// CSVBankFormatter is a generic one-row-per-payment layout most bank portals
+// can map on upload.
+type CSVBankFormatter struct{}
+
+func (CSVBankFormatter) Code() string          { return BankFormatCSV }
+func (CSVBankFormatter) Name() string          { return "Generic CSV" }
+func (CSVBankFormatter) FileExtension() string { return "csv" }
+func (CSVBankFormatter) ContentType() string   { return "text/csv" }
+
+func (CSVBankFormatter) Validate(BankTransfer) []BankValidationIssue {
+	return nil
+}
+
+func (CSVBankFormatter) Format(transfer BankTransfer) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := []string{"Beneficiary Name", "Bank Name", "Bank Code", "Branch", "Account Number", "Amount", "Currency", "Reference"}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("write bank csv header: %w", err)
+	}
+	for _, payment := range transfer.Payments {
+		record := []string{
+			accountName(payment),
+			payment.BankName,
+			payment.BankCode,
+			payment.BankBranch,
+			payment.BankAccountNumber,
+			payment.Amount.String(),
+			transfer.Currency,
+			transfer.Reference(),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("write bank csv row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("flush bank csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FixedWidthBankFormatter writes 120-character records:
+//
+//	H  debtor account(34) execution date YYYYMMDD(8) count(6) total cents(15) currency(3) filler
+//	D  account number(34) bank code(11) beneficiary name(35) amount cents(15) reference(18) filler
+//	T  count(6) total cents(15) filler
+//
+// Text is upper-cased ASCII, left-aligned and space-padded; numbers are
+// right-aligned and zero-padded.
+type FixedWidthBankFormatter struct{}
+
+const fixedWidthRecordLength = 120
+
+func (FixedWidthBankFormatter) Code() string          { return BankFormatFixedWidth }
+func (FixedWidthBankFormatter) Name() string          { return "Fixed-width (120 columns)" }
+func (FixedWidthBankFormatter) FileExtension() string { return "txt" }
+func (FixedWidthBankFormatter) ContentType() string   { return "text/plain" }
+
+func (FixedWidthBankFormatter) Validate(transfer BankTransfer) []BankValidationIssue {
+	issues := make([]BankValidationIssue, 0)
+	if transfer.Debtor.BankAccount == "" {
+		issues = append(issues, BankValidationIssue{Field: "debtor_account", Message: "employer bank account is not configured"})
+	}
+	for _, payment := range transfer.Payments {
+		if payment.BankCode == "" {
+			issues = append(issues, BankValidationIssue{EmployeeID: payment.EmployeeID, EmployeeName: payment.EmployeeName, Field: "bank_code", Message: "bank code is missing"})
+		}
+	}
+	return issues
+}
+
+func (FixedWidthBankFormatter) Format(transfer BankTransfer) ([]byte, error) {
+	var buf bytes.Buffer
+	writeRecord := func(fields ...string) {
+		record := strings.Join(fields, "")
+		buf.WriteString(padRight(record, fixedWidthRecordLength))
+		buf.WriteString("\r\n")
+	}
+	count := len(transfer.Payments)
+	total := transfer.Total()
+	writeRecord("H", padRight(transfer.Debtor.BankAccount, 34), transfer.ExecutionDate.Format("20060102"),
+		padCents(int64(count), 6), padCents(total.Cents(), 15), transfer.Currency)
+	for _, payment := range transfer.Payments {
+		writeRecord("D", padRight(payment.BankAccountNumber, 34), padRight(payment.BankCode, 11),
+			padRight(accountName(payment), 35), padCents(payment.Amount.Cents(), 15), padRight(transfer.Reference(), 18))
+	}
+	writeRecord("T", padCents(int64(count), 6), padCents(total.Cents(), 15))
+	return buf.Bytes(), nil
+}
+
+func padRight(value string, width int) string {
+	var sb strings.Builder
+	for _, char := range strings.ToUpper(value) {
+		if sb.Len() == width {
+			break
+		}
+		if char < 32 || char > 126 {
+			char = ' '
+		}
+		sb.WriteRune(char)
+	}
+	for sb.Len() < width {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+func padCents(value int64, width int) string {
+	return fmt.Sprintf("%0*d", width, value)
+}
+
+// Pain001BankFormatter writes an ISO 20022 customer credit transfer initiation
+// (pain.001.001.03) with one payment information block for the batch.
+type Pain001BankFormatter struct{}
+
+func (Pain001BankFormatter) Code() string          { return BankFormatPain001 }
+func (Pain001BankFormatter) Name() string          { return "ISO 20022 pain.001.001.03" }
+func (Pain001BankFormatter) FileExtension() string { return "xml" }
+func (Pain001BankFormatter) ContentType() string   { return "application/xml" }
+
+func (Pain001BankFormatter) Validate(transfer BankTransfer) []BankValidationIssue {
+	issues := make([]BankValidationIssue, 0)
+	if transfer.Debtor.BankAccount == "" {
+		issues = append(issues, BankValidationIssue{Field: "debtor_account", Message: "employer bank account is not configured"})
+	}
+	if !bicPattern.MatchString(transfer.Debtor.BankCode) {
+		issues = append(issues, BankValidationIssue{Field: "debtor_bic", Message: "employer bank BIC is missing or invalid"})
+	}
+	for _, payment := range transfer.Payments {
+		if !bicPattern.MatchString(payment.BankCode) {
+			issues = append(issues, BankValidationIssue{EmployeeID: payment.EmployeeID, EmployeeName: payment.EmployeeName, Field: "bank_code", Message: "bank code must be a valid BIC"})
+		}
+	}
+	return issues
+}
+
+type painDocument struct {
+	XMLName xml.Name     `xml:"Document"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	CstmrCd painInitiate `xml:"CstmrCdtTrfInitn"`
+}
+
+type painInitiate struct {
+	GrpHdr painGroupHeader `xml:"GrpHdr"`
+	PmtInf painPaymentInfo `xml:"PmtInf"`
+}
+
+type painGroupHeader struct {
+	MsgID    string    `xml:"MsgId"`
+	CreDtTm  string    `xml:"CreDtTm"`
+	NbOfTxs  int       `xml:"NbOfTxs"`
+	CtrlSum  string    `xml:"CtrlSum"`
+	InitgPty painParty `xml:"InitgPty"`
+}
+
+type painParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type painAccount struct {
+	ID struct {
+		Othr struct {
+			ID string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+}
+
+type painAgent struct {
+	FinInstnID struct {
+		BIC string `xml:"BIC"`
+	} `xml:"FinInstnId"`
+}
+
+type painPaymentInfo struct {
+	PmtInfID    string            `xml:"PmtInfId"`
+	PmtMtd      string            `xml:"PmtMtd"`
+	NbOfTxs     int               `xml:"NbOfTxs"`
+	CtrlSum     string            `xml:"CtrlSum"`
+	PmtTpInf    painPaymentType   `xml:"PmtTpInf"`
+	ReqdExctnDt string            `xml:"ReqdExctnDt"`
+	Dbtr        painParty         `xml:"Dbtr"`
+	DbtrAcct    painAccount       `xml:"DbtrAcct"`
+	DbtrAgt     painAgent         `xml:"DbtrAgt"`
+	CdtTrfTxInf []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+type painPaymentType struct {
+	CtgyPurp struct {
+		Cd string `xml:"Cd"`
+	} `xml:"CtgyPurp"`
+}
+
+type painTransaction struct {
+	PmtID struct {
+		EndToEndID string `xml:"EndToEndId"`
+	} `xml:"PmtId"`
+	Amt struct {
+		InstdAmt struct {
+			Ccy   string `xml:"Ccy,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"InstdAmt"`
+	} `xml:"Amt"`
+	CdtrAgt  painAgent   `xml:"CdtrAgt"`
+	Cdtr     painParty   `xml:"Cdtr"`
+	CdtrAcct painAccount `xml:"CdtrAcct"`
+	RmtInf   struct {
+		Ustrd string `xml:"Ustrd"`
+	} `xml:"RmtInf"`
+}
+
+func (Pain001BankFormatter) Format(transfer BankTransfer) ([]byte, error) {
+	messageID := fmt.Sprintf("PAYROLL-%d-%s", transfer.Batch.ID, transfer.CreatedAt.Format("20060102150405"))
+	total := transfer.Total().String()
+
+	info := painPaymentInfo{
+		PmtInfID:    fmt.Sprintf("PAYROLL-%d-%s", transfer.Batch.ID, transfer.Batch.Month),
+		PmtMtd:      "TRF",
+		NbOfTxs:     len(transfer.Payments),
+		CtrlSum:     total,
+		ReqdExctnDt: transfer.ExecutionDate.Format("2006-01-02"),
+		Dbtr:        painParty{Nm: transfer.Debtor.Name},
+	}
+	info.PmtTpInf.CtgyPurp.Cd = "SALA"
+	info.DbtrAcct.ID.Othr.ID = transfer.Debtor.BankAccount
+	info.DbtrAgt.FinInstnID.BIC = transfer.Debtor.BankCode
+	for _, payment := range transfer.Payments {
+		var tx painTransaction
+		tx.PmtID.EndToEndID = fmt.Sprintf("PAYROLL-%d-%d", transfer.Batch.ID, payment.EntryID)
+		tx.Amt.InstdAmt.Ccy = transfer.Currency
+		tx.Amt.InstdAmt.Value = payment.Amount.String()
+		tx.CdtrAgt.FinInstnID.BIC = payment.BankCode
+		tx.Cdtr.Nm = accountName(payment)
+		tx.CdtrAcct.ID.Othr.ID = payment.BankAccountNumber
+		tx.RmtInf.Ustrd = transfer.Reference()
+		info.CdtTrfTxInf = append(info.CdtTrfTxInf, tx)
+	}
+
+	doc := painDocument{
+		Xmlns: "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03",
+		CstmrCd: painInitiate{
+			GrpHdr: painGroupHeader{
+				MsgID:    messageID,
+				CreDtTm:  transfer.CreatedAt.Format("2006-01-02T15:04:05"),
+				NbOfTxs:  len(transfer.Payments),
+				CtrlSum:  total,
+				InitgPty: painParty{Nm: transfer.Debtor.Name},
+			},
+			PmtInf: info,
+		},
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode pain.001: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
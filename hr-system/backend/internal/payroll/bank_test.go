@@ -0,0 +1,99 @@
+package payroll
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+func testBankTransfer() BankTransfer {
+	when := time.Date(2026, 1, 28, 9, 30, 0, 0, time.UTC)
+	return BankTransfer{
+		Batch:         Batch{ID: 4, Month: "2026-01"},
+		Debtor:        Employer{Name: "HISP Uganda", BankCode: "SBICUGKX", BankAccount: "9030000000001"},
+		Currency:      BankCurrency,
+		ExecutionDate: when,
+		CreatedAt:     when,
+		Payments: []BankPayment{
+			{EntryID: 10, EmployeeID: 21, EmployeeName: "Doe, Jane", BankName: "Stanbic Bank", BankCode: "SBICUGKX", BankAccountNumber: "9030001234567", Amount: money.MustParse("1250000.50")},
+			{EntryID: 11, EmployeeID: 22, EmployeeName: "Okello, Peter", BankAccountName: "Peter Okello", BankName: "Centenary Bank", BankCode: "CERBUGKA", BankAccountNumber: "3100012345", Amount: money.FromInt(800000)},
+		},
+	}
+}
+
+func TestFixedWidthBankFormat(t *testing.T) {
+	out, err := FixedWidthBankFormatter{}.Format(testBankTransfer())
+	if err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	records := strings.Split(strings.TrimSuffix(string(out), "\r\n"), "\r\n")
+	if len(records) != 4 {
+		t.Fatalf("expected header, two details and trailer, got %d records", len(records))
+	}
+	for i, record := range records {
+		if len(record) != fixedWidthRecordLength {
+			t.Fatalf("record %d is %d characters", i, len(record))
+		}
+	}
+	if want := "H9030000000001                     20260128000002000000205000050UGX"; !strings.HasPrefix(records[0], want) {
+		t.Fatalf("unexpected header %q", records[0])
+	}
+	if want := "D3100012345                        CERBUGKA   PETER OKELLO                       000000080000000SALARY 2026-01"; !strings.HasPrefix(records[2], want) {
+		t.Fatalf("unexpected detail %q", records[2])
+	}
+	if want := "T000002000000205000050"; !strings.HasPrefix(records[3], want) {
+		t.Fatalf("unexpected trailer %q", records[3])
+	}
+}
+
+func TestPain001BankFormat(t *testing.T) {
+	out, err := Pain001BankFormatter{}.Format(testBankTransfer())
+	if err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	var doc struct {
+		Header struct {
+			Transactions int    `xml:"NbOfTxs"`
+			ControlSum   string `xml:"CtrlSum"`
+		} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+		Payments []struct {
+			EndToEndID string `xml:"PmtId>EndToEndId"`
+			Amount     struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			Account string `xml:"CdtrAcct>Id>Othr>Id"`
+		} `xml:"CstmrCdtTrfInitn>PmtInf>CdtTrfTxInf"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("parse pain.001: %v", err)
+	}
+	if doc.Header.Transactions != 2 || doc.Header.ControlSum != "2050000.50" {
+		t.Fatalf("unexpected group header %+v", doc.Header)
+	}
+	if len(doc.Payments) != 2 || doc.Payments[0].EndToEndID != "PAYROLL-4-10" || doc.Payments[0].Amount.Value != "1250000.50" || doc.Payments[0].Amount.Currency != "UGX" || doc.Payments[1].Account != "3100012345" {
+		t.Fatalf("unexpected transactions %+v", doc.Payments)
+	}
+}
+
+func TestValidateBankTransfer(t *testing.T) {
+	transfer := testBankTransfer()
+	transfer.Payments[0].BankAccountNumber = "12-34"
+	transfer.Payments[1].BankCode = "CENTENARY"
+	transfer.Payments[1].Amount = money.FromInt(-5)
+
+	issues := ValidateBankTransfer(transfer, Pain001BankFormatter{})
+	fields := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	if got := strings.Join(fields, ","); got != "bank_account_number,amount,bank_code" {
+		t.Fatalf("unexpected issues %s", got)
+	}
+	if issues := ValidateBankTransfer(testBankTransfer(), CSVBankFormatter{}); len(issues) != 0 {
+		t.Fatalf("expected valid transfer, got %+v", issues)
+	}
+}
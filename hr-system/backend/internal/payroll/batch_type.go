@@ -0,0 +1,75 @@
+package payroll
+
+import (
+	"fmt"
+	"strings"
+
+	"hr-system/backend/internal/money"
+)
+
+// A month has at most one Regular batch, which pays every employee on the
+// payroll. Any number of off-cycle batches may run alongside it, each paying
+// only the employees chosen for it.
+const (
+	BatchTypeRegular       = "Regular"
+	BatchTypeSupplementary = "Supplementary"
+	BatchTypeBonus         = "Bonus"
+	BatchTypeArrears       = "Arrears"
+)
+
+// PriorIncome is what an employee has already been paid in a month by other
+// In Approval, Approved or Locked batches. PAYE is charged on the month as a whole, so a
+// batch only withholds the tax on its pay that those batches did not.
+type PriorIncome struct {
+	EmployeeID int64        `db:"employee_id"`
+	TaxablePay money.Amount `db:"taxable_pay"`
+	TaxTotal   money.Amount `db:"tax_total"`
+}
+
+// IsOffCycle reports whether the batch pays only its chosen employees.
+func (b Batch) IsOffCycle() bool {
+	return b.BatchType != "" && b.BatchType != BatchTypeRegular
+}
+
+// PeriodLabel is the batch month in words, followed by the batch type for
+// off-cycle batches, e.g. "January 2026 (Bonus)".
+func (b Batch) PeriodLabel() string {
+	if !b.IsOffCycle() {
+		return monthLabel(b.Month)
+	}
+	return fmt.Sprintf("%s (%s)", monthLabel(b.Month), b.BatchType)
+}
+
+// combinedTax is the PAYE due on the month's total taxable pay less the PAYE
+// already withheld by other batches, never below zero.
+func combinedTax(table TaxTable, taxablePay money.Amount, prior PriorIncome) (money.Amount, error) {
+	tax, err := table.Compute(taxablePay.Add(prior.TaxablePay))
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return money.Max(tax.Sub(prior.TaxTotal), money.Amount{}), nil
+}
+
+// referencePrefix starts the bank payment reference; it is kept short enough
+// for the fixed-width EFT layout.
+func referencePrefix(batchType string) string {
+	switch batchType {
+	case BatchTypeSupplementary:
+		return "SUPPL"
+	case BatchTypeBonus:
+		return "BONUS"
+	case BatchTypeArrears:
+		return "ARREARS"
+	default:
+		return "SALARY"
+	}
+}
+
+func isValidBatchType(batchType string) bool {
+	switch strings.TrimSpace(batchType) {
+	case BatchTypeRegular, BatchTypeSupplementary, BatchTypeBonus, BatchTypeArrears:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,7 +1,183 @@
 package payroll
 
-func CalculateAmounts(baseSalary, allowancesTotal, deductionsTotal, taxTotal float64) (grossPay float64, netPay float64) {
-	grossPay = baseSalary + allowancesTotal
-	netPay = grossPay - deductionsTotal - taxTotal
-	return grossPay, netPay
+import "hr-system/backend/internal/money"
+
+type CalculationInput struct {
+	BaseSalary       money.Amount
+	Lines            []EntryLine
+	Components       []Component
+	Schemes          []ContributionScheme
+	TaxTable         *TaxTable
+	TaxOverride      *money.Amount
+	UnpaidLeave      money.Amount
+	LoanInstallments money.Amount
+	PriorIncome      PriorIncome
+}
+
+type CalculationResult struct {
+	Lines   []EntryLine
+	Amounts Amounts
+}
+
+// Calculate runs the entry pipeline: manually entered lines are kept, the
+// system-owned UNPAID_LEAVE and LOAN_INSTALLMENT lines are posted for any
+// unpaid leave and loan installments due, contribution lines are posted for
+// each applicable scheme from pensionable pay, the system-owned PAYE line is
+// recomputed from taxable pay together with any prior income in the month (or
+// taken from a recorded override) and the totals are derived from the final
+// lines.
+func Calculate(input CalculationInput) (CalculationResult, error) {
+	lines := make([]EntryLine, 0, len(input.Lines)+2*len(input.Schemes)+2)
+	for _, line := range input.Lines {
+		if line.IsSystem {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if input.UnpaidLeave.IsPositive() {
+		unpaidLeave, ok := findComponent(input.Components, ComponentCodeUnpaidLeave)
+		if !ok {
+			return CalculationResult{}, ErrComponentNotFound
+		}
+		lines = append(lines, newEntryLine(unpaidLeave, input.UnpaidLeave))
+	}
+	if input.LoanInstallments.IsPositive() {
+		installment, ok := findComponent(input.Components, ComponentCodeLoanInstallment)
+		if !ok {
+			return CalculationResult{}, ErrComponentNotFound
+		}
+		lines = append(lines, newEntryLine(installment, input.LoanInstallments))
+	}
+
+	pensionablePay := CalculateAmounts(input.BaseSalary, lines).PensionablePay
+	for _, scheme := range input.Schemes {
+		employeeComponent, ok := findComponentByID(input.Components, scheme.EmployeeComponentID)
+		if !ok {
+			return CalculationResult{}, ErrComponentNotFound
+		}
+		employerComponent, ok := findComponentByID(input.Components, scheme.EmployerComponentID)
+		if !ok {
+			return CalculationResult{}, ErrComponentNotFound
+		}
+		employee, employer, err := scheme.Compute(pensionablePay)
+		if err != nil {
+			return CalculationResult{}, err
+		}
+		if employee.IsPositive() {
+			lines = append(lines, newEntryLine(employeeComponent, employee))
+		}
+		if employer.IsPositive() {
+			lines = append(lines, newEntryLine(employerComponent, employer))
+		}
+	}
+
+	paye, ok := findComponent(input.Components, ComponentCodePAYE)
+	if !ok {
+		return CalculationResult{}, ErrComponentNotFound
+	}
+
+	var tax money.Amount
+	switch {
+	case input.TaxOverride != nil:
+		tax = *input.TaxOverride
+	case input.TaxTable != nil:
+		var err error
+		tax, err = combinedTax(*input.TaxTable, CalculateAmounts(input.BaseSalary, lines).TaxablePay, input.PriorIncome)
+		if err != nil {
+			return CalculationResult{}, err
+		}
+	default:
+		return CalculationResult{}, ErrTaxTableNotFound
+	}
+	if tax.IsPositive() {
+		lines = append(lines, newEntryLine(paye, tax))
+	}
+
+	return CalculationResult{Lines: lines, Amounts: CalculateAmounts(input.BaseSalary, lines)}, nil
+}
+
+// CalculateAmounts derives the entry totals from its line items. Earnings add
+// to gross pay; deductions and tax lines are taken off gross to reach net pay.
+// Employer lines are a cost to the organisation and never touch net pay.
+// Base salary plus taxable (or pensionable) earnings make up taxable (or
+// pensionable) pay. Unpaid leave is pay that was never earned, so it comes off
+// earnings, and with them gross, taxable and pensionable pay.
+func CalculateAmounts(baseSalary money.Amount, lines []EntryLine) Amounts {
+	amounts := Amounts{TaxablePay: baseSalary, PensionablePay: baseSalary}
+	for _, line := range lines {
+		switch line.ComponentType {
+		case ComponentTypeEarning:
+			if reducesEarnings(line) {
+				amounts.AllowancesTotal = amounts.AllowancesTotal.Sub(line.Amount)
+				amounts.TaxablePay = amounts.TaxablePay.Sub(line.Amount)
+				amounts.PensionablePay = amounts.PensionablePay.Sub(line.Amount)
+				continue
+			}
+			amounts.AllowancesTotal = amounts.AllowancesTotal.Add(line.Amount)
+			if line.IsTaxable {
+				amounts.TaxablePay = amounts.TaxablePay.Add(line.Amount)
+			}
+			if line.IsPensionable {
+				amounts.PensionablePay = amounts.PensionablePay.Add(line.Amount)
+			}
+		case ComponentTypeDeduction:
+			amounts.DeductionsTotal = amounts.DeductionsTotal.Add(line.Amount)
+		case ComponentTypeTax:
+			amounts.TaxTotal = amounts.TaxTotal.Add(line.Amount)
+		case ComponentTypeEmployer:
+			amounts.EmployerContributionsTotal = amounts.EmployerContributionsTotal.Add(line.Amount)
+		}
+	}
+	amounts.GrossPay = baseSalary.Add(amounts.AllowancesTotal)
+	amounts.NetPay = amounts.GrossPay.Sub(amounts.DeductionsTotal).Sub(amounts.TaxTotal)
+	return amounts
+}
+
+// SumAmounts totals a set of entries. Every figure is exact in cents, so the
+// batch totals always equal the sum of the rows.
+func SumAmounts(entries []Entry) Amounts {
+	var totals Amounts
+	for _, entry := range entries {
+		totals.BaseSalary = totals.BaseSalary.Add(entry.BaseSalary)
+		totals.TaxablePay = totals.TaxablePay.Add(entry.TaxablePay)
+		totals.PensionablePay = totals.PensionablePay.Add(entry.PensionablePay)
+		totals.AllowancesTotal = totals.AllowancesTotal.Add(entry.AllowancesTotal)
+		totals.DeductionsTotal = totals.DeductionsTotal.Add(entry.DeductionsTotal)
+		totals.TaxTotal = totals.TaxTotal.Add(entry.TaxTotal)
+		totals.EmployerContributionsTotal = totals.EmployerContributionsTotal.Add(entry.EmployerContributionsTotal)
+		totals.GrossPay = totals.GrossPay.Add(entry.GrossPay)
+		totals.NetPay = totals.NetPay.Add(entry.NetPay)
+	}
+	return totals
+}
+
+func newEntryLine(component Component, amount money.Amount) EntryLine {
+	return EntryLine{
+		ComponentID:   component.ID,
+		ComponentCode: component.Code,
+		ComponentName: component.Name,
+		ComponentType: component.Type,
+		IsTaxable:     component.IsTaxable,
+		IsPensionable: component.IsPensionable,
+		IsSystem:      component.IsSystem,
+		Amount:        amount,
+	}
+}
+
+func findComponent(components []Component, code string) (Component, bool) {
+	for _, component := range components {
+		if component.Code == code {
+			return component, true
+		}
+	}
+	return Component{}, false
+}
+
+func findComponentByID(components []Component, componentID int64) (Component, bool) {
+	for _, component := range components {
+		if component.ID == componentID {
+			return component, true
+		}
+	}
+	return Component{}, false
 }
@@ -1,13 +1,136 @@
 package payroll
 
-import "testing"
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
 
 func TestCalculateAmounts(t *testing.T) {
-	gross, net := CalculateAmounts(1000, 250, 80, 40)
-	if gross != 1250 {
-		t.Fatalf("expected gross 1250, got %v", gross)
+	amounts := CalculateAmounts(money.FromInt(1000), []EntryLine{
+		{ComponentCode: "HOUSING", ComponentType: ComponentTypeEarning, Amount: money.FromInt(200)},
+		{ComponentCode: "TRANSPORT", ComponentType: ComponentTypeEarning, Amount: money.FromInt(50)},
+		{ComponentCode: "SACCO", ComponentType: ComponentTypeDeduction, Amount: money.FromInt(80)},
+		{ComponentCode: "PAYE", ComponentType: ComponentTypeTax, Amount: money.FromInt(40)},
+	})
+	if amounts.AllowancesTotal != money.FromInt(250) {
+		t.Fatalf("expected allowances 250, got %v", amounts.AllowancesTotal)
+	}
+	if amounts.DeductionsTotal != money.FromInt(80) || amounts.TaxTotal != money.FromInt(40) {
+		t.Fatalf("expected deductions 80 and tax 40, got %v and %v", amounts.DeductionsTotal, amounts.TaxTotal)
+	}
+	if amounts.GrossPay != money.FromInt(1250) {
+		t.Fatalf("expected gross 1250, got %v", amounts.GrossPay)
+	}
+	if amounts.NetPay != money.FromInt(1130) {
+		t.Fatalf("expected net 1130, got %v", amounts.NetPay)
+	}
+}
+
+func TestCalculateAmountsWithoutLines(t *testing.T) {
+	amounts := CalculateAmounts(money.FromInt(1000), nil)
+	if amounts.GrossPay != money.FromInt(1000) || amounts.NetPay != money.FromInt(1000) {
+		t.Fatalf("expected gross and net 1000, got %v and %v", amounts.GrossPay, amounts.NetPay)
+	}
+}
+
+func TestContributionSchemeComputeAppliesCeiling(t *testing.T) {
+	scheme := ContributionScheme{EmployeeRate: 0.05, EmployerRate: 0.10, Ceiling: amountPtr(1000)}
+
+	employee, employer, err := scheme.Compute(money.FromInt(800))
+	if err != nil || employee != money.FromInt(40) || employer != money.FromInt(80) {
+		t.Fatalf("expected 40/80 below ceiling, got %v/%v (%v)", employee, employer, err)
+	}
+	employee, employer, err = scheme.Compute(money.FromInt(5000))
+	if err != nil || employee != money.FromInt(50) || employer != money.FromInt(100) {
+		t.Fatalf("expected 50/100 at ceiling, got %v/%v (%v)", employee, employer, err)
+	}
+}
+
+func TestSchemesForEmployee(t *testing.T) {
+	schemes := []ContributionScheme{
+		{ID: 1, Code: ContributionSchemeNSSF, IsMandatory: true, IsActive: true},
+		{ID: 2, Code: ContributionSchemePension, IsActive: true},
+		{ID: 3, Code: "RETIRED", IsMandatory: true},
+	}
+	members := []ContributionMember{{SchemeID: 2, EmployeeID: 7}}
+
+	if got := SchemesForEmployee(schemes, members, 7); len(got) != 2 || got[1].ID != 2 {
+		t.Fatalf("expected NSSF and enrolled pension, got %+v", got)
+	}
+	if got := SchemesForEmployee(schemes, members, 8); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("expected only NSSF for non-member, got %+v", got)
+	}
+}
+
+func TestBatchTotalsEqualSumOfRows(t *testing.T) {
+	components := []Component{
+		{ID: 1, Code: "HOUSING", Type: ComponentTypeEarning, IsTaxable: true, IsPensionable: true, IsActive: true},
+		{ID: 4, Code: ComponentCodePAYE, Type: ComponentTypeTax, IsSystem: true, IsActive: true},
+		{ID: 7, Code: "NSSF_EMPLOYEE", Type: ComponentTypeDeduction, IsSystem: true, IsActive: true},
+		{ID: 8, Code: "NSSF_EMPLOYER", Type: ComponentTypeEmployer, IsSystem: true, IsActive: true},
+	}
+	scheme := ContributionScheme{ID: 1, EmployeeRate: 0.05, EmployerRate: 0.1, IsMandatory: true, IsActive: true, EmployeeComponentID: 7, EmployerComponentID: 8}
+	table := TaxTable{Brackets: []TaxBracket{
+		{LowerBound: money.FromInt(0), UpperBound: amountPtr(235000), Rate: 0},
+		{LowerBound: money.FromInt(235000), Rate: 0.175},
+	}}
+
+	// Odd-cent salaries make every percentage land on fractional cents.
+	entries := make([]Entry, 0, 500)
+	var rowNet, rowGross, rowTax, rowDeductions, rowEmployer money.Amount
+	for i := 0; i < 500; i++ {
+		base := money.FromCents(33333333 + int64(i)*7)
+		result, err := Calculate(CalculationInput{
+			BaseSalary: base,
+			Lines:      []EntryLine{newEntryLine(components[0], money.FromCents(1001+int64(i)))},
+			Components: components,
+			Schemes:    []ContributionScheme{scheme},
+			TaxTable:   &table,
+		})
+		if err != nil {
+			t.Fatalf("calculate row %d: %v", i, err)
+		}
+		amounts := result.Amounts
+		entries = append(entries, Entry{
+			BaseSalary:                 base,
+			AllowancesTotal:            amounts.AllowancesTotal,
+			DeductionsTotal:            amounts.DeductionsTotal,
+			TaxTotal:                   amounts.TaxTotal,
+			GrossPay:                   amounts.GrossPay,
+			NetPay:                     amounts.NetPay,
+			EmployerContributionsTotal: amounts.EmployerContributionsTotal,
+		})
+
+		// Re-read each row as printed, the way a reader would add up an export.
+		rowNet = rowNet.Add(money.MustParse(amounts.NetPay.String()))
+		rowGross = rowGross.Add(money.MustParse(amounts.GrossPay.String()))
+		rowTax = rowTax.Add(money.MustParse(amounts.TaxTotal.String()))
+		rowDeductions = rowDeductions.Add(money.MustParse(amounts.DeductionsTotal.String()))
+		rowEmployer = rowEmployer.Add(money.MustParse(amounts.EmployerContributionsTotal.String()))
+	}
+
+	totals := SumAmounts(entries)
+	if totals.NetPay != rowNet || totals.GrossPay != rowGross || totals.TaxTotal != rowTax ||
+		totals.DeductionsTotal != rowDeductions || totals.EmployerContributionsTotal != rowEmployer {
+		t.Fatalf("batch totals drifted from row sums: %+v", totals)
+	}
+	if totals.GrossPay.Sub(totals.DeductionsTotal).Sub(totals.TaxTotal) != totals.NetPay {
+		t.Fatalf("batch net %s does not reconcile with gross, deductions and tax", totals.NetPay)
+	}
+}
+
+func TestFiscalYearStart(t *testing.T) {
+	cases := map[string]string{
+		"2026-07": "2026-07",
+		"2026-12": "2026-07",
+		"2027-06": "2026-07",
+		"2027-01": "2026-07",
 	}
-	if net != 1130 {
-		t.Fatalf("expected net 1130, got %v", net)
+	for month, want := range cases {
+		got, err := FiscalYearStart(month)
+		if err != nil || got != want {
+			t.Fatalf("fiscal year start for %s: expected %s, got %s (%v)", month, want, got, err)
+		}
 	}
 }
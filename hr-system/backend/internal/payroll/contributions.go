@@ -0,0 +1,173 @@
+package payroll
+
+import (
+	"fmt"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+const (
+	ContributionSchemeNSSF    = "NSSF"
+	ContributionSchemePension = "PENSION"
+)
+
+// ContributionScheme is a statutory or private contribution fund. Each scheme
+// posts an employee deduction line and an employer-cost line, both taken as a
+// percentage of pensionable pay capped at the optional ceiling.
+type ContributionScheme struct {
+	ID                  int64         `db:"id" json:"id"`
+	Code                string        `db:"code" json:"code"`
+	Name                string        `db:"name" json:"name"`
+	EmployeeRate        float64       `db:"employee_rate" json:"employee_rate"`
+	EmployerRate        float64       `db:"employer_rate" json:"employer_rate"`
+	Ceiling             *money.Amount `db:"ceiling" json:"ceiling"`
+	IsMandatory         bool          `db:"is_mandatory" json:"is_mandatory"`
+	IsActive            bool          `db:"is_active" json:"is_active"`
+	EmployeeComponentID int64         `db:"employee_component_id" json:"employee_component_id"`
+	EmployerComponentID int64         `db:"employer_component_id" json:"employer_component_id"`
+	UpdatedBy           *int64        `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt           time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+type ContributionSchemeInput struct {
+	Name         string        `json:"name"`
+	EmployeeRate float64       `json:"employee_rate"`
+	EmployerRate float64       `json:"employer_rate"`
+	Ceiling      *money.Amount `json:"ceiling"`
+	IsMandatory  bool          `json:"is_mandatory"`
+	IsActive     bool          `json:"is_active"`
+}
+
+// ContributionMember enrols an employee in a scheme and carries the fund's
+// member number used on remittance filings.
+type ContributionMember struct {
+	SchemeID     int64     `db:"scheme_id" json:"scheme_id"`
+	EmployeeID   int64     `db:"employee_id" json:"employee_id"`
+	EmployeeName string    `db:"employee_name" json:"employee_name"`
+	MemberNumber string    `db:"member_number" json:"member_number"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ContributionMemberInput struct {
+	EmployeeID   int64  `json:"employee_id"`
+	MemberNumber string `json:"member_number"`
+}
+
+type RemittanceRow struct {
+	EmployeeID           int64        `json:"employee_id"`
+	EmployeeName         string       `json:"employee_name"`
+	MemberNumber         string       `json:"member_number"`
+	PensionablePay       money.Amount `json:"pensionable_pay"`
+	EmployeeContribution money.Amount `json:"employee_contribution"`
+	EmployerContribution money.Amount `json:"employer_contribution"`
+	TotalContribution    money.Amount `json:"total_contribution"`
+}
+
+type RemittanceScheme struct {
+	SchemeID      int64           `json:"scheme_id"`
+	SchemeCode    string          `json:"scheme_code"`
+	SchemeName    string          `json:"scheme_name"`
+	Rows          []RemittanceRow `json:"rows"`
+	EmployeeTotal money.Amount    `json:"employee_total"`
+	EmployerTotal money.Amount    `json:"employer_total"`
+	Total         money.Amount    `json:"total"`
+}
+
+type RemittanceSchedule struct {
+	Batch   Batch              `json:"batch"`
+	Schemes []RemittanceScheme `json:"schemes"`
+}
+
+// Compute returns the employee and employer contributions for a month's
+// pensionable pay.
+func (s ContributionScheme) Compute(pensionablePay money.Amount) (employee money.Amount, employer money.Amount, err error) {
+	basis := money.Max(pensionablePay, money.Amount{})
+	if s.Ceiling != nil {
+		basis = money.Min(basis, *s.Ceiling)
+	}
+	if employee, err = basis.MulRate(s.EmployeeRate); err != nil {
+		return money.Amount{}, money.Amount{}, fmt.Errorf("%s employee contribution: %w", s.Code, err)
+	}
+	if employer, err = basis.MulRate(s.EmployerRate); err != nil {
+		return money.Amount{}, money.Amount{}, fmt.Errorf("%s employer contribution: %w", s.Code, err)
+	}
+	return employee, employer, nil
+}
+
+// SchemesForEmployee returns the active schemes an employee contributes to:
+// every mandatory scheme plus the optional ones they are enrolled in.
+func SchemesForEmployee(schemes []ContributionScheme, members []ContributionMember, employeeID int64) []ContributionScheme {
+	enrolled := make(map[int64]struct{})
+	for _, member := range members {
+		if member.EmployeeID == employeeID {
+			enrolled[member.SchemeID] = struct{}{}
+		}
+	}
+
+	applicable := make([]ContributionScheme, 0, len(schemes))
+	for _, scheme := range schemes {
+		if !scheme.IsActive {
+			continue
+		}
+		if _, ok := enrolled[scheme.ID]; ok || scheme.IsMandatory {
+			applicable = append(applicable, scheme)
+		}
+	}
+	return applicable
+}
+
+// BuildRemittanceSchedule lists each employee's contributions per scheme from
+// the persisted entry lines, so the schedule always matches what was paid.
+func BuildRemittanceSchedule(batch Batch, entries []Entry, schemes []ContributionScheme, members []ContributionMember) RemittanceSchedule {
+	memberNumbers := make(map[[2]int64]string, len(members))
+	for _, member := range members {
+		memberNumbers[[2]int64{member.SchemeID, member.EmployeeID}] = member.MemberNumber
+	}
+
+	schedule := RemittanceSchedule{Batch: batch, Schemes: make([]RemittanceScheme, 0, len(schemes))}
+	for _, scheme := range schemes {
+		remittance := RemittanceScheme{
+			SchemeID:   scheme.ID,
+			SchemeCode: scheme.Code,
+			SchemeName: scheme.Name,
+			Rows:       make([]RemittanceRow, 0),
+		}
+		for _, entry := range entries {
+			var employee, employer money.Amount
+			for _, line := range entry.Lines {
+				switch line.ComponentID {
+				case scheme.EmployeeComponentID:
+					employee = employee.Add(line.Amount)
+				case scheme.EmployerComponentID:
+					employer = employer.Add(line.Amount)
+				}
+			}
+			if employee.IsZero() && employer.IsZero() {
+				continue
+			}
+			remittance.Rows = append(remittance.Rows, RemittanceRow{
+				EmployeeID:           entry.EmployeeID,
+				EmployeeName:         entry.EmployeeName,
+				MemberNumber:         memberNumbers[[2]int64{scheme.ID, entry.EmployeeID}],
+				PensionablePay:       entry.PensionablePay,
+				EmployeeContribution: employee,
+				EmployerContribution: employer,
+				TotalContribution:    employee.Add(employer),
+			})
+			remittance.EmployeeTotal = remittance.EmployeeTotal.Add(employee)
+			remittance.EmployerTotal = remittance.EmployerTotal.Add(employer)
+		}
+		remittance.Total = remittance.EmployeeTotal.Add(remittance.EmployerTotal)
+		if len(remittance.Rows) > 0 || scheme.IsActive {
+			schedule.Schemes = append(schedule.Schemes, remittance)
+		}
+	}
+	return schedule
+}
+
+func isValidRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
@@ -0,0 +1,191 @@
+package payroll
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// PaymentCurrency is the currency payroll is computed and paid in. Salaries
+// contracted in another currency are converted at the rate for the payroll
+// month when entries are generated.
+const PaymentCurrency = "UGX"
+
+// exchangeRateScale is the number of rate units per 1.0, matching the six
+// decimal places of the NUMERIC(18,6) rate columns.
+const exchangeRateScale = 1_000_000
+
+// ExchangeRate is the number of payment currency units per unit of Currency
+// for one payroll month.
+type ExchangeRate struct {
+	Month     string    `db:"month" json:"month"`
+	Currency  string    `db:"currency" json:"currency"`
+	Rate      float64   `db:"rate" json:"rate"`
+	UpdatedBy *int64    `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ExchangeRateInput struct {
+	Month    string  `json:"month"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+}
+
+// CurrencyAmounts are an entry's figures in one currency.
+type CurrencyAmounts struct {
+	MonthlySalary money.Amount `json:"monthly_salary"`
+	GrossPay      money.Amount `json:"gross_pay"`
+	TaxTotal      money.Amount `json:"tax_total"`
+	NetPay        money.Amount `json:"net_pay"`
+}
+
+func (a CurrencyAmounts) add(b CurrencyAmounts) CurrencyAmounts {
+	return CurrencyAmounts{
+		MonthlySalary: a.MonthlySalary.Add(b.MonthlySalary),
+		GrossPay:      a.GrossPay.Add(b.GrossPay),
+		TaxTotal:      a.TaxTotal.Add(b.TaxTotal),
+		NetPay:        a.NetPay.Add(b.NetPay),
+	}
+}
+
+// CurrencyReportRow is one entry in its contract currency, converted back at
+// the rate stored on the entry, and in the payment currency.
+type CurrencyReportRow struct {
+	EntryID          int64           `json:"entry_id"`
+	EmployeeID       int64           `json:"employee_id"`
+	EmployeeName     string          `json:"employee_name"`
+	ContractCurrency string          `json:"contract_currency"`
+	ExchangeRate     float64         `json:"exchange_rate"`
+	Contract         CurrencyAmounts `json:"contract"`
+	Payment          CurrencyAmounts `json:"payment"`
+}
+
+// CurrencyTotals sums the rows of one contract currency.
+type CurrencyTotals struct {
+	ContractCurrency string          `json:"contract_currency"`
+	Employees        int             `json:"employees"`
+	Contract         CurrencyAmounts `json:"contract"`
+	Payment          CurrencyAmounts `json:"payment"`
+}
+
+// CurrencyReport shows a batch in both contract and payment currency. Payment
+// totals are per batch; contract totals only add up within a currency.
+type CurrencyReport struct {
+	Batch           Batch               `json:"batch"`
+	PaymentCurrency string              `json:"payment_currency"`
+	Rows            []CurrencyReportRow `json:"rows"`
+	ByCurrency      []CurrencyTotals    `json:"by_currency"`
+	PaymentTotals   CurrencyAmounts     `json:"payment_totals"`
+}
+
+// ExchangeRateFor returns the rate for currency in month; the payment currency
+// is always 1.
+func ExchangeRateFor(rates []ExchangeRate, month, currency string) (float64, bool) {
+	if currency == PaymentCurrency {
+		return 1, true
+	}
+	for _, rate := range rates {
+		if rate.Month == month && rate.Currency == currency {
+			return rate.Rate, true
+		}
+	}
+	return 0, false
+}
+
+// ToPaymentCurrency converts a contract currency amount at rate, rounding
+// half-up to the cent.
+func ToPaymentCurrency(amount money.Amount, rate float64) (money.Amount, error) {
+	return amount.MulFrac(rateUnits(rate), exchangeRateScale)
+}
+
+// ToContractCurrency converts a payment currency amount back at rate,
+// rounding half-up to the cent.
+func ToContractCurrency(amount money.Amount, rate float64) (money.Amount, error) {
+	return amount.MulFrac(exchangeRateScale, rateUnits(rate))
+}
+
+func rateUnits(rate float64) int64 {
+	return int64(math.Round(rate * exchangeRateScale))
+}
+
+// BuildCurrencyReport converts each entry back to its contract currency at
+// the rate stored on it. Rows are converted one by one, so each currency's
+// totals are the sum of its rows.
+func BuildCurrencyReport(batch Batch, entries []Entry) (CurrencyReport, error) {
+	report := CurrencyReport{
+		Batch:           batch,
+		PaymentCurrency: PaymentCurrency,
+		Rows:            make([]CurrencyReportRow, 0, len(entries)),
+		ByCurrency:      make([]CurrencyTotals, 0),
+	}
+	totals := make(map[string]*CurrencyTotals)
+	for _, entry := range entries {
+		row := CurrencyReportRow{
+			EntryID:          entry.ID,
+			EmployeeID:       entry.EmployeeID,
+			EmployeeName:     entry.EmployeeName,
+			ContractCurrency: entry.ContractCurrency,
+			ExchangeRate:     entry.ExchangeRate,
+			Payment: CurrencyAmounts{
+				MonthlySalary: entry.MonthlyBaseSalary,
+				GrossPay:      entry.GrossPay,
+				TaxTotal:      entry.TaxTotal,
+				NetPay:        entry.NetPay,
+			},
+		}
+		if row.ContractCurrency == PaymentCurrency {
+			row.Contract = row.Payment
+		} else {
+			contract, err := contractAmounts(entry)
+			if err != nil {
+				return CurrencyReport{}, err
+			}
+			row.Contract = contract
+		}
+		report.Rows = append(report.Rows, row)
+
+		total, ok := totals[row.ContractCurrency]
+		if !ok {
+			total = &CurrencyTotals{ContractCurrency: row.ContractCurrency}
+			totals[row.ContractCurrency] = total
+		}
+		total.Employees++
+		total.Contract = total.Contract.add(row.Contract)
+		total.Payment = total.Payment.add(row.Payment)
+		report.PaymentTotals = report.PaymentTotals.add(row.Payment)
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].ContractCurrency != report.Rows[j].ContractCurrency {
+			return report.Rows[i].ContractCurrency < report.Rows[j].ContractCurrency
+		}
+		return report.Rows[i].EmployeeName < report.Rows[j].EmployeeName
+	})
+	for _, total := range totals {
+		report.ByCurrency = append(report.ByCurrency, *total)
+	}
+	sort.Slice(report.ByCurrency, func(i, j int) bool {
+		return report.ByCurrency[i].ContractCurrency < report.ByCurrency[j].ContractCurrency
+	})
+	return report, nil
+}
+
+// contractAmounts converts an entry's payment currency figures back to its
+// contract currency.
+func contractAmounts(entry Entry) (CurrencyAmounts, error) {
+	amounts := CurrencyAmounts{MonthlySalary: entry.ContractMonthlySalary}
+	var err error
+	if amounts.GrossPay, err = ToContractCurrency(entry.GrossPay, entry.ExchangeRate); err != nil {
+		return CurrencyAmounts{}, fmt.Errorf("convert entry %d to %s: %w", entry.ID, entry.ContractCurrency, err)
+	}
+	if amounts.TaxTotal, err = ToContractCurrency(entry.TaxTotal, entry.ExchangeRate); err != nil {
+		return CurrencyAmounts{}, fmt.Errorf("convert entry %d to %s: %w", entry.ID, entry.ContractCurrency, err)
+	}
+	if amounts.NetPay, err = ToContractCurrency(entry.NetPay, entry.ExchangeRate); err != nil {
+		return CurrencyAmounts{}, fmt.Errorf("convert entry %d to %s: %w", entry.ID, entry.ContractCurrency, err)
+	}
+	return amounts, nil
+}
@@ -0,0 +1,83 @@
+package payroll
+
+import (
+	"errors"
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestExchangeRateForMonthAndCurrency(t *testing.T) {
+	rates := []ExchangeRate{
+		{Month: "2026-01", Currency: "USD", Rate: 3650},
+		{Month: "2026-02", Currency: "USD", Rate: 3712.5},
+		{Month: "2026-02", Currency: "EUR", Rate: 4010},
+	}
+	if rate, ok := ExchangeRateFor(rates, "2026-02", "USD"); !ok || rate != 3712.5 {
+		t.Fatalf("expected the February USD rate, got %v (%v)", rate, ok)
+	}
+	if rate, ok := ExchangeRateFor(nil, "2026-02", PaymentCurrency); !ok || rate != 1 {
+		t.Fatalf("expected the payment currency at 1, got %v (%v)", rate, ok)
+	}
+	if _, ok := ExchangeRateFor(rates, "2026-03", "USD"); ok {
+		t.Fatalf("expected no rate for a month without one")
+	}
+}
+
+func TestCurrencyConversionRoundsHalfUp(t *testing.T) {
+	if got, err := ToPaymentCurrency(money.FromInt(1000), 3712.345678); err != nil || got != money.FromCents(371234568) {
+		t.Fatalf("expected 3,712,345.68, got %s (%v)", got, err)
+	}
+	if got, err := ToPaymentCurrency(money.FromCents(1005), 0.5); err != nil || got != money.FromCents(503) {
+		t.Fatalf("expected 5.03, got %s (%v)", got, err)
+	}
+	if got, err := ToContractCurrency(money.FromCents(371234568), 3712.345678); err != nil || got != money.FromInt(1000) {
+		t.Fatalf("expected 1,000.00 back, got %s (%v)", got, err)
+	}
+	// A zero rate cannot be converted back rather than coming out as zero.
+	if _, err := ToContractCurrency(money.FromInt(1000), 0); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Fatalf("expected a conversion error for a zero rate, got %v", err)
+	}
+}
+
+func TestBuildCurrencyReportConvertsBackPerEntry(t *testing.T) {
+	batch := Batch{ID: 3, Month: "2026-02", Status: StatusApproved}
+	entries := []Entry{
+		{ID: 1, EmployeeID: 21, EmployeeName: "Okello, Ann", ContractCurrency: "USD", ContractMonthlySalary: money.FromInt(1000), ExchangeRate: 3700, MonthlyBaseSalary: money.FromInt(3700000), GrossPay: money.FromInt(3700000), TaxTotal: money.FromInt(100000), NetPay: money.FromInt(3600000)},
+		{ID: 2, EmployeeID: 22, EmployeeName: "Akello, Bea", ContractCurrency: "USD", ContractMonthlySalary: money.FromInt(500), ExchangeRate: 3700, MonthlyBaseSalary: money.FromInt(1850000), GrossPay: money.FromInt(1850000), TaxTotal: money.FromInt(50000), NetPay: money.FromInt(1800000)},
+		{ID: 3, EmployeeID: 23, EmployeeName: "Mugisha, Cal", ContractCurrency: PaymentCurrency, ContractMonthlySalary: money.FromInt(900000), ExchangeRate: 1, MonthlyBaseSalary: money.FromInt(900000), GrossPay: money.FromInt(900000), TaxTotal: money.FromInt(20000), NetPay: money.FromInt(880000)},
+	}
+	report, err := BuildCurrencyReport(batch, entries)
+	if err != nil {
+		t.Fatalf("build currency report: %v", err)
+	}
+
+	if len(report.Rows) != 3 || report.Rows[0].ContractCurrency != PaymentCurrency || report.Rows[1].EmployeeName != "Akello, Bea" {
+		t.Fatalf("expected rows by currency then name, got %+v", report.Rows)
+	}
+	ann := report.Rows[2].Contract
+	if ann.MonthlySalary != money.FromInt(1000) || ann.GrossPay != money.FromInt(1000) || ann.TaxTotal != money.FromCents(2703) || ann.NetPay != money.FromCents(97297) {
+		t.Fatalf("unexpected USD figures: %+v", ann)
+	}
+	if len(report.ByCurrency) != 2 || report.ByCurrency[1].ContractCurrency != "USD" || report.ByCurrency[1].Employees != 2 {
+		t.Fatalf("unexpected currency totals: %+v", report.ByCurrency)
+	}
+	if usd := report.ByCurrency[1].Contract; usd.TaxTotal != money.FromCents(4054) || usd.NetPay != money.FromCents(145946) {
+		t.Fatalf("unexpected USD totals: %+v", usd)
+	}
+	if report.PaymentTotals.GrossPay != money.FromInt(6450000) || report.PaymentTotals.NetPay != money.FromInt(6280000) {
+		t.Fatalf("unexpected payment totals: %+v", report.PaymentTotals)
+	}
+}
+
+func TestPayslipShowsForeignContractSalary(t *testing.T) {
+	batch := Batch{ID: 3, Month: "2026-02"}
+	entry := Entry{ContractCurrency: "USD", ContractMonthlySalary: money.FromInt(1000), ExchangeRate: 3712.5, BaseSalary: money.FromCents(371250000)}
+	if slip := BuildPayslip(Employer{}, EmployeeDetails{}, batch, entry, Amounts{}, nil, nil); slip.ContractSalary != "USD 1,000.00 at 3712.500000" {
+		t.Fatalf("unexpected contract salary: %q", slip.ContractSalary)
+	}
+	entry.ContractCurrency = PaymentCurrency
+	if slip := BuildPayslip(Employer{}, EmployeeDetails{}, batch, entry, Amounts{}, nil, nil); slip.ContractSalary != "" {
+		t.Fatalf("expected no contract salary for the payment currency, got %q", slip.ContractSalary)
+	}
+}
@@ -3,11 +3,31 @@ package payroll
 import "errors"
 
 var (
-	ErrInvalidInput            = errors.New("invalid payroll input")
-	ErrForbidden               = errors.New("forbidden")
-	ErrBatchNotFound           = errors.New("payroll batch not found")
-	ErrEntryNotFound           = errors.New("payroll entry not found")
-	ErrBatchAlreadyExists      = errors.New("payroll batch already exists")
-	ErrInvalidStatusTransition = errors.New("invalid payroll status transition")
-	ErrBatchImmutable          = errors.New("payroll batch is immutable")
+	ErrInvalidInput               = errors.New("invalid payroll input")
+	ErrForbidden                  = errors.New("forbidden")
+	ErrBatchNotFound              = errors.New("payroll batch not found")
+	ErrEntryNotFound              = errors.New("payroll entry not found")
+	ErrBatchAlreadyExists         = errors.New("payroll batch already exists")
+	ErrInvalidStatusTransition    = errors.New("invalid payroll status transition")
+	ErrBatchImmutable             = errors.New("payroll batch is immutable")
+	ErrComponentNotFound          = errors.New("payroll component not found")
+	ErrComponentAlreadyExists     = errors.New("payroll component already exists")
+	ErrTaxTableNotFound           = errors.New("no tax table in force for payroll month")
+	ErrTaxOverrideReason          = errors.New("tax override requires a reason")
+	ErrContributionSchemeNotFound = errors.New("contribution scheme not found")
+	ErrContributionMemberNotFound = errors.New("contribution scheme member not found")
+	ErrBankDetailsInvalid         = errors.New("bank details are missing or invalid")
+	ErrJustificationRequired      = errors.New("a justification is required")
+	ErrSegregationOfDuties        = errors.New("segregation of duties: the approver must not have created or edited the payroll batch")
+	ErrApprovalInProgress         = errors.New("approval chain cannot change while a batch is in approval")
+	ErrLoanNotFound               = errors.New("payroll loan not found")
+	ErrRecurringItemNotFound      = errors.New("payroll recurring item not found")
+	ErrEmployeeNotFound           = errors.New("employee not found")
+	ErrNoAnnualEarnings           = errors.New("no locked payroll for the employee in this fiscal year")
+	ErrProjectNotFound            = errors.New("payroll project not found")
+	ErrProjectAlreadyExists       = errors.New("payroll project already exists")
+	ErrCostAllocationNotFound     = errors.New("payroll cost allocation not found")
+	ErrGLAccountMissing           = errors.New("general ledger accounts are not mapped")
+	ErrExchangeRateNotFound       = errors.New("no exchange rate for the salary currency in payroll month")
+	ErrMonthBatchPending          = errors.New("another payroll batch of the month is in approval or approved")
 )
@@ -0,0 +1,291 @@
+package payroll
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"hr-system/backend/internal/money"
+	"hr-system/backend/internal/xlsx"
+)
+
+// Import key columns; every other column is a component code.
+const (
+	ImportColumnEmployeeID   = "employee_id"
+	ImportColumnNationalID   = "national_id"
+	ImportColumnEmployeeName = "employee_name"
+)
+
+// ImportEntriesInput is a CSV or XLSX sheet of entry amounts. The first row
+// names the columns: employee_id and/or national_id to find the employee,
+// then one column per component code. A blank cell leaves that component as
+// it is and 0 removes it; components without a column are untouched.
+type ImportEntriesInput struct {
+	FileName string `json:"file_name"`
+	Content  []byte `json:"content"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+type ImportChange struct {
+	ComponentCode string       `json:"component_code"`
+	ComponentName string       `json:"component_name"`
+	Previous      money.Amount `json:"previous"`
+	Amount        money.Amount `json:"amount"`
+}
+
+type ImportRow struct {
+	// Row is the spreadsheet row number, counting the header as row 1.
+	Row            int            `json:"row"`
+	EmployeeKey    string         `json:"employee_key"`
+	EmployeeID     int64          `json:"employee_id,omitempty"`
+	EmployeeName   string         `json:"employee_name"`
+	EntryID        int64          `json:"entry_id,omitempty"`
+	Changes        []ImportChange `json:"changes"`
+	PreviousNetPay money.Amount   `json:"previous_net_pay"`
+	NetPay         money.Amount   `json:"net_pay"`
+	Errors         []string       `json:"errors"`
+}
+
+// ImportResult previews an import row by row. Nothing is applied unless the
+// import was not a dry run and ErrorCount is zero.
+type ImportResult struct {
+	BatchID int64 `json:"batch_id"`
+	DryRun  bool  `json:"dry_run"`
+	Applied bool  `json:"applied"`
+	// Errors are problems with the file as a whole, such as unknown columns.
+	Errors       []string    `json:"errors"`
+	Rows         []ImportRow `json:"rows"`
+	ErrorCount   int         `json:"error_count"`
+	UpdatedCount int         `json:"updated_count"`
+}
+
+// importPlan is a parsed import: the preview plus, for each row without
+// errors, the entry and its merged line inputs.
+type importPlan struct {
+	result  ImportResult
+	entries []Entry
+	inputs  [][]EntryLineInput
+	rows    []int
+}
+
+// readImportSheet returns the sheet's rows, reading XLSX by file extension
+// (or zip signature) and anything else as CSV. Blank CSV lines are kept as
+// empty rows so row numbers match the file.
+func readImportSheet(fileName string, content []byte) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(fileName), ".xlsx") || bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		return xlsx.ReadFirstSheet(content)
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows := make([][]string, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for len(rows) < line-1 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// planImport matches each sheet row to its entry in the batch and merges the
+// row's amounts into the entry's current editable lines.
+func planImport(sheet [][]string, entries []Entry, employees []EmployeeDetails, components []Component) importPlan {
+	plan := importPlan{result: ImportResult{Errors: make([]string, 0), Rows: make([]ImportRow, 0)}}
+	if len(sheet) == 0 {
+		plan.result.Errors = append(plan.result.Errors, "the file is empty")
+		return plan
+	}
+
+	componentsByCode := make(map[string]Component, len(components))
+	for _, component := range components {
+		componentsByCode[strings.ToUpper(component.Code)] = component
+	}
+	employeeIDColumn, nationalIDColumn := -1, -1
+	componentColumns := make(map[int]Component)
+	for column, heading := range sheet[0] {
+		heading = strings.TrimSpace(heading)
+		switch strings.ToLower(heading) {
+		case "":
+			continue
+		case ImportColumnEmployeeID:
+			employeeIDColumn = column
+			continue
+		case ImportColumnNationalID:
+			nationalIDColumn = column
+			continue
+		case ImportColumnEmployeeName:
+			continue
+		}
+		component, ok := componentsByCode[strings.ToUpper(heading)]
+		switch {
+		case !ok:
+			plan.result.Errors = append(plan.result.Errors, fmt.Sprintf("column %q is not a payroll component code", heading))
+		case component.IsSystem:
+			plan.result.Errors = append(plan.result.Errors, fmt.Sprintf("column %q is calculated by payroll and cannot be imported", heading))
+		case !component.IsActive:
+			plan.result.Errors = append(plan.result.Errors, fmt.Sprintf("column %q is an inactive component", heading))
+		default:
+			componentColumns[column] = component
+		}
+	}
+	if employeeIDColumn < 0 && nationalIDColumn < 0 {
+		plan.result.Errors = append(plan.result.Errors, fmt.Sprintf("the header needs an %s or %s column", ImportColumnEmployeeID, ImportColumnNationalID))
+	}
+	if len(plan.result.Errors) > 0 {
+		return plan
+	}
+
+	entriesByEmployee := make(map[int64]Entry, len(entries))
+	for _, entry := range entries {
+		entriesByEmployee[entry.EmployeeID] = entry
+	}
+	employeesByNationalID := make(map[string][]int64, len(employees))
+	for _, employee := range employees {
+		if key := strings.ToUpper(strings.TrimSpace(employee.NationalID)); key != "" {
+			employeesByNationalID[key] = append(employeesByNationalID[key], employee.ID)
+		}
+	}
+	seenRows := make(map[int64]int, len(sheet))
+
+	for index, cells := range sheet[1:] {
+		cell := func(column int) string {
+			if column < 0 || column >= len(cells) {
+				return ""
+			}
+			return strings.TrimSpace(cells[column])
+		}
+		if isBlankRow(cells) {
+			continue
+		}
+		row := ImportRow{Row: index + 2, Changes: make([]ImportChange, 0), Errors: make([]string, 0)}
+
+		var employeeID int64
+		switch {
+		case cell(employeeIDColumn) != "":
+			row.EmployeeKey = cell(employeeIDColumn)
+			id, err := strconv.ParseInt(row.EmployeeKey, 10, 64)
+			if err != nil || id <= 0 {
+				row.Errors = append(row.Errors, fmt.Sprintf("%q is not an employee ID", row.EmployeeKey))
+			}
+			employeeID = id
+		case cell(nationalIDColumn) != "":
+			row.EmployeeKey = cell(nationalIDColumn)
+			matches := employeesByNationalID[strings.ToUpper(row.EmployeeKey)]
+			switch len(matches) {
+			case 0:
+				row.Errors = append(row.Errors, fmt.Sprintf("no employee in this batch has national ID %s", row.EmployeeKey))
+			case 1:
+				employeeID = matches[0]
+			default:
+				row.Errors = append(row.Errors, fmt.Sprintf("national ID %s belongs to more than one employee", row.EmployeeKey))
+			}
+		default:
+			row.Errors = append(row.Errors, "missing employee ID or national ID")
+		}
+
+		entry, found := entriesByEmployee[employeeID]
+		if employeeID > 0 && !found && len(row.Errors) == 0 {
+			row.Errors = append(row.Errors, fmt.Sprintf("employee %d is not in this batch", employeeID))
+		}
+		if found {
+			row.EmployeeID = entry.EmployeeID
+			row.EmployeeName = entry.EmployeeName
+			row.EntryID = entry.ID
+			row.PreviousNetPay = entry.NetPay
+			row.NetPay = entry.NetPay
+			if first, duplicate := seenRows[employeeID]; duplicate {
+				row.Errors = append(row.Errors, fmt.Sprintf("employee already listed on row %d", first))
+			} else {
+				seenRows[employeeID] = row.Row
+			}
+		}
+
+		current := make(map[int64]money.Amount, len(entry.Lines))
+		order := make([]int64, 0, len(entry.Lines)+len(componentColumns))
+		for _, line := range entry.Lines {
+			if line.IsSystem {
+				continue
+			}
+			current[line.ComponentID] = current[line.ComponentID].Add(line.Amount)
+			order = append(order, line.ComponentID)
+		}
+		merged := make(map[int64]money.Amount, len(current))
+		for id, amount := range current {
+			merged[id] = amount
+		}
+		for column := range cells {
+			component, ok := componentColumns[column]
+			if !ok || cell(column) == "" {
+				continue
+			}
+			amount, err := money.Parse(strings.NewReplacer(",", "", " ", "").Replace(cell(column)))
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: %q is not an amount", component.Code, cell(column)))
+				continue
+			}
+			if amount.IsNegative() {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: amount must not be negative", component.Code))
+				continue
+			}
+			if _, ok := merged[component.ID]; !ok {
+				order = append(order, component.ID)
+			}
+			merged[component.ID] = amount
+			if amount != current[component.ID] {
+				row.Changes = append(row.Changes, ImportChange{
+					ComponentCode: component.Code,
+					ComponentName: component.Name,
+					Previous:      current[component.ID],
+					Amount:        amount,
+				})
+			}
+		}
+
+		plan.result.Rows = append(plan.result.Rows, row)
+		if len(row.Errors) > 0 {
+			continue
+		}
+		inputs := make([]EntryLineInput, 0, len(order))
+		for _, id := range order {
+			inputs = append(inputs, EntryLineInput{ComponentID: id, Amount: merged[id]})
+		}
+		plan.entries = append(plan.entries, entry)
+		plan.inputs = append(plan.inputs, inputs)
+		plan.rows = append(plan.rows, len(plan.result.Rows)-1)
+	}
+	if len(plan.result.Rows) == 0 {
+		plan.result.Errors = append(plan.result.Errors, "the file has no rows to import")
+	}
+	return plan
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// countImportErrors totals file and row errors.
+func countImportErrors(result ImportResult) int {
+	count := len(result.Errors)
+	for _, row := range result.Rows {
+		count += len(row.Errors)
+	}
+	return count
+}
@@ -0,0 +1,188 @@
+package payroll
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// DigestAlgorithm is the hash behind Batch.LockDigest.
+const DigestAlgorithm = "SHA-256"
+
+// BatchVerification compares a locked batch as stored now with the digest
+// taken when it was locked.
+type BatchVerification struct {
+	BatchID        int64      `json:"batch_id"`
+	Month          string     `json:"month"`
+	Status         string     `json:"status"`
+	LockedAt       *time.Time `json:"locked_at,omitempty"`
+	Algorithm      string     `json:"algorithm"`
+	Entries        int        `json:"entries"`
+	RecordedDigest string     `json:"recorded_digest"`
+	ComputedDigest string     `json:"computed_digest"`
+	VerifiedAt     time.Time  `json:"verified_at"`
+
+	// Sealed is false for batches locked before digests were recorded; they
+	// cannot be verified. Intact is set only when the digests match.
+	Sealed bool `json:"sealed"`
+	Intact bool `json:"intact"`
+}
+
+// The digested form of a batch. Only stored figures are included: names,
+// residency and component details are read through joins and may change
+// without touching the batch. Rate-like floats are written to the six places
+// their columns hold.
+type digestBatch struct {
+	ID              int64         `json:"id"`
+	Month           string        `json:"month"`
+	BatchType       string        `json:"batch_type"`
+	Description     string        `json:"description"`
+	CorrectsBatchID *int64        `json:"corrects_batch_id"`
+	CreatedBy       int64         `json:"created_by"`
+	ApprovedBy      *int64        `json:"approved_by"`
+	Entries         []digestEntry `json:"entries"`
+}
+
+type digestEntry struct {
+	ID                         int64                  `json:"id"`
+	EmployeeID                 int64                  `json:"employee_id"`
+	BaseSalary                 money.Amount           `json:"base_salary"`
+	MonthlyBaseSalary          money.Amount           `json:"monthly_base_salary"`
+	ContractCurrency           string                 `json:"contract_currency"`
+	ContractMonthlySalary      money.Amount           `json:"contract_monthly_salary"`
+	ExchangeRate               string                 `json:"exchange_rate"`
+	ProrationBasis             string                 `json:"proration_basis"`
+	ProrationDaysPaid          int                    `json:"proration_days_paid"`
+	ProrationPeriodDays        int                    `json:"proration_period_days"`
+	ProrationFactor            string                 `json:"proration_factor"`
+	AllowancesTotal            money.Amount           `json:"allowances_total"`
+	DeductionsTotal            money.Amount           `json:"deductions_total"`
+	TaxTotal                   money.Amount           `json:"tax_total"`
+	GrossPay                   money.Amount           `json:"gross_pay"`
+	NetPay                     money.Amount           `json:"net_pay"`
+	TaxablePay                 money.Amount           `json:"taxable_pay"`
+	PensionablePay             money.Amount           `json:"pensionable_pay"`
+	EmployerContributionsTotal money.Amount           `json:"employer_contributions_total"`
+	TaxTableID                 *int64                 `json:"tax_table_id"`
+	TaxOverride                bool                   `json:"tax_override"`
+	TaxOverrideReason          string                 `json:"tax_override_reason"`
+	TaxOverrideBy              *int64                 `json:"tax_override_by"`
+	Lines                      []digestLine           `json:"lines"`
+	LeaveDeductions            []digestLeaveDeduction `json:"leave_deductions"`
+	LoanDeductions             []digestLoanDeduction  `json:"loan_deductions"`
+}
+
+type digestLine struct {
+	ID          int64        `json:"id"`
+	ComponentID int64        `json:"component_id"`
+	Amount      money.Amount `json:"amount"`
+}
+
+type digestLeaveDeduction struct {
+	LeaveRequestID int64        `json:"leave_request_id"`
+	UnpaidDays     int          `json:"unpaid_days"`
+	DailyRate      money.Amount `json:"daily_rate"`
+	Amount         money.Amount `json:"amount"`
+}
+
+type digestLoanDeduction struct {
+	LoanID int64        `json:"loan_id"`
+	Amount money.Amount `json:"amount"`
+}
+
+// ComputeBatchDigest returns the hex SHA-256 of the batch, its entries and
+// their lines and leave and loan deductions. Everything is ordered by ID, so
+// the digest does not depend on the order the entries were read in.
+func ComputeBatchDigest(batch Batch, entries []Entry) string {
+	document := digestBatch{
+		ID:              batch.ID,
+		Month:           batch.Month,
+		BatchType:       batch.BatchType,
+		Description:     batch.Description,
+		CorrectsBatchID: batch.CorrectsBatchID,
+		CreatedBy:       batch.CreatedBy,
+		ApprovedBy:      batch.ApprovedBy,
+		Entries:         make([]digestEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		item := digestEntry{
+			ID:                         entry.ID,
+			EmployeeID:                 entry.EmployeeID,
+			BaseSalary:                 entry.BaseSalary,
+			MonthlyBaseSalary:          entry.MonthlyBaseSalary,
+			ContractCurrency:           entry.ContractCurrency,
+			ContractMonthlySalary:      entry.ContractMonthlySalary,
+			ExchangeRate:               strconv.FormatFloat(entry.ExchangeRate, 'f', 6, 64),
+			ProrationBasis:             entry.ProrationBasis,
+			ProrationDaysPaid:          entry.ProrationDaysPaid,
+			ProrationPeriodDays:        entry.ProrationPeriodDays,
+			ProrationFactor:            strconv.FormatFloat(entry.ProrationFactor, 'f', 6, 64),
+			AllowancesTotal:            entry.AllowancesTotal,
+			DeductionsTotal:            entry.DeductionsTotal,
+			TaxTotal:                   entry.TaxTotal,
+			GrossPay:                   entry.GrossPay,
+			NetPay:                     entry.NetPay,
+			TaxablePay:                 entry.TaxablePay,
+			PensionablePay:             entry.PensionablePay,
+			EmployerContributionsTotal: entry.EmployerContributionsTotal,
+			TaxTableID:                 entry.TaxTableID,
+			TaxOverride:                entry.TaxOverride,
+			TaxOverrideReason:          entry.TaxOverrideReason,
+			TaxOverrideBy:              entry.TaxOverrideBy,
+			Lines:                      make([]digestLine, 0, len(entry.Lines)),
+			LeaveDeductions:            make([]digestLeaveDeduction, 0, len(entry.LeaveDeductions)),
+			LoanDeductions:             make([]digestLoanDeduction, 0, len(entry.LoanDeductions)),
+		}
+		for _, line := range entry.Lines {
+			item.Lines = append(item.Lines, digestLine{ID: line.ID, ComponentID: line.ComponentID, Amount: line.Amount})
+		}
+		for _, deduction := range entry.LeaveDeductions {
+			item.LeaveDeductions = append(item.LeaveDeductions, digestLeaveDeduction{
+				LeaveRequestID: deduction.LeaveRequestID,
+				UnpaidDays:     deduction.UnpaidDays,
+				DailyRate:      deduction.DailyRate,
+				Amount:         deduction.Amount,
+			})
+		}
+		for _, deduction := range entry.LoanDeductions {
+			item.LoanDeductions = append(item.LoanDeductions, digestLoanDeduction{LoanID: deduction.LoanID, Amount: deduction.Amount})
+		}
+		sort.Slice(item.Lines, func(i, j int) bool { return item.Lines[i].ID < item.Lines[j].ID })
+		sort.Slice(item.LeaveDeductions, func(i, j int) bool {
+			return item.LeaveDeductions[i].LeaveRequestID < item.LeaveDeductions[j].LeaveRequestID
+		})
+		sort.Slice(item.LoanDeductions, func(i, j int) bool { return item.LoanDeductions[i].LoanID < item.LoanDeductions[j].LoanID })
+		document.Entries = append(document.Entries, item)
+	}
+	sort.Slice(document.Entries, func(i, j int) bool { return document.Entries[i].ID < document.Entries[j].ID })
+
+	// The document holds only strings, numbers and slices, so encoding
+	// cannot fail.
+	encoded, _ := json.Marshal(document)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyBatchDigest recomputes the digest of a locked batch and compares it
+// with the one recorded when it was locked.
+func VerifyBatchDigest(batch Batch, entries []Entry, verifiedAt time.Time) BatchVerification {
+	result := BatchVerification{
+		BatchID:        batch.ID,
+		Month:          batch.Month,
+		Status:         batch.Status,
+		LockedAt:       batch.LockedAt,
+		Algorithm:      DigestAlgorithm,
+		Entries:        len(entries),
+		RecordedDigest: batch.LockDigest,
+		ComputedDigest: ComputeBatchDigest(batch, entries),
+		VerifiedAt:     verifiedAt,
+		Sealed:         batch.LockDigest != "",
+	}
+	result.Intact = result.Sealed && result.RecordedDigest == result.ComputedDigest
+	return result
+}
@@ -0,0 +1,107 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+func integrityTestBatch() (Batch, []Entry) {
+	approvedBy := int64(9)
+	batch := Batch{ID: 4, Month: "2026-02", Status: StatusLocked, BatchType: BatchTypeRegular, CreatedBy: 7, ApprovedBy: &approvedBy}
+	entries := []Entry{
+		{
+			ID:           12,
+			BatchID:      4,
+			EmployeeID:   3,
+			EmployeeName: "Okello, Grace",
+			BaseSalary:   money.FromInt(1000),
+			GrossPay:     money.FromInt(1050),
+			NetPay:       money.FromInt(950),
+			ExchangeRate: 1,
+			Lines: []EntryLine{
+				{ID: 31, ComponentID: 4, Amount: money.FromInt(100)},
+				{ID: 30, ComponentID: 1, Amount: money.FromInt(50)},
+			},
+		},
+		{ID: 11, BatchID: 4, EmployeeID: 2, BaseSalary: money.FromInt(500), GrossPay: money.FromInt(500), NetPay: money.FromInt(500), ExchangeRate: 1},
+	}
+	return batch, entries
+}
+
+func TestComputeBatchDigestIgnoresReadOrder(t *testing.T) {
+	batch, entries := integrityTestBatch()
+	digest := ComputeBatchDigest(batch, entries)
+	if len(digest) != 64 {
+		t.Fatalf("expected a hex SHA-256 digest, got %q", digest)
+	}
+
+	reordered := []Entry{entries[1], entries[0]}
+	reordered[1].Lines = []EntryLine{entries[0].Lines[1], entries[0].Lines[0]}
+	if ComputeBatchDigest(batch, reordered) != digest {
+		t.Fatalf("expected the digest not to depend on row order")
+	}
+
+	// Names are read through a join and are not part of the batch.
+	reordered[1].EmployeeName = "Okello-Achieng, Grace"
+	batch.Status = StatusReversed
+	if ComputeBatchDigest(batch, reordered) != digest {
+		t.Fatalf("expected the digest to ignore the employee name and batch status")
+	}
+}
+
+func TestComputeBatchDigestCoversStoredFigures(t *testing.T) {
+	batch, entries := integrityTestBatch()
+	digest := ComputeBatchDigest(batch, entries)
+
+	changes := map[string]func(*Batch, []Entry) []Entry{
+		"line amount": func(_ *Batch, items []Entry) []Entry {
+			items[0].Lines[0].Amount = money.FromInt(99)
+			return items
+		},
+		"removed line": func(_ *Batch, items []Entry) []Entry {
+			items[0].Lines = items[0].Lines[:1]
+			return items
+		},
+		"removed entry": func(_ *Batch, items []Entry) []Entry {
+			return items[:1]
+		},
+		"exchange rate": func(_ *Batch, items []Entry) []Entry {
+			items[1].ExchangeRate = 1.000001
+			return items
+		},
+		"leave deduction": func(_ *Batch, items []Entry) []Entry {
+			items[1].LeaveDeductions = []LeaveDeduction{{LeaveRequestID: 5, UnpaidDays: 1, Amount: money.FromInt(20)}}
+			return items
+		},
+		"approver": func(b *Batch, items []Entry) []Entry {
+			other := int64(10)
+			b.ApprovedBy = &other
+			return items
+		},
+	}
+	for name, change := range changes {
+		changedBatch, items := integrityTestBatch()
+		items = change(&changedBatch, items)
+		if ComputeBatchDigest(changedBatch, items) == digest {
+			t.Fatalf("expected a changed %s to change the digest", name)
+		}
+	}
+}
+
+func TestVerifyBatchDigest(t *testing.T) {
+	batch, entries := integrityTestBatch()
+	batch.LockDigest = ComputeBatchDigest(batch, entries)
+	verifiedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	result := VerifyBatchDigest(batch, entries, verifiedAt)
+	if !result.Sealed || !result.Intact || result.Algorithm != DigestAlgorithm || result.Entries != 2 || !result.VerifiedAt.Equal(verifiedAt) {
+		t.Fatalf("expected the batch to verify, got %+v", result)
+	}
+
+	entries[1].NetPay = money.FromInt(5000)
+	if result := VerifyBatchDigest(batch, entries, verifiedAt); result.Intact || result.ComputedDigest == batch.LockDigest {
+		t.Fatalf("expected the change to be detected, got %+v", result)
+	}
+}
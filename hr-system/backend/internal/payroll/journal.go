@@ -0,0 +1,340 @@
+package payroll
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// GL postings: the account the batch's base salary is expensed to, the
+// clearing account net pay is credited to, and one per component.
+const (
+	GLPostingBaseSalary = "BaseSalary"
+	GLPostingNetPay     = "NetPay"
+	GLPostingComponent  = "Component"
+)
+
+// Journal CSV columns.
+const (
+	JournalColumnDate        = "date"
+	JournalColumnReference   = "reference"
+	JournalColumnMonth       = "month"
+	JournalColumnBatchID     = "batch_id"
+	JournalColumnAccountCode = "account_code"
+	JournalColumnAccountName = "account_name"
+	JournalColumnDescription = "description"
+	JournalColumnDebit       = "debit"
+	JournalColumnCredit      = "credit"
+	// JournalColumnAmount is signed: debits positive, credits negative.
+	JournalColumnAmount = "amount"
+)
+
+var journalColumnHeadings = map[string]string{
+	JournalColumnDate:        "Date",
+	JournalColumnReference:   "Reference",
+	JournalColumnMonth:       "Month",
+	JournalColumnBatchID:     "Batch ID",
+	JournalColumnAccountCode: "Account Code",
+	JournalColumnAccountName: "Account Name",
+	JournalColumnDescription: "Description",
+	JournalColumnDebit:       "Debit",
+	JournalColumnCredit:      "Credit",
+	JournalColumnAmount:      "Amount",
+}
+
+// journalDelimiters are the field separators accounting imports accept.
+var journalDelimiters = map[string]rune{",": ',', ";": ';', "|": '|', "\t": '\t'}
+
+// GLAccount maps a posting to a ledger account. For Employer components
+// AccountCode is the payable credited and ExpenseAccountCode the expense
+// debited.
+type GLAccount struct {
+	ID                 int64     `db:"id" json:"id"`
+	Posting            string    `db:"posting" json:"posting"`
+	ComponentID        *int64    `db:"component_id" json:"component_id,omitempty"`
+	ComponentCode      string    `db:"component_code" json:"component_code"`
+	ComponentName      string    `db:"component_name" json:"component_name"`
+	ComponentType      string    `db:"component_type" json:"component_type"`
+	AccountCode        string    `db:"account_code" json:"account_code"`
+	AccountName        string    `db:"account_name" json:"account_name"`
+	ExpenseAccountCode string    `db:"expense_account_code" json:"expense_account_code"`
+	ExpenseAccountName string    `db:"expense_account_name" json:"expense_account_name"`
+	UpdatedBy          *int64    `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GLAccountInput sets ComponentID for Component postings only.
+type GLAccountInput struct {
+	Posting            string `json:"posting"`
+	ComponentID        int64  `json:"component_id"`
+	AccountCode        string `json:"account_code"`
+	AccountName        string `json:"account_name"`
+	ExpenseAccountCode string `json:"expense_account_code"`
+	ExpenseAccountName string `json:"expense_account_name"`
+}
+
+type JournalLayout struct {
+	Columns       []string   `json:"columns"`
+	Delimiter     string     `json:"delimiter"`
+	IncludeHeader bool       `json:"include_header"`
+	UpdatedBy     *int64     `json:"updated_by,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+type JournalLayoutInput struct {
+	Columns       []string `json:"columns"`
+	Delimiter     string   `json:"delimiter"`
+	IncludeHeader bool     `json:"include_header"`
+}
+
+// DefaultJournalLayout applies until a layout is saved.
+func DefaultJournalLayout() JournalLayout {
+	return JournalLayout{
+		Columns:       []string{JournalColumnDate, JournalColumnReference, JournalColumnAccountCode, JournalColumnAccountName, JournalColumnDescription, JournalColumnDebit, JournalColumnCredit},
+		Delimiter:     ",",
+		IncludeHeader: true,
+	}
+}
+
+type JournalLine struct {
+	AccountCode string       `json:"account_code"`
+	AccountName string       `json:"account_name"`
+	Description string       `json:"description"`
+	Debit       money.Amount `json:"debit"`
+	Credit      money.Amount `json:"credit"`
+}
+
+// Journal is the double-entry posting of a Locked batch, dated the last day
+// of the batch month.
+type Journal struct {
+	Batch       Batch         `json:"batch"`
+	Date        string        `json:"date"`
+	Reference   string        `json:"reference"`
+	Lines       []JournalLine `json:"lines"`
+	TotalDebit  money.Amount  `json:"total_debit"`
+	TotalCredit money.Amount  `json:"total_credit"`
+}
+
+// GLAccountError lists every posting without an account so the mapping can be
+// completed in one pass.
+type GLAccountError struct {
+	Missing []string
+}
+
+func (e *GLAccountError) Error() string {
+	return ErrGLAccountMissing.Error() + ": " + strings.Join(e.Missing, ", ")
+}
+
+func (e *GLAccountError) Unwrap() error {
+	return ErrGLAccountMissing
+}
+
+// BuildJournal debits base salary, earnings and employer contribution expense
+// and credits deductions, tax, employer contribution payables and net pay.
+// Unpaid leave was never earned, so it reduces the base salary debit.
+// Amounts come from the persisted entries and lines, so the journal balances
+// whenever the batch does.
+func BuildJournal(batch Batch, entries []Entry, components []Component, accounts []GLAccount) (Journal, error) {
+	end, err := monthEnd(batch.Month)
+	if err != nil {
+		return Journal{}, fmt.Errorf("resolve journal date for %s: %w", batch.Month, err)
+	}
+	journal := Journal{
+		Batch:     batch,
+		Date:      end.Format("2006-01-02"),
+		Reference: fmt.Sprintf("%s %s #%d", referencePrefix(batch.BatchType), batch.Month, batch.ID),
+		Lines:     make([]JournalLine, 0),
+	}
+
+	var baseSalary, netPay money.Amount
+	componentTotals := make(map[int64]money.Amount)
+	for _, entry := range entries {
+		baseSalary = baseSalary.Add(entry.BaseSalary)
+		netPay = netPay.Add(entry.NetPay)
+		for _, line := range entry.Lines {
+			if reducesEarnings(line) {
+				baseSalary = baseSalary.Sub(line.Amount)
+				continue
+			}
+			componentTotals[line.ComponentID] = componentTotals[line.ComponentID].Add(line.Amount)
+		}
+	}
+	used := make([]Component, 0, len(componentTotals))
+	for componentID, total := range componentTotals {
+		if total.IsZero() {
+			continue
+		}
+		component, ok := findComponentByID(components, componentID)
+		if !ok {
+			return Journal{}, ErrComponentNotFound
+		}
+		used = append(used, component)
+	}
+	sort.Slice(used, func(i, j int) bool {
+		if journalTypeOrder(used[i].Type) != journalTypeOrder(used[j].Type) {
+			return journalTypeOrder(used[i].Type) < journalTypeOrder(used[j].Type)
+		}
+		return used[i].Code < used[j].Code
+	})
+
+	missing := make([]string, 0)
+	lookup := func(posting string, component *Component) GLAccount {
+		for _, account := range accounts {
+			if account.Posting != posting {
+				continue
+			}
+			if component == nil || (account.ComponentID != nil && *account.ComponentID == component.ID) {
+				return account
+			}
+		}
+		if component == nil {
+			missing = append(missing, posting)
+		} else {
+			missing = append(missing, component.Code)
+		}
+		return GLAccount{}
+	}
+
+	debits := make([]JournalLine, 0)
+	credits := make([]JournalLine, 0)
+	if !baseSalary.IsZero() {
+		account := lookup(GLPostingBaseSalary, nil)
+		debits = append(debits, journalLine(account.AccountCode, account.AccountName, "Basic salary", baseSalary, true))
+	}
+	for _, component := range used {
+		total := componentTotals[component.ID]
+		account := lookup(GLPostingComponent, &component)
+		switch component.Type {
+		case ComponentTypeEarning:
+			debits = append(debits, journalLine(account.AccountCode, account.AccountName, component.Name, total, true))
+		case ComponentTypeEmployer:
+			if account.AccountCode != "" && account.ExpenseAccountCode == "" {
+				missing = append(missing, component.Code+" expense")
+			}
+			debits = append(debits, journalLine(account.ExpenseAccountCode, account.ExpenseAccountName, component.Name+" expense", total, true))
+			credits = append(credits, journalLine(account.AccountCode, account.AccountName, component.Name+" payable", total, false))
+		default:
+			credits = append(credits, journalLine(account.AccountCode, account.AccountName, component.Name, total, false))
+		}
+	}
+	if !netPay.IsZero() {
+		account := lookup(GLPostingNetPay, nil)
+		credits = append(credits, journalLine(account.AccountCode, account.AccountName, "Net pay", netPay, false))
+	}
+	if len(missing) > 0 {
+		return Journal{}, &GLAccountError{Missing: missing}
+	}
+
+	journal.Lines = append(debits, credits...)
+	for _, line := range journal.Lines {
+		journal.TotalDebit = journal.TotalDebit.Add(line.Debit)
+		journal.TotalCredit = journal.TotalCredit.Add(line.Credit)
+	}
+	if journal.TotalDebit != journal.TotalCredit {
+		return Journal{}, fmt.Errorf("payroll journal for batch %d does not balance: debits %s, credits %s", batch.ID, journal.TotalDebit, journal.TotalCredit)
+	}
+	return journal, nil
+}
+
+// journalLine posts amount to the debit or credit side; a negative amount
+// goes to the other side.
+func journalLine(accountCode, accountName, description string, amount money.Amount, debit bool) JournalLine {
+	line := JournalLine{AccountCode: accountCode, AccountName: accountName, Description: description}
+	if amount.IsNegative() {
+		amount = amount.Neg()
+		debit = !debit
+	}
+	if debit {
+		line.Debit = amount
+	} else {
+		line.Credit = amount
+	}
+	return line
+}
+
+// journalTypeOrder lists earnings before employer costs, deductions and tax.
+func journalTypeOrder(componentType string) int {
+	switch componentType {
+	case ComponentTypeEarning:
+		return 0
+	case ComponentTypeEmployer:
+		return 1
+	case ComponentTypeDeduction:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// FormatJournalCSV writes the journal in the given column layout, one row
+// per line.
+func FormatJournalCSV(journal Journal, layout JournalLayout) (string, error) {
+	delimiter, ok := journalDelimiters[layout.Delimiter]
+	if !ok {
+		return "", ErrInvalidInput
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Comma = delimiter
+	if layout.IncludeHeader {
+		header := make([]string, 0, len(layout.Columns))
+		for _, column := range layout.Columns {
+			header = append(header, journalColumnHeadings[column])
+		}
+		if writeErr := writer.Write(header); writeErr != nil {
+			return "", fmt.Errorf("write journal csv header: %w", writeErr)
+		}
+	}
+	for _, line := range journal.Lines {
+		record := make([]string, 0, len(layout.Columns))
+		for _, column := range layout.Columns {
+			record = append(record, journalColumnValue(journal, line, column))
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write journal csv row: %w", writeErr)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush journal csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func journalColumnValue(journal Journal, line JournalLine, column string) string {
+	switch column {
+	case JournalColumnDate:
+		return journal.Date
+	case JournalColumnReference:
+		return journal.Reference
+	case JournalColumnMonth:
+		return journal.Batch.Month
+	case JournalColumnBatchID:
+		return strconv.FormatInt(journal.Batch.ID, 10)
+	case JournalColumnAccountCode:
+		return line.AccountCode
+	case JournalColumnAccountName:
+		return line.AccountName
+	case JournalColumnDescription:
+		return line.Description
+	case JournalColumnDebit:
+		return line.Debit.String()
+	case JournalColumnCredit:
+		return line.Credit.String()
+	case JournalColumnAmount:
+		return line.Debit.Sub(line.Credit).String()
+	default:
+		return ""
+	}
+}
+
+func isValidJournalColumn(column string) bool {
+	_, ok := journalColumnHeadings[column]
+	return ok
+}
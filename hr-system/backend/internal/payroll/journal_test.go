@@ -0,0 +1,188 @@
+package payroll
+
+import (
+	"errors"
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func journalTestComponents() []Component {
+	return []Component{
+		{ID: 1, Code: "HOUSING", Name: "Housing Allowance", Type: ComponentTypeEarning},
+		{ID: 3, Code: "SACCO", Name: "SACCO Contribution", Type: ComponentTypeDeduction},
+		{ID: 4, Code: "PAYE", Name: "PAYE", Type: ComponentTypeTax},
+		{ID: 7, Code: "NSSF_EMPLOYEE", Name: "NSSF Employee", Type: ComponentTypeDeduction},
+		{ID: 8, Code: "NSSF_EMPLOYER", Name: "NSSF Employer", Type: ComponentTypeEmployer},
+	}
+}
+
+func journalTestAccounts() []GLAccount {
+	componentID := func(id int64) *int64 { return &id }
+	return []GLAccount{
+		{Posting: GLPostingBaseSalary, AccountCode: "6000", AccountName: "Salaries"},
+		{Posting: GLPostingNetPay, AccountCode: "2100", AccountName: "Net Pay Clearing"},
+		{Posting: GLPostingComponent, ComponentID: componentID(1), AccountCode: "6010"},
+		{Posting: GLPostingComponent, ComponentID: componentID(3), AccountCode: "2300"},
+		{Posting: GLPostingComponent, ComponentID: componentID(4), AccountCode: "2200"},
+		{Posting: GLPostingComponent, ComponentID: componentID(7), AccountCode: "2210"},
+		{Posting: GLPostingComponent, ComponentID: componentID(8), AccountCode: "2210", ExpenseAccountCode: "6100"},
+	}
+}
+
+func journalTestEntries() []Entry {
+	return []Entry{
+		{
+			BaseSalary: money.FromInt(1000),
+			NetPay:     money.FromInt(895),
+			Lines: []EntryLine{
+				{ComponentID: 1, Amount: money.FromInt(50)},
+				{ComponentID: 4, Amount: money.FromInt(100)},
+				{ComponentID: 7, Amount: money.FromInt(50)},
+				{ComponentID: 8, Amount: money.FromInt(100)},
+				{ComponentID: 3, Amount: money.FromInt(5)},
+			},
+		},
+		{
+			BaseSalary: money.FromInt(500),
+			NetPay:     money.FromInt(475),
+			Lines: []EntryLine{
+				{ComponentID: 7, Amount: money.FromInt(25)},
+				{ComponentID: 8, Amount: money.FromInt(50)},
+			},
+		},
+	}
+}
+
+func TestBuildJournalBalances(t *testing.T) {
+	batch := Batch{ID: 4, Month: "2026-02", Status: StatusLocked}
+	journal, err := BuildJournal(batch, journalTestEntries(), journalTestComponents(), journalTestAccounts())
+	if err != nil {
+		t.Fatalf("build journal: %v", err)
+	}
+	if journal.Date != "2026-02-28" || journal.Reference != "SALARY 2026-02 #4" {
+		t.Fatalf("unexpected journal header: %s %s", journal.Date, journal.Reference)
+	}
+
+	want := []JournalLine{
+		{AccountCode: "6000", AccountName: "Salaries", Description: "Basic salary", Debit: money.FromInt(1500)},
+		{AccountCode: "6010", Description: "Housing Allowance", Debit: money.FromInt(50)},
+		{AccountCode: "6100", Description: "NSSF Employer expense", Debit: money.FromInt(150)},
+		{AccountCode: "2210", Description: "NSSF Employer payable", Credit: money.FromInt(150)},
+		{AccountCode: "2210", Description: "NSSF Employee", Credit: money.FromInt(75)},
+		{AccountCode: "2300", Description: "SACCO Contribution", Credit: money.FromInt(5)},
+		{AccountCode: "2200", Description: "PAYE", Credit: money.FromInt(100)},
+		{AccountCode: "2100", AccountName: "Net Pay Clearing", Description: "Net pay", Credit: money.FromInt(1370)},
+	}
+	if len(journal.Lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), journal.Lines)
+	}
+	for i, line := range want {
+		if journal.Lines[i] != line {
+			t.Fatalf("line %d: expected %+v, got %+v", i, line, journal.Lines[i])
+		}
+	}
+	if journal.TotalDebit != money.FromInt(1700) || journal.TotalCredit != money.FromInt(1700) {
+		t.Fatalf("unexpected totals: %s / %s", journal.TotalDebit, journal.TotalCredit)
+	}
+}
+
+func TestBuildJournalListsEveryMissingAccount(t *testing.T) {
+	accounts := journalTestAccounts()
+	accounts[6].ExpenseAccountCode = ""
+	accounts = append(accounts[1:3], accounts[4:]...)
+
+	_, err := BuildJournal(Batch{ID: 4, Month: "2026-02"}, journalTestEntries(), journalTestComponents(), accounts)
+	var accountErr *GLAccountError
+	if !errors.As(err, &accountErr) || !errors.Is(err, ErrGLAccountMissing) {
+		t.Fatalf("expected a missing account error, got %v", err)
+	}
+	want := []string{GLPostingBaseSalary, "NSSF_EMPLOYER expense", "SACCO"}
+	if len(accountErr.Missing) != len(want) {
+		t.Fatalf("expected %v missing, got %v", want, accountErr.Missing)
+	}
+	for i := range want {
+		if accountErr.Missing[i] != want[i] {
+			t.Fatalf("expected %v missing, got %v", want, accountErr.Missing)
+		}
+	}
+}
+
+func TestBuildJournalPostsNegativeAmountsToTheOtherSide(t *testing.T) {
+	// A reversal batch carries negated amounts.
+	entries := []Entry{{
+		BaseSalary: money.FromInt(-1000),
+		NetPay:     money.FromInt(-900),
+		Lines:      []EntryLine{{ComponentID: 4, Amount: money.FromInt(-100)}},
+	}}
+	journal, err := BuildJournal(Batch{ID: 5, Month: "2026-02"}, entries, journalTestComponents(), journalTestAccounts())
+	if err != nil {
+		t.Fatalf("build journal: %v", err)
+	}
+	if journal.Lines[0].Credit != money.FromInt(1000) || !journal.Lines[0].Debit.IsZero() {
+		t.Fatalf("expected the salary reversal on the credit side, got %+v", journal.Lines[0])
+	}
+	if journal.Lines[2].AccountCode != "2100" || journal.Lines[2].Debit != money.FromInt(900) {
+		t.Fatalf("expected the net pay reversal on the debit side, got %+v", journal.Lines[2])
+	}
+}
+
+func TestBuildJournalTakesUnpaidLeaveOffSalary(t *testing.T) {
+	components := append(journalTestComponents(), Component{ID: 9, Code: ComponentCodeUnpaidLeave, Name: "Unpaid Leave", Type: ComponentTypeEarning, IsSystem: true})
+	entries := []Entry{{
+		BaseSalary: money.FromInt(1000),
+		NetPay:     money.FromInt(850),
+		Lines: []EntryLine{
+			{ComponentID: 9, ComponentCode: ComponentCodeUnpaidLeave, ComponentType: ComponentTypeEarning, Amount: money.FromInt(100)},
+			{ComponentID: 4, ComponentCode: "PAYE", ComponentType: ComponentTypeTax, Amount: money.FromInt(50)},
+		},
+	}}
+	// No account is mapped for unpaid leave: it reduces salary expense.
+	journal, err := BuildJournal(Batch{ID: 6, Month: "2026-02"}, entries, components, journalTestAccounts())
+	if err != nil {
+		t.Fatalf("build journal: %v", err)
+	}
+	if len(journal.Lines) != 3 || journal.Lines[0].AccountCode != "6000" || journal.Lines[0].Debit != money.FromInt(900) {
+		t.Fatalf("expected salary debit net of unpaid leave, got %+v", journal.Lines)
+	}
+	if journal.TotalDebit != money.FromInt(900) || journal.TotalCredit != money.FromInt(900) {
+		t.Fatalf("unexpected totals: %s / %s", journal.TotalDebit, journal.TotalCredit)
+	}
+}
+
+func TestFormatJournalCSVFollowsLayout(t *testing.T) {
+	journal := Journal{
+		Batch:     Batch{ID: 4, Month: "2026-02"},
+		Date:      "2026-02-28",
+		Reference: "SALARY 2026-02 #4",
+		Lines: []JournalLine{
+			{AccountCode: "6000", Description: "Basic salary", Debit: money.FromInt(1000)},
+			{AccountCode: "2100", Description: "Net pay", Credit: money.FromInt(1000)},
+		},
+	}
+
+	text, err := FormatJournalCSV(journal, DefaultJournalLayout())
+	if err != nil {
+		t.Fatalf("format journal: %v", err)
+	}
+	want := "Date,Reference,Account Code,Account Name,Description,Debit,Credit\n" +
+		"2026-02-28,SALARY 2026-02 #4,6000,,Basic salary,1000.00,0.00\n" +
+		"2026-02-28,SALARY 2026-02 #4,2100,,Net pay,0.00,1000.00\n"
+	if text != want {
+		t.Fatalf("unexpected default layout:\n%s", text)
+	}
+
+	layout := JournalLayout{Columns: []string{JournalColumnBatchID, JournalColumnAccountCode, JournalColumnAmount}, Delimiter: "|"}
+	text, err = FormatJournalCSV(journal, layout)
+	if err != nil {
+		t.Fatalf("format journal: %v", err)
+	}
+	if text != "4|6000|1000.00\n4|2100|-1000.00\n" {
+		t.Fatalf("unexpected custom layout:\n%s", text)
+	}
+
+	layout.Delimiter = ":"
+	if _, err := FormatJournalCSV(journal, layout); err != ErrInvalidInput {
+		t.Fatalf("expected an unsupported delimiter to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package payroll
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// ComponentCodeLoanInstallment is the system deduction line carrying the
+// total of an entry's LoanDeductions.
+const ComponentCodeLoanInstallment = "LOAN_INSTALLMENT"
+
+const (
+	LoanTypeAdvance = "Advance"
+	LoanTypeLoan    = "Loan"
+)
+
+const (
+	LoanStatusActive    = "Active"
+	LoanStatusSettled   = "Settled"
+	LoanStatusCancelled = "Cancelled"
+)
+
+// maxLoanInstallments caps a repayment schedule at ten years.
+const maxLoanInstallments = 120
+
+// Loan is a staff loan or salary advance. Its installments are deducted in
+// regular batches from StartMonth; OutstandingBalance falls only when a batch
+// carrying an installment is locked.
+type Loan struct {
+	ID                  int64        `db:"id" json:"id"`
+	EmployeeID          int64        `db:"employee_id" json:"employee_id"`
+	EmployeeName        string       `db:"employee_name" json:"employee_name"`
+	LoanType            string       `db:"loan_type" json:"loan_type"`
+	Description         string       `db:"description" json:"description"`
+	Principal           money.Amount `db:"principal" json:"principal"`
+	InterestRatePercent float64      `db:"interest_rate_percent" json:"interest_rate_percent"`
+	TotalRepayable      money.Amount `db:"total_repayable" json:"total_repayable"`
+	Installments        int          `db:"installments" json:"installments"`
+	InstallmentAmount   money.Amount `db:"installment_amount" json:"installment_amount"`
+	StartMonth          string       `db:"start_month" json:"start_month"`
+	OutstandingBalance  money.Amount `db:"outstanding_balance" json:"outstanding_balance"`
+	Status              string       `db:"status" json:"status"`
+	CancelReason        string       `db:"cancel_reason" json:"cancel_reason"`
+	CreatedBy           int64        `db:"created_by" json:"created_by"`
+	CreatedAt           time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// Repaid is the part of the loan already recovered through locked batches.
+func (l Loan) Repaid() money.Amount {
+	return l.TotalRepayable.Sub(l.OutstandingBalance)
+}
+
+type LoanInput struct {
+	EmployeeID          int64        `json:"employee_id"`
+	LoanType            string       `json:"loan_type"`
+	Description         string       `json:"description"`
+	Principal           money.Amount `json:"principal"`
+	InterestRatePercent float64      `json:"interest_rate_percent"`
+	Installments        int          `json:"installments"`
+	StartMonth          string       `json:"start_month"`
+}
+
+type CancelLoanInput struct {
+	Reason string `json:"reason"`
+}
+
+type LoanFilter struct {
+	EmployeeID int64  `json:"employee_id"`
+	Status     string `json:"status"`
+}
+
+// LoanDeduction is the part of an entry's LOAN_INSTALLMENT line that repays
+// one loan.
+type LoanDeduction struct {
+	EntryID     int64        `db:"entry_id" json:"entry_id"`
+	LoanID      int64        `db:"loan_id" json:"loan_id"`
+	LoanType    string       `db:"loan_type" json:"loan_type"`
+	Description string       `db:"description" json:"description"`
+	Amount      money.Amount `db:"amount" json:"amount"`
+}
+
+// LoanRepayment is an installment taken by a locked batch.
+type LoanRepayment struct {
+	ID           int64        `db:"id" json:"id"`
+	LoanID       int64        `db:"loan_id" json:"loan_id"`
+	BatchID      int64        `db:"batch_id" json:"batch_id"`
+	Month        string       `db:"month" json:"month"`
+	EntryID      int64        `db:"entry_id" json:"entry_id"`
+	Amount       money.Amount `db:"amount" json:"amount"`
+	BalanceAfter money.Amount `db:"balance_after" json:"balance_after"`
+	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
+	ReversedAt   *time.Time   `db:"reversed_at" json:"reversed_at,omitempty"`
+}
+
+type LoanDetail struct {
+	Loan       Loan            `json:"loan"`
+	Repayments []LoanRepayment `json:"repayments"`
+}
+
+type LoanBalanceRow struct {
+	EmployeeID     int64        `json:"employee_id"`
+	EmployeeName   string       `json:"employee_name"`
+	ActiveLoans    int          `json:"active_loans"`
+	TotalRepayable money.Amount `json:"total_repayable"`
+	Repaid         money.Amount `json:"repaid"`
+	Outstanding    money.Amount `json:"outstanding"`
+}
+
+type LoanBalanceReport struct {
+	Rows           []LoanBalanceRow `json:"rows"`
+	TotalRepayable money.Amount     `json:"total_repayable"`
+	Repaid         money.Amount     `json:"repaid"`
+	Outstanding    money.Amount     `json:"outstanding"`
+}
+
+// LoanTerms returns the amount to be repaid, principal plus flat interest,
+// and the monthly installment. The installment is rounded up to the cent so
+// the loan is cleared within the agreed number of installments; the last one
+// takes whatever remains.
+func LoanTerms(principal money.Amount, interestRatePercent float64, installments int) (money.Amount, money.Amount, error) {
+	interest, err := principal.MulRate(interestRatePercent / 100)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, fmt.Errorf("loan interest: %w", err)
+	}
+	total := principal.Add(interest)
+	count := int64(installments)
+	return total, money.FromCents((total.Cents() + count - 1) / count), nil
+}
+
+// DueInstallment is what the loan takes from a batch: one installment, or
+// less once the balance not already claimed by other unlocked batches
+// (pending) runs short.
+func DueInstallment(loan Loan, pending money.Amount) money.Amount {
+	remaining := loan.OutstandingBalance.Sub(pending)
+	return money.Max(money.Min(loan.InstallmentAmount, remaining), money.Amount{})
+}
+
+// TotalLoanDeductions sums an entry's loan deductions into the amount of its
+// LOAN_INSTALLMENT line.
+func TotalLoanDeductions(deductions []LoanDeduction) money.Amount {
+	var total money.Amount
+	for _, deduction := range deductions {
+		total = total.Add(deduction.Amount)
+	}
+	return total
+}
+
+// BuildLoanBalanceReport totals loans per employee, leaving out employees
+// whose loans were all cancelled.
+func BuildLoanBalanceReport(loans []Loan) LoanBalanceReport {
+	report := LoanBalanceReport{Rows: make([]LoanBalanceRow, 0)}
+	rows := make(map[int64]*LoanBalanceRow)
+	for _, loan := range loans {
+		if loan.Status == LoanStatusCancelled {
+			continue
+		}
+		row, ok := rows[loan.EmployeeID]
+		if !ok {
+			row = &LoanBalanceRow{EmployeeID: loan.EmployeeID, EmployeeName: loan.EmployeeName}
+			rows[loan.EmployeeID] = row
+		}
+		if loan.Status == LoanStatusActive {
+			row.ActiveLoans++
+		}
+		row.TotalRepayable = row.TotalRepayable.Add(loan.TotalRepayable)
+		row.Repaid = row.Repaid.Add(loan.Repaid())
+		row.Outstanding = row.Outstanding.Add(loan.OutstandingBalance)
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+		report.TotalRepayable = report.TotalRepayable.Add(row.TotalRepayable)
+		report.Repaid = report.Repaid.Add(row.Repaid)
+		report.Outstanding = report.Outstanding.Add(row.Outstanding)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].EmployeeName != report.Rows[j].EmployeeName {
+			return report.Rows[i].EmployeeName < report.Rows[j].EmployeeName
+		}
+		return report.Rows[i].EmployeeID < report.Rows[j].EmployeeID
+	})
+	return report
+}
+
+func isValidLoanType(loanType string) bool {
+	return loanType == LoanTypeAdvance || loanType == LoanTypeLoan
+}
+
+func isValidLoanStatus(status string) bool {
+	return status == LoanStatusActive || status == LoanStatusSettled || status == LoanStatusCancelled
+}
@@ -0,0 +1,96 @@
+package payroll
+
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestLoanTerms(t *testing.T) {
+	// 1,000 at 10% flat is 1,100 over 3 installments of 366.67; the last
+	// installment takes the 366.66 left.
+	total, installment, err := LoanTerms(money.FromInt(1000), 10, 3)
+	if err != nil {
+		t.Fatalf("loan terms: %v", err)
+	}
+	if total != money.FromInt(1100) || installment != money.FromCents(36667) {
+		t.Fatalf("expected 1100 in installments of 366.67, got %s and %s", total, installment)
+	}
+
+	total, installment, err = LoanTerms(money.FromInt(600), 0, 6)
+	if err != nil {
+		t.Fatalf("loan terms: %v", err)
+	}
+	if total != money.FromInt(600) || installment != money.FromInt(100) {
+		t.Fatalf("expected an interest-free advance of 6 x 100, got %s and %s", total, installment)
+	}
+}
+
+func TestDueInstallment(t *testing.T) {
+	loan := Loan{InstallmentAmount: money.FromCents(36667), OutstandingBalance: money.FromCents(36666)}
+	if got := DueInstallment(loan, money.Amount{}); got != money.FromCents(36666) {
+		t.Fatalf("expected the last installment to clear the balance, got %s", got)
+	}
+
+	loan.OutstandingBalance = money.FromInt(1100)
+	if got := DueInstallment(loan, money.FromCents(36667)); got != money.FromCents(36667) {
+		t.Fatalf("expected a full installment, got %s", got)
+	}
+	// Another unlocked batch already claims the whole balance.
+	if got := DueInstallment(loan, money.FromInt(1100)); !got.IsZero() {
+		t.Fatalf("expected nothing due, got %s", got)
+	}
+}
+
+func TestCalculatePostsLoanInstallmentAfterTax(t *testing.T) {
+	components := []Component{
+		{ID: 4, Code: ComponentCodePAYE, Type: ComponentTypeTax, IsSystem: true, IsActive: true},
+		{ID: 12, Code: ComponentCodeLoanInstallment, Type: ComponentTypeDeduction, IsSystem: true, IsActive: true},
+	}
+	table := TaxTable{Brackets: []TaxBracket{
+		{LowerBound: money.FromInt(0), UpperBound: amountPtr(1000), Rate: 0},
+		{LowerBound: money.FromInt(1000), Rate: 0.1},
+	}}
+
+	result, err := Calculate(CalculationInput{
+		BaseSalary:       money.FromInt(2000),
+		Components:       components,
+		TaxTable:         &table,
+		LoanInstallments: money.FromInt(300),
+	})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+	amounts := result.Amounts
+	if amounts.TaxablePay != money.FromInt(2000) || amounts.TaxTotal != money.FromInt(100) {
+		t.Fatalf("loan installments must not reduce taxable pay, got %+v", amounts)
+	}
+	if amounts.DeductionsTotal != money.FromInt(300) || amounts.NetPay != money.FromInt(1600) {
+		t.Fatalf("expected deductions 300 and net 1600, got %v and %v", amounts.DeductionsTotal, amounts.NetPay)
+	}
+
+	if _, err := Calculate(CalculationInput{BaseSalary: money.FromInt(2000), Components: components[:1], TaxTable: &table, LoanInstallments: money.FromInt(300)}); err != ErrComponentNotFound {
+		t.Fatalf("expected missing component error, got %v", err)
+	}
+}
+
+func TestBuildLoanBalanceReport(t *testing.T) {
+	loans := []Loan{
+		{ID: 1, EmployeeID: 22, EmployeeName: "Doe, John", Status: LoanStatusActive, TotalRepayable: money.FromInt(1100), OutstandingBalance: money.FromInt(700)},
+		{ID: 2, EmployeeID: 21, EmployeeName: "Doe, Jane", Status: LoanStatusSettled, TotalRepayable: money.FromInt(500), OutstandingBalance: money.Amount{}},
+		{ID: 3, EmployeeID: 21, EmployeeName: "Doe, Jane", Status: LoanStatusActive, TotalRepayable: money.FromInt(300), OutstandingBalance: money.FromInt(300)},
+		{ID: 4, EmployeeID: 23, EmployeeName: "Okello, Sam", Status: LoanStatusCancelled, CancelReason: "Issued in error", TotalRepayable: money.FromInt(900), OutstandingBalance: money.FromInt(900)},
+	}
+
+	report := BuildLoanBalanceReport(loans)
+	if len(report.Rows) != 2 {
+		t.Fatalf("expected cancelled loans to be left out, got %+v", report.Rows)
+	}
+	jane := report.Rows[0]
+	if jane.EmployeeID != 21 || jane.ActiveLoans != 1 || jane.TotalRepayable != money.FromInt(800) || jane.Repaid != money.FromInt(500) || jane.Outstanding != money.FromInt(300) {
+		t.Fatalf("unexpected row for Jane: %+v", jane)
+	}
+	if report.TotalRepayable != money.FromInt(1900) || report.Repaid != money.FromInt(900) || report.Outstanding != money.FromInt(1000) {
+		t.Fatalf("unexpected report totals: %+v", report)
+	}
+}
@@ -1,11 +1,24 @@
 package payroll
 
-import "time"
+import (
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+const (
+	StatusDraft      = "Draft"
+	StatusInApproval = "In Approval"
+	StatusApproved   = "Approved"
+	StatusLocked     = "Locked"
+	StatusReversed   = "Reversed"
+)
 
 const (
-	StatusDraft    = "Draft"
-	StatusApproved = "Approved"
-	StatusLocked   = "Locked"
+	ComponentTypeEarning   = "Earning"
+	ComponentTypeDeduction = "Deduction"
+	ComponentTypeTax       = "Tax"
+	ComponentTypeEmployer  = "Employer"
 )
 
 type Actor struct {
@@ -22,41 +35,188 @@ type Batch struct {
 	ApprovedBy *int64     `db:"approved_by" json:"approved_by,omitempty"`
 	ApprovedAt *time.Time `db:"approved_at" json:"approved_at,omitempty"`
 	LockedAt   *time.Time `db:"locked_at" json:"locked_at,omitempty"`
+	LockDigest string     `db:"lock_digest" json:"lock_digest,omitempty"`
+
+	// CorrectsBatchID is set on the batch created by reversing a locked batch.
+	CorrectsBatchID *int64 `db:"corrects_batch_id" json:"corrects_batch_id,omitempty"`
+
+	BatchType   string `db:"batch_type" json:"batch_type"`
+	Description string `db:"description" json:"description"`
+}
+
+// BatchTransition records a reopen or reversal of a batch with the
+// justification given for it.
+type BatchTransition struct {
+	ID             int64     `db:"id" json:"id"`
+	BatchID        int64     `db:"batch_id" json:"batch_id"`
+	FromStatus     string    `db:"from_status" json:"from_status"`
+	ToStatus       string    `db:"to_status" json:"to_status"`
+	Justification  string    `db:"justification" json:"justification"`
+	RelatedBatchID *int64    `db:"related_batch_id" json:"related_batch_id,omitempty"`
+	ActorUserID    int64     `db:"actor_user_id" json:"actor_user_id"`
+	ActorName      string    `db:"actor_name" json:"actor_name"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
 }
 
 type Entry struct {
-	ID              int64     `db:"id" json:"id"`
-	BatchID         int64     `db:"batch_id" json:"batch_id"`
-	EmployeeID      int64     `db:"employee_id" json:"employee_id"`
-	EmployeeName    string    `db:"employee_name" json:"employee_name"`
-	BaseSalary      float64   `db:"base_salary" json:"base_salary"`
-	AllowancesTotal float64   `db:"allowances_total" json:"allowances_total"`
-	DeductionsTotal float64   `db:"deductions_total" json:"deductions_total"`
-	TaxTotal        float64   `db:"tax_total" json:"tax_total"`
-	GrossPay        float64   `db:"gross_pay" json:"gross_pay"`
-	NetPay          float64   `db:"net_pay" json:"net_pay"`
-	CreatedAt       time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+	ID                         int64        `db:"id" json:"id"`
+	BatchID                    int64        `db:"batch_id" json:"batch_id"`
+	EmployeeID                 int64        `db:"employee_id" json:"employee_id"`
+	EmployeeName               string       `db:"employee_name" json:"employee_name"`
+	TaxResidency               string       `db:"tax_residency" json:"tax_residency"`
+	BaseSalary                 money.Amount `db:"base_salary" json:"base_salary"`
+	MonthlyBaseSalary          money.Amount `db:"monthly_base_salary" json:"monthly_base_salary"`
+	ProrationBasis             string       `db:"proration_basis" json:"proration_basis"`
+	ProrationDaysPaid          int          `db:"proration_days_paid" json:"proration_days_paid"`
+	ProrationPeriodDays        int          `db:"proration_period_days" json:"proration_period_days"`
+	ProrationFactor            float64      `db:"proration_factor" json:"proration_factor"`
+	AllowancesTotal            money.Amount `db:"allowances_total" json:"allowances_total"`
+	DeductionsTotal            money.Amount `db:"deductions_total" json:"deductions_total"`
+	TaxTotal                   money.Amount `db:"tax_total" json:"tax_total"`
+	GrossPay                   money.Amount `db:"gross_pay" json:"gross_pay"`
+	NetPay                     money.Amount `db:"net_pay" json:"net_pay"`
+	TaxablePay                 money.Amount `db:"taxable_pay" json:"taxable_pay"`
+	PensionablePay             money.Amount `db:"pensionable_pay" json:"pensionable_pay"`
+	EmployerContributionsTotal money.Amount `db:"employer_contributions_total" json:"employer_contributions_total"`
+	TaxTableID                 *int64       `db:"tax_table_id" json:"tax_table_id,omitempty"`
+	TaxOverride                bool         `db:"tax_override" json:"tax_override"`
+	TaxOverrideReason          string       `db:"tax_override_reason" json:"tax_override_reason"`
+	TaxOverrideBy              *int64       `db:"tax_override_by" json:"tax_override_by,omitempty"`
+	CreatedAt                  time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt                  time.Time    `db:"updated_at" json:"updated_at"`
+
+	// The monthly salary as contracted, and the rate it was converted to
+	// MonthlyBaseSalary at; the rate is 1 for salaries in the payment currency.
+	ContractCurrency      string       `db:"contract_currency" json:"contract_currency"`
+	ContractMonthlySalary money.Amount `db:"contract_monthly_salary" json:"contract_monthly_salary"`
+	ExchangeRate          float64      `db:"exchange_rate" json:"exchange_rate"`
+
+	Lines           []EntryLine      `db:"-" json:"lines"`
+	LeaveDeductions []LeaveDeduction `db:"-" json:"leave_deductions"`
+	LoanDeductions  []LoanDeduction  `db:"-" json:"loan_deductions"`
+}
+
+type Component struct {
+	ID            int64     `db:"id" json:"id"`
+	Code          string    `db:"code" json:"code"`
+	Name          string    `db:"name" json:"name"`
+	Type          string    `db:"component_type" json:"component_type"`
+	IsTaxable     bool      `db:"is_taxable" json:"is_taxable"`
+	IsPensionable bool      `db:"is_pensionable" json:"is_pensionable"`
+	IsSystem      bool      `db:"is_system" json:"is_system"`
+	IsActive      bool      `db:"is_active" json:"is_active"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type EntryLine struct {
+	ID            int64        `db:"id" json:"id"`
+	EntryID       int64        `db:"entry_id" json:"entry_id"`
+	ComponentID   int64        `db:"component_id" json:"component_id"`
+	ComponentCode string       `db:"component_code" json:"component_code"`
+	ComponentName string       `db:"component_name" json:"component_name"`
+	ComponentType string       `db:"component_type" json:"component_type"`
+	IsTaxable     bool         `db:"is_taxable" json:"is_taxable"`
+	IsPensionable bool         `db:"is_pensionable" json:"is_pensionable"`
+	IsSystem      bool         `db:"is_system" json:"is_system"`
+	Amount        money.Amount `db:"amount" json:"amount"`
+}
+
+type Amounts struct {
+	BaseSalary                 money.Amount `json:"base_salary"`
+	TaxablePay                 money.Amount `json:"taxable_pay"`
+	PensionablePay             money.Amount `json:"pensionable_pay"`
+	AllowancesTotal            money.Amount `json:"allowances_total"`
+	DeductionsTotal            money.Amount `json:"deductions_total"`
+	TaxTotal                   money.Amount `json:"tax_total"`
+	EmployerContributionsTotal money.Amount `json:"employer_contributions_total"`
+	GrossPay                   money.Amount `json:"gross_pay"`
+	NetPay                     money.Amount `json:"net_pay"`
+}
+
+// EmployeeEntry is an entry as seen by the employee it pays, with the month
+// and status of its batch.
+type EmployeeEntry struct {
+	Entry
+	Month       string `db:"month" json:"month"`
+	BatchStatus string `db:"batch_status" json:"batch_status"`
+	BatchType   string `db:"batch_type" json:"batch_type"`
+}
+
+type Settings struct {
+	ProrationBasis           string    `db:"proration_basis" json:"proration_basis"`
+	VarianceThresholdPercent float64   `db:"variance_threshold_percent" json:"variance_threshold_percent"`
+	SegregationPolicy        string    `db:"segregation_policy" json:"segregation_policy"`
+	UpdatedBy                *int64    `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt                time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SettingsInput replaces the proration basis; optional settings left nil or
+// empty keep their current value.
+type SettingsInput struct {
+	ProrationBasis           string   `json:"proration_basis"`
+	VarianceThresholdPercent *float64 `json:"variance_threshold_percent"`
+	SegregationPolicy        string   `json:"segregation_policy"`
 }
 
 type BatchFilter struct {
-	Month  string `json:"month"`
-	Status string `json:"status"`
+	Month     string `json:"month"`
+	Status    string `json:"status"`
+	BatchType string `json:"batch_type"`
 }
 
 type BatchDetail struct {
-	Batch   Batch   `json:"batch"`
-	Entries []Entry `json:"entries"`
+	Batch       Batch             `json:"batch"`
+	Entries     []Entry           `json:"entries"`
+	Totals      Amounts           `json:"totals"`
+	Transitions []BatchTransition `json:"transitions"`
+	// Stages is the approval chain; NextStage is the stage awaiting sign-off
+	// while the batch is Draft or In Approval.
+	Stages    []ApprovalStage `json:"approval_stages"`
+	SignOffs  []BatchSignOff  `json:"sign_offs"`
+	NextStage *ApprovalStage  `json:"next_stage,omitempty"`
 }
 
+// CreateBatchInput defaults to a Regular batch. Off-cycle batches must name
+// the employees they pay.
 type CreateBatchInput struct {
-	Month string `json:"month"`
+	Month       string  `json:"month"`
+	BatchType   string  `json:"batch_type"`
+	Description string  `json:"description"`
+	EmployeeIDs []int64 `json:"employee_ids"`
+}
+
+type BatchTransitionInput struct {
+	Justification string `json:"justification"`
+}
+
+type EntryLineInput struct {
+	ComponentID int64        `json:"component_id"`
+	Amount      money.Amount `json:"amount"`
 }
 
 type UpdateEntryAmountsInput struct {
-	AllowancesTotal float64 `json:"allowances_total"`
-	DeductionsTotal float64 `json:"deductions_total"`
-	TaxTotal        float64 `json:"tax_total"`
+	Lines             []EntryLineInput `json:"lines"`
+	TaxOverride       *money.Amount    `json:"tax_override"`
+	TaxOverrideReason string           `json:"tax_override_reason"`
+}
+
+type EntryUpdate struct {
+	Lines             []EntryLine
+	Amounts           Amounts
+	TaxTableID        *int64
+	TaxOverride       bool
+	TaxOverrideReason string
+	UpdatedBy         int64
+}
+
+type ComponentInput struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Type          string `json:"component_type"`
+	IsTaxable     bool   `json:"is_taxable"`
+	IsPensionable bool   `json:"is_pensionable"`
+	IsActive      bool   `json:"is_active"`
 }
 
 type BatchListResult struct {
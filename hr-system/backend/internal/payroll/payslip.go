@@ -0,0 +1,329 @@
+package payroll
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hr-system/backend/internal/money"
+	"hr-system/backend/internal/pdf"
+)
+
+const (
+	PayslipFormatPDF = "pdf"
+	PayslipFormatZip = "zip"
+)
+
+// FiscalYearStartMonth is the first month of the Ugandan fiscal year, used to
+// reset year-to-date figures.
+const FiscalYearStartMonth = time.July
+
+// Employer is printed in the payslip header and is the debtor on bank
+// transfer files.
+type Employer struct {
+	Name        string
+	Address     string
+	TIN         string
+	BankCode    string
+	BankAccount string
+}
+
+// EmployeeDetails is the slice of the employee record printed on a payslip.
+type EmployeeDetails struct {
+	ID           int64  `db:"id"`
+	Name         string `db:"employee_name"`
+	Position     string `db:"position"`
+	Department   string `db:"department_name"`
+	NationalID   string `db:"national_id"`
+	HireDate     string `db:"hire_date"`
+	TaxResidency string `db:"tax_residency"`
+}
+
+type PayslipLine struct {
+	Label  string
+	Amount money.Amount
+}
+
+type Payslip struct {
+	Employer      Employer
+	Employee      EmployeeDetails
+	MemberNumbers []PayslipMemberNumber
+	Month         string
+	Period        string
+	EntryID       int64
+	Earnings      []PayslipLine
+	Deductions    []PayslipLine
+	EmployerCosts []PayslipLine
+	Amounts       Amounts
+	YearToDate    Amounts
+	GeneratedAt   time.Time
+
+	// ContractSalary describes a salary contracted in another currency, e.g.
+	// "USD 1,000.00 at 3700.000000"; it is empty for the payment currency.
+	ContractSalary string
+}
+
+type PayslipMemberNumber struct {
+	SchemeCode   string
+	MemberNumber string
+}
+
+// File is a rendered document handed back to the desktop shell.
+type File struct {
+	Name        string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// FiscalYearStart returns the first month (YYYY-MM) of the fiscal year that
+// contains month.
+func FiscalYearStart(month string) (string, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return "", err
+	}
+	year := start.Year()
+	if start.Month() < FiscalYearStartMonth {
+		year--
+	}
+	return time.Date(year, FiscalYearStartMonth, 1, 0, 0, 0, 0, time.UTC).Format("2006-01"), nil
+}
+
+// BuildPayslip lays an entry's lines out into payslip sections. Base salary is
+// shown as the first earning, except on off-cycle batches which pay none;
+// unpaid leave is listed as a negative earning and tax with the deductions.
+func BuildPayslip(employer Employer, employee EmployeeDetails, batch Batch, entry Entry, yearToDate Amounts, schemes []ContributionScheme, members []ContributionMember) Payslip {
+	slip := Payslip{
+		Employer:    employer,
+		Employee:    employee,
+		Month:       batch.Month,
+		Period:      batch.PeriodLabel(),
+		EntryID:     entry.ID,
+		Earnings:    make([]PayslipLine, 0, len(entry.Lines)+1),
+		Amounts:     amountsOf(entry),
+		YearToDate:  yearToDate,
+		GeneratedAt: time.Now().UTC(),
+	}
+	if entry.ContractCurrency != "" && entry.ContractCurrency != PaymentCurrency {
+		slip.ContractSalary = fmt.Sprintf("%s %s at %s", entry.ContractCurrency, entry.ContractMonthlySalary.FormatThousands(), strconv.FormatFloat(entry.ExchangeRate, 'f', 6, 64))
+	}
+	if !batch.IsOffCycle() || !entry.BaseSalary.IsZero() {
+		slip.Earnings = append(slip.Earnings, PayslipLine{Label: basicSalaryLabel(entry), Amount: entry.BaseSalary})
+	}
+	for _, line := range entry.Lines {
+		item := PayslipLine{Label: line.ComponentName, Amount: line.Amount}
+		if reducesEarnings(line) {
+			item.Amount = line.Amount.Neg()
+		}
+		switch line.ComponentType {
+		case ComponentTypeEarning:
+			slip.Earnings = append(slip.Earnings, item)
+		case ComponentTypeDeduction, ComponentTypeTax:
+			slip.Deductions = append(slip.Deductions, item)
+		case ComponentTypeEmployer:
+			slip.EmployerCosts = append(slip.EmployerCosts, item)
+		}
+	}
+
+	codes := make(map[int64]string, len(schemes))
+	for _, scheme := range schemes {
+		codes[scheme.ID] = scheme.Code
+	}
+	for _, member := range members {
+		if member.EmployeeID == entry.EmployeeID && member.MemberNumber != "" {
+			slip.MemberNumbers = append(slip.MemberNumbers, PayslipMemberNumber{SchemeCode: codes[member.SchemeID], MemberNumber: member.MemberNumber})
+		}
+	}
+	return slip
+}
+
+// RenderPayslipsPDF renders one A4 page per payslip into a single document.
+func RenderPayslipsPDF(slips []Payslip) []byte {
+	doc := pdf.New()
+	for _, slip := range slips {
+		renderPayslipPage(doc.AddPage(), slip)
+	}
+	return doc.Bytes()
+}
+
+// ZipPayslips packs one PDF per payslip into a zip archive.
+func ZipPayslips(slips []Payslip) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, slip := range slips {
+		writer, err := archive.Create(PayslipFileName(slip))
+		if err != nil {
+			return nil, fmt.Errorf("add payslip to archive: %w", err)
+		}
+		if _, err := writer.Write(RenderPayslipsPDF([]Payslip{slip})); err != nil {
+			return nil, fmt.Errorf("write payslip to archive: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("close payslip archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func PayslipFileName(slip Payslip) string {
+	return fmt.Sprintf("payslip-%s-%s-%d.pdf", slip.Month, fileNamePart(slip.Employee.Name), slip.EntryID)
+}
+
+const (
+	payslipLeft    = 40.0
+	payslipRight   = pdf.A4Width - 40
+	payslipMiddle  = pdf.A4Width / 2
+	payslipLeading = 15.0
+)
+
+func renderPayslipPage(page *pdf.Page, slip Payslip) {
+	y := pdf.A4Height - 60
+	page.Text(payslipLeft, y, pdf.Bold, 16, slip.Employer.Name)
+	page.TextRight(payslipRight, y, pdf.Bold, 14, "PAYSLIP")
+	y -= 16
+	if slip.Employer.Address != "" {
+		page.Text(payslipLeft, y, pdf.Regular, 9, slip.Employer.Address)
+	}
+	page.TextRight(payslipRight, y, pdf.Regular, 10, "Pay period: "+slip.Period)
+	y -= 12
+	if slip.Employer.TIN != "" {
+		page.Text(payslipLeft, y, pdf.Regular, 9, "TIN: "+slip.Employer.TIN)
+	}
+	y -= 12
+	page.Line(payslipLeft, y, payslipRight, y, 1)
+
+	y -= 20
+	details := [][2]string{
+		{"Employee", slip.Employee.Name},
+		{"Employee No.", fmt.Sprintf("%d", slip.Employee.ID)},
+		{"Position", slip.Employee.Position},
+		{"Department", slip.Employee.Department},
+		{"National ID", slip.Employee.NationalID},
+		{"Hire Date", slip.Employee.HireDate},
+		{"Tax Residency", slip.Employee.TaxResidency},
+	}
+	for _, member := range slip.MemberNumbers {
+		details = append(details, [2]string{member.SchemeCode + " No.", member.MemberNumber})
+	}
+	if slip.ContractSalary != "" {
+		details = append(details, [2]string{"Contract Salary", slip.ContractSalary})
+	}
+	for i, detail := range details {
+		x := payslipLeft
+		if i%2 == 1 {
+			x = payslipMiddle
+		}
+		page.Text(x, y, pdf.Bold, 9, detail[0])
+		page.Text(x+80, y, pdf.Regular, 9, detail[1])
+		if i%2 == 1 || i == len(details)-1 {
+			y -= payslipLeading
+		}
+	}
+
+	y -= 10
+	y = renderPayslipSection(page, y, "Earnings", slip.Earnings, "Gross Pay", slip.Amounts.GrossPay)
+	y -= 10
+	y = renderPayslipSection(page, y, "Deductions", slip.Deductions, "Total Deductions", slip.Amounts.DeductionsTotal.Add(slip.Amounts.TaxTotal))
+
+	y -= 14
+	page.Rect(payslipLeft, y-8, payslipRight-payslipLeft, 26, 1)
+	page.Text(payslipLeft+10, y, pdf.Bold, 13, "NET PAY")
+	page.TextRight(payslipRight-10, y, pdf.Bold, 13, slip.Amounts.NetPay.FormatThousands())
+	y -= 34
+
+	if len(slip.EmployerCosts) > 0 {
+		y = renderPayslipSection(page, y, "Employer Contributions (not deducted)", slip.EmployerCosts, "Total Employer Contributions", slip.Amounts.EmployerContributionsTotal)
+		y -= 10
+	}
+
+	page.Text(payslipLeft, y, pdf.Bold, 11, "Year to Date (fiscal year)")
+	y -= 4
+	page.Line(payslipLeft, y, payslipRight, y, 0.5)
+	y -= payslipLeading
+	ytd := []PayslipLine{
+		{Label: "Gross Pay", Amount: slip.YearToDate.GrossPay},
+		{Label: "Taxable Pay", Amount: slip.YearToDate.TaxablePay},
+		{Label: "PAYE", Amount: slip.YearToDate.TaxTotal},
+		{Label: "Deductions", Amount: slip.YearToDate.DeductionsTotal},
+		{Label: "Net Pay", Amount: slip.YearToDate.NetPay},
+	}
+	for _, line := range ytd {
+		page.Text(payslipLeft, y, pdf.Regular, 10, line.Label)
+		page.TextRight(payslipRight, y, pdf.Regular, 10, line.Amount.FormatThousands())
+		y -= payslipLeading
+	}
+
+	page.Line(payslipLeft, 60, payslipRight, 60, 0.5)
+	page.Text(payslipLeft, 46, pdf.Regular, 8, "This is a computer-generated payslip and requires no signature.")
+	page.TextRight(payslipRight, 46, pdf.Regular, 8, "Generated "+slip.GeneratedAt.Format("2006-01-02 15:04 MST"))
+}
+
+func renderPayslipSection(page *pdf.Page, y float64, title string, lines []PayslipLine, totalLabel string, total money.Amount) float64 {
+	page.Text(payslipLeft, y, pdf.Bold, 11, title)
+	page.TextRight(payslipRight, y, pdf.Bold, 11, "Amount")
+	y -= 4
+	page.Line(payslipLeft, y, payslipRight, y, 0.5)
+	y -= payslipLeading
+	for _, line := range lines {
+		page.Text(payslipLeft, y, pdf.Regular, 10, line.Label)
+		page.TextRight(payslipRight, y, pdf.Regular, 10, line.Amount.FormatThousands())
+		y -= payslipLeading
+	}
+	page.Line(payslipMiddle, y+payslipLeading-4, payslipRight, y+payslipLeading-4, 0.5)
+	page.Text(payslipLeft, y, pdf.Bold, 10, totalLabel)
+	page.TextRight(payslipRight, y, pdf.Bold, 10, total.FormatThousands())
+	return y - payslipLeading
+}
+
+func basicSalaryLabel(entry Entry) string {
+	if entry.ProrationPeriodDays == 0 || entry.ProrationDaysPaid >= entry.ProrationPeriodDays {
+		return "Basic Salary"
+	}
+	unit := "working days"
+	if entry.ProrationBasis == ProrationCalendarDays {
+		unit = "days"
+	}
+	return fmt.Sprintf("Basic Salary (%d of %d %s)", entry.ProrationDaysPaid, entry.ProrationPeriodDays, unit)
+}
+
+func amountsOf(entry Entry) Amounts {
+	return Amounts{
+		BaseSalary:                 entry.BaseSalary,
+		TaxablePay:                 entry.TaxablePay,
+		PensionablePay:             entry.PensionablePay,
+		AllowancesTotal:            entry.AllowancesTotal,
+		DeductionsTotal:            entry.DeductionsTotal,
+		TaxTotal:                   entry.TaxTotal,
+		EmployerContributionsTotal: entry.EmployerContributionsTotal,
+		GrossPay:                   entry.GrossPay,
+		NetPay:                     entry.NetPay,
+	}
+}
+
+func monthLabel(month string) string {
+	parsed, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return parsed.Format("January 2006")
+}
+
+func fileNamePart(value string) string {
+	var sb strings.Builder
+	lastDash := true
+	for _, char := range strings.ToLower(value) {
+		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') {
+			sb.WriteRune(char)
+			lastDash = false
+			continue
+		}
+		if !lastDash {
+			sb.WriteByte('-')
+			lastDash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
+}
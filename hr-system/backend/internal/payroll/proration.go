@@ -0,0 +1,89 @@
+package payroll
+
+import (
+	"math"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+const (
+	ProrationWorkingDays  = "WorkingDays"
+	ProrationCalendarDays = "CalendarDays"
+)
+
+// Proration is the share of a month an employee was on the payroll, counted
+// in working days (Monday to Friday, as leave does) or calendar days.
+type Proration struct {
+	Basis      string
+	DaysPaid   int
+	PeriodDays int
+}
+
+// Factor is DaysPaid/PeriodDays rounded to six places, for display and storage.
+func (p Proration) Factor() float64 {
+	if p.PeriodDays == 0 {
+		return 1
+	}
+	return math.Round(float64(p.DaysPaid)/float64(p.PeriodDays)*1e6) / 1e6
+}
+
+func (p Proration) IsPartial() bool {
+	return p.DaysPaid < p.PeriodDays
+}
+
+// Apply scales a monthly amount by the exact day ratio, rounding half-up once.
+func (p Proration) Apply(amount money.Amount) (money.Amount, error) {
+	if !p.IsPartial() {
+		return amount, nil
+	}
+	return amount.MulFrac(int64(p.DaysPaid), int64(p.PeriodDays))
+}
+
+// ComputeProration counts the days of month that fall within employment,
+// hire date and termination date both inclusive.
+func ComputeProration(basis, month string, hireDate time.Time, terminationDate *time.Time) (Proration, error) {
+	if !isValidProrationBasis(basis) {
+		return Proration{}, ErrInvalidInput
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return Proration{}, ErrInvalidInput
+	}
+	end := start.AddDate(0, 1, -1)
+
+	from, to := start, end
+	if hire := dateOnly(hireDate); hire.After(from) {
+		from = hire
+	}
+	if terminationDate != nil {
+		if exit := dateOnly(*terminationDate); exit.Before(to) {
+			to = exit
+		}
+	}
+
+	proration := Proration{Basis: basis, PeriodDays: countDays(basis, start, end)}
+	if !from.After(to) {
+		proration.DaysPaid = countDays(basis, from, to)
+	}
+	return proration, nil
+}
+
+func countDays(basis string, from, to time.Time) int {
+	days := 0
+	for current := from; !current.After(to); current = current.AddDate(0, 0, 1) {
+		if basis == ProrationWorkingDays && (current.Weekday() == time.Saturday || current.Weekday() == time.Sunday) {
+			continue
+		}
+		days++
+	}
+	return days
+}
+
+func dateOnly(value time.Time) time.Time {
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func isValidProrationBasis(basis string) bool {
+	return basis == ProrationWorkingDays || basis == ProrationCalendarDays
+}
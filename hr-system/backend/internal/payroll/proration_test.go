@@ -0,0 +1,64 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestComputeProration(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		return parsed
+	}
+	exit := date("2026-03-13")
+
+	cases := []struct {
+		name        string
+		basis       string
+		hire        time.Time
+		termination *time.Time
+		wantPaid    int
+		wantPeriod  int
+	}{
+		// March 2026 has 31 days, 22 of them Monday to Friday.
+		{name: "full month", basis: ProrationWorkingDays, hire: date("2020-01-15"), wantPaid: 22, wantPeriod: 22},
+		{name: "hired on the 20th", basis: ProrationWorkingDays, hire: date("2026-03-20"), wantPaid: 8, wantPeriod: 22},
+		{name: "hired on a Saturday", basis: ProrationWorkingDays, hire: date("2026-03-21"), wantPaid: 7, wantPeriod: 22},
+		{name: "left on the 13th", basis: ProrationWorkingDays, hire: date("2020-01-15"), termination: &exit, wantPaid: 10, wantPeriod: 22},
+		{name: "calendar days", basis: ProrationCalendarDays, hire: date("2026-03-20"), wantPaid: 12, wantPeriod: 31},
+		{name: "hired after month", basis: ProrationCalendarDays, hire: date("2026-04-01"), wantPaid: 0, wantPeriod: 31},
+	}
+	for _, tc := range cases {
+		got, err := ComputeProration(tc.basis, "2026-03", tc.hire, tc.termination)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got.DaysPaid != tc.wantPaid || got.PeriodDays != tc.wantPeriod {
+			t.Fatalf("%s: expected %d/%d days, got %d/%d", tc.name, tc.wantPaid, tc.wantPeriod, got.DaysPaid, got.PeriodDays)
+		}
+	}
+
+	if _, err := ComputeProration("Hours", "2026-03", date("2020-01-15"), nil); err != ErrInvalidInput {
+		t.Fatalf("expected invalid basis error, got %v", err)
+	}
+}
+
+func TestProrationApplyUsesExactDayRatio(t *testing.T) {
+	proration := Proration{Basis: ProrationWorkingDays, DaysPaid: 8, PeriodDays: 22}
+	// 1,000,000 x 8/22 = 363,636.3636... rounds to 363,636.36.
+	if got, err := proration.Apply(money.FromInt(1000000)); err != nil || got != money.MustParse("363636.36") {
+		t.Fatalf("expected 363636.36, got %s (%v)", got, err)
+	}
+	if got := proration.Factor(); got != 0.363636 {
+		t.Fatalf("expected factor 0.363636, got %v", got)
+	}
+	full := Proration{Basis: ProrationCalendarDays, DaysPaid: 31, PeriodDays: 31}
+	if got, err := full.Apply(money.MustParse("1234.56")); err != nil || got != money.MustParse("1234.56") {
+		t.Fatalf("expected full month unchanged, got %s (%v)", got, err)
+	}
+}
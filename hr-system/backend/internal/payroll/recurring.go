@@ -0,0 +1,99 @@
+package payroll
+
+import (
+	"fmt"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// RecurringItem is a standing earning or deduction for one employee. Regular
+// batch generation posts it as a line for every month it covers: a fixed
+// Amount, or PercentOfBase of the base salary paid for the month.
+type RecurringItem struct {
+	ID            int64         `db:"id" json:"id"`
+	EmployeeID    int64         `db:"employee_id" json:"employee_id"`
+	EmployeeName  string        `db:"employee_name" json:"employee_name"`
+	ComponentID   int64         `db:"component_id" json:"component_id"`
+	ComponentCode string        `db:"component_code" json:"component_code"`
+	ComponentName string        `db:"component_name" json:"component_name"`
+	ComponentType string        `db:"component_type" json:"component_type"`
+	Amount        *money.Amount `db:"amount" json:"amount"`
+	PercentOfBase *float64      `db:"percent_of_base" json:"percent_of_base"`
+	StartMonth    string        `db:"start_month" json:"start_month"`
+	// EndMonth is the last month covered; empty means until further notice.
+	EndMonth  string    `db:"end_month" json:"end_month"`
+	Note      string    `db:"note" json:"note"`
+	CreatedBy int64     `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RecurringItemInput sets exactly one of Amount and PercentOfBase.
+type RecurringItemInput struct {
+	EmployeeID    int64         `json:"employee_id"`
+	ComponentID   int64         `json:"component_id"`
+	Amount        *money.Amount `json:"amount"`
+	PercentOfBase *float64      `json:"percent_of_base"`
+	StartMonth    string        `json:"start_month"`
+	EndMonth      string        `json:"end_month"`
+	Note          string        `json:"note"`
+}
+
+type RecurringItemFilter struct {
+	EmployeeID int64 `json:"employee_id"`
+	// Month, when set, keeps only the items that apply to it.
+	Month string `json:"month"`
+}
+
+// AppliesTo reports whether the item covers month (YYYY-MM).
+func (item RecurringItem) AppliesTo(month string) bool {
+	return item.StartMonth <= month && (item.EndMonth == "" || item.EndMonth >= month)
+}
+
+// Value is what the item contributes for a month whose paid base salary is
+// baseSalary.
+func (item RecurringItem) Value(baseSalary money.Amount) (money.Amount, error) {
+	if item.PercentOfBase != nil {
+		return baseSalary.MulRate(*item.PercentOfBase / 100)
+	}
+	if item.Amount != nil {
+		return *item.Amount, nil
+	}
+	return money.Amount{}, nil
+}
+
+// RecurringLines turns the items covering month into entry lines, one per
+// component in the order first seen. Items on the same component add up;
+// items whose component is missing or no longer active are skipped.
+func RecurringLines(items []RecurringItem, components []Component, month string, baseSalary money.Amount) ([]EntryLine, error) {
+	lines := make([]EntryLine, 0, len(items))
+	positions := make(map[int64]int, len(items))
+	for _, item := range items {
+		if !item.AppliesTo(month) {
+			continue
+		}
+		component, ok := findComponentByID(components, item.ComponentID)
+		if !ok || !component.IsActive || component.IsSystem {
+			continue
+		}
+		amount, err := item.Value(baseSalary)
+		if err != nil {
+			return nil, fmt.Errorf("recurring %s item: %w", component.Code, err)
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+		if position, ok := positions[component.ID]; ok {
+			lines[position].Amount = lines[position].Amount.Add(amount)
+			continue
+		}
+		positions[component.ID] = len(lines)
+		lines = append(lines, newEntryLine(component, amount))
+	}
+	return lines, nil
+}
+
+func isRecurringComponentType(componentType string) bool {
+	return componentType == ComponentTypeEarning || componentType == ComponentTypeDeduction
+}
@@ -0,0 +1,52 @@
+package payroll
+
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestRecurringLines(t *testing.T) {
+	components := []Component{
+		{ID: 1, Code: "HOUSING", Name: "Housing Allowance", Type: ComponentTypeEarning, IsTaxable: true, IsActive: true},
+		{ID: 3, Code: "UNION", Name: "Union Dues", Type: ComponentTypeDeduction, IsActive: true},
+		{ID: 5, Code: "AIRTIME", Name: "Airtime Allowance", Type: ComponentTypeEarning, IsActive: false},
+	}
+	housing := money.FromInt(500)
+	topUp := money.FromInt(50)
+	airtime := money.FromInt(20)
+	dues := 1.5
+	items := []RecurringItem{
+		{ID: 1, ComponentID: 1, Amount: &housing, StartMonth: "2026-01"},
+		{ID: 2, ComponentID: 3, PercentOfBase: &dues, StartMonth: "2026-01", EndMonth: "2026-03"},
+		// Adds to the housing line.
+		{ID: 3, ComponentID: 1, Amount: &topUp, StartMonth: "2026-03", EndMonth: "2026-03"},
+		// Not yet started.
+		{ID: 4, ComponentID: 1, Amount: &topUp, StartMonth: "2026-04"},
+		// Component retired since the item was set up.
+		{ID: 5, ComponentID: 5, Amount: &airtime, StartMonth: "2026-01"},
+	}
+
+	// 1.5% of a prorated base of 1,234.56 is 18.5184, rounded to 18.52.
+	lines, err := RecurringLines(items, components, "2026-03", money.FromCents(123456))
+	if err != nil {
+		t.Fatalf("recurring lines: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected housing and union lines, got %+v", lines)
+	}
+	if lines[0].ComponentCode != "HOUSING" || lines[0].Amount != money.FromInt(550) || !lines[0].IsTaxable {
+		t.Fatalf("unexpected housing line: %+v", lines[0])
+	}
+	if lines[1].ComponentCode != "UNION" || lines[1].Amount != money.FromCents(1852) {
+		t.Fatalf("unexpected union line: %+v", lines[1])
+	}
+
+	lines, err = RecurringLines(items, components, "2026-04", money.FromCents(123456))
+	if err != nil {
+		t.Fatalf("recurring lines: %v", err)
+	}
+	if len(lines) != 1 || lines[0].Amount != money.FromInt(550) {
+		t.Fatalf("expected only housing with the April top-up, got %+v", lines)
+	}
+}
@@ -3,15 +3,190 @@ package payroll
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"hr-system/backend/internal/money"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
+const entrySelectColumns = `
+	pe.id,
+	pe.batch_id,
+	pe.employee_id,
+	TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+	e.tax_residency,
+	pe.base_salary,
+	pe.monthly_base_salary,
+	pe.contract_currency,
+	pe.contract_monthly_salary,
+	pe.exchange_rate,
+	pe.proration_basis,
+	pe.proration_days_paid,
+	pe.proration_period_days,
+	pe.proration_factor,
+	pe.allowances_total,
+	pe.deductions_total,
+	pe.tax_total,
+	pe.gross_pay,
+	pe.net_pay,
+	pe.taxable_pay,
+	pe.pensionable_pay,
+	pe.employer_contributions_total,
+	pe.tax_table_id,
+	pe.tax_override,
+	COALESCE(pe.tax_override_reason, '') AS tax_override_reason,
+	pe.tax_override_by,
+	pe.created_at,
+	pe.updated_at
+`
+
+const contributionSchemeSelectColumns = `
+	id,
+	code,
+	name,
+	employee_rate,
+	employer_rate,
+	ceiling,
+	is_mandatory,
+	is_active,
+	employee_component_id,
+	employer_component_id,
+	updated_by,
+	created_at,
+	updated_at
+`
+
+const batchSelectColumns = `id, month, status, created_by, created_at, approved_by, approved_at, locked_at, COALESCE(lock_digest, '') AS lock_digest, corrects_batch_id, batch_type, COALESCE(description, '') AS description`
+
+const settingsSelectColumns = `proration_basis, variance_threshold_percent, segregation_policy, updated_by, updated_at`
+
+const componentSelectColumns = `id, code, name, component_type, is_taxable, is_pensionable, is_system, is_active, created_at, updated_at`
+
+// recurringItemSelectColumns reads payroll_recurring_items ri joined to
+// employees e and payroll_components c.
+const recurringItemSelectColumns = `
+	ri.id,
+	ri.employee_id,
+	TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+	ri.component_id,
+	c.code AS component_code,
+	c.name AS component_name,
+	c.component_type,
+	ri.amount,
+	ri.percent_of_base,
+	ri.start_month,
+	COALESCE(ri.end_month, '') AS end_month,
+	COALESCE(ri.note, '') AS note,
+	ri.created_by,
+	ri.created_at,
+	ri.updated_at
+`
+
+const exchangeRateSelectColumns = `month, currency, rate, updated_by, updated_at`
+
+const projectSelectColumns = `
+	id,
+	code,
+	name,
+	COALESCE(funding_source, '') AS funding_source,
+	is_active,
+	created_at,
+	updated_at
+`
+
+// costAllocationSelectColumns reads payroll_cost_allocations a joined to
+// employees e and payroll_projects p.
+const costAllocationSelectColumns = `
+	a.employee_id,
+	TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+	a.effective_from,
+	a.project_id,
+	p.code AS project_code,
+	p.name AS project_name,
+	COALESCE(p.funding_source, '') AS funding_source,
+	a.percent,
+	a.created_by,
+	a.created_at
+`
+
+// glAccountSelectColumns reads payroll_gl_accounts a left-joined to
+// payroll_components c.
+const glAccountSelectColumns = `
+	a.id,
+	a.posting,
+	a.component_id,
+	COALESCE(c.code, '') AS component_code,
+	COALESCE(c.name, '') AS component_name,
+	COALESCE(c.component_type, '') AS component_type,
+	a.account_code,
+	COALESCE(a.account_name, '') AS account_name,
+	COALESCE(a.expense_account_code, '') AS expense_account_code,
+	COALESCE(a.expense_account_name, '') AS expense_account_name,
+	a.updated_by,
+	a.updated_at
+`
+
+// entryYTDColumns are the per-entry figures accumulated into
+// payroll_ytd_totals, read from payroll_entries pe. Employee contributions are
+// the entry's lines on a scheme's employee component.
+const entryYTDColumns = `
+	pe.employee_id,
+	pe.gross_pay,
+	pe.taxable_pay,
+	pe.pensionable_pay,
+	pe.tax_total,
+	pe.deductions_total,
+	COALESCE((
+		SELECT SUM(l.amount)
+		FROM payroll_entry_lines l
+		WHERE l.entry_id = pe.id
+			AND l.component_id IN (SELECT employee_component_id FROM payroll_contribution_schemes)
+	), 0) AS employee_contributions,
+	pe.employer_contributions_total AS employer_contributions,
+	pe.net_pay
+`
+
+// ytdSumColumns total entryYTDColumns rows (aliased t), in
+// payroll_ytd_totals column order.
+const ytdSumColumns = `
+	SUM(t.gross_pay) AS gross_pay,
+	SUM(t.taxable_pay) AS taxable_pay,
+	SUM(t.pensionable_pay) AS pensionable_pay,
+	SUM(t.tax_total) AS tax_total,
+	SUM(t.deductions_total) AS deductions_total,
+	SUM(t.employee_contributions) AS employee_contributions,
+	SUM(t.employer_contributions) AS employer_contributions,
+	SUM(t.net_pay) AS net_pay
+`
+
+// loanSelectColumns reads payroll_loans l joined to employees e.
+const loanSelectColumns = `
+	l.id,
+	l.employee_id,
+	TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+	l.loan_type,
+	COALESCE(l.description, '') AS description,
+	l.principal,
+	l.interest_rate_percent,
+	l.total_repayable,
+	l.installments,
+	l.installment_amount,
+	l.start_month,
+	l.outstanding_balance,
+	l.status,
+	COALESCE(l.cancel_reason, '') AS cancel_reason,
+	l.created_by,
+	l.created_at,
+	l.updated_at
+`
+
 type Repository struct {
 	db *sqlx.DB
 }
@@ -21,8 +196,8 @@ func NewRepository(db *sqlx.DB) *Repository {
 }
 
 func (r *Repository) ListBatches(ctx context.Context, filter BatchFilter) ([]Batch, error) {
-	conditions := make([]string, 0, 2)
-	args := make([]any, 0, 2)
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 3)
 	position := 1
 
 	if month := strings.TrimSpace(filter.Month); month != "" {
@@ -35,9 +210,14 @@ func (r *Repository) ListBatches(ctx context.Context, filter BatchFilter) ([]Bat
 		args = append(args, status)
 		position++
 	}
+	if batchType := strings.TrimSpace(filter.BatchType); batchType != "" {
+		conditions = append(conditions, "batch_type = $"+fmt.Sprintf("%d", position))
+		args = append(args, batchType)
+		position++
+	}
 
 	query := `
-		SELECT id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		SELECT ` + batchSelectColumns + `
 		FROM payroll_batches
 	`
 	if len(conditions) > 0 {
@@ -54,7 +234,7 @@ func (r *Repository) ListBatches(ctx context.Context, filter BatchFilter) ([]Bat
 
 func (r *Repository) GetBatch(ctx context.Context, batchID int64) (Batch, error) {
 	const query = `
-		SELECT id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		SELECT ` + batchSelectColumns + `
 		FROM payroll_batches
 		WHERE id = $1
 	`
@@ -69,47 +249,56 @@ func (r *Repository) GetBatch(ctx context.Context, batchID int64) (Batch, error)
 }
 
 func (r *Repository) GetBatchEntries(ctx context.Context, batchID int64) ([]Entry, error) {
-	const query = `
-		SELECT
-			pe.id,
-			pe.batch_id,
-			pe.employee_id,
-			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
-			pe.base_salary,
-			pe.allowances_total,
-			pe.deductions_total,
-			pe.tax_total,
-			pe.gross_pay,
-			pe.net_pay,
-			pe.created_at,
-			pe.updated_at
+	return listBatchEntries(ctx, r.db, batchID)
+}
+
+// ListEmployeeEntries returns an employee's entries from approved and locked
+// batches, newest month first.
+func (r *Repository) ListEmployeeEntries(ctx context.Context, employeeID int64) ([]EmployeeEntry, error) {
+	query := `
+		SELECT ` + entrySelectColumns + `,
+			b.month,
+			b.status AS batch_status,
+			b.batch_type
 		FROM payroll_entries pe
 		JOIN employees e ON e.id = pe.employee_id
-		WHERE pe.batch_id = $1
-		ORDER BY e.last_name ASC, e.first_name ASC, pe.id ASC
+		JOIN payroll_batches b ON b.id = pe.batch_id
+		WHERE pe.employee_id = $1
+			AND b.status IN ('Approved', 'Locked')
+		ORDER BY b.month DESC, pe.id DESC
 	`
-	items := make([]Entry, 0)
-	if err := r.db.SelectContext(ctx, &items, query, batchID); err != nil {
-		return nil, fmt.Errorf("list payroll entries: %w", err)
+	items := make([]EmployeeEntry, 0)
+	if err := r.db.SelectContext(ctx, &items, query, employeeID); err != nil {
+		return nil, fmt.Errorf("list employee payroll entries: %w", err)
+	}
+	entries := make([]Entry, len(items))
+	for i := range items {
+		entries[i] = items[i].Entry
+	}
+	if err := attachEntryLines(ctx, r.db, entries); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Lines = entries[i].Lines
 	}
 	return items, nil
 }
 
+func (r *Repository) ResolveEmployeeByUserID(ctx context.Context, userID int64) (int64, error) {
+	const query = `SELECT id FROM employees WHERE user_id = $1`
+	var employeeID int64
+	if err := r.db.GetContext(ctx, &employeeID, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrForbidden
+		}
+		return 0, fmt.Errorf("resolve employee by user id: %w", err)
+	}
+	return employeeID, nil
+}
+
 func (r *Repository) GetEntry(ctx context.Context, entryID int64) (Entry, error) {
-	const query = `
-		SELECT
-			pe.id,
-			pe.batch_id,
-			pe.employee_id,
-			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
-			pe.base_salary,
-			pe.allowances_total,
-			pe.deductions_total,
-			pe.tax_total,
-			pe.gross_pay,
-			pe.net_pay,
-			pe.created_at,
-			pe.updated_at
+	query := `
+		SELECT ` + entrySelectColumns + `
 		FROM payroll_entries pe
 		JOIN employees e ON e.id = pe.employee_id
 		WHERE pe.id = $1
@@ -121,26 +310,57 @@ func (r *Repository) GetEntry(ctx context.Context, entryID int64) (Entry, error)
 		}
 		return Entry{}, fmt.Errorf("get payroll entry: %w", err)
 	}
-	return item, nil
+	items := []Entry{item}
+	if err := attachEntryLines(ctx, r.db, items); err != nil {
+		return Entry{}, err
+	}
+	return items[0], nil
 }
 
-func (r *Repository) CreateBatch(ctx context.Context, month string, createdBy int64) (Batch, error) {
+// CreateBatch inserts a Draft batch together with the employees chosen for
+// it. Only one Regular batch may exist per month.
+func (r *Repository) CreateBatch(ctx context.Context, input CreateBatchInput, createdBy int64) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll batch create tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	const query = `
-		INSERT INTO payroll_batches (month, status, created_by)
-		VALUES ($1, $2, $3)
-		RETURNING id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		INSERT INTO payroll_batches (month, status, created_by, batch_type, description)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING ` + batchSelectColumns + `
 	`
 	var batch Batch
-	if err := r.db.GetContext(ctx, &batch, query, month, StatusDraft, createdBy); err != nil {
+	if err := tx.GetContext(ctx, &batch, query, input.Month, StatusDraft, createdBy, input.BatchType, input.Description); err != nil {
 		if isUniqueViolation(err, "uq_payroll_batches_month") {
 			return Batch{}, ErrBatchAlreadyExists
 		}
 		return Batch{}, fmt.Errorf("create payroll batch: %w", err)
 	}
+	const insertEmployee = `
+		INSERT INTO payroll_batch_employees (batch_id, employee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	for _, employeeID := range input.EmployeeIDs {
+		if _, err := tx.ExecContext(ctx, insertEmployee, batch.ID, employeeID); err != nil {
+			if isForeignKeyViolation(err, "payroll_batch_employees_employee_id_fkey") {
+				return Batch{}, ErrInvalidInput
+			}
+			return Batch{}, fmt.Errorf("add employee %d to payroll batch: %w", employeeID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll batch create tx: %w", err)
+	}
 	return batch, nil
 }
 
-func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64) error {
+func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64, actorUserID int64) error {
 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return fmt.Errorf("begin payroll generation tx: %w", err)
@@ -150,7 +370,7 @@ func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64)
 	}()
 
 	const batchQuery = `
-		SELECT id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		SELECT ` + batchSelectColumns + `
 		FROM payroll_batches
 		WHERE id = $1
 		FOR UPDATE
@@ -170,19 +390,9 @@ func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64)
 		return fmt.Errorf("clear payroll entries for regenerate: %w", err)
 	}
 
-	type employeeBase struct {
-		ID         int64   `db:"id"`
-		BaseSalary float64 `db:"base_salary"`
-	}
-	const employeeQuery = `
-		SELECT id, base_salary
-		FROM employees
-		WHERE LOWER(employment_status) = 'active'
-		ORDER BY id ASC
-	`
-	employees := make([]employeeBase, 0)
-	if err := tx.SelectContext(ctx, &employees, employeeQuery); err != nil {
-		return fmt.Errorf("list active employees for payroll generation: %w", err)
+	entries, err := computeEntries(ctx, tx, batch, nil)
+	if err != nil {
+		return err
 	}
 
 	const insertEntry = `
@@ -194,15 +404,74 @@ func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64)
 			deductions_total,
 			tax_total,
 			gross_pay,
-			net_pay
+			net_pay,
+			taxable_pay,
+			pensionable_pay,
+			employer_contributions_total,
+			tax_table_id,
+			monthly_base_salary,
+			proration_basis,
+			proration_days_paid,
+			proration_period_days,
+			proration_factor,
+			contract_currency,
+			contract_monthly_salary,
+			exchange_rate
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
+		RETURNING id
 	`
-	for _, employee := range employees {
-		grossPay, netPay := CalculateAmounts(employee.BaseSalary, 0, 0, 0)
-		if _, err := tx.ExecContext(ctx, insertEntry, batchID, employee.ID, employee.BaseSalary, 0, 0, 0, grossPay, netPay); err != nil {
-			return fmt.Errorf("insert payroll entry for employee %d: %w", employee.ID, err)
+	const insertLeaveDeduction = `
+		INSERT INTO payroll_entry_leave_deductions (entry_id, leave_request_id, unpaid_days, daily_rate, amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	const insertLoanDeduction = `
+		INSERT INTO payroll_entry_loan_deductions (entry_id, loan_id, amount)
+		VALUES ($1, $2, $3)
+	`
+	for _, entry := range entries {
+		var entryID int64
+		if err := tx.GetContext(ctx, &entryID, insertEntry,
+			batchID,
+			entry.EmployeeID,
+			entry.BaseSalary,
+			entry.AllowancesTotal,
+			entry.DeductionsTotal,
+			entry.TaxTotal,
+			entry.GrossPay,
+			entry.NetPay,
+			entry.TaxablePay,
+			entry.PensionablePay,
+			entry.EmployerContributionsTotal,
+			entry.TaxTableID,
+			entry.MonthlyBaseSalary,
+			entry.ProrationBasis,
+			entry.ProrationDaysPaid,
+			entry.ProrationPeriodDays,
+			entry.ProrationFactor,
+			entry.ContractCurrency,
+			entry.ContractMonthlySalary,
+			entry.ExchangeRate,
+		); err != nil {
+			return fmt.Errorf("insert payroll entry for employee %d: %w", entry.EmployeeID, err)
+		}
+		if err := replaceEntryLines(ctx, tx, entryID, entry.Lines); err != nil {
+			return err
 		}
+		for _, deduction := range entry.LeaveDeductions {
+			if _, err := tx.ExecContext(ctx, insertLeaveDeduction, entryID, deduction.LeaveRequestID, deduction.UnpaidDays, deduction.DailyRate, deduction.Amount); err != nil {
+				return fmt.Errorf("insert unpaid leave deduction for employee %d: %w", entry.EmployeeID, err)
+			}
+		}
+		for _, deduction := range entry.LoanDeductions {
+			if _, err := tx.ExecContext(ctx, insertLoanDeduction, entryID, deduction.LoanID, deduction.Amount); err != nil {
+				return fmt.Errorf("insert loan deduction for employee %d: %w", entry.EmployeeID, err)
+			}
+		}
+	}
+
+	if err := recordBatchEditor(ctx, tx, batchID, actorUserID); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -211,7 +480,318 @@ func (r *Repository) GenerateEntriesForBatch(ctx context.Context, batchID int64)
 	return nil
 }
 
-func (r *Repository) UpdateEntryAmounts(ctx context.Context, entryID int64, allowancesTotal, deductionsTotal, taxTotal, grossPay, netPay float64) (Entry, error) {
+// computeEntries runs the generation pipeline for batch and returns the
+// entries it would write, with their lines and leave and loan deductions.
+// Nothing is written. Taxable pay and PAYE already in the month's other In
+// Approval, Approved and Locked batches count toward PAYE. A scenario, when
+// given, raises the salaries and adds items as a what-if simulation.
+func computeEntries(ctx context.Context, q sqlx.QueryerContext, batch Batch, scenario *SimulationInput) ([]Entry, error) {
+	components := make([]Component, 0)
+	componentsQuery := `SELECT ` + componentSelectColumns + ` FROM payroll_components`
+	if err := sqlx.SelectContext(ctx, q, &components, componentsQuery); err != nil {
+		return nil, fmt.Errorf("load payroll components for generation: %w", err)
+	}
+	taxTables, err := loadTaxTables(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	schemes, err := loadContributionSchemes(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	members, err := loadContributionMembers(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadSettings(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	monthStart, err := time.Parse("2006-01", batch.Month)
+	if err != nil {
+		return nil, fmt.Errorf("parse payroll batch month: %w", err)
+	}
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	priorIncome, err := loadPriorMonthIncome(ctx, q, batch.Month, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := listExchangeRates(ctx, q, batch.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	type employeeBase struct {
+		ID              int64        `db:"id"`
+		Name            string       `db:"employee_name"`
+		BaseSalary      money.Amount `db:"base_salary"`
+		Currency        string       `db:"currency"`
+		TaxResidency    string       `db:"tax_residency"`
+		HireDate        time.Time    `db:"hire_date"`
+		TerminationDate *time.Time   `db:"termination_date"`
+	}
+	// A regular batch pays active employees hired by month end, plus
+	// terminated employees who left during or after the month, so their final
+	// partial month is still paid. An off-cycle batch pays only the employees
+	// chosen for it. The monthly salary is the one in force on the last paid
+	// day of the month; employees without history fall back to their current
+	// salary. The salary is in the contract currency of the same history row.
+	const employeeSelect = `
+		SELECT
+			e.id,
+			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+			COALESCE(h.base_salary, e.base_salary) AS base_salary,
+			COALESCE(h.currency, e.salary_currency) AS currency,
+			e.tax_residency,
+			e.hire_date,
+			e.termination_date
+		FROM employees e
+		LEFT JOIN LATERAL (
+			SELECT sh.base_salary, sh.currency
+			FROM employee_salary_history sh
+			WHERE sh.employee_id = e.id
+				AND sh.effective_from <= LEAST($2::DATE, COALESCE(e.termination_date, $2::DATE))
+			ORDER BY sh.effective_from DESC, sh.id DESC
+			LIMIT 1
+		) h ON TRUE
+	`
+	const regularFilter = `
+		WHERE e.hire_date <= $2
+			AND (e.termination_date IS NULL OR e.termination_date >= $1)
+			AND (
+				LOWER(e.employment_status) = 'active'
+				OR (LOWER(e.employment_status) = 'terminated' AND e.termination_date IS NOT NULL)
+			)
+		ORDER BY e.id ASC
+	`
+	const offCycleFilter = `
+		WHERE e.id IN (SELECT be.employee_id FROM payroll_batch_employees be WHERE be.batch_id = $1)
+		ORDER BY e.id ASC
+	`
+	employees := make([]employeeBase, 0)
+	if batch.IsOffCycle() {
+		err = sqlx.SelectContext(ctx, q, &employees, employeeSelect+offCycleFilter, batch.ID, monthEnd.Format("2006-01-02"))
+	} else {
+		err = sqlx.SelectContext(ctx, q, &employees, employeeSelect+regularFilter, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list employees for payroll generation: %w", err)
+	}
+
+	// Approved leave of unpaid types overlapping the month, by employee.
+	const leaveQuery = `
+		SELECT
+			lr.id AS leave_request_id,
+			lr.employee_id,
+			lt.name AS leave_type,
+			lr.start_date,
+			lr.end_date
+		FROM leave_requests lr
+		JOIN leave_types lt ON lt.id = lr.leave_type_id
+		WHERE lr.status = 'Approved'
+			AND lt.is_paid = FALSE
+			AND lr.start_date <= $2
+			AND lr.end_date >= $1
+		ORDER BY lr.employee_id ASC, lr.start_date ASC, lr.id ASC
+	`
+	leaves := make([]UnpaidLeave, 0)
+	if err := sqlx.SelectContext(ctx, q, &leaves, leaveQuery, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("list unpaid leave for payroll generation: %w", err)
+	}
+	leavesByEmployee := make(map[int64][]UnpaidLeave)
+	for _, leave := range leaves {
+		leavesByEmployee[leave.EmployeeID] = append(leavesByEmployee[leave.EmployeeID], leave)
+	}
+
+	// Recurring items and loan installments apply to regular batches only.
+	recurringItems := make(map[int64][]RecurringItem)
+	loanDeductions := make(map[int64][]LoanDeduction)
+	if !batch.IsOffCycle() {
+		items, err := listRecurringItems(ctx, q, RecurringItemFilter{Month: batch.Month})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			recurringItems[item.EmployeeID] = append(recurringItems[item.EmployeeID], item)
+		}
+		loanDeductions, err = loadDueLoanDeductions(ctx, q, batch.Month, batch.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]Entry, 0, len(employees))
+	for _, employee := range employees {
+		taxTable, ok := SelectTaxTable(taxTables, employee.TaxResidency, batch.Month)
+		if !ok {
+			return nil, fmt.Errorf("generate payroll entry for employee %d: %w", employee.ID, ErrTaxTableNotFound)
+		}
+		contractSalary := employee.BaseSalary
+		items := recurringItems[employee.ID]
+		if scenario != nil {
+			contractSalary, err = scenario.AdjustSalary(contractSalary)
+			if err != nil {
+				return nil, fmt.Errorf("simulate salary for employee %d: %w", employee.ID, err)
+			}
+			items = append(append([]RecurringItem(nil), items...), scenario.RecurringItems(employee.ID, batch.Month)...)
+		}
+		// Everything from here on is in the payment currency.
+		rate, ok := ExchangeRateFor(rates, batch.Month, employee.Currency)
+		if !ok {
+			return nil, fmt.Errorf("generate payroll entry for employee %d paid in %s: %w", employee.ID, employee.Currency, ErrExchangeRateNotFound)
+		}
+		monthlySalary, err := ToPaymentCurrency(contractSalary, rate)
+		if err != nil {
+			return nil, fmt.Errorf("convert salary of employee %d from %s: %w", employee.ID, employee.Currency, err)
+		}
+		// Off-cycle entries carry no base salary, unpaid leave or recurring
+		// items; their pay is entered as lines once the entries exist.
+		proration := Proration{Basis: settings.ProrationBasis}
+		var baseSalary money.Amount
+		var leaveDeductions []LeaveDeduction
+		var lines []EntryLine
+		if !batch.IsOffCycle() {
+			proration, err = ComputeProration(settings.ProrationBasis, batch.Month, employee.HireDate, employee.TerminationDate)
+			if err != nil {
+				return nil, fmt.Errorf("prorate payroll entry for employee %d: %w", employee.ID, err)
+			}
+			baseSalary, err = proration.Apply(monthlySalary)
+			if err != nil {
+				return nil, fmt.Errorf("prorate payroll entry for employee %d: %w", employee.ID, err)
+			}
+			leaveDeductions, err = ComputeLeaveDeductions(settings.ProrationBasis, batch.Month, monthlySalary, employee.HireDate, employee.TerminationDate, leavesByEmployee[employee.ID])
+			if err != nil {
+				return nil, fmt.Errorf("compute unpaid leave for employee %d: %w", employee.ID, err)
+			}
+			lines, err = RecurringLines(items, components, batch.Month, baseSalary)
+			if err != nil {
+				return nil, fmt.Errorf("recurring items for employee %d: %w", employee.ID, err)
+			}
+		}
+		result, err := Calculate(CalculationInput{
+			BaseSalary:       baseSalary,
+			Lines:            lines,
+			Components:       components,
+			Schemes:          SchemesForEmployee(schemes, members, employee.ID),
+			TaxTable:         &taxTable,
+			UnpaidLeave:      TotalLeaveDeductions(leaveDeductions),
+			LoanInstallments: TotalLoanDeductions(loanDeductions[employee.ID]),
+			PriorIncome:      priorIncome[employee.ID],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("calculate payroll entry for employee %d: %w", employee.ID, err)
+		}
+
+		amounts := result.Amounts
+		taxTableID := taxTable.ID
+		entries = append(entries, Entry{
+			BatchID:                    batch.ID,
+			EmployeeID:                 employee.ID,
+			EmployeeName:               employee.Name,
+			TaxResidency:               employee.TaxResidency,
+			BaseSalary:                 baseSalary,
+			MonthlyBaseSalary:          monthlySalary,
+			ProrationBasis:             proration.Basis,
+			ProrationDaysPaid:          proration.DaysPaid,
+			ProrationPeriodDays:        proration.PeriodDays,
+			ProrationFactor:            proration.Factor(),
+			AllowancesTotal:            amounts.AllowancesTotal,
+			DeductionsTotal:            amounts.DeductionsTotal,
+			TaxTotal:                   amounts.TaxTotal,
+			GrossPay:                   amounts.GrossPay,
+			NetPay:                     amounts.NetPay,
+			TaxablePay:                 amounts.TaxablePay,
+			PensionablePay:             amounts.PensionablePay,
+			EmployerContributionsTotal: amounts.EmployerContributionsTotal,
+			TaxTableID:                 &taxTableID,
+			ContractCurrency:           employee.Currency,
+			ContractMonthlySalary:      contractSalary,
+			ExchangeRate:               rate,
+			Lines:                      result.Lines,
+			LeaveDeductions:            leaveDeductions,
+			LoanDeductions:             loanDeductions[employee.ID],
+		})
+	}
+	return entries, nil
+}
+
+func (r *Repository) UpdateEntryAmounts(ctx context.Context, entryID int64, update EntryUpdate) (Entry, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Entry{}, fmt.Errorf("begin payroll entry update tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	batchID, err := updateEntryAmounts(ctx, tx, entryID, update)
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := recordBatchEditor(ctx, tx, batchID, update.UpdatedBy); err != nil {
+		return Entry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Entry{}, fmt.Errorf("commit payroll entry update tx: %w", err)
+	}
+	return r.GetEntry(ctx, entryID)
+}
+
+// ApplyEntryUpdates writes an import's updates, keyed by entry ID, all or
+// nothing. The batch is locked and must still be Draft.
+func (r *Repository) ApplyEntryUpdates(ctx context.Context, batchID int64, updates map[int64]EntryUpdate, actorUserID int64) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return fmt.Errorf("begin payroll entry import tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	batch, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status != StatusDraft {
+		return ErrBatchImmutable
+	}
+
+	entryIDs := make([]int64, 0, len(updates))
+	for entryID := range updates {
+		entryIDs = append(entryIDs, entryID)
+	}
+	sort.Slice(entryIDs, func(i, j int) bool { return entryIDs[i] < entryIDs[j] })
+	for _, entryID := range entryIDs {
+		entryBatchID, err := updateEntryAmounts(ctx, tx, entryID, updates[entryID])
+		if err != nil {
+			return err
+		}
+		if entryBatchID != batchID {
+			return ErrInvalidInput
+		}
+	}
+	if err := recordBatchEditor(ctx, tx, batchID, actorUserID); err != nil {
+		return err
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.import", batchID, map[string]any{
+		"month":   batch.Month,
+		"entries": len(entryIDs),
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit payroll entry import tx: %w", err)
+	}
+	return nil
+}
+
+// updateEntryAmounts writes an entry's recomputed totals and lines, returning
+// the entry's batch.
+func updateEntryAmounts(ctx context.Context, tx *sqlx.Tx, entryID int64, update EntryUpdate) (int64, error) {
 	const query = `
 		UPDATE payroll_entries
 		SET allowances_total = $2,
@@ -219,61 +799,2133 @@ func (r *Repository) UpdateEntryAmounts(ctx context.Context, entryID int64, allo
 			tax_total = $4,
 			gross_pay = $5,
 			net_pay = $6,
+			taxable_pay = $7,
+			pensionable_pay = $8,
+			employer_contributions_total = $9,
+			tax_table_id = $10,
+			tax_override = $11,
+			tax_override_reason = NULLIF($12, ''),
+			tax_override_by = CASE WHEN $11 THEN $13::BIGINT ELSE NULL END,
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id
+		RETURNING batch_id
 	`
-	var updatedID int64
-	if err := r.db.GetContext(ctx, &updatedID, query, entryID, allowancesTotal, deductionsTotal, taxTotal, grossPay, netPay); err != nil {
+	amounts := update.Amounts
+	var batchID int64
+	if err := tx.GetContext(ctx, &batchID, query,
+		entryID,
+		amounts.AllowancesTotal,
+		amounts.DeductionsTotal,
+		amounts.TaxTotal,
+		amounts.GrossPay,
+		amounts.NetPay,
+		amounts.TaxablePay,
+		amounts.PensionablePay,
+		amounts.EmployerContributionsTotal,
+		update.TaxTableID,
+		update.TaxOverride,
+		update.TaxOverrideReason,
+		update.UpdatedBy,
+	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return Entry{}, ErrEntryNotFound
+			return 0, ErrEntryNotFound
 		}
-		return Entry{}, fmt.Errorf("update payroll entry amounts: %w", err)
+		return 0, fmt.Errorf("update payroll entry amounts: %w", err)
+	}
+	if err := replaceEntryLines(ctx, tx, entryID, update.Lines); err != nil {
+		return 0, err
 	}
-	return r.GetEntry(ctx, updatedID)
+	return batchID, nil
 }
 
-func (r *Repository) ApproveBatch(ctx context.Context, batchID int64, approvedBy int64, approvedAt time.Time) (Batch, error) {
+// SignOffBatch records stage as signed. The first sign-off moves a Draft
+// batch into approval; signing the final stage approves it, with the final
+// signer as approver.
+func (r *Repository) SignOffBatch(ctx context.Context, batchID int64, stage ApprovalStage, final bool, actorUserID int64, comment string) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll sign-off tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	current, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if current.Status != StatusDraft && current.Status != StatusInApproval {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+
+	const insertSignOff = `
+		INSERT INTO payroll_batch_signoffs (batch_id, stage_position, stage_name, stage_role, decision, comment, actor_user_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`
+	if _, err := tx.ExecContext(ctx, insertSignOff, batchID, stage.Position, stage.Name, stage.Role, SignOffSigned, comment, actorUserID); err != nil {
+		if isUniqueViolation(err, "uq_payroll_batch_signoffs_current_stage") {
+			return Batch{}, ErrInvalidStatusTransition
+		}
+		return Batch{}, fmt.Errorf("record payroll sign-off: %w", err)
+	}
+
 	const query = `
 		UPDATE payroll_batches
 		SET status = $2,
-			approved_by = $3,
-			approved_at = $4
+			approved_by = CASE WHEN $3 THEN $4::BIGINT ELSE NULL END,
+			approved_at = CASE WHEN $3 THEN NOW() ELSE NULL END
 		WHERE id = $1
-		RETURNING id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		RETURNING ` + batchSelectColumns + `
 	`
+	status := StatusInApproval
+	if final {
+		status = StatusApproved
+	}
 	var batch Batch
-	if err := r.db.GetContext(ctx, &batch, query, batchID, StatusApproved, approvedBy, approvedAt); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return Batch{}, ErrBatchNotFound
-		}
-		return Batch{}, fmt.Errorf("approve payroll batch: %w", err)
+	if err := tx.GetContext(ctx, &batch, query, batchID, status, final, actorUserID); err != nil {
+		return Batch{}, fmt.Errorf("update payroll batch after sign-off: %w", err)
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.signoff", batchID, map[string]any{
+		"month":   batch.Month,
+		"stage":   stage.Name,
+		"role":    stage.Role,
+		"status":  batch.Status,
+		"comment": comment,
+	}); err != nil {
+		return Batch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll sign-off tx: %w", err)
 	}
 	return batch, nil
 }
 
-func (r *Repository) LockBatch(ctx context.Context, batchID int64, lockedAt time.Time) (Batch, error) {
+// RejectBatch records the rejection at stage and returns the batch to Draft;
+// the sign-offs of the round are superseded so approval starts over.
+func (r *Repository) RejectBatch(ctx context.Context, batchID int64, stage ApprovalStage, actorUserID int64, comment string) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll rejection tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	current, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if current.Status != StatusInApproval {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+
+	const insertRejection = `
+		INSERT INTO payroll_batch_signoffs (batch_id, stage_position, stage_name, stage_role, decision, comment, actor_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.ExecContext(ctx, insertRejection, batchID, stage.Position, stage.Name, stage.Role, SignOffRejected, comment, actorUserID); err != nil {
+		return Batch{}, fmt.Errorf("record payroll rejection: %w", err)
+	}
+	if err := supersedeSignOffs(ctx, tx, batchID); err != nil {
+		return Batch{}, err
+	}
+
 	const query = `
 		UPDATE payroll_batches
-		SET status = $2,
-			locked_at = $3
+		SET status = $2
 		WHERE id = $1
-		RETURNING id, month, status, created_by, created_at, approved_by, approved_at, locked_at
+		RETURNING ` + batchSelectColumns + `
 	`
 	var batch Batch
-	if err := r.db.GetContext(ctx, &batch, query, batchID, StatusLocked, lockedAt); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return Batch{}, ErrBatchNotFound
-		}
-		return Batch{}, fmt.Errorf("lock payroll batch: %w", err)
+	if err := tx.GetContext(ctx, &batch, query, batchID, StatusDraft); err != nil {
+		return Batch{}, fmt.Errorf("return rejected payroll batch to draft: %w", err)
+	}
+	if err := insertBatchTransition(ctx, tx, batchID, StatusInApproval, StatusDraft, comment, nil, actorUserID); err != nil {
+		return Batch{}, err
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.reject", batchID, map[string]any{
+		"month":   batch.Month,
+		"stage":   stage.Name,
+		"role":    stage.Role,
+		"comment": comment,
+	}); err != nil {
+		return Batch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll rejection tx: %w", err)
 	}
 	return batch, nil
 }
 
-func isUniqueViolation(err error, constraint string) bool {
-	var pqErr *pq.Error
-	if !errors.As(err, &pqErr) {
-		return false
+func (r *Repository) ListBatchSignOffs(ctx context.Context, batchID int64) ([]BatchSignOff, error) {
+	const query = `
+		SELECT
+			s.id,
+			s.batch_id,
+			s.stage_position,
+			s.stage_name,
+			s.stage_role,
+			s.decision,
+			COALESCE(s.comment, '') AS comment,
+			s.actor_user_id,
+			COALESCE(u.username, '') AS actor_name,
+			s.created_at,
+			s.superseded_at
+		FROM payroll_batch_signoffs s
+		LEFT JOIN users u ON u.id = s.actor_user_id
+		WHERE s.batch_id = $1
+		ORDER BY s.created_at ASC, s.id ASC
+	`
+	items := make([]BatchSignOff, 0)
+	if err := r.db.SelectContext(ctx, &items, query, batchID); err != nil {
+		return nil, fmt.Errorf("list payroll batch sign-offs: %w", err)
 	}
-	return pqErr.Code == "23505" && pqErr.Constraint == constraint
+	return items, nil
+}
+
+func (r *Repository) ListApprovalStages(ctx context.Context) ([]ApprovalStage, error) {
+	const query = `
+		SELECT id, position, name, role, updated_by, created_at
+		FROM payroll_approval_stages
+		ORDER BY position ASC
+	`
+	items := make([]ApprovalStage, 0)
+	if err := r.db.SelectContext(ctx, &items, query); err != nil {
+		return nil, fmt.Errorf("list payroll approval stages: %w", err)
+	}
+	return items, nil
+}
+
+// ReplaceApprovalStages swaps the whole chain for stages, numbered in the
+// order given. Batches part-way through approval must finish or be rejected
+// first.
+func (r *Repository) ReplaceApprovalStages(ctx context.Context, stages []ApprovalStageInput, updatedBy int64) ([]ApprovalStage, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, fmt.Errorf("begin payroll approval stages tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE payroll_approval_stages IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("lock payroll approval stages: %w", err)
+	}
+	var inApproval int
+	if err := tx.GetContext(ctx, &inApproval, `SELECT COUNT(1) FROM payroll_batches WHERE status = $1`, StatusInApproval); err != nil {
+		return nil, fmt.Errorf("count payroll batches in approval: %w", err)
+	}
+	if inApproval > 0 {
+		return nil, ErrApprovalInProgress
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_approval_stages`); err != nil {
+		return nil, fmt.Errorf("clear payroll approval stages: %w", err)
+	}
+	const insertStage = `
+		INSERT INTO payroll_approval_stages (position, name, role, updated_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, position, name, role, updated_by, created_at
+	`
+	items := make([]ApprovalStage, 0, len(stages))
+	for i, stage := range stages {
+		var item ApprovalStage
+		if err := tx.GetContext(ctx, &item, insertStage, i+1, stage.Name, stage.Role, updatedBy); err != nil {
+			return nil, fmt.Errorf("insert payroll approval stage: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit payroll approval stages tx: %w", err)
+	}
+	return items, nil
+}
+
+// LockBatch finalizes an approved batch, records the digest of its entries
+// and books its loan installments as repayments, settling the loans they
+// clear.
+func (r *Repository) LockBatch(ctx context.Context, batchID int64, lockedAt time.Time) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll lock tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	current, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if current.Status != StatusApproved {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+	entries, err := listBatchEntries(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	const query = `
+		UPDATE payroll_batches
+		SET status = $2,
+			locked_at = $3,
+			lock_digest = $4
+		WHERE id = $1
+		RETURNING ` + batchSelectColumns + `
+	`
+	var batch Batch
+	if err := tx.GetContext(ctx, &batch, query, batchID, StatusLocked, lockedAt, ComputeBatchDigest(current, entries)); err != nil {
+		return Batch{}, fmt.Errorf("lock payroll batch: %w", err)
+	}
+	if err := recordLoanRepayments(ctx, tx, batchID); err != nil {
+		return Batch{}, err
+	}
+	if err := applyYTDTotals(ctx, tx, batch, 1); err != nil {
+		return Batch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll lock tx: %w", err)
+	}
+	return batch, nil
+}
+
+// ReopenBatch returns an approved batch to Draft, clearing its approval and
+// superseding its sign-offs.
+func (r *Repository) ReopenBatch(ctx context.Context, batchID int64, actorUserID int64, justification string) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll reopen tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	current, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if current.Status != StatusApproved {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+
+	const query = `
+		UPDATE payroll_batches
+		SET status = $2,
+			approved_by = NULL,
+			approved_at = NULL
+		WHERE id = $1
+		RETURNING ` + batchSelectColumns + `
+	`
+	var batch Batch
+	if err := tx.GetContext(ctx, &batch, query, batchID, StatusDraft); err != nil {
+		return Batch{}, fmt.Errorf("reopen payroll batch: %w", err)
+	}
+	if err := supersedeSignOffs(ctx, tx, batchID); err != nil {
+		return Batch{}, err
+	}
+	if err := insertBatchTransition(ctx, tx, batchID, StatusApproved, StatusDraft, justification, nil, actorUserID); err != nil {
+		return Batch{}, err
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.reopen", batchID, map[string]any{
+		"month":         batch.Month,
+		"justification": justification,
+	}); err != nil {
+		return Batch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll reopen tx: %w", err)
+	}
+	return batch, nil
+}
+
+// ReverseBatch marks a locked batch Reversed and creates a Draft correcting
+// batch for the same month holding a copy of its entries, lines and leave
+// deductions. The correcting batch is returned.
+func (r *Repository) ReverseBatch(ctx context.Context, batchID int64, actorUserID int64, justification string) (Batch, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin payroll reversal tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	original, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if original.Status != StatusLocked {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE payroll_batches SET status = $2 WHERE id = $1`, batchID, StatusReversed); err != nil {
+		return Batch{}, fmt.Errorf("mark payroll batch reversed: %w", err)
+	}
+	const createQuery = `
+		INSERT INTO payroll_batches (month, status, created_by, corrects_batch_id, batch_type, description)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING ` + batchSelectColumns + `
+	`
+	var correcting Batch
+	if err := tx.GetContext(ctx, &correcting, createQuery, original.Month, StatusDraft, actorUserID, batchID, original.BatchType, original.Description); err != nil {
+		return Batch{}, fmt.Errorf("create correcting payroll batch: %w", err)
+	}
+	const copyEmployees = `
+		INSERT INTO payroll_batch_employees (batch_id, employee_id)
+		SELECT $2, employee_id
+		FROM payroll_batch_employees
+		WHERE batch_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, copyEmployees, batchID, correcting.ID); err != nil {
+		return Batch{}, fmt.Errorf("copy payroll batch employees: %w", err)
+	}
+	if err := copyBatchEntries(ctx, tx, batchID, correcting.ID); err != nil {
+		return Batch{}, err
+	}
+	if err := reverseLoanRepayments(ctx, tx, batchID); err != nil {
+		return Batch{}, err
+	}
+	if err := applyYTDTotals(ctx, tx, original, -1); err != nil {
+		return Batch{}, err
+	}
+
+	if err := insertBatchTransition(ctx, tx, batchID, StatusLocked, StatusReversed, justification, &correcting.ID, actorUserID); err != nil {
+		return Batch{}, err
+	}
+	if err := insertBatchTransition(ctx, tx, correcting.ID, "", StatusDraft, justification, &batchID, actorUserID); err != nil {
+		return Batch{}, err
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.reverse", batchID, map[string]any{
+		"month":               original.Month,
+		"justification":       justification,
+		"correcting_batch_id": correcting.ID,
+	}); err != nil {
+		return Batch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, fmt.Errorf("commit payroll reversal tx: %w", err)
+	}
+	return correcting, nil
+}
+
+// DeleteBatch removes a Draft batch; its entries, lines, leave deductions and
+// history go with it through ON DELETE CASCADE.
+func (r *Repository) DeleteBatch(ctx context.Context, batchID int64, actorUserID int64) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return fmt.Errorf("begin payroll batch delete tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	batch, err := lockBatch(ctx, tx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status != StatusDraft {
+		return ErrBatchImmutable
+	}
+
+	var summary struct {
+		Entries int          `db:"entries"`
+		NetPay  money.Amount `db:"net_pay"`
+	}
+	const summaryQuery = `
+		SELECT COUNT(1) AS entries, COALESCE(SUM(net_pay), 0) AS net_pay
+		FROM payroll_entries
+		WHERE batch_id = $1
+	`
+	if err := tx.GetContext(ctx, &summary, summaryQuery, batchID); err != nil {
+		return fmt.Errorf("summarise payroll batch for delete: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_batches WHERE id = $1`, batchID); err != nil {
+		return fmt.Errorf("delete payroll batch: %w", err)
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.batch.delete", batchID, map[string]any{
+		"month":             batch.Month,
+		"created_by":        batch.CreatedBy,
+		"corrects_batch_id": batch.CorrectsBatchID,
+		"entries":           summary.Entries,
+		"net_pay_total":     summary.NetPay.String(),
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit payroll batch delete tx: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListBatchTransitions(ctx context.Context, batchID int64) ([]BatchTransition, error) {
+	const query = `
+		SELECT
+			t.id,
+			t.batch_id,
+			t.from_status,
+			t.to_status,
+			t.justification,
+			t.related_batch_id,
+			t.actor_user_id,
+			COALESCE(u.username, '') AS actor_name,
+			t.created_at
+		FROM payroll_batch_transitions t
+		LEFT JOIN users u ON u.id = t.actor_user_id
+		WHERE t.batch_id = $1
+		ORDER BY t.created_at ASC, t.id ASC
+	`
+	items := make([]BatchTransition, 0)
+	if err := r.db.SelectContext(ctx, &items, query, batchID); err != nil {
+		return nil, fmt.Errorf("list payroll batch transitions: %w", err)
+	}
+	return items, nil
+}
+
+// ListBatchEditors returns the users who generated or edited the batch's
+// entries.
+func (r *Repository) ListBatchEditors(ctx context.Context, batchID int64) ([]int64, error) {
+	items := make([]int64, 0)
+	if err := r.db.SelectContext(ctx, &items, `SELECT user_id FROM payroll_batch_editors WHERE batch_id = $1 ORDER BY user_id ASC`, batchID); err != nil {
+		return nil, fmt.Errorf("list payroll batch editors: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) ListComponents(ctx context.Context) ([]Component, error) {
+	query := `
+		SELECT ` + componentSelectColumns + `
+		FROM payroll_components
+		ORDER BY
+			CASE component_type WHEN 'Earning' THEN 1 WHEN 'Deduction' THEN 2 WHEN 'Tax' THEN 3 ELSE 4 END,
+			name ASC,
+			id ASC
+	`
+	items := make([]Component, 0)
+	if err := r.db.SelectContext(ctx, &items, query); err != nil {
+		return nil, fmt.Errorf("list payroll components: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) GetComponent(ctx context.Context, componentID int64) (Component, error) {
+	query := `
+		SELECT ` + componentSelectColumns + `
+		FROM payroll_components
+		WHERE id = $1
+	`
+	var item Component
+	if err := r.db.GetContext(ctx, &item, query, componentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Component{}, ErrComponentNotFound
+		}
+		return Component{}, fmt.Errorf("get payroll component: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) CreateComponent(ctx context.Context, input ComponentInput) (Component, error) {
+	query := `
+		INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable, is_active)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING ` + componentSelectColumns
+	var item Component
+	if err := r.db.GetContext(ctx, &item, query, input.Code, input.Name, input.Type, input.IsTaxable, input.IsPensionable, input.IsActive); err != nil {
+		if isUniqueViolation(err, "uq_payroll_components_code") {
+			return Component{}, ErrComponentAlreadyExists
+		}
+		return Component{}, fmt.Errorf("create payroll component: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) UpdateComponent(ctx context.Context, componentID int64, input ComponentInput) (Component, error) {
+	query := `
+		UPDATE payroll_components
+		SET name = $2,
+			is_taxable = $3,
+			is_pensionable = $4,
+			is_active = $5,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + componentSelectColumns
+	var item Component
+	if err := r.db.GetContext(ctx, &item, query, componentID, input.Name, input.IsTaxable, input.IsPensionable, input.IsActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Component{}, ErrComponentNotFound
+		}
+		return Component{}, fmt.Errorf("update payroll component: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) ListTaxTables(ctx context.Context) ([]TaxTable, error) {
+	return loadTaxTables(ctx, r.db)
+}
+
+func (r *Repository) CreateTaxTable(ctx context.Context, table TaxTable) (TaxTable, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return TaxTable{}, fmt.Errorf("begin tax table tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Serialize version numbering per residency.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE payroll_tax_tables IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return TaxTable{}, fmt.Errorf("lock tax tables: %w", err)
+	}
+
+	const insertTable = `
+		INSERT INTO payroll_tax_tables (name, residency, version, effective_from, created_by)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4
+		FROM payroll_tax_tables
+		WHERE residency = $2
+		RETURNING id, name, residency, version, effective_from, created_by, created_at
+	`
+	var created TaxTable
+	if err := tx.GetContext(ctx, &created, insertTable, table.Name, table.Residency, table.EffectiveFrom, table.CreatedBy); err != nil {
+		return TaxTable{}, fmt.Errorf("create tax table: %w", err)
+	}
+
+	const insertBracket = `
+		INSERT INTO payroll_tax_brackets (table_id, lower_bound, upper_bound, rate)
+		VALUES ($1,$2,$3,$4)
+		RETURNING id, table_id, lower_bound, upper_bound, rate
+	`
+	created.Brackets = make([]TaxBracket, 0, len(table.Brackets))
+	for _, bracket := range table.Brackets {
+		var stored TaxBracket
+		if err := tx.GetContext(ctx, &stored, insertBracket, created.ID, bracket.LowerBound, bracket.UpperBound, bracket.Rate); err != nil {
+			return TaxTable{}, fmt.Errorf("create tax bracket: %w", err)
+		}
+		created.Brackets = append(created.Brackets, stored)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TaxTable{}, fmt.Errorf("commit tax table tx: %w", err)
+	}
+	return created, nil
+}
+
+func (r *Repository) ListContributionSchemes(ctx context.Context) ([]ContributionScheme, error) {
+	return loadContributionSchemes(ctx, r.db)
+}
+
+func (r *Repository) GetContributionScheme(ctx context.Context, schemeID int64) (ContributionScheme, error) {
+	query := `
+		SELECT ` + contributionSchemeSelectColumns + `
+		FROM payroll_contribution_schemes
+		WHERE id = $1
+	`
+	var item ContributionScheme
+	if err := r.db.GetContext(ctx, &item, query, schemeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ContributionScheme{}, ErrContributionSchemeNotFound
+		}
+		return ContributionScheme{}, fmt.Errorf("get contribution scheme: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) UpdateContributionScheme(ctx context.Context, schemeID int64, input ContributionSchemeInput, updatedBy int64) (ContributionScheme, error) {
+	query := `
+		UPDATE payroll_contribution_schemes
+		SET name = $2,
+			employee_rate = $3,
+			employer_rate = $4,
+			ceiling = $5,
+			is_mandatory = $6,
+			is_active = $7,
+			updated_by = $8,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + contributionSchemeSelectColumns
+	var item ContributionScheme
+	if err := r.db.GetContext(ctx, &item, query,
+		schemeID,
+		input.Name,
+		input.EmployeeRate,
+		input.EmployerRate,
+		input.Ceiling,
+		input.IsMandatory,
+		input.IsActive,
+		updatedBy,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ContributionScheme{}, ErrContributionSchemeNotFound
+		}
+		return ContributionScheme{}, fmt.Errorf("update contribution scheme: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) ListContributionMembers(ctx context.Context) ([]ContributionMember, error) {
+	return loadContributionMembers(ctx, r.db)
+}
+
+func (r *Repository) UpsertContributionMember(ctx context.Context, schemeID int64, input ContributionMemberInput) (ContributionMember, error) {
+	const query = `
+		WITH upserted AS (
+			INSERT INTO payroll_contribution_members (scheme_id, employee_id, member_number)
+			VALUES ($1, $2, NULLIF($3, ''))
+			ON CONFLICT (scheme_id, employee_id) DO UPDATE
+			SET member_number = EXCLUDED.member_number,
+				updated_at = NOW()
+			RETURNING scheme_id, employee_id, member_number, created_at, updated_at
+		)
+		SELECT
+			u.scheme_id,
+			u.employee_id,
+			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+			COALESCE(u.member_number, '') AS member_number,
+			u.created_at,
+			u.updated_at
+		FROM upserted u
+		JOIN employees e ON e.id = u.employee_id
+	`
+	var item ContributionMember
+	if err := r.db.GetContext(ctx, &item, query, schemeID, input.EmployeeID, input.MemberNumber); err != nil {
+		if isForeignKeyViolation(err, "payroll_contribution_members_scheme_id_fkey") {
+			return ContributionMember{}, ErrContributionSchemeNotFound
+		}
+		if isForeignKeyViolation(err, "payroll_contribution_members_employee_id_fkey") {
+			return ContributionMember{}, ErrInvalidInput
+		}
+		return ContributionMember{}, fmt.Errorf("upsert contribution member: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) DeleteContributionMember(ctx context.Context, schemeID int64, employeeID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM payroll_contribution_members WHERE scheme_id = $1 AND employee_id = $2`, schemeID, employeeID)
+	if err != nil {
+		return fmt.Errorf("delete contribution member: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete contribution member rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrContributionMemberNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ListBatchEmployeeDetails(ctx context.Context, batchID int64) ([]EmployeeDetails, error) {
+	const query = `
+		SELECT
+			e.id,
+			TRIM(e.first_name || ' ' || COALESCE(e.other_name || ' ', '') || e.last_name) AS employee_name,
+			e.position,
+			COALESCE(d.name, '') AS department_name,
+			COALESCE(e.national_id, '') AS national_id,
+			TO_CHAR(e.hire_date, 'YYYY-MM-DD') AS hire_date,
+			e.tax_residency
+		FROM payroll_entries pe
+		JOIN employees e ON e.id = pe.employee_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE pe.batch_id = $1
+	`
+	items := make([]EmployeeDetails, 0)
+	if err := r.db.SelectContext(ctx, &items, query, batchID); err != nil {
+		return nil, fmt.Errorf("list payslip employee details: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) ListBatchBankPayments(ctx context.Context, batchID int64) ([]BankPayment, error) {
+	const query = `
+		SELECT
+			pe.id AS entry_id,
+			pe.employee_id,
+			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+			COALESCE(e.bank_name, '') AS bank_name,
+			COALESCE(e.bank_branch, '') AS bank_branch,
+			COALESCE(e.bank_code, '') AS bank_code,
+			COALESCE(e.bank_account_name, '') AS bank_account_name,
+			COALESCE(e.bank_account_number, '') AS bank_account_number,
+			pe.net_pay
+		FROM payroll_entries pe
+		JOIN employees e ON e.id = pe.employee_id
+		WHERE pe.batch_id = $1
+		ORDER BY e.last_name ASC, e.first_name ASC, pe.id ASC
+	`
+	items := make([]BankPayment, 0)
+	if err := r.db.SelectContext(ctx, &items, query, batchID); err != nil {
+		return nil, fmt.Errorf("list payroll bank payments: %w", err)
+	}
+	return items, nil
+}
+
+// SumEntriesByEmployee totals each employee's entries in approved and locked
+// batches for months in [fromMonth, toMonth].
+func (r *Repository) SumEntriesByEmployee(ctx context.Context, fromMonth, toMonth string) (map[int64]Amounts, error) {
+	const query = `
+		SELECT
+			pe.employee_id,
+			COALESCE(SUM(pe.base_salary), 0) AS base_salary,
+			COALESCE(SUM(pe.taxable_pay), 0) AS taxable_pay,
+			COALESCE(SUM(pe.pensionable_pay), 0) AS pensionable_pay,
+			COALESCE(SUM(pe.allowances_total), 0) AS allowances_total,
+			COALESCE(SUM(pe.deductions_total), 0) AS deductions_total,
+			COALESCE(SUM(pe.tax_total), 0) AS tax_total,
+			COALESCE(SUM(pe.employer_contributions_total), 0) AS employer_contributions_total,
+			COALESCE(SUM(pe.gross_pay), 0) AS gross_pay,
+			COALESCE(SUM(pe.net_pay), 0) AS net_pay
+		FROM payroll_entries pe
+		JOIN payroll_batches b ON b.id = pe.batch_id
+		WHERE b.status IN ('Approved', 'Locked')
+			AND b.month BETWEEN $1 AND $2
+		GROUP BY pe.employee_id
+	`
+	type employeeTotals struct {
+		EmployeeID                 int64        `db:"employee_id"`
+		BaseSalary                 money.Amount `db:"base_salary"`
+		TaxablePay                 money.Amount `db:"taxable_pay"`
+		PensionablePay             money.Amount `db:"pensionable_pay"`
+		AllowancesTotal            money.Amount `db:"allowances_total"`
+		DeductionsTotal            money.Amount `db:"deductions_total"`
+		TaxTotal                   money.Amount `db:"tax_total"`
+		EmployerContributionsTotal money.Amount `db:"employer_contributions_total"`
+		GrossPay                   money.Amount `db:"gross_pay"`
+		NetPay                     money.Amount `db:"net_pay"`
+	}
+	rows := make([]employeeTotals, 0)
+	if err := r.db.SelectContext(ctx, &rows, query, fromMonth, toMonth); err != nil {
+		return nil, fmt.Errorf("sum payroll entries by employee: %w", err)
+	}
+	totals := make(map[int64]Amounts, len(rows))
+	for _, row := range rows {
+		totals[row.EmployeeID] = Amounts{
+			BaseSalary:                 row.BaseSalary,
+			TaxablePay:                 row.TaxablePay,
+			PensionablePay:             row.PensionablePay,
+			AllowancesTotal:            row.AllowancesTotal,
+			DeductionsTotal:            row.DeductionsTotal,
+			TaxTotal:                   row.TaxTotal,
+			EmployerContributionsTotal: row.EmployerContributionsTotal,
+			GrossPay:                   row.GrossPay,
+			NetPay:                     row.NetPay,
+		}
+	}
+	return totals, nil
+}
+
+// ListPriorMonthIncome totals each employee's taxable pay and PAYE for month
+// in In Approval, Approved and Locked batches other than excludeBatchID.
+func (r *Repository) ListPriorMonthIncome(ctx context.Context, month string, excludeBatchID int64) (map[int64]PriorIncome, error) {
+	return loadPriorMonthIncome(ctx, r.db, month, excludeBatchID)
+}
+
+func (r *Repository) GetSettings(ctx context.Context) (Settings, error) {
+	return loadSettings(ctx, r.db)
+}
+
+func (r *Repository) UpdateSettings(ctx context.Context, input SettingsInput, updatedBy int64) (Settings, error) {
+	const query = `
+		INSERT INTO payroll_settings (id, proration_basis, variance_threshold_percent, segregation_policy, updated_by, updated_at)
+		VALUES (1, $1, $2, $3, $4, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET proration_basis = EXCLUDED.proration_basis,
+			variance_threshold_percent = EXCLUDED.variance_threshold_percent,
+			segregation_policy = EXCLUDED.segregation_policy,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + settingsSelectColumns + `
+	`
+	threshold := DefaultVarianceThresholdPercent
+	if input.VarianceThresholdPercent != nil {
+		threshold = *input.VarianceThresholdPercent
+	}
+	policy := input.SegregationPolicy
+	if policy == "" {
+		policy = SegregationCreatorAndEditors
+	}
+	var item Settings
+	if err := r.db.GetContext(ctx, &item, query, input.ProrationBasis, threshold, policy, updatedBy); err != nil {
+		return Settings{}, fmt.Errorf("update payroll settings: %w", err)
+	}
+	return item, nil
+}
+
+// ListYTDTotals returns the fiscal year's totals for every employee, or for
+// one when employeeID is set.
+func (r *Repository) ListYTDTotals(ctx context.Context, fiscalYear int, employeeID int64) ([]YTDTotals, error) {
+	const query = `
+		SELECT
+			y.employee_id,
+			TRIM(e.first_name || ' ' || COALESCE(e.other_name || ' ', '') || e.last_name) AS employee_name,
+			COALESCE(e.national_id, '') AS national_id,
+			y.fiscal_year,
+			y.gross_pay,
+			y.taxable_pay,
+			y.pensionable_pay,
+			y.tax_total,
+			y.deductions_total,
+			y.employee_contributions,
+			y.employer_contributions,
+			y.net_pay,
+			y.updated_at
+		FROM payroll_ytd_totals y
+		JOIN employees e ON e.id = y.employee_id
+		WHERE y.fiscal_year = $1
+			AND ($2::BIGINT = 0 OR y.employee_id = $2)
+		ORDER BY e.last_name ASC, e.first_name ASC, y.employee_id ASC
+	`
+	items := make([]YTDTotals, 0)
+	if err := r.db.SelectContext(ctx, &items, query, fiscalYear, employeeID); err != nil {
+		return nil, fmt.Errorf("list payroll ytd totals: %w", err)
+	}
+	return items, nil
+}
+
+// ListLockedMonthlyTotals sums Locked entries per employee and month between
+// fromMonth and toMonth, for every employee or just employeeID.
+func (r *Repository) ListLockedMonthlyTotals(ctx context.Context, fromMonth, toMonth string, employeeID int64) ([]MonthlyTotals, error) {
+	query := `
+		SELECT t.employee_id, t.month, ` + ytdSumColumns + `
+		FROM (
+			SELECT b.month, ` + entryYTDColumns + `
+			FROM payroll_entries pe
+			JOIN payroll_batches b ON b.id = pe.batch_id
+			WHERE b.status = 'Locked'
+				AND b.month BETWEEN $1 AND $2
+				AND ($3::BIGINT = 0 OR pe.employee_id = $3)
+		) t
+		GROUP BY t.employee_id, t.month
+		ORDER BY t.employee_id ASC, t.month ASC
+	`
+	items := make([]MonthlyTotals, 0)
+	if err := r.db.SelectContext(ctx, &items, query, fromMonth, toMonth, employeeID); err != nil {
+		return nil, fmt.Errorf("list locked payroll monthly totals: %w", err)
+	}
+	return items, nil
+}
+
+// RebuildYTDTotals recomputes a fiscal year's totals from its Locked batches,
+// replacing whatever had been accumulated.
+func (r *Repository) RebuildYTDTotals(ctx context.Context, fiscalYear int, actorUserID int64) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return fmt.Errorf("begin payroll ytd rebuild tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Batches cannot be locked or reversed while the year is rebuilt.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE payroll_ytd_totals IN EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("lock payroll ytd totals: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_ytd_totals WHERE fiscal_year = $1`, fiscalYear); err != nil {
+		return fmt.Errorf("clear payroll ytd totals: %w", err)
+	}
+	fromMonth, toMonth := FiscalYearMonths(fiscalYear)
+	query := `
+		INSERT INTO payroll_ytd_totals (` + ytdInsertColumns + `)
+		SELECT t.employee_id, $1, ` + ytdSumColumns + `, NOW()
+		FROM (
+			SELECT ` + entryYTDColumns + `
+			FROM payroll_entries pe
+			JOIN payroll_batches b ON b.id = pe.batch_id
+			WHERE b.status = 'Locked'
+				AND b.month BETWEEN $2 AND $3
+		) t
+		GROUP BY t.employee_id
+	`
+	result, err := tx.ExecContext(ctx, query, fiscalYear, fromMonth, toMonth)
+	if err != nil {
+		return fmt.Errorf("rebuild payroll ytd totals: %w", err)
+	}
+	employees, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rebuild payroll ytd totals: %w", err)
+	}
+	if err := writeAuditLog(ctx, tx, actorUserID, "payroll.ytd.rebuild", 0, map[string]any{
+		"fiscal_year": fiscalYear,
+		"employees":   employees,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit payroll ytd rebuild tx: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetEmployeeDetails(ctx context.Context, employeeID int64) (EmployeeDetails, error) {
+	const query = `
+		SELECT
+			e.id,
+			TRIM(e.first_name || ' ' || COALESCE(e.other_name || ' ', '') || e.last_name) AS employee_name,
+			e.position,
+			COALESCE(d.name, '') AS department_name,
+			COALESCE(e.national_id, '') AS national_id,
+			TO_CHAR(e.hire_date, 'YYYY-MM-DD') AS hire_date,
+			e.tax_residency
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE e.id = $1
+	`
+	var item EmployeeDetails
+	if err := r.db.GetContext(ctx, &item, query, employeeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EmployeeDetails{}, ErrEmployeeNotFound
+		}
+		return EmployeeDetails{}, fmt.Errorf("get payroll employee details: %w", err)
+	}
+	return item, nil
+}
+
+// ListEmployeeDetails returns the details of the given employees, in no
+// particular order.
+func (r *Repository) ListEmployeeDetails(ctx context.Context, employeeIDs []int64) ([]EmployeeDetails, error) {
+	const query = `
+		SELECT
+			e.id,
+			TRIM(e.first_name || ' ' || COALESCE(e.other_name || ' ', '') || e.last_name) AS employee_name,
+			e.position,
+			COALESCE(d.name, '') AS department_name,
+			COALESCE(e.national_id, '') AS national_id,
+			TO_CHAR(e.hire_date, 'YYYY-MM-DD') AS hire_date,
+			e.tax_residency
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE e.id = ANY($1)
+	`
+	items := make([]EmployeeDetails, 0)
+	if err := r.db.SelectContext(ctx, &items, query, pq.Array(employeeIDs)); err != nil {
+		return nil, fmt.Errorf("list payroll employee details: %w", err)
+	}
+	return items, nil
+}
+
+// SimulateEntries computes the regular batch for the scenario month as if
+// that month's batch were regenerated, in a read-only transaction so nothing
+// can be written.
+func (r *Repository) SimulateEntries(ctx context.Context, input SimulationInput) ([]Entry, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin payroll simulation tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// The month's own regular batch is left out of PAYE and loan balances,
+	// as regeneration would.
+	const batchQuery = `
+		SELECT ` + batchSelectColumns + `
+		FROM payroll_batches
+		WHERE month = $1
+			AND batch_type = $2
+			AND status <> $3
+	`
+	batch := Batch{Month: input.Month, BatchType: BatchTypeRegular}
+	if err := tx.GetContext(ctx, &batch, batchQuery, input.Month, BatchTypeRegular, StatusReversed); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("load regular payroll batch for simulation: %w", err)
+	}
+	return computeEntries(ctx, tx, batch, &input)
+}
+
+func (r *Repository) ListProjects(ctx context.Context) ([]Project, error) {
+	query := `SELECT ` + projectSelectColumns + ` FROM payroll_projects ORDER BY code ASC`
+	items := make([]Project, 0)
+	if err := r.db.SelectContext(ctx, &items, query); err != nil {
+		return nil, fmt.Errorf("list payroll projects: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) CreateProject(ctx context.Context, input ProjectInput) (Project, error) {
+	query := `
+		INSERT INTO payroll_projects (code, name, funding_source, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING ` + projectSelectColumns
+	var item Project
+	if err := r.db.GetContext(ctx, &item, query, input.Code, input.Name, input.FundingSource, input.IsActive); err != nil {
+		if isUniqueViolation(err, "uq_payroll_projects_code") {
+			return Project{}, ErrProjectAlreadyExists
+		}
+		return Project{}, fmt.Errorf("create payroll project: %w", err)
+	}
+	return item, nil
+}
+
+// UpdateProject renames a project or retires it; the code is fixed.
+func (r *Repository) UpdateProject(ctx context.Context, projectID int64, input ProjectInput) (Project, error) {
+	query := `
+		UPDATE payroll_projects
+		SET name = $2,
+			funding_source = NULLIF($3, ''),
+			is_active = $4,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + projectSelectColumns
+	var item Project
+	if err := r.db.GetContext(ctx, &item, query, projectID, input.Name, input.FundingSource, input.IsActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Project{}, ErrProjectNotFound
+		}
+		return Project{}, fmt.Errorf("update payroll project: %w", err)
+	}
+	return item, nil
+}
+
+// ListCostAllocations returns every allocation share, past and future, for
+// one employee or for everyone when employeeID is 0.
+func (r *Repository) ListCostAllocations(ctx context.Context, employeeID int64) ([]CostAllocation, error) {
+	query := `
+		SELECT ` + costAllocationSelectColumns + `
+		FROM payroll_cost_allocations a
+		JOIN employees e ON e.id = a.employee_id
+		JOIN payroll_projects p ON p.id = a.project_id
+		WHERE ($1::BIGINT = 0 OR a.employee_id = $1)
+		ORDER BY employee_name ASC, a.employee_id ASC, a.effective_from ASC, p.code ASC
+	`
+	items := make([]CostAllocation, 0)
+	if err := r.db.SelectContext(ctx, &items, query, employeeID); err != nil {
+		return nil, fmt.Errorf("list payroll cost allocations: %w", err)
+	}
+	return items, nil
+}
+
+// ReplaceCostAllocation swaps the employee's shares taking effect in
+// input.EffectiveFrom for input.Shares. Earlier and later allocations are kept.
+func (r *Repository) ReplaceCostAllocation(ctx context.Context, employeeID int64, input CostAllocationInput, createdBy int64) ([]CostAllocation, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, fmt.Errorf("begin cost allocation tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_cost_allocations WHERE employee_id = $1 AND effective_from = $2`, employeeID, input.EffectiveFrom); err != nil {
+		return nil, fmt.Errorf("clear payroll cost allocation: %w", err)
+	}
+	const insert = `
+		INSERT INTO payroll_cost_allocations (employee_id, effective_from, project_id, percent, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, share := range input.Shares {
+		if _, err := tx.ExecContext(ctx, insert, employeeID, input.EffectiveFrom, share.ProjectID, share.Percent, createdBy); err != nil {
+			if isForeignKeyViolation(err, "payroll_cost_allocations_employee_id_fkey") {
+				return nil, ErrInvalidInput
+			}
+			if isForeignKeyViolation(err, "payroll_cost_allocations_project_id_fkey") {
+				return nil, ErrProjectNotFound
+			}
+			return nil, fmt.Errorf("insert payroll cost allocation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit cost allocation tx: %w", err)
+	}
+	return r.ListCostAllocations(ctx, employeeID)
+}
+
+func (r *Repository) DeleteCostAllocation(ctx context.Context, employeeID int64, effectiveFrom string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM payroll_cost_allocations WHERE employee_id = $1 AND effective_from = $2`, employeeID, effectiveFrom)
+	if err != nil {
+		return fmt.Errorf("delete payroll cost allocation: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete payroll cost allocation: %w", err)
+	}
+	if affected == 0 {
+		return ErrCostAllocationNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ListGLAccounts(ctx context.Context) ([]GLAccount, error) {
+	return listGLAccounts(ctx, r.db)
+}
+
+// ReplaceGLAccounts swaps the whole chart-of-accounts mapping for accounts.
+func (r *Repository) ReplaceGLAccounts(ctx context.Context, accounts []GLAccountInput, updatedBy int64) ([]GLAccount, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, fmt.Errorf("begin payroll gl accounts tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_gl_accounts`); err != nil {
+		return nil, fmt.Errorf("clear payroll gl accounts: %w", err)
+	}
+	const insert = `
+		INSERT INTO payroll_gl_accounts (posting, component_id, account_code, account_name, expense_account_code, expense_account_name, updated_by)
+		VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+	`
+	for _, account := range accounts {
+		if _, err := tx.ExecContext(ctx, insert, account.Posting, account.ComponentID, account.AccountCode, account.AccountName, account.ExpenseAccountCode, account.ExpenseAccountName, updatedBy); err != nil {
+			if isForeignKeyViolation(err, "payroll_gl_accounts_component_id_fkey") {
+				return nil, ErrComponentNotFound
+			}
+			return nil, fmt.Errorf("insert payroll gl account: %w", err)
+		}
+	}
+	items, err := listGLAccounts(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit payroll gl accounts tx: %w", err)
+	}
+	return items, nil
+}
+
+// journalLayoutRow is payroll_gl_journal_layout as stored: the columns are a
+// comma-separated list.
+type journalLayoutRow struct {
+	Columns       string    `db:"columns"`
+	Delimiter     string    `db:"delimiter"`
+	IncludeHeader bool      `db:"include_header"`
+	UpdatedBy     *int64    `db:"updated_by"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+func (row journalLayoutRow) layout() JournalLayout {
+	return JournalLayout{
+		Columns:       strings.Split(row.Columns, ","),
+		Delimiter:     row.Delimiter,
+		IncludeHeader: row.IncludeHeader,
+		UpdatedBy:     row.UpdatedBy,
+		UpdatedAt:     &row.UpdatedAt,
+	}
+}
+
+func (r *Repository) GetJournalLayout(ctx context.Context) (JournalLayout, error) {
+	const query = `
+		SELECT columns, delimiter, include_header, updated_by, updated_at
+		FROM payroll_gl_journal_layout
+		WHERE id = 1
+	`
+	var row journalLayoutRow
+	if err := r.db.GetContext(ctx, &row, query); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DefaultJournalLayout(), nil
+		}
+		return JournalLayout{}, fmt.Errorf("get payroll journal layout: %w", err)
+	}
+	return row.layout(), nil
+}
+
+func (r *Repository) UpdateJournalLayout(ctx context.Context, input JournalLayoutInput, updatedBy int64) (JournalLayout, error) {
+	const query = `
+		INSERT INTO payroll_gl_journal_layout (id, columns, delimiter, include_header, updated_by, updated_at)
+		VALUES (1, $1, $2, $3, $4, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET columns = EXCLUDED.columns,
+			delimiter = EXCLUDED.delimiter,
+			include_header = EXCLUDED.include_header,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING columns, delimiter, include_header, updated_by, updated_at
+	`
+	var row journalLayoutRow
+	if err := r.db.GetContext(ctx, &row, query, strings.Join(input.Columns, ","), input.Delimiter, input.IncludeHeader, updatedBy); err != nil {
+		return JournalLayout{}, fmt.Errorf("update payroll journal layout: %w", err)
+	}
+	return row.layout(), nil
+}
+
+func (r *Repository) ListExchangeRates(ctx context.Context, month string) ([]ExchangeRate, error) {
+	return listExchangeRates(ctx, r.db, month)
+}
+
+func (r *Repository) UpsertExchangeRate(ctx context.Context, input ExchangeRateInput, updatedBy int64) (ExchangeRate, error) {
+	const query = `
+		INSERT INTO payroll_exchange_rates (month, currency, rate, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (month, currency) DO UPDATE
+		SET rate = EXCLUDED.rate,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + exchangeRateSelectColumns + `
+	`
+	var rate ExchangeRate
+	if err := r.db.GetContext(ctx, &rate, query, input.Month, input.Currency, input.Rate, updatedBy); err != nil {
+		return ExchangeRate{}, fmt.Errorf("upsert payroll exchange rate: %w", err)
+	}
+	return rate, nil
+}
+
+func (r *Repository) DeleteExchangeRate(ctx context.Context, month, currency string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM payroll_exchange_rates WHERE month = $1 AND currency = $2`, month, currency)
+	if err != nil {
+		return fmt.Errorf("delete payroll exchange rate: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete payroll exchange rate: %w", err)
+	}
+	if affected == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ListRecurringItems(ctx context.Context, filter RecurringItemFilter) ([]RecurringItem, error) {
+	return listRecurringItems(ctx, r.db, filter)
+}
+
+func (r *Repository) GetRecurringItem(ctx context.Context, itemID int64) (RecurringItem, error) {
+	query := `
+		SELECT ` + recurringItemSelectColumns + `
+		FROM payroll_recurring_items ri
+		JOIN employees e ON e.id = ri.employee_id
+		JOIN payroll_components c ON c.id = ri.component_id
+		WHERE ri.id = $1
+	`
+	var item RecurringItem
+	if err := r.db.GetContext(ctx, &item, query, itemID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RecurringItem{}, ErrRecurringItemNotFound
+		}
+		return RecurringItem{}, fmt.Errorf("get payroll recurring item: %w", err)
+	}
+	return item, nil
+}
+
+func (r *Repository) CreateRecurringItem(ctx context.Context, input RecurringItemInput, createdBy int64) (RecurringItem, error) {
+	const query = `
+		INSERT INTO payroll_recurring_items (employee_id, component_id, amount, percent_of_base, start_month, end_month, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+		RETURNING id
+	`
+	var itemID int64
+	if err := r.db.GetContext(ctx, &itemID, query, input.EmployeeID, input.ComponentID, input.Amount, input.PercentOfBase, input.StartMonth, input.EndMonth, input.Note, createdBy); err != nil {
+		if isForeignKeyViolation(err, "payroll_recurring_items_employee_id_fkey") {
+			return RecurringItem{}, ErrInvalidInput
+		}
+		return RecurringItem{}, fmt.Errorf("create payroll recurring item: %w", err)
+	}
+	return r.GetRecurringItem(ctx, itemID)
+}
+
+// UpdateRecurringItem changes an item's component, value and months; the
+// employee is fixed. Batches already generated keep the lines they have.
+func (r *Repository) UpdateRecurringItem(ctx context.Context, itemID int64, input RecurringItemInput) (RecurringItem, error) {
+	const query = `
+		UPDATE payroll_recurring_items
+		SET component_id = $2,
+			amount = $3,
+			percent_of_base = $4,
+			start_month = $5,
+			end_month = NULLIF($6, ''),
+			note = NULLIF($7, ''),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, itemID, input.ComponentID, input.Amount, input.PercentOfBase, input.StartMonth, input.EndMonth, input.Note)
+	if err != nil {
+		return RecurringItem{}, fmt.Errorf("update payroll recurring item: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return RecurringItem{}, fmt.Errorf("update payroll recurring item: %w", err)
+	}
+	if affected == 0 {
+		return RecurringItem{}, ErrRecurringItemNotFound
+	}
+	return r.GetRecurringItem(ctx, itemID)
+}
+
+func (r *Repository) DeleteRecurringItem(ctx context.Context, itemID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM payroll_recurring_items WHERE id = $1`, itemID)
+	if err != nil {
+		return fmt.Errorf("delete payroll recurring item: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete payroll recurring item: %w", err)
+	}
+	if affected == 0 {
+		return ErrRecurringItemNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ListLoans(ctx context.Context, filter LoanFilter) ([]Loan, error) {
+	query := `
+		SELECT ` + loanSelectColumns + `
+		FROM payroll_loans l
+		JOIN employees e ON e.id = l.employee_id
+		WHERE ($1::BIGINT = 0 OR l.employee_id = $1)
+			AND ($2 = '' OR l.status = $2)
+		ORDER BY employee_name ASC, l.start_month ASC, l.id ASC
+	`
+	items := make([]Loan, 0)
+	if err := r.db.SelectContext(ctx, &items, query, filter.EmployeeID, filter.Status); err != nil {
+		return nil, fmt.Errorf("list payroll loans: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) GetLoan(ctx context.Context, loanID int64) (Loan, error) {
+	query := `
+		SELECT ` + loanSelectColumns + `
+		FROM payroll_loans l
+		JOIN employees e ON e.id = l.employee_id
+		WHERE l.id = $1
+	`
+	var item Loan
+	if err := r.db.GetContext(ctx, &item, query, loanID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Loan{}, ErrLoanNotFound
+		}
+		return Loan{}, fmt.Errorf("get payroll loan: %w", err)
+	}
+	return item, nil
+}
+
+// CreateLoan stores a loan whose terms the service has already worked out;
+// the whole amount repayable starts outstanding.
+func (r *Repository) CreateLoan(ctx context.Context, loan Loan) (Loan, error) {
+	const query = `
+		INSERT INTO payroll_loans (
+			employee_id,
+			loan_type,
+			description,
+			principal,
+			interest_rate_percent,
+			total_repayable,
+			installments,
+			installment_amount,
+			start_month,
+			outstanding_balance,
+			created_by
+		)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $6, $10)
+		RETURNING id
+	`
+	var loanID int64
+	if err := r.db.GetContext(ctx, &loanID, query,
+		loan.EmployeeID,
+		loan.LoanType,
+		loan.Description,
+		loan.Principal,
+		loan.InterestRatePercent,
+		loan.TotalRepayable,
+		loan.Installments,
+		loan.InstallmentAmount,
+		loan.StartMonth,
+		loan.CreatedBy,
+	); err != nil {
+		if isForeignKeyViolation(err, "payroll_loans_employee_id_fkey") {
+			return Loan{}, ErrInvalidInput
+		}
+		return Loan{}, fmt.Errorf("create payroll loan: %w", err)
+	}
+	return r.GetLoan(ctx, loanID)
+}
+
+// CancelLoan writes off an active loan's remaining balance. Installments
+// already placed in unlocked batches stay there until they are regenerated.
+func (r *Repository) CancelLoan(ctx context.Context, loanID int64, reason string) (Loan, error) {
+	const query = `
+		UPDATE payroll_loans
+		SET status = $2,
+			cancel_reason = $3,
+			updated_at = NOW()
+		WHERE id = $1
+			AND status = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, loanID, LoanStatusCancelled, reason, LoanStatusActive)
+	if err != nil {
+		return Loan{}, fmt.Errorf("cancel payroll loan: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Loan{}, fmt.Errorf("cancel payroll loan: %w", err)
+	}
+	if affected == 0 {
+		if _, err := r.GetLoan(ctx, loanID); err != nil {
+			return Loan{}, err
+		}
+		return Loan{}, ErrInvalidStatusTransition
+	}
+	return r.GetLoan(ctx, loanID)
+}
+
+func (r *Repository) ListLoanRepayments(ctx context.Context, loanID int64) ([]LoanRepayment, error) {
+	const query = `
+		SELECT
+			lr.id,
+			lr.loan_id,
+			lr.batch_id,
+			b.month,
+			lr.entry_id,
+			lr.amount,
+			lr.balance_after,
+			lr.created_at,
+			lr.reversed_at
+		FROM payroll_loan_repayments lr
+		JOIN payroll_batches b ON b.id = lr.batch_id
+		WHERE lr.loan_id = $1
+		ORDER BY lr.created_at ASC, lr.id ASC
+	`
+	items := make([]LoanRepayment, 0)
+	if err := r.db.SelectContext(ctx, &items, query, loanID); err != nil {
+		return nil, fmt.Errorf("list payroll loan repayments: %w", err)
+	}
+	return items, nil
+}
+
+func lockBatch(ctx context.Context, tx *sqlx.Tx, batchID int64) (Batch, error) {
+	const query = `
+		SELECT ` + batchSelectColumns + `
+		FROM payroll_batches
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var batch Batch
+	if err := tx.GetContext(ctx, &batch, query, batchID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Batch{}, ErrBatchNotFound
+		}
+		return Batch{}, fmt.Errorf("lock payroll batch: %w", err)
+	}
+	return batch, nil
+}
+
+// copiedEntryColumns are the payroll_entries columns carried into a
+// correcting batch; everything except keys and timestamps.
+const copiedEntryColumns = `
+	employee_id,
+	base_salary,
+	allowances_total,
+	deductions_total,
+	tax_total,
+	gross_pay,
+	net_pay,
+	taxable_pay,
+	pensionable_pay,
+	employer_contributions_total,
+	tax_table_id,
+	tax_override,
+	tax_override_reason,
+	tax_override_by,
+	monthly_base_salary,
+	contract_currency,
+	contract_monthly_salary,
+	exchange_rate,
+	proration_basis,
+	proration_days_paid,
+	proration_period_days,
+	proration_factor
+`
+
+func copyBatchEntries(ctx context.Context, tx *sqlx.Tx, fromBatchID, toBatchID int64) error {
+	entryIDs := make([]int64, 0)
+	if err := tx.SelectContext(ctx, &entryIDs, `SELECT id FROM payroll_entries WHERE batch_id = $1 ORDER BY id ASC`, fromBatchID); err != nil {
+		return fmt.Errorf("list payroll entries to copy: %w", err)
+	}
+
+	const copyEntry = `
+		INSERT INTO payroll_entries (batch_id,` + copiedEntryColumns + `)
+		SELECT $2,` + copiedEntryColumns + `
+		FROM payroll_entries
+		WHERE id = $1
+		RETURNING id
+	`
+	const copyLines = `
+		INSERT INTO payroll_entry_lines (entry_id, component_id, amount)
+		SELECT $2, component_id, amount
+		FROM payroll_entry_lines
+		WHERE entry_id = $1
+	`
+	const copyLeaveDeductions = `
+		INSERT INTO payroll_entry_leave_deductions (entry_id, leave_request_id, unpaid_days, daily_rate, amount)
+		SELECT $2, leave_request_id, unpaid_days, daily_rate, amount
+		FROM payroll_entry_leave_deductions
+		WHERE entry_id = $1
+	`
+	const copyLoanDeductions = `
+		INSERT INTO payroll_entry_loan_deductions (entry_id, loan_id, amount)
+		SELECT $2, loan_id, amount
+		FROM payroll_entry_loan_deductions
+		WHERE entry_id = $1
+	`
+	for _, entryID := range entryIDs {
+		var copiedID int64
+		if err := tx.GetContext(ctx, &copiedID, copyEntry, entryID, toBatchID); err != nil {
+			return fmt.Errorf("copy payroll entry %d: %w", entryID, err)
+		}
+		if _, err := tx.ExecContext(ctx, copyLines, entryID, copiedID); err != nil {
+			return fmt.Errorf("copy payroll entry lines %d: %w", entryID, err)
+		}
+		if _, err := tx.ExecContext(ctx, copyLeaveDeductions, entryID, copiedID); err != nil {
+			return fmt.Errorf("copy payroll leave deductions %d: %w", entryID, err)
+		}
+		if _, err := tx.ExecContext(ctx, copyLoanDeductions, entryID, copiedID); err != nil {
+			return fmt.Errorf("copy payroll loan deductions %d: %w", entryID, err)
+		}
+	}
+	return nil
+}
+
+func supersedeSignOffs(ctx context.Context, tx *sqlx.Tx, batchID int64) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE payroll_batch_signoffs SET superseded_at = NOW() WHERE batch_id = $1 AND superseded_at IS NULL`, batchID); err != nil {
+		return fmt.Errorf("supersede payroll sign-offs: %w", err)
+	}
+	return nil
+}
+
+func recordBatchEditor(ctx context.Context, tx *sqlx.Tx, batchID int64, userID int64) error {
+	const query = `
+		INSERT INTO payroll_batch_editors (batch_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (batch_id, user_id) DO UPDATE
+		SET last_edited_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, query, batchID, userID); err != nil {
+		return fmt.Errorf("record payroll batch editor: %w", err)
+	}
+	return nil
+}
+
+func insertBatchTransition(ctx context.Context, tx *sqlx.Tx, batchID int64, fromStatus, toStatus, justification string, relatedBatchID *int64, actorUserID int64) error {
+	const query = `
+		INSERT INTO payroll_batch_transitions (batch_id, from_status, to_status, justification, related_batch_id, actor_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(ctx, query, batchID, fromStatus, toStatus, justification, relatedBatchID, actorUserID); err != nil {
+		return fmt.Errorf("record payroll batch transition: %w", err)
+	}
+	return nil
+}
+
+func writeAuditLog(ctx context.Context, tx *sqlx.Tx, actorUserID int64, action string, batchID int64, metadata map[string]any) error {
+	const query = `
+		INSERT INTO audit_logs (actor_user_id, action, entity_type, entity_id, metadata)
+		VALUES ($1, $2, 'payroll_batch', $3, $4::jsonb)
+	`
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, actorUserID, action, fmt.Sprintf("%d", batchID), string(payload)); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+func loadSettings(ctx context.Context, q sqlx.QueryerContext) (Settings, error) {
+	const query = `
+		SELECT ` + settingsSelectColumns + `
+		FROM payroll_settings
+		WHERE id = 1
+	`
+	var item Settings
+	if err := sqlx.GetContext(ctx, q, &item, query); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Settings{ProrationBasis: ProrationWorkingDays, VarianceThresholdPercent: DefaultVarianceThresholdPercent, SegregationPolicy: SegregationCreatorAndEditors}, nil
+		}
+		return Settings{}, fmt.Errorf("load payroll settings: %w", err)
+	}
+	return item, nil
+}
+
+func loadPriorMonthIncome(ctx context.Context, q sqlx.QueryerContext, month string, excludeBatchID int64) (map[int64]PriorIncome, error) {
+	const query = `
+		SELECT
+			pe.employee_id,
+			COALESCE(SUM(pe.taxable_pay), 0) AS taxable_pay,
+			COALESCE(SUM(pe.tax_total), 0) AS tax_total
+		FROM payroll_entries pe
+		JOIN payroll_batches b ON b.id = pe.batch_id
+		WHERE b.month = $1
+			AND b.id <> $2
+			AND b.status IN ('In Approval', 'Approved', 'Locked')
+		GROUP BY pe.employee_id
+	`
+	rows := make([]PriorIncome, 0)
+	if err := sqlx.SelectContext(ctx, q, &rows, query, month, excludeBatchID); err != nil {
+		return nil, fmt.Errorf("sum prior payroll income for month: %w", err)
+	}
+	income := make(map[int64]PriorIncome, len(rows))
+	for _, row := range rows {
+		income[row.EmployeeID] = row
+	}
+	return income, nil
+}
+
+// listExchangeRates returns the rates of month, or of every month when month
+// is empty, newest first.
+func listExchangeRates(ctx context.Context, q sqlx.QueryerContext, month string) ([]ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateSelectColumns + `
+		FROM payroll_exchange_rates
+		WHERE ($1::TEXT = '' OR month = $1)
+		ORDER BY month DESC, currency ASC
+	`
+	items := make([]ExchangeRate, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query, month); err != nil {
+		return nil, fmt.Errorf("list payroll exchange rates: %w", err)
+	}
+	return items, nil
+}
+
+func listGLAccounts(ctx context.Context, q sqlx.QueryerContext) ([]GLAccount, error) {
+	query := `
+		SELECT ` + glAccountSelectColumns + `
+		FROM payroll_gl_accounts a
+		LEFT JOIN payroll_components c ON c.id = a.component_id
+		ORDER BY
+			CASE a.posting WHEN 'BaseSalary' THEN 0 WHEN 'Component' THEN 1 ELSE 2 END,
+			c.code ASC
+	`
+	items := make([]GLAccount, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query); err != nil {
+		return nil, fmt.Errorf("list payroll gl accounts: %w", err)
+	}
+	return items, nil
+}
+
+func listRecurringItems(ctx context.Context, q sqlx.QueryerContext, filter RecurringItemFilter) ([]RecurringItem, error) {
+	query := `
+		SELECT ` + recurringItemSelectColumns + `
+		FROM payroll_recurring_items ri
+		JOIN employees e ON e.id = ri.employee_id
+		JOIN payroll_components c ON c.id = ri.component_id
+		WHERE ($1::BIGINT = 0 OR ri.employee_id = $1)
+			AND ($2 = '' OR (ri.start_month <= $2 AND (ri.end_month IS NULL OR ri.end_month >= $2)))
+		ORDER BY employee_name ASC, ri.employee_id ASC, ri.start_month ASC, ri.id ASC
+	`
+	items := make([]RecurringItem, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query, filter.EmployeeID, filter.Month); err != nil {
+		return nil, fmt.Errorf("list payroll recurring items: %w", err)
+	}
+	return items, nil
+}
+
+// loadDueLoanDeductions returns, by employee, the installment each active
+// loan takes from a regular batch for month. Amounts already sitting in other
+// unlocked batches count against the balance so a loan is never over-deducted.
+func loadDueLoanDeductions(ctx context.Context, q sqlx.QueryerContext, month string, excludeBatchID int64) (map[int64][]LoanDeduction, error) {
+	const loansQuery = `
+		SELECT ` + loanSelectColumns + `
+		FROM payroll_loans l
+		JOIN employees e ON e.id = l.employee_id
+		WHERE l.status = 'Active'
+			AND l.start_month <= $1
+			AND l.outstanding_balance > 0
+		ORDER BY l.employee_id ASC, l.id ASC
+	`
+	loans := make([]Loan, 0)
+	if err := sqlx.SelectContext(ctx, q, &loans, loansQuery, month); err != nil {
+		return nil, fmt.Errorf("list due payroll loans: %w", err)
+	}
+
+	const pendingQuery = `
+		SELECT d.loan_id, COALESCE(SUM(d.amount), 0) AS amount
+		FROM payroll_entry_loan_deductions d
+		JOIN payroll_entries pe ON pe.id = d.entry_id
+		JOIN payroll_batches b ON b.id = pe.batch_id
+		WHERE b.id <> $1
+			AND b.status NOT IN ('Locked', 'Reversed')
+		GROUP BY d.loan_id
+	`
+	pendingRows := make([]struct {
+		LoanID int64        `db:"loan_id"`
+		Amount money.Amount `db:"amount"`
+	}, 0)
+	if err := sqlx.SelectContext(ctx, q, &pendingRows, pendingQuery, excludeBatchID); err != nil {
+		return nil, fmt.Errorf("sum pending payroll loan deductions: %w", err)
+	}
+	pending := make(map[int64]money.Amount, len(pendingRows))
+	for _, row := range pendingRows {
+		pending[row.LoanID] = row.Amount
+	}
+
+	deductions := make(map[int64][]LoanDeduction)
+	for _, loan := range loans {
+		amount := DueInstallment(loan, pending[loan.ID])
+		if !amount.IsPositive() {
+			continue
+		}
+		deductions[loan.EmployeeID] = append(deductions[loan.EmployeeID], LoanDeduction{
+			LoanID:      loan.ID,
+			LoanType:    loan.LoanType,
+			Description: loan.Description,
+			Amount:      amount,
+		})
+	}
+	return deductions, nil
+}
+
+const ytdInsertColumns = `
+	employee_id,
+	fiscal_year,
+	gross_pay,
+	taxable_pay,
+	pensionable_pay,
+	tax_total,
+	deductions_total,
+	employee_contributions,
+	employer_contributions,
+	net_pay,
+	updated_at
+`
+
+// applyYTDTotals adds a batch's entries to its fiscal year's running totals
+// (sign 1, on lock) or takes them off again (sign -1, on reversal).
+func applyYTDTotals(ctx context.Context, tx *sqlx.Tx, batch Batch, sign int) error {
+	fiscalYear, err := FiscalYearOf(batch.Month)
+	if err != nil {
+		return fmt.Errorf("resolve fiscal year for %s: %w", batch.Month, err)
+	}
+	query := `
+		INSERT INTO payroll_ytd_totals (` + ytdInsertColumns + `)
+		SELECT
+			t.employee_id,
+			$2,
+			$3 * SUM(t.gross_pay),
+			$3 * SUM(t.taxable_pay),
+			$3 * SUM(t.pensionable_pay),
+			$3 * SUM(t.tax_total),
+			$3 * SUM(t.deductions_total),
+			$3 * SUM(t.employee_contributions),
+			$3 * SUM(t.employer_contributions),
+			$3 * SUM(t.net_pay),
+			NOW()
+		FROM (
+			SELECT ` + entryYTDColumns + `
+			FROM payroll_entries pe
+			WHERE pe.batch_id = $1
+		) t
+		GROUP BY t.employee_id
+		ON CONFLICT (employee_id, fiscal_year) DO UPDATE
+		SET gross_pay = payroll_ytd_totals.gross_pay + EXCLUDED.gross_pay,
+			taxable_pay = payroll_ytd_totals.taxable_pay + EXCLUDED.taxable_pay,
+			pensionable_pay = payroll_ytd_totals.pensionable_pay + EXCLUDED.pensionable_pay,
+			tax_total = payroll_ytd_totals.tax_total + EXCLUDED.tax_total,
+			deductions_total = payroll_ytd_totals.deductions_total + EXCLUDED.deductions_total,
+			employee_contributions = payroll_ytd_totals.employee_contributions + EXCLUDED.employee_contributions,
+			employer_contributions = payroll_ytd_totals.employer_contributions + EXCLUDED.employer_contributions,
+			net_pay = payroll_ytd_totals.net_pay + EXCLUDED.net_pay,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.ExecContext(ctx, query, batch.ID, fiscalYear, sign); err != nil {
+		return fmt.Errorf("update payroll ytd totals: %w", err)
+	}
+	return nil
+}
+
+// recordLoanRepayments books the loan deductions of a batch being locked
+// against the loans' balances.
+func recordLoanRepayments(ctx context.Context, tx *sqlx.Tx, batchID int64) error {
+	const deductionsQuery = `
+		SELECT d.entry_id, d.loan_id, d.amount
+		FROM payroll_entry_loan_deductions d
+		JOIN payroll_entries pe ON pe.id = d.entry_id
+		WHERE pe.batch_id = $1
+		ORDER BY d.loan_id ASC, d.entry_id ASC
+	`
+	deductions := make([]LoanDeduction, 0)
+	if err := tx.SelectContext(ctx, &deductions, deductionsQuery, batchID); err != nil {
+		return fmt.Errorf("list payroll loan deductions to repay: %w", err)
+	}
+
+	const updateLoan = `
+		UPDATE payroll_loans
+		SET outstanding_balance = GREATEST(outstanding_balance - $2, 0),
+			status = CASE
+				WHEN status = 'Active' AND outstanding_balance - $2 <= 0 THEN 'Settled'
+				ELSE status
+			END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING outstanding_balance
+	`
+	const insertRepayment = `
+		INSERT INTO payroll_loan_repayments (loan_id, batch_id, entry_id, amount, balance_after)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, deduction := range deductions {
+		var balance money.Amount
+		if err := tx.GetContext(ctx, &balance, updateLoan, deduction.LoanID, deduction.Amount); err != nil {
+			return fmt.Errorf("update payroll loan %d balance: %w", deduction.LoanID, err)
+		}
+		if _, err := tx.ExecContext(ctx, insertRepayment, deduction.LoanID, batchID, deduction.EntryID, deduction.Amount, balance); err != nil {
+			return fmt.Errorf("record payroll loan %d repayment: %w", deduction.LoanID, err)
+		}
+	}
+	return nil
+}
+
+// reverseLoanRepayments gives back the installments a reversed batch took,
+// reopening loans it had settled. The correcting batch carries the same
+// deductions and books them again when it is locked.
+func reverseLoanRepayments(ctx context.Context, tx *sqlx.Tx, batchID int64) error {
+	const restoreLoans = `
+		UPDATE payroll_loans l
+		SET outstanding_balance = l.outstanding_balance + r.amount,
+			status = CASE WHEN l.status = 'Settled' THEN 'Active' ELSE l.status END,
+			updated_at = NOW()
+		FROM (
+			SELECT loan_id, SUM(amount) AS amount
+			FROM payroll_loan_repayments
+			WHERE batch_id = $1 AND reversed_at IS NULL
+			GROUP BY loan_id
+		) r
+		WHERE l.id = r.loan_id
+	`
+	if _, err := tx.ExecContext(ctx, restoreLoans, batchID); err != nil {
+		return fmt.Errorf("restore payroll loan balances: %w", err)
+	}
+	const markReversed = `
+		UPDATE payroll_loan_repayments
+		SET reversed_at = NOW()
+		WHERE batch_id = $1 AND reversed_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, markReversed, batchID); err != nil {
+		return fmt.Errorf("reverse payroll loan repayments: %w", err)
+	}
+	return nil
+}
+
+func loadContributionSchemes(ctx context.Context, q sqlx.QueryerContext) ([]ContributionScheme, error) {
+	query := `
+		SELECT ` + contributionSchemeSelectColumns + `
+		FROM payroll_contribution_schemes
+		ORDER BY code ASC
+	`
+	items := make([]ContributionScheme, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query); err != nil {
+		return nil, fmt.Errorf("list contribution schemes: %w", err)
+	}
+	return items, nil
+}
+
+func loadContributionMembers(ctx context.Context, q sqlx.QueryerContext) ([]ContributionMember, error) {
+	const query = `
+		SELECT
+			m.scheme_id,
+			m.employee_id,
+			TRIM(e.last_name || ', ' || e.first_name) AS employee_name,
+			COALESCE(m.member_number, '') AS member_number,
+			m.created_at,
+			m.updated_at
+		FROM payroll_contribution_members m
+		JOIN employees e ON e.id = m.employee_id
+		ORDER BY m.scheme_id ASC, e.last_name ASC, e.first_name ASC
+	`
+	items := make([]ContributionMember, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query); err != nil {
+		return nil, fmt.Errorf("list contribution members: %w", err)
+	}
+	return items, nil
+}
+
+func loadTaxTables(ctx context.Context, q sqlx.QueryerContext) ([]TaxTable, error) {
+	const tablesQuery = `
+		SELECT id, name, residency, version, effective_from, created_by, created_at
+		FROM payroll_tax_tables
+		ORDER BY residency ASC, effective_from DESC, version DESC
+	`
+	tables := make([]TaxTable, 0)
+	if err := sqlx.SelectContext(ctx, q, &tables, tablesQuery); err != nil {
+		return nil, fmt.Errorf("list tax tables: %w", err)
+	}
+
+	const bracketsQuery = `
+		SELECT id, table_id, lower_bound, upper_bound, rate
+		FROM payroll_tax_brackets
+		ORDER BY table_id ASC, lower_bound ASC
+	`
+	brackets := make([]TaxBracket, 0)
+	if err := sqlx.SelectContext(ctx, q, &brackets, bracketsQuery); err != nil {
+		return nil, fmt.Errorf("list tax brackets: %w", err)
+	}
+
+	byTable := make(map[int64][]TaxBracket, len(tables))
+	for _, bracket := range brackets {
+		byTable[bracket.TableID] = append(byTable[bracket.TableID], bracket)
+	}
+	for i := range tables {
+		tables[i].Brackets = byTable[tables[i].ID]
+	}
+	return tables, nil
+}
+
+func listBatchEntries(ctx context.Context, q sqlx.QueryerContext, batchID int64) ([]Entry, error) {
+	query := `
+		SELECT ` + entrySelectColumns + `
+		FROM payroll_entries pe
+		JOIN employees e ON e.id = pe.employee_id
+		WHERE pe.batch_id = $1
+		ORDER BY e.last_name ASC, e.first_name ASC, pe.id ASC
+	`
+	items := make([]Entry, 0)
+	if err := sqlx.SelectContext(ctx, q, &items, query, batchID); err != nil {
+		return nil, fmt.Errorf("list payroll entries: %w", err)
+	}
+	if err := attachEntryLines(ctx, q, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func attachEntryLines(ctx context.Context, q sqlx.QueryerContext, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	entryIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+	}
+
+	const query = `
+		SELECT
+			l.id,
+			l.entry_id,
+			l.component_id,
+			c.code AS component_code,
+			c.name AS component_name,
+			c.component_type,
+			c.is_taxable,
+			c.is_pensionable,
+			c.is_system,
+			l.amount
+		FROM payroll_entry_lines l
+		JOIN payroll_components c ON c.id = l.component_id
+		WHERE l.entry_id = ANY($1)
+		ORDER BY
+			l.entry_id ASC,
+			CASE c.component_type WHEN 'Earning' THEN 1 WHEN 'Deduction' THEN 2 WHEN 'Tax' THEN 3 ELSE 4 END,
+			c.name ASC
+	`
+	lines := make([]EntryLine, 0)
+	if err := sqlx.SelectContext(ctx, q, &lines, query, pq.Array(entryIDs)); err != nil {
+		return fmt.Errorf("list payroll entry lines: %w", err)
+	}
+
+	byEntry := make(map[int64][]EntryLine, len(entries))
+	for _, line := range lines {
+		byEntry[line.EntryID] = append(byEntry[line.EntryID], line)
+	}
+	for i := range entries {
+		entries[i].Lines = byEntry[entries[i].ID]
+		if entries[i].Lines == nil {
+			entries[i].Lines = make([]EntryLine, 0)
+		}
+	}
+	return attachLeaveDeductions(ctx, q, entries, entryIDs)
+}
+
+func attachLeaveDeductions(ctx context.Context, q sqlx.QueryerContext, entries []Entry, entryIDs []int64) error {
+	const query = `
+		SELECT
+			d.entry_id,
+			d.leave_request_id,
+			lt.name AS leave_type,
+			lr.start_date,
+			lr.end_date,
+			d.unpaid_days,
+			d.daily_rate,
+			d.amount
+		FROM payroll_entry_leave_deductions d
+		JOIN leave_requests lr ON lr.id = d.leave_request_id
+		JOIN leave_types lt ON lt.id = lr.leave_type_id
+		WHERE d.entry_id = ANY($1)
+		ORDER BY d.entry_id ASC, lr.start_date ASC, d.leave_request_id ASC
+	`
+	deductions := make([]LeaveDeduction, 0)
+	if err := sqlx.SelectContext(ctx, q, &deductions, query, pq.Array(entryIDs)); err != nil {
+		return fmt.Errorf("list payroll entry leave deductions: %w", err)
+	}
+
+	byEntry := make(map[int64][]LeaveDeduction, len(entries))
+	for _, deduction := range deductions {
+		byEntry[deduction.EntryID] = append(byEntry[deduction.EntryID], deduction)
+	}
+	for i := range entries {
+		entries[i].LeaveDeductions = byEntry[entries[i].ID]
+		if entries[i].LeaveDeductions == nil {
+			entries[i].LeaveDeductions = make([]LeaveDeduction, 0)
+		}
+	}
+	return attachLoanDeductions(ctx, q, entries, entryIDs)
+}
+
+func attachLoanDeductions(ctx context.Context, q sqlx.QueryerContext, entries []Entry, entryIDs []int64) error {
+	const query = `
+		SELECT
+			d.entry_id,
+			d.loan_id,
+			l.loan_type,
+			COALESCE(l.description, '') AS description,
+			d.amount
+		FROM payroll_entry_loan_deductions d
+		JOIN payroll_loans l ON l.id = d.loan_id
+		WHERE d.entry_id = ANY($1)
+		ORDER BY d.entry_id ASC, d.loan_id ASC
+	`
+	deductions := make([]LoanDeduction, 0)
+	if err := sqlx.SelectContext(ctx, q, &deductions, query, pq.Array(entryIDs)); err != nil {
+		return fmt.Errorf("list payroll entry loan deductions: %w", err)
+	}
+
+	byEntry := make(map[int64][]LoanDeduction, len(entries))
+	for _, deduction := range deductions {
+		byEntry[deduction.EntryID] = append(byEntry[deduction.EntryID], deduction)
+	}
+	for i := range entries {
+		entries[i].LoanDeductions = byEntry[entries[i].ID]
+		if entries[i].LoanDeductions == nil {
+			entries[i].LoanDeductions = make([]LoanDeduction, 0)
+		}
+	}
+	return nil
+}
+
+func replaceEntryLines(ctx context.Context, tx *sqlx.Tx, entryID int64, lines []EntryLine) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_entry_lines WHERE entry_id = $1`, entryID); err != nil {
+		return fmt.Errorf("clear payroll entry lines: %w", err)
+	}
+
+	const insertLine = `
+		INSERT INTO payroll_entry_lines (entry_id, component_id, amount)
+		VALUES ($1,$2,$3)
+	`
+	for _, line := range lines {
+		if _, err := tx.ExecContext(ctx, insertLine, entryID, line.ComponentID, line.Amount); err != nil {
+			return fmt.Errorf("insert payroll entry line for component %d: %w", line.ComponentID, err)
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23503" && pqErr.Constraint == constraint
 }
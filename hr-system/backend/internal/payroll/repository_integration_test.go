@@ -23,12 +23,47 @@ func TestGenerateEntriesTransactionalRollback(t *testing.T) {
 
 	ctx := context.Background()
 	setup := []string{
+		`DROP TABLE IF EXISTS payroll_exchange_rates`,
+		`DROP TABLE IF EXISTS payroll_recurring_items`,
+		`DROP TABLE IF EXISTS payroll_entry_loan_deductions`,
+		`DROP TABLE IF EXISTS payroll_loans`,
+		`DROP TABLE IF EXISTS payroll_entry_leave_deductions`,
+		`DROP TABLE IF EXISTS payroll_entry_lines`,
 		`DROP TABLE IF EXISTS payroll_entries`,
 		`DROP TABLE IF EXISTS payroll_batches`,
+		`DROP TABLE IF EXISTS payroll_tax_brackets`,
+		`DROP TABLE IF EXISTS payroll_tax_tables`,
+		`DROP TABLE IF EXISTS payroll_contribution_members`,
+		`DROP TABLE IF EXISTS payroll_contribution_schemes`,
+		`DROP TABLE IF EXISTS payroll_components`,
+		`DROP TABLE IF EXISTS payroll_settings`,
+		`DROP TABLE IF EXISTS leave_requests`,
+		`DROP TABLE IF EXISTS leave_types`,
+		`DROP TABLE IF EXISTS employee_salary_history`,
 		`DROP TABLE IF EXISTS employees`,
-		`CREATE TABLE employees (id BIGINT PRIMARY KEY, employment_status TEXT NOT NULL, base_salary NUMERIC(14,2) NOT NULL)`,
-		`CREATE TABLE payroll_batches (id BIGSERIAL PRIMARY KEY, month TEXT NOT NULL, status TEXT NOT NULL, created_by BIGINT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), approved_by BIGINT, approved_at TIMESTAMPTZ, locked_at TIMESTAMPTZ)`,
-		`CREATE TABLE payroll_entries (id BIGSERIAL PRIMARY KEY, batch_id BIGINT NOT NULL, employee_id BIGINT NOT NULL, base_salary NUMERIC(14,2) NOT NULL, allowances_total NUMERIC(14,2) NOT NULL, deductions_total NUMERIC(14,2) NOT NULL, tax_total NUMERIC(14,2) NOT NULL, gross_pay NUMERIC(14,2) NOT NULL, net_pay NUMERIC(14,2) NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_settings (id SMALLINT PRIMARY KEY, proration_basis TEXT NOT NULL, variance_threshold_percent NUMERIC(7,2) NOT NULL DEFAULT 10, segregation_policy TEXT NOT NULL DEFAULT 'CreatorAndEditors', updated_by BIGINT, updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`INSERT INTO payroll_settings (id, proration_basis) VALUES (1, 'WorkingDays')`,
+		`CREATE TABLE employees (id BIGINT PRIMARY KEY, first_name TEXT NOT NULL DEFAULT '', last_name TEXT NOT NULL DEFAULT '', employment_status TEXT NOT NULL, base_salary NUMERIC(14,2) NOT NULL, salary_currency TEXT NOT NULL DEFAULT 'UGX', tax_residency TEXT NOT NULL DEFAULT 'Resident', hire_date DATE NOT NULL DEFAULT DATE '2020-01-01', termination_date DATE)`,
+		`CREATE TABLE payroll_components (id BIGSERIAL PRIMARY KEY, code TEXT NOT NULL, name TEXT NOT NULL, component_type TEXT NOT NULL, is_taxable BOOLEAN NOT NULL DEFAULT FALSE, is_pensionable BOOLEAN NOT NULL DEFAULT FALSE, is_system BOOLEAN NOT NULL DEFAULT FALSE, is_active BOOLEAN NOT NULL DEFAULT TRUE, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_tax_tables (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL, residency TEXT NOT NULL, version INTEGER NOT NULL, effective_from DATE NOT NULL, created_by BIGINT, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_tax_brackets (id BIGSERIAL PRIMARY KEY, table_id BIGINT NOT NULL, lower_bound NUMERIC(14,2) NOT NULL, upper_bound NUMERIC(14,2), rate NUMERIC(7,4) NOT NULL)`,
+		`CREATE TABLE payroll_contribution_schemes (id BIGSERIAL PRIMARY KEY, code TEXT NOT NULL, name TEXT NOT NULL, employee_rate NUMERIC(7,4) NOT NULL, employer_rate NUMERIC(7,4) NOT NULL, ceiling NUMERIC(14,2), is_mandatory BOOLEAN NOT NULL, is_active BOOLEAN NOT NULL, employee_component_id BIGINT NOT NULL, employer_component_id BIGINT NOT NULL, updated_by BIGINT, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_contribution_members (scheme_id BIGINT NOT NULL, employee_id BIGINT NOT NULL, member_number TEXT, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY (scheme_id, employee_id))`,
+		`CREATE TABLE payroll_batches (id BIGSERIAL PRIMARY KEY, month TEXT NOT NULL, status TEXT NOT NULL, created_by BIGINT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), approved_by BIGINT, approved_at TIMESTAMPTZ, locked_at TIMESTAMPTZ, lock_digest TEXT, corrects_batch_id BIGINT, batch_type TEXT NOT NULL DEFAULT 'Regular', description TEXT)`,
+		`CREATE TABLE payroll_entries (id BIGSERIAL PRIMARY KEY, batch_id BIGINT NOT NULL, employee_id BIGINT NOT NULL, base_salary NUMERIC(14,2) NOT NULL, allowances_total NUMERIC(14,2) NOT NULL, deductions_total NUMERIC(14,2) NOT NULL, tax_total NUMERIC(14,2) NOT NULL, gross_pay NUMERIC(14,2) NOT NULL, net_pay NUMERIC(14,2) NOT NULL, taxable_pay NUMERIC(14,2) NOT NULL DEFAULT 0, pensionable_pay NUMERIC(14,2) NOT NULL DEFAULT 0, employer_contributions_total NUMERIC(14,2) NOT NULL DEFAULT 0, tax_table_id BIGINT, tax_override BOOLEAN NOT NULL DEFAULT FALSE, tax_override_reason TEXT, tax_override_by BIGINT, monthly_base_salary NUMERIC(14,2) NOT NULL, proration_basis TEXT NOT NULL, proration_days_paid INTEGER NOT NULL, proration_period_days INTEGER NOT NULL, proration_factor NUMERIC(7,6) NOT NULL, contract_currency TEXT NOT NULL DEFAULT 'UGX', contract_monthly_salary NUMERIC(14,2) NOT NULL, exchange_rate NUMERIC(18,6) NOT NULL DEFAULT 1, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_entry_lines (id BIGSERIAL PRIMARY KEY, entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE, component_id BIGINT NOT NULL, amount NUMERIC(14,2) NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_entry_leave_deductions (entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE, leave_request_id BIGINT NOT NULL, unpaid_days INTEGER NOT NULL, daily_rate NUMERIC(14,2) NOT NULL, amount NUMERIC(14,2) NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY (entry_id, leave_request_id))`,
+		`CREATE TABLE payroll_recurring_items (id BIGSERIAL PRIMARY KEY, employee_id BIGINT NOT NULL, component_id BIGINT NOT NULL, amount NUMERIC(14,2), percent_of_base NUMERIC(7,4), start_month TEXT NOT NULL, end_month TEXT, note TEXT, created_by BIGINT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_loans (id BIGSERIAL PRIMARY KEY, employee_id BIGINT NOT NULL, loan_type TEXT NOT NULL, description TEXT, principal NUMERIC(14,2) NOT NULL, interest_rate_percent NUMERIC(7,4) NOT NULL DEFAULT 0, total_repayable NUMERIC(14,2) NOT NULL, installments INTEGER NOT NULL, installment_amount NUMERIC(14,2) NOT NULL, start_month TEXT NOT NULL, outstanding_balance NUMERIC(14,2) NOT NULL, status TEXT NOT NULL DEFAULT 'Active', cancel_reason TEXT, created_by BIGINT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`CREATE TABLE payroll_entry_loan_deductions (entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE, loan_id BIGINT NOT NULL, amount NUMERIC(14,2) NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY (entry_id, loan_id))`,
+		`CREATE TABLE payroll_ytd_totals (employee_id BIGINT NOT NULL, fiscal_year INTEGER NOT NULL, gross_pay NUMERIC(14,2) NOT NULL DEFAULT 0, taxable_pay NUMERIC(14,2) NOT NULL DEFAULT 0, pensionable_pay NUMERIC(14,2) NOT NULL DEFAULT 0, tax_total NUMERIC(14,2) NOT NULL DEFAULT 0, deductions_total NUMERIC(14,2) NOT NULL DEFAULT 0, employee_contributions NUMERIC(14,2) NOT NULL DEFAULT 0, employer_contributions NUMERIC(14,2) NOT NULL DEFAULT 0, net_pay NUMERIC(14,2) NOT NULL DEFAULT 0, updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY (employee_id, fiscal_year))`,
+		`CREATE TABLE employee_salary_history (id BIGSERIAL PRIMARY KEY, employee_id BIGINT NOT NULL, base_salary NUMERIC(14,2) NOT NULL, currency TEXT NOT NULL DEFAULT 'UGX', effective_from DATE NOT NULL)`,
+		`CREATE TABLE payroll_exchange_rates (month TEXT NOT NULL, currency TEXT NOT NULL, rate NUMERIC(18,6) NOT NULL, updated_by BIGINT, updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY (month, currency))`,
+		`CREATE TABLE leave_types (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL, is_paid BOOLEAN NOT NULL DEFAULT TRUE)`,
+		`CREATE TABLE leave_requests (id BIGSERIAL PRIMARY KEY, employee_id BIGINT NOT NULL, leave_type_id BIGINT NOT NULL, start_date DATE NOT NULL, end_date DATE NOT NULL, status TEXT NOT NULL)`,
+		`INSERT INTO payroll_components (code, name, component_type, is_system) VALUES ('PAYE', 'PAYE', 'Tax', TRUE)`,
+		`INSERT INTO payroll_tax_tables (id, name, residency, version, effective_from) VALUES (1, 'Test PAYE', 'Resident', 1, DATE '2020-01-01')`,
+		`INSERT INTO payroll_tax_brackets (table_id, lower_bound, upper_bound, rate) VALUES (1, 0, 500, 0), (1, 500, NULL, 0.1)`,
 		`INSERT INTO payroll_batches (id, month, status, created_by) VALUES (1, '2026-02', 'Draft', 1)`,
 		`INSERT INTO employees (id, employment_status, base_salary) VALUES (1, 'Active', 1000), (2, 'Active', 1200)`,
 		`CREATE OR REPLACE FUNCTION fail_second_payroll_insert() RETURNS trigger AS $$ BEGIN IF NEW.employee_id = 2 THEN RAISE EXCEPTION 'boom'; END IF; RETURN NEW; END; $$ LANGUAGE plpgsql`,
@@ -42,13 +77,28 @@ func TestGenerateEntriesTransactionalRollback(t *testing.T) {
 	defer func() {
 		_, _ = db.ExecContext(ctx, `DROP TRIGGER IF EXISTS payroll_entries_fail_second ON payroll_entries`)
 		_, _ = db.ExecContext(ctx, `DROP FUNCTION IF EXISTS fail_second_payroll_insert`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_exchange_rates`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_recurring_items`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_entry_loan_deductions`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_loans`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_entry_leave_deductions`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_entry_lines`)
 		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_entries`)
 		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_batches`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_tax_brackets`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_tax_tables`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_contribution_members`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_contribution_schemes`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_components`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS payroll_settings`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS leave_requests`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS leave_types`)
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS employee_salary_history`)
 		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS employees`)
 	}()
 
 	repo := NewRepository(db)
-	err = repo.GenerateEntriesForBatch(ctx, 1)
+	err = repo.GenerateEntriesForBatch(ctx, 1, 1)
 	if err == nil {
 		t.Fatalf("expected generation failure")
 	}
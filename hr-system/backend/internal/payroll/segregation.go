@@ -0,0 +1,40 @@
+package payroll
+
+// Segregation policies decide who may approve a batch.
+const (
+	// SegregationOff lets any payroll manager approve.
+	SegregationOff = "Off"
+	// SegregationCreator bars the user who created the batch.
+	SegregationCreator = "Creator"
+	// SegregationCreatorAndEditors also bars anyone who generated or edited
+	// the batch's entries.
+	SegregationCreatorAndEditors = "CreatorAndEditors"
+)
+
+// checkSegregationOfDuties returns ErrSegregationOfDuties when policy bars
+// approverID from approving batch.
+func checkSegregationOfDuties(policy string, batch Batch, editors []int64, approverID int64) error {
+	switch policy {
+	case SegregationOff:
+		return nil
+	case SegregationCreator:
+		if batch.CreatedBy == approverID {
+			return ErrSegregationOfDuties
+		}
+		return nil
+	default:
+		if batch.CreatedBy == approverID {
+			return ErrSegregationOfDuties
+		}
+		for _, editor := range editors {
+			if editor == approverID {
+				return ErrSegregationOfDuties
+			}
+		}
+		return nil
+	}
+}
+
+func isValidSegregationPolicy(policy string) bool {
+	return policy == SegregationOff || policy == SegregationCreator || policy == SegregationCreatorAndEditors
+}
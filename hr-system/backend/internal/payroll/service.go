@@ -3,10 +3,15 @@ package payroll
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	"hr-system/backend/internal/auth"
+	"hr-system/backend/internal/employees"
+	"hr-system/backend/internal/money"
 )
 
 type Store interface {
@@ -14,26 +19,100 @@ type Store interface {
 	GetBatch(ctx context.Context, batchID int64) (Batch, error)
 	GetBatchEntries(ctx context.Context, batchID int64) ([]Entry, error)
 	GetEntry(ctx context.Context, entryID int64) (Entry, error)
-	CreateBatch(ctx context.Context, month string, createdBy int64) (Batch, error)
-	GenerateEntriesForBatch(ctx context.Context, batchID int64) error
-	UpdateEntryAmounts(ctx context.Context, entryID int64, allowancesTotal, deductionsTotal, taxTotal, grossPay, netPay float64) (Entry, error)
-	ApproveBatch(ctx context.Context, batchID int64, approvedBy int64, approvedAt time.Time) (Batch, error)
+	CreateBatch(ctx context.Context, input CreateBatchInput, createdBy int64) (Batch, error)
+	GenerateEntriesForBatch(ctx context.Context, batchID int64, actorUserID int64) error
+	UpdateEntryAmounts(ctx context.Context, entryID int64, update EntryUpdate) (Entry, error)
+	ApplyEntryUpdates(ctx context.Context, batchID int64, updates map[int64]EntryUpdate, actorUserID int64) error
+	SignOffBatch(ctx context.Context, batchID int64, stage ApprovalStage, final bool, actorUserID int64, comment string) (Batch, error)
+	RejectBatch(ctx context.Context, batchID int64, stage ApprovalStage, actorUserID int64, comment string) (Batch, error)
+	ListBatchSignOffs(ctx context.Context, batchID int64) ([]BatchSignOff, error)
+	ListApprovalStages(ctx context.Context) ([]ApprovalStage, error)
+	ReplaceApprovalStages(ctx context.Context, stages []ApprovalStageInput, updatedBy int64) ([]ApprovalStage, error)
 	LockBatch(ctx context.Context, batchID int64, lockedAt time.Time) (Batch, error)
+	ReopenBatch(ctx context.Context, batchID int64, actorUserID int64, justification string) (Batch, error)
+	ReverseBatch(ctx context.Context, batchID int64, actorUserID int64, justification string) (Batch, error)
+	ListBatchTransitions(ctx context.Context, batchID int64) ([]BatchTransition, error)
+	ListBatchEditors(ctx context.Context, batchID int64) ([]int64, error)
+	DeleteBatch(ctx context.Context, batchID int64, actorUserID int64) error
+	ListComponents(ctx context.Context) ([]Component, error)
+	GetComponent(ctx context.Context, componentID int64) (Component, error)
+	CreateComponent(ctx context.Context, input ComponentInput) (Component, error)
+	UpdateComponent(ctx context.Context, componentID int64, input ComponentInput) (Component, error)
+	ListTaxTables(ctx context.Context) ([]TaxTable, error)
+	CreateTaxTable(ctx context.Context, table TaxTable) (TaxTable, error)
+	ListContributionSchemes(ctx context.Context) ([]ContributionScheme, error)
+	GetContributionScheme(ctx context.Context, schemeID int64) (ContributionScheme, error)
+	UpdateContributionScheme(ctx context.Context, schemeID int64, input ContributionSchemeInput, updatedBy int64) (ContributionScheme, error)
+	ListContributionMembers(ctx context.Context) ([]ContributionMember, error)
+	UpsertContributionMember(ctx context.Context, schemeID int64, input ContributionMemberInput) (ContributionMember, error)
+	DeleteContributionMember(ctx context.Context, schemeID int64, employeeID int64) error
+	ListBatchEmployeeDetails(ctx context.Context, batchID int64) ([]EmployeeDetails, error)
+	SumEntriesByEmployee(ctx context.Context, fromMonth, toMonth string) (map[int64]Amounts, error)
+	ListPriorMonthIncome(ctx context.Context, month string, excludeBatchID int64) (map[int64]PriorIncome, error)
+	ResolveEmployeeByUserID(ctx context.Context, userID int64) (int64, error)
+	ListEmployeeEntries(ctx context.Context, employeeID int64) ([]EmployeeEntry, error)
+	ListBatchBankPayments(ctx context.Context, batchID int64) ([]BankPayment, error)
+	GetSettings(ctx context.Context) (Settings, error)
+	UpdateSettings(ctx context.Context, input SettingsInput, updatedBy int64) (Settings, error)
+	ListRecurringItems(ctx context.Context, filter RecurringItemFilter) ([]RecurringItem, error)
+	GetRecurringItem(ctx context.Context, itemID int64) (RecurringItem, error)
+	CreateRecurringItem(ctx context.Context, input RecurringItemInput, createdBy int64) (RecurringItem, error)
+	UpdateRecurringItem(ctx context.Context, itemID int64, input RecurringItemInput) (RecurringItem, error)
+	DeleteRecurringItem(ctx context.Context, itemID int64) error
+	ListLoans(ctx context.Context, filter LoanFilter) ([]Loan, error)
+	GetLoan(ctx context.Context, loanID int64) (Loan, error)
+	CreateLoan(ctx context.Context, loan Loan) (Loan, error)
+	CancelLoan(ctx context.Context, loanID int64, reason string) (Loan, error)
+	ListLoanRepayments(ctx context.Context, loanID int64) ([]LoanRepayment, error)
+	GetEmployeeDetails(ctx context.Context, employeeID int64) (EmployeeDetails, error)
+	ListYTDTotals(ctx context.Context, fiscalYear int, employeeID int64) ([]YTDTotals, error)
+	ListLockedMonthlyTotals(ctx context.Context, fromMonth, toMonth string, employeeID int64) ([]MonthlyTotals, error)
+	RebuildYTDTotals(ctx context.Context, fiscalYear int, actorUserID int64) error
+	ListProjects(ctx context.Context) ([]Project, error)
+	CreateProject(ctx context.Context, input ProjectInput) (Project, error)
+	UpdateProject(ctx context.Context, projectID int64, input ProjectInput) (Project, error)
+	ListCostAllocations(ctx context.Context, employeeID int64) ([]CostAllocation, error)
+	ReplaceCostAllocation(ctx context.Context, employeeID int64, input CostAllocationInput, createdBy int64) ([]CostAllocation, error)
+	DeleteCostAllocation(ctx context.Context, employeeID int64, effectiveFrom string) error
+	ListGLAccounts(ctx context.Context) ([]GLAccount, error)
+	ReplaceGLAccounts(ctx context.Context, accounts []GLAccountInput, updatedBy int64) ([]GLAccount, error)
+	GetJournalLayout(ctx context.Context) (JournalLayout, error)
+	UpdateJournalLayout(ctx context.Context, input JournalLayoutInput, updatedBy int64) (JournalLayout, error)
+	ListExchangeRates(ctx context.Context, month string) ([]ExchangeRate, error)
+	UpsertExchangeRate(ctx context.Context, input ExchangeRateInput, updatedBy int64) (ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, month, currency string) error
+	SimulateEntries(ctx context.Context, input SimulationInput) ([]Entry, error)
+	ListEmployeeDetails(ctx context.Context, employeeIDs []int64) ([]EmployeeDetails, error)
 }
 
 type Service struct {
-	store Store
+	store          Store
+	employer       Employer
+	bankFormatters map[string]BankFormatter
 }
 
-func NewService(store Store) (*Service, error) {
+func NewService(store Store, employer Employer) (*Service, error) {
 	if store == nil {
 		return nil, fmt.Errorf("payroll store is required")
 	}
-	return &Service{store: store}, nil
+	service := &Service{store: store, employer: employer, bankFormatters: make(map[string]BankFormatter)}
+	for _, formatter := range defaultBankFormatters() {
+		service.RegisterBankFormatter(formatter)
+	}
+	return service, nil
+}
+
+// RegisterBankFormatter adds or replaces a bank file layout, keyed by its code.
+func (s *Service) RegisterBankFormatter(formatter BankFormatter) {
+	s.bankFormatters[formatter.Code()] = formatter
 }
 
 func (s *Service) ListBatches(ctx context.Context, actor Actor, filter BatchFilter) (BatchListResult, error) {
-	if !canManagePayroll(actor.Role) {
+	allowed, err := s.canViewBatches(ctx, actor.Role)
+	if err != nil {
+		return BatchListResult{}, err
+	}
+	if !allowed {
 		return BatchListResult{}, ErrForbidden
 	}
 	if filter.Month != "" && !isValidMonth(filter.Month) {
@@ -42,6 +121,9 @@ func (s *Service) ListBatches(ctx context.Context, actor Actor, filter BatchFilt
 	if filter.Status != "" && !isValidStatus(filter.Status) {
 		return BatchListResult{}, ErrInvalidInput
 	}
+	if filter.BatchType != "" && !isValidBatchType(filter.BatchType) {
+		return BatchListResult{}, ErrInvalidInput
+	}
 
 	items, err := s.store.ListBatches(ctx, filter)
 	if err != nil {
@@ -51,7 +133,11 @@ func (s *Service) ListBatches(ctx context.Context, actor Actor, filter BatchFilt
 }
 
 func (s *Service) GetBatch(ctx context.Context, actor Actor, batchID int64) (BatchDetail, error) {
-	if !canManagePayroll(actor.Role) {
+	allowed, err := s.canViewBatches(ctx, actor.Role)
+	if err != nil {
+		return BatchDetail{}, err
+	}
+	if !allowed {
 		return BatchDetail{}, ErrForbidden
 	}
 	if batchID <= 0 {
@@ -66,18 +152,64 @@ func (s *Service) GetBatch(ctx context.Context, actor Actor, batchID int64) (Bat
 	if err != nil {
 		return BatchDetail{}, err
 	}
-	return BatchDetail{Batch: batch, Entries: entries}, nil
+	transitions, err := s.store.ListBatchTransitions(ctx, batchID)
+	if err != nil {
+		return BatchDetail{}, err
+	}
+	stages, err := s.store.ListApprovalStages(ctx)
+	if err != nil {
+		return BatchDetail{}, err
+	}
+	signOffs, err := s.store.ListBatchSignOffs(ctx, batchID)
+	if err != nil {
+		return BatchDetail{}, err
+	}
+	detail := BatchDetail{
+		Batch:       batch,
+		Entries:     entries,
+		Totals:      SumAmounts(entries),
+		Transitions: transitions,
+		Stages:      stages,
+		SignOffs:    signOffs,
+	}
+	if batch.Status == StatusDraft || batch.Status == StatusInApproval {
+		if stage, ok := nextApprovalStage(stages, signOffs); ok {
+			detail.NextStage = &stage
+		}
+	}
+	return detail, nil
 }
 
 func (s *Service) CreateBatch(ctx context.Context, actor Actor, input CreateBatchInput) (Batch, error) {
 	if !canManagePayroll(actor.Role) {
 		return Batch{}, ErrForbidden
 	}
-	month := strings.TrimSpace(input.Month)
-	if !isValidMonth(month) {
+	input.Month = strings.TrimSpace(input.Month)
+	if !isValidMonth(input.Month) {
+		return Batch{}, ErrInvalidInput
+	}
+	input.BatchType = strings.TrimSpace(input.BatchType)
+	if input.BatchType == "" {
+		input.BatchType = BatchTypeRegular
+	}
+	if !isValidBatchType(input.BatchType) {
+		return Batch{}, ErrInvalidInput
+	}
+	input.Description = strings.TrimSpace(input.Description)
+
+	// A regular batch always pays everyone on the payroll; an off-cycle batch
+	// pays only the employees named for it.
+	if input.BatchType == BatchTypeRegular {
+		input.EmployeeIDs = nil
+	} else if len(input.EmployeeIDs) == 0 {
 		return Batch{}, ErrInvalidInput
 	}
-	return s.store.CreateBatch(ctx, month, actor.UserID)
+	for _, employeeID := range input.EmployeeIDs {
+		if employeeID <= 0 {
+			return Batch{}, ErrInvalidInput
+		}
+	}
+	return s.store.CreateBatch(ctx, input, actor.UserID)
 }
 
 func (s *Service) GenerateEntries(ctx context.Context, actor Actor, batchID int64) error {
@@ -87,7 +219,7 @@ func (s *Service) GenerateEntries(ctx context.Context, actor Actor, batchID int6
 	if batchID <= 0 {
 		return ErrInvalidInput
 	}
-	return s.store.GenerateEntriesForBatch(ctx, batchID)
+	return s.store.GenerateEntriesForBatch(ctx, batchID, actor.UserID)
 }
 
 func (s *Service) UpdateEntryAmounts(ctx context.Context, actor Actor, entryID int64, input UpdateEntryAmountsInput) (Entry, error) {
@@ -97,8 +229,17 @@ func (s *Service) UpdateEntryAmounts(ctx context.Context, actor Actor, entryID i
 	if entryID <= 0 {
 		return Entry{}, ErrInvalidInput
 	}
-	if input.AllowancesTotal < 0 || input.DeductionsTotal < 0 || input.TaxTotal < 0 {
-		return Entry{}, ErrInvalidInput
+	if err := validateLineInputs(input.Lines); err != nil {
+		return Entry{}, err
+	}
+	overrideReason := strings.TrimSpace(input.TaxOverrideReason)
+	if input.TaxOverride != nil {
+		if input.TaxOverride.IsNegative() {
+			return Entry{}, ErrInvalidInput
+		}
+		if overrideReason == "" {
+			return Entry{}, ErrTaxOverrideReason
+		}
 	}
 
 	entry, err := s.store.GetEntry(ctx, entryID)
@@ -113,11 +254,298 @@ func (s *Service) UpdateEntryAmounts(ctx context.Context, actor Actor, entryID i
 		return Entry{}, ErrBatchImmutable
 	}
 
-	grossPay, netPay := CalculateAmounts(entry.BaseSalary, input.AllowancesTotal, input.DeductionsTotal, input.TaxTotal)
-	return s.store.UpdateEntryAmounts(ctx, entryID, input.AllowancesTotal, input.DeductionsTotal, input.TaxTotal, grossPay, netPay)
+	recalculator, err := s.newEntryRecalculator(ctx, batch)
+	if err != nil {
+		return Entry{}, err
+	}
+	update, err := recalculator.recalculate(entry, input.Lines, input.TaxOverride, overrideReason)
+	if err != nil {
+		return Entry{}, err
+	}
+	update.UpdatedBy = actor.UserID
+	return s.store.UpdateEntryAmounts(ctx, entryID, update)
+}
+
+// ImportEntryAmounts updates a Draft batch's entries from a CSV or XLSX sheet
+// (see ImportEntriesInput). Every row is validated and recalculated first;
+// the updates are applied together in one transaction, and only when the
+// sheet has no errors and DryRun is off.
+func (s *Service) ImportEntryAmounts(ctx context.Context, actor Actor, batchID int64, input ImportEntriesInput) (ImportResult, error) {
+	if !canManagePayroll(actor.Role) {
+		return ImportResult{}, ErrForbidden
+	}
+	if batchID <= 0 || len(input.Content) == 0 {
+		return ImportResult{}, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if batch.Status != StatusDraft {
+		return ImportResult{}, ErrBatchImmutable
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	employees, err := s.store.ListBatchEmployeeDetails(ctx, batchID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	recalculator, err := s.newEntryRecalculator(ctx, batch)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	var plan importPlan
+	sheet, err := readImportSheet(input.FileName, input.Content)
+	if err != nil {
+		plan.result = ImportResult{Errors: []string{fmt.Sprintf("the file could not be read: %v", err)}, Rows: make([]ImportRow, 0)}
+	} else {
+		plan = planImport(sheet, entries, employees, recalculator.components)
+	}
+	plan.result.BatchID = batchID
+	plan.result.DryRun = input.DryRun
+
+	updates := make(map[int64]EntryUpdate, len(plan.entries))
+	for i, entry := range plan.entries {
+		row := &plan.result.Rows[plan.rows[i]]
+		// A tax override stays in place; only the lines change.
+		var taxOverride *money.Amount
+		if entry.TaxOverride {
+			taxOverride = &entry.TaxTotal
+		}
+		update, err := recalculator.recalculate(entry, plan.inputs[i], taxOverride, entry.TaxOverrideReason)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		row.NetPay = update.Amounts.NetPay
+		if len(row.Changes) == 0 {
+			continue
+		}
+		update.UpdatedBy = actor.UserID
+		updates[entry.ID] = update
+	}
+	plan.result.ErrorCount = countImportErrors(plan.result)
+	plan.result.UpdatedCount = len(updates)
+	if input.DryRun || plan.result.ErrorCount > 0 || len(updates) == 0 {
+		return plan.result, nil
+	}
+
+	if err := s.store.ApplyEntryUpdates(ctx, batchID, updates, actor.UserID); err != nil {
+		return ImportResult{}, err
+	}
+	plan.result.Applied = true
+	return plan.result, nil
+}
+
+// entryRecalculator holds what recomputing a batch's entries needs, loaded
+// once so an import of many rows does not reload it per entry.
+type entryRecalculator struct {
+	batch       Batch
+	components  []Component
+	schemes     []ContributionScheme
+	members     []ContributionMember
+	tables      []TaxTable
+	priorIncome map[int64]PriorIncome
+}
+
+func (s *Service) newEntryRecalculator(ctx context.Context, batch Batch) (entryRecalculator, error) {
+	components, err := s.store.ListComponents(ctx)
+	if err != nil {
+		return entryRecalculator{}, err
+	}
+	schemes, err := s.store.ListContributionSchemes(ctx)
+	if err != nil {
+		return entryRecalculator{}, err
+	}
+	members, err := s.store.ListContributionMembers(ctx)
+	if err != nil {
+		return entryRecalculator{}, err
+	}
+	tables, err := s.store.ListTaxTables(ctx)
+	if err != nil {
+		return entryRecalculator{}, err
+	}
+	priorIncome, err := s.store.ListPriorMonthIncome(ctx, batch.Month, batch.ID)
+	if err != nil {
+		return entryRecalculator{}, err
+	}
+	return entryRecalculator{
+		batch:       batch,
+		components:  components,
+		schemes:     schemes,
+		members:     members,
+		tables:      tables,
+		priorIncome: priorIncome,
+	}, nil
+}
+
+// recalculate replaces the entry's editable lines with inputs and recomputes
+// its totals. PAYE comes from the tax table unless taxOverride is set.
+func (r entryRecalculator) recalculate(entry Entry, inputs []EntryLineInput, taxOverride *money.Amount, overrideReason string) (EntryUpdate, error) {
+	lines, err := buildEntryLines(entry.ID, inputs, r.components)
+	if err != nil {
+		return EntryUpdate{}, err
+	}
+	calculation := CalculationInput{
+		BaseSalary:       entry.BaseSalary,
+		Lines:            lines,
+		Components:       r.components,
+		Schemes:          SchemesForEmployee(r.schemes, r.members, entry.EmployeeID),
+		TaxOverride:      taxOverride,
+		UnpaidLeave:      TotalLeaveDeductions(entry.LeaveDeductions),
+		LoanInstallments: TotalLoanDeductions(entry.LoanDeductions),
+	}
+	var update EntryUpdate
+	if taxOverride != nil {
+		update.TaxOverride = true
+		update.TaxOverrideReason = overrideReason
+	} else {
+		calculation.PriorIncome = r.priorIncome[entry.EmployeeID]
+		table, ok := SelectTaxTable(r.tables, entry.TaxResidency, r.batch.Month)
+		if !ok {
+			return EntryUpdate{}, ErrTaxTableNotFound
+		}
+		calculation.TaxTable = &table
+		update.TaxTableID = &table.ID
+	}
+
+	result, err := Calculate(calculation)
+	if err != nil {
+		return EntryUpdate{}, err
+	}
+	update.Lines = result.Lines
+	update.Amounts = result.Amounts
+	return update, nil
 }
 
+// ApproveBatch signs the batch's next approval stage without a comment.
 func (s *Service) ApproveBatch(ctx context.Context, actor Actor, batchID int64) (Batch, error) {
+	return s.SignOffBatch(ctx, actor, batchID, SignOffInput{})
+}
+
+// SignOffBatch signs the next stage of the approval chain on behalf of actor,
+// who must hold that stage's role or be an Admin. The first sign-off moves a
+// Draft batch to In Approval; the last one approves it.
+func (s *Service) SignOffBatch(ctx context.Context, actor Actor, batchID int64, input SignOffInput) (Batch, error) {
+	if batchID <= 0 {
+		return Batch{}, ErrInvalidInput
+	}
+	batch, stage, final, err := s.pendingApprovalStage(ctx, actor, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if batch.Status != StatusDraft && batch.Status != StatusInApproval {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		return Batch{}, err
+	}
+	editors, err := s.store.ListBatchEditors(ctx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if err := checkSegregationOfDuties(settings.SegregationPolicy, batch, editors, actor.UserID); err != nil {
+		return Batch{}, err
+	}
+	return s.store.SignOffBatch(ctx, batchID, stage, final, actor.UserID, strings.TrimSpace(input.Comment))
+}
+
+// RejectBatch turns down a batch in approval at its next stage and returns
+// it to Draft. The comment is required so preparers know what to fix.
+func (s *Service) RejectBatch(ctx context.Context, actor Actor, batchID int64, input SignOffInput) (Batch, error) {
+	if batchID <= 0 {
+		return Batch{}, ErrInvalidInput
+	}
+	comment := strings.TrimSpace(input.Comment)
+	if comment == "" {
+		return Batch{}, ErrJustificationRequired
+	}
+	batch, stage, _, err := s.pendingApprovalStage(ctx, actor, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if batch.Status != StatusInApproval {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+	return s.store.RejectBatch(ctx, batchID, stage, actor.UserID, comment)
+}
+
+// pendingApprovalStage loads the batch and the stage awaiting sign-off, and
+// checks that actor may sign it. final reports whether it is the
+// last stage of the chain.
+func (s *Service) pendingApprovalStage(ctx context.Context, actor Actor, batchID int64) (Batch, ApprovalStage, bool, error) {
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return Batch{}, ApprovalStage{}, false, err
+	}
+	stages, err := s.store.ListApprovalStages(ctx)
+	if err != nil {
+		return Batch{}, ApprovalStage{}, false, err
+	}
+	if !isApproverRole(stages, actor.Role) {
+		return Batch{}, ApprovalStage{}, false, ErrForbidden
+	}
+	signOffs, err := s.store.ListBatchSignOffs(ctx, batchID)
+	if err != nil {
+		return Batch{}, ApprovalStage{}, false, err
+	}
+	stage, ok := nextApprovalStage(stages, signOffs)
+	if !ok {
+		return Batch{}, ApprovalStage{}, false, ErrInvalidStatusTransition
+	}
+	if !canSignStage(stage, actor.Role) {
+		return Batch{}, ApprovalStage{}, false, ErrForbidden
+	}
+	final := true
+	for _, other := range stages {
+		if other.Position > stage.Position {
+			final = false
+		}
+	}
+	return batch, stage, final, nil
+}
+
+func (s *Service) ListApprovalStages(ctx context.Context, actor Actor) ([]ApprovalStage, error) {
+	stages, err := s.store.ListApprovalStages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !canManagePayroll(actor.Role) && !isApproverRole(stages, actor.Role) {
+		return nil, ErrForbidden
+	}
+	return stages, nil
+}
+
+// UpdateApprovalStages replaces the approval chain; stages are signed in the
+// order given. Each stage must name a role users can hold, or the chain could
+// never be completed. It is refused while any batch is part-way through
+// approval.
+func (s *Service) UpdateApprovalStages(ctx context.Context, actor Actor, stages []ApprovalStageInput) ([]ApprovalStage, error) {
+	if !isAdmin(actor.Role) {
+		return nil, ErrForbidden
+	}
+	if len(stages) == 0 {
+		return nil, ErrInvalidInput
+	}
+	cleaned := make([]ApprovalStageInput, 0, len(stages))
+	for _, stage := range stages {
+		stage.Name = strings.TrimSpace(stage.Name)
+		stage.Role = strings.TrimSpace(stage.Role)
+		if stage.Name == "" || !auth.IsKnownRole(stage.Role) {
+			return nil, ErrInvalidInput
+		}
+		cleaned = append(cleaned, stage)
+	}
+	return s.store.ReplaceApprovalStages(ctx, cleaned, actor.UserID)
+}
+
+func (s *Service) LockBatch(ctx context.Context, actor Actor, batchID int64) (Batch, error) {
 	if !canManagePayroll(actor.Role) {
 		return Batch{}, ErrForbidden
 	}
@@ -129,19 +557,45 @@ func (s *Service) ApproveBatch(ctx context.Context, actor Actor, batchID int64)
 	if err != nil {
 		return Batch{}, err
 	}
-	if batch.Status != StatusDraft {
+	if batch.Status != StatusApproved {
 		return Batch{}, ErrInvalidStatusTransition
 	}
-	return s.store.ApproveBatch(ctx, batchID, actor.UserID, time.Now().UTC())
+	return s.store.LockBatch(ctx, batchID, time.Now().UTC())
 }
 
-func (s *Service) LockBatch(ctx context.Context, actor Actor, batchID int64) (Batch, error) {
+// DeleteBatch lets a Master user remove a mistakenly created month while it
+// is still Draft, freeing the month for a new batch.
+func (s *Service) DeleteBatch(ctx context.Context, actor Actor, batchID int64) error {
+	if !isMaster(actor.Role) {
+		return ErrForbidden
+	}
+	if batchID <= 0 {
+		return ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status != StatusDraft {
+		return ErrBatchImmutable
+	}
+	return s.store.DeleteBatch(ctx, batchID, actor.UserID)
+}
+
+// ReopenBatch sends an approved batch back to Draft so errors found after
+// approval can be fixed. The justification is kept in the batch history.
+func (s *Service) ReopenBatch(ctx context.Context, actor Actor, batchID int64, input BatchTransitionInput) (Batch, error) {
 	if !canManagePayroll(actor.Role) {
 		return Batch{}, ErrForbidden
 	}
 	if batchID <= 0 {
 		return Batch{}, ErrInvalidInput
 	}
+	justification := strings.TrimSpace(input.Justification)
+	if justification == "" {
+		return Batch{}, ErrJustificationRequired
+	}
 
 	batch, err := s.store.GetBatch(ctx, batchID)
 	if err != nil {
@@ -150,7 +604,43 @@ func (s *Service) LockBatch(ctx context.Context, actor Actor, batchID int64) (Ba
 	if batch.Status != StatusApproved {
 		return Batch{}, ErrInvalidStatusTransition
 	}
-	return s.store.LockBatch(ctx, batchID, time.Now().UTC())
+	// Batches of the month in approval or approved withheld PAYE on top of
+	// this one's pay; reopening it would leave their tax stale.
+	others, err := s.store.ListBatches(ctx, BatchFilter{Month: batch.Month})
+	if err != nil {
+		return Batch{}, err
+	}
+	for _, other := range others {
+		if other.ID != batch.ID && other.Month == batch.Month && (other.Status == StatusInApproval || other.Status == StatusApproved) {
+			return Batch{}, ErrMonthBatchPending
+		}
+	}
+	return s.store.ReopenBatch(ctx, batchID, actor.UserID, justification)
+}
+
+// ReverseBatch is the Master user's recourse for a locked batch: it is
+// marked Reversed, drops out of exports and year-to-date figures, and a Draft
+// correcting batch for the same month is returned in its place.
+func (s *Service) ReverseBatch(ctx context.Context, actor Actor, batchID int64, input BatchTransitionInput) (Batch, error) {
+	if !isMaster(actor.Role) {
+		return Batch{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return Batch{}, ErrInvalidInput
+	}
+	justification := strings.TrimSpace(input.Justification)
+	if justification == "" {
+		return Batch{}, ErrJustificationRequired
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if batch.Status != StatusLocked {
+		return Batch{}, ErrInvalidStatusTransition
+	}
+	return s.store.ReverseBatch(ctx, batchID, actor.UserID, justification)
 }
 
 func (s *Service) ExportBatchCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
@@ -174,21 +664,41 @@ func (s *Service) ExportBatchCSV(ctx context.Context, actor Actor, batchID int64
 		return "", err
 	}
 
+	components, err := s.store.ListComponents(ctx)
+	if err != nil {
+		return "", err
+	}
+	columns := exportComponents(components, entries)
+
+	header := []string{"Employee Name", "Base Salary", "Proration Factor"}
+	for _, component := range columns {
+		header = append(header, component.Name)
+	}
+	header = append(header, "Allowances", "Deductions", "Tax", "Gross Pay", "Net Pay", "Employer Contributions")
+
 	var sb strings.Builder
 	writer := csv.NewWriter(&sb)
-	if writeErr := writer.Write([]string{"Employee Name", "Base Salary", "Allowances", "Deductions", "Tax", "Gross Pay", "Net Pay"}); writeErr != nil {
+	if writeErr := writer.Write(header); writeErr != nil {
 		return "", fmt.Errorf("write payroll csv header: %w", writeErr)
 	}
 	for _, entry := range entries {
-		record := []string{
-			entry.EmployeeName,
-			toMoney(entry.BaseSalary),
-			toMoney(entry.AllowancesTotal),
-			toMoney(entry.DeductionsTotal),
-			toMoney(entry.TaxTotal),
-			toMoney(entry.GrossPay),
-			toMoney(entry.NetPay),
+		amountsByComponent := make(map[int64]money.Amount, len(entry.Lines))
+		for _, line := range entry.Lines {
+			amountsByComponent[line.ComponentID] = amountsByComponent[line.ComponentID].Add(line.Amount)
+		}
+
+		record := []string{entry.EmployeeName, entry.BaseSalary.String(), strconv.FormatFloat(entry.ProrationFactor, 'f', 4, 64)}
+		for _, component := range columns {
+			record = append(record, amountsByComponent[component.ID].String())
 		}
+		record = append(record,
+			entry.AllowancesTotal.String(),
+			entry.DeductionsTotal.String(),
+			entry.TaxTotal.String(),
+			entry.GrossPay.String(),
+			entry.NetPay.String(),
+			entry.EmployerContributionsTotal.String(),
+		)
 		if writeErr := writer.Write(record); writeErr != nil {
 			return "", fmt.Errorf("write payroll csv row: %w", writeErr)
 		}
@@ -200,24 +710,1705 @@ func (s *Service) ExportBatchCSV(ctx context.Context, actor Actor, batchID int64
 	return sb.String(), nil
 }
 
-func canManagePayroll(role string) bool {
-	return role == "Admin" || role == "Finance Officer"
+// RenderPayslip renders a single entry's payslip as a PDF. Payslips are only
+// issued once the batch has been approved.
+func (s *Service) RenderPayslip(ctx context.Context, actor Actor, entryID int64) (File, error) {
+	if !canManagePayroll(actor.Role) {
+		return File{}, ErrForbidden
+	}
+	if entryID <= 0 {
+		return File{}, ErrInvalidInput
+	}
+
+	entry, err := s.store.GetEntry(ctx, entryID)
+	if err != nil {
+		return File{}, err
+	}
+	slips, err := s.payslips(ctx, entry.BatchID, []Entry{entry})
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:        PayslipFileName(slips[0]),
+		ContentType: "application/pdf",
+		Content:     RenderPayslipsPDF(slips),
+	}, nil
 }
 
-func isValidMonth(month string) bool {
-	_, err := time.Parse("2006-01", strings.TrimSpace(month))
-	return err == nil
+// RenderBatchPayslips renders every payslip in a batch, either as one PDF per
+// employee inside a zip archive or as a single merged PDF for printing.
+func (s *Service) RenderBatchPayslips(ctx context.Context, actor Actor, batchID int64, format string) (File, error) {
+	if !canManagePayroll(actor.Role) {
+		return File{}, ErrForbidden
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if batchID <= 0 || (format != PayslipFormatZip && format != PayslipFormatPDF) {
+		return File{}, ErrInvalidInput
+	}
+
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return File{}, err
+	}
+	slips, err := s.payslips(ctx, batchID, entries)
+	if err != nil {
+		return File{}, err
+	}
+	// An empty batch has no payslips to bundle.
+	if len(slips) == 0 {
+		return File{}, ErrInvalidInput
+	}
+
+	month := slips[0].Month
+	if format == PayslipFormatPDF {
+		return File{
+			Name:        fmt.Sprintf("payslips-%s-batch-%d.pdf", month, batchID),
+			ContentType: "application/pdf",
+			Content:     RenderPayslipsPDF(slips),
+		}, nil
+	}
+	archive, err := ZipPayslips(slips)
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:        fmt.Sprintf("payslips-%s-batch-%d.zip", month, batchID),
+		ContentType: "application/zip",
+		Content:     archive,
+	}, nil
 }
 
-func isValidStatus(status string) bool {
-	switch strings.TrimSpace(status) {
-	case StatusDraft, StatusApproved, StatusLocked:
-		return true
-	default:
-		return false
+// ListMyEntries is the self-service view: any signed-in user linked to an
+// employee record sees their own entries from approved and locked batches.
+func (s *Service) ListMyEntries(ctx context.Context, actor Actor) ([]EmployeeEntry, error) {
+	employeeID, err := s.store.ResolveEmployeeByUserID(ctx, actor.UserID)
+	if err != nil {
+		return nil, ErrForbidden
+	}
+	return s.store.ListEmployeeEntries(ctx, employeeID)
+}
+
+// RenderMyPayslip renders the caller's own payslip. Entries belonging to
+// someone else are forbidden and draft entries are reported as not found.
+func (s *Service) RenderMyPayslip(ctx context.Context, actor Actor, entryID int64) (File, error) {
+	if entryID <= 0 {
+		return File{}, ErrInvalidInput
+	}
+	employeeID, err := s.store.ResolveEmployeeByUserID(ctx, actor.UserID)
+	if err != nil {
+		return File{}, ErrForbidden
+	}
+
+	entry, err := s.store.GetEntry(ctx, entryID)
+	if err != nil {
+		return File{}, err
 	}
+	if entry.EmployeeID != employeeID {
+		return File{}, ErrForbidden
+	}
+	slips, err := s.payslips(ctx, entry.BatchID, []Entry{entry})
+	if errors.Is(err, ErrBatchImmutable) {
+		return File{}, ErrEntryNotFound
+	}
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:        PayslipFileName(slips[0]),
+		ContentType: "application/pdf",
+		Content:     RenderPayslipsPDF(slips),
+	}, nil
 }
 
-func toMoney(value float64) string {
-	return strconv.FormatFloat(value, 'f', 2, 64)
+func (s *Service) payslips(ctx context.Context, batchID int64, entries []Entry) ([]Payslip, error) {
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != StatusApproved && batch.Status != StatusLocked {
+		return nil, ErrBatchImmutable
+	}
+
+	details, err := s.store.ListBatchEmployeeDetails(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	employees := make(map[int64]EmployeeDetails, len(details))
+	for _, detail := range details {
+		employees[detail.ID] = detail
+	}
+
+	fiscalStart, err := FiscalYearStart(batch.Month)
+	if err != nil {
+		return nil, fmt.Errorf("resolve fiscal year for %s: %w", batch.Month, err)
+	}
+	yearToDate, err := s.store.SumEntriesByEmployee(ctx, fiscalStart, batch.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	schemes, err := s.store.ListContributionSchemes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.store.ListContributionMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slips := make([]Payslip, 0, len(entries))
+	for _, entry := range entries {
+		employee, ok := employees[entry.EmployeeID]
+		if !ok {
+			employee = EmployeeDetails{ID: entry.EmployeeID, Name: entry.EmployeeName, TaxResidency: entry.TaxResidency}
+		}
+		slips = append(slips, BuildPayslip(s.employer, employee, batch, entry, yearToDate[entry.EmployeeID], schemes, members))
+	}
+	return slips, nil
+}
+
+func (s *Service) ListBankFormats(_ context.Context, actor Actor) ([]BankFormat, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return sortedFormats(s.bankFormatters), nil
+}
+
+// ValidateBankFile reports every missing or invalid account detail that would
+// stop the batch being exported in the given layout.
+func (s *Service) ValidateBankFile(ctx context.Context, actor Actor, batchID int64, format string) ([]BankValidationIssue, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	transfer, formatter, err := s.bankTransfer(ctx, batchID, format)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateBankTransfer(transfer, formatter), nil
+}
+
+// ExportBankFile renders the bulk-payment file for an approved or locked
+// batch. Nothing is produced while any payment fails validation.
+func (s *Service) ExportBankFile(ctx context.Context, actor Actor, batchID int64, format string) (File, error) {
+	if !canManagePayroll(actor.Role) {
+		return File{}, ErrForbidden
+	}
+	transfer, formatter, err := s.bankTransfer(ctx, batchID, format)
+	if err != nil {
+		return File{}, err
+	}
+	if issues := ValidateBankTransfer(transfer, formatter); len(issues) > 0 {
+		return File{}, &BankValidationError{Issues: issues}
+	}
+
+	content, err := formatter.Format(transfer)
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:        fmt.Sprintf("bank-payments-%s-batch-%d.%s", transfer.Batch.Month, batchID, formatter.FileExtension()),
+		ContentType: formatter.ContentType(),
+		Content:     content,
+	}, nil
+}
+
+func (s *Service) bankTransfer(ctx context.Context, batchID int64, format string) (BankTransfer, BankFormatter, error) {
+	formatter, ok := s.bankFormatters[strings.ToLower(strings.TrimSpace(format))]
+	if batchID <= 0 || !ok {
+		return BankTransfer{}, nil, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return BankTransfer{}, nil, err
+	}
+	if batch.Status != StatusApproved && batch.Status != StatusLocked {
+		return BankTransfer{}, nil, ErrBatchImmutable
+	}
+	payments, err := s.store.ListBatchBankPayments(ctx, batchID)
+	if err != nil {
+		return BankTransfer{}, nil, err
+	}
+
+	// Employees whose deductions absorb all their pay have nothing to transfer.
+	payable := make([]BankPayment, 0, len(payments))
+	for _, payment := range payments {
+		if !payment.Amount.IsZero() {
+			payable = append(payable, payment)
+		}
+	}
+	now := time.Now().UTC()
+	return BankTransfer{
+		Batch:         batch,
+		Debtor:        s.employer,
+		Currency:      BankCurrency,
+		ExecutionDate: now,
+		CreatedAt:     now,
+		Payments:      payable,
+	}, formatter, nil
+}
+
+func (s *Service) GetSettings(ctx context.Context, actor Actor) (Settings, error) {
+	if !canManagePayroll(actor.Role) {
+		return Settings{}, ErrForbidden
+	}
+	return s.store.GetSettings(ctx)
+}
+
+// UpdateSettings changes organisation-wide payroll rules. They apply the next
+// time a draft batch is generated; existing entries keep what they recorded.
+func (s *Service) UpdateSettings(ctx context.Context, actor Actor, input SettingsInput) (Settings, error) {
+	if !isAdmin(actor.Role) {
+		return Settings{}, ErrForbidden
+	}
+	// Settings left out of the input keep their current value.
+	current, err := s.store.GetSettings(ctx)
+	if err != nil {
+		return Settings{}, err
+	}
+	input.ProrationBasis = strings.TrimSpace(input.ProrationBasis)
+	if input.ProrationBasis == "" {
+		input.ProrationBasis = current.ProrationBasis
+	}
+	if !isValidProrationBasis(input.ProrationBasis) {
+		return Settings{}, ErrInvalidInput
+	}
+	if input.VarianceThresholdPercent == nil {
+		input.VarianceThresholdPercent = &current.VarianceThresholdPercent
+	}
+	if *input.VarianceThresholdPercent < 0 || *input.VarianceThresholdPercent > 1000 {
+		return Settings{}, ErrInvalidInput
+	}
+	input.SegregationPolicy = strings.TrimSpace(input.SegregationPolicy)
+	if input.SegregationPolicy == "" {
+		input.SegregationPolicy = current.SegregationPolicy
+	}
+	if !isValidSegregationPolicy(input.SegregationPolicy) {
+		return Settings{}, ErrInvalidInput
+	}
+	return s.store.UpdateSettings(ctx, input, actor.UserID)
+}
+
+func (s *Service) ListComponents(ctx context.Context, actor Actor) ([]Component, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return s.store.ListComponents(ctx)
+}
+
+func (s *Service) CreateComponent(ctx context.Context, actor Actor, input ComponentInput) (Component, error) {
+	if !canManagePayroll(actor.Role) {
+		return Component{}, ErrForbidden
+	}
+	normalized, err := normalizeComponentInput(input)
+	if err != nil {
+		return Component{}, err
+	}
+	return s.store.CreateComponent(ctx, normalized)
+}
+
+func (s *Service) UpdateComponent(ctx context.Context, actor Actor, componentID int64, input ComponentInput) (Component, error) {
+	if !canManagePayroll(actor.Role) {
+		return Component{}, ErrForbidden
+	}
+	if componentID <= 0 {
+		return Component{}, ErrInvalidInput
+	}
+	normalized, err := normalizeComponentInput(input)
+	if err != nil {
+		return Component{}, err
+	}
+
+	existing, err := s.store.GetComponent(ctx, componentID)
+	if err != nil {
+		return Component{}, err
+	}
+	// Code and type identify how historic lines were totalled, so they are fixed once created.
+	if existing.Code != normalized.Code || existing.Type != normalized.Type {
+		return Component{}, ErrInvalidInput
+	}
+	return s.store.UpdateComponent(ctx, componentID, normalized)
+}
+
+func (s *Service) ListTaxTables(ctx context.Context, actor Actor) ([]TaxTable, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return s.store.ListTaxTables(ctx)
+}
+
+func (s *Service) CreateTaxTable(ctx context.Context, actor Actor, input TaxTableInput) (TaxTable, error) {
+	if !isAdmin(actor.Role) {
+		return TaxTable{}, ErrForbidden
+	}
+	name := strings.TrimSpace(input.Name)
+	residency := strings.TrimSpace(input.Residency)
+	if name == "" || !isValidTaxResidency(residency) {
+		return TaxTable{}, ErrInvalidInput
+	}
+	effectiveFrom, err := time.Parse("2006-01-02", strings.TrimSpace(input.EffectiveFrom))
+	if err != nil {
+		return TaxTable{}, ErrInvalidInput
+	}
+	brackets, err := validateTaxBrackets(input.Brackets)
+	if err != nil {
+		return TaxTable{}, err
+	}
+
+	createdBy := actor.UserID
+	return s.store.CreateTaxTable(ctx, TaxTable{
+		Name:          name,
+		Residency:     residency,
+		EffectiveFrom: effectiveFrom,
+		CreatedBy:     &createdBy,
+		Brackets:      brackets,
+	})
+}
+
+func (s *Service) ListContributionSchemes(ctx context.Context, actor Actor) ([]ContributionScheme, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return s.store.ListContributionSchemes(ctx)
+}
+
+func (s *Service) UpdateContributionScheme(ctx context.Context, actor Actor, schemeID int64, input ContributionSchemeInput) (ContributionScheme, error) {
+	if !isAdmin(actor.Role) {
+		return ContributionScheme{}, ErrForbidden
+	}
+	if schemeID <= 0 {
+		return ContributionScheme{}, ErrInvalidInput
+	}
+	normalized := input
+	normalized.Name = strings.TrimSpace(input.Name)
+	if normalized.Name == "" || !isValidRate(normalized.EmployeeRate) || !isValidRate(normalized.EmployerRate) {
+		return ContributionScheme{}, ErrInvalidInput
+	}
+	if normalized.Ceiling != nil && !normalized.Ceiling.IsPositive() {
+		return ContributionScheme{}, ErrInvalidInput
+	}
+	return s.store.UpdateContributionScheme(ctx, schemeID, normalized, actor.UserID)
+}
+
+func (s *Service) ListContributionMembers(ctx context.Context, actor Actor, schemeID int64) ([]ContributionMember, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	if schemeID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.store.GetContributionScheme(ctx, schemeID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.store.ListContributionMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ContributionMember, 0)
+	for _, member := range members {
+		if member.SchemeID == schemeID {
+			items = append(items, member)
+		}
+	}
+	return items, nil
+}
+
+func (s *Service) SetContributionMember(ctx context.Context, actor Actor, schemeID int64, input ContributionMemberInput) (ContributionMember, error) {
+	if !canManagePayroll(actor.Role) {
+		return ContributionMember{}, ErrForbidden
+	}
+	if schemeID <= 0 || input.EmployeeID <= 0 {
+		return ContributionMember{}, ErrInvalidInput
+	}
+	normalized := input
+	normalized.MemberNumber = strings.TrimSpace(input.MemberNumber)
+	return s.store.UpsertContributionMember(ctx, schemeID, normalized)
+}
+
+func (s *Service) RemoveContributionMember(ctx context.Context, actor Actor, schemeID int64, employeeID int64) error {
+	if !canManagePayroll(actor.Role) {
+		return ErrForbidden
+	}
+	if schemeID <= 0 || employeeID <= 0 {
+		return ErrInvalidInput
+	}
+	return s.store.DeleteContributionMember(ctx, schemeID, employeeID)
+}
+
+func (s *Service) GetRemittanceSchedule(ctx context.Context, actor Actor, batchID int64) (RemittanceSchedule, error) {
+	if !canManagePayroll(actor.Role) {
+		return RemittanceSchedule{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return RemittanceSchedule{}, ErrInvalidInput
+	}
+	return s.remittanceSchedule(ctx, batchID)
+}
+
+// ExportRemittanceCSV renders the contribution schedule for filing; like the
+// payroll CSV it is only available once the batch has been approved.
+func (s *Service) ExportRemittanceCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
+	if !canManagePayroll(actor.Role) {
+		return "", ErrForbidden
+	}
+	if batchID <= 0 {
+		return "", ErrInvalidInput
+	}
+
+	schedule, err := s.remittanceSchedule(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if schedule.Batch.Status != StatusApproved && schedule.Batch.Status != StatusLocked {
+		return "", ErrBatchImmutable
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := []string{"Scheme", "Month", "Employee Name", "Member Number", "Pensionable Pay", "Employee Contribution", "Employer Contribution", "Total Contribution"}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write remittance csv header: %w", writeErr)
+	}
+	for _, scheme := range schedule.Schemes {
+		for _, row := range scheme.Rows {
+			record := []string{
+				scheme.SchemeCode,
+				schedule.Batch.Month,
+				row.EmployeeName,
+				row.MemberNumber,
+				row.PensionablePay.String(),
+				row.EmployeeContribution.String(),
+				row.EmployerContribution.String(),
+				row.TotalContribution.String(),
+			}
+			if writeErr := writer.Write(record); writeErr != nil {
+				return "", fmt.Errorf("write remittance csv row: %w", writeErr)
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush remittance csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// GetVarianceReport compares a batch with the latest earlier Locked batch of
+// the same type, so changes can be reviewed before approval.
+func (s *Service) GetVarianceReport(ctx context.Context, actor Actor, batchID int64) (VarianceReport, error) {
+	if !canManagePayroll(actor.Role) {
+		return VarianceReport{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return VarianceReport{}, ErrInvalidInput
+	}
+	return s.varianceReport(ctx, batchID)
+}
+
+// ExportVarianceCSV renders the variance report with one row per employee;
+// component changes are listed in a single column.
+func (s *Service) ExportVarianceCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
+	if !canManagePayroll(actor.Role) {
+		return "", ErrForbidden
+	}
+	if batchID <= 0 {
+		return "", ErrInvalidInput
+	}
+
+	report, err := s.varianceReport(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	previousMonth := ""
+	if report.PreviousBatch != nil {
+		previousMonth = report.PreviousBatch.Month
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := []string{"Month", "Previous Month", "Employee ID", "Employee Name", "Change", "Previous Base Salary", "Current Base Salary", "Component Changes", "Previous Net Pay", "Current Net Pay", "Net Pay Delta", "Net Pay Delta %", "Flagged"}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write variance csv header: %w", writeErr)
+	}
+	for _, row := range report.Rows {
+		changes := make([]string, 0, len(row.Components))
+		for _, component := range row.Components {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", component.ComponentName, component.Previous.String(), component.Current.String()))
+		}
+		percent := ""
+		if row.NetPayDeltaPercent != nil {
+			percent = strconv.FormatFloat(*row.NetPayDeltaPercent, 'f', 2, 64)
+		}
+		flagged := "No"
+		if row.Flagged {
+			flagged = "Yes"
+		}
+		record := []string{
+			report.Batch.Month,
+			previousMonth,
+			strconv.FormatInt(row.EmployeeID, 10),
+			row.EmployeeName,
+			row.Change,
+			row.PreviousBaseSalary.String(),
+			row.CurrentBaseSalary.String(),
+			strings.Join(changes, "; "),
+			row.PreviousNetPay.String(),
+			row.CurrentNetPay.String(),
+			row.NetPayDelta.String(),
+			percent,
+			flagged,
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write variance csv row: %w", writeErr)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush variance csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (s *Service) varianceReport(ctx context.Context, batchID int64) (VarianceReport, error) {
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return VarianceReport{}, err
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return VarianceReport{}, err
+	}
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		return VarianceReport{}, err
+	}
+
+	locked, err := s.store.ListBatches(ctx, BatchFilter{Status: StatusLocked, BatchType: batch.BatchType})
+	if err != nil {
+		return VarianceReport{}, err
+	}
+	var previous *Batch
+	for i := range locked {
+		candidate := locked[i]
+		if candidate.Status != StatusLocked || candidate.BatchType != batch.BatchType || candidate.Month >= batch.Month {
+			continue
+		}
+		if previous == nil || candidate.Month > previous.Month || (candidate.Month == previous.Month && candidate.ID > previous.ID) {
+			previous = &candidate
+		}
+	}
+	prior := make([]Entry, 0)
+	if previous != nil {
+		prior, err = s.store.GetBatchEntries(ctx, previous.ID)
+		if err != nil {
+			return VarianceReport{}, err
+		}
+	}
+	return BuildVarianceReport(batch, previous, entries, prior, settings.VarianceThresholdPercent), nil
+}
+
+func (s *Service) remittanceSchedule(ctx context.Context, batchID int64) (RemittanceSchedule, error) {
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return RemittanceSchedule{}, err
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return RemittanceSchedule{}, err
+	}
+	schemes, err := s.store.ListContributionSchemes(ctx)
+	if err != nil {
+		return RemittanceSchedule{}, err
+	}
+	members, err := s.store.ListContributionMembers(ctx)
+	if err != nil {
+		return RemittanceSchedule{}, err
+	}
+	return BuildRemittanceSchedule(batch, entries, schemes, members), nil
+}
+
+func (s *Service) ListRecurringItems(ctx context.Context, actor Actor, filter RecurringItemFilter) ([]RecurringItem, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	filter.Month = strings.TrimSpace(filter.Month)
+	if filter.EmployeeID < 0 || (filter.Month != "" && !isValidMonth(filter.Month)) {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ListRecurringItems(ctx, filter)
+}
+
+// CreateRecurringItem assigns a standing earning or deduction to an employee.
+// It is posted by regular batches generated for the months it covers.
+func (s *Service) CreateRecurringItem(ctx context.Context, actor Actor, input RecurringItemInput) (RecurringItem, error) {
+	if !canManagePayroll(actor.Role) {
+		return RecurringItem{}, ErrForbidden
+	}
+	if input.EmployeeID <= 0 {
+		return RecurringItem{}, ErrInvalidInput
+	}
+	normalized, err := s.normalizeRecurringItemInput(ctx, input)
+	if err != nil {
+		return RecurringItem{}, err
+	}
+	return s.store.CreateRecurringItem(ctx, normalized, actor.UserID)
+}
+
+// UpdateRecurringItem changes an item from the next generation on; entries
+// already generated are not touched. The employee cannot be changed.
+func (s *Service) UpdateRecurringItem(ctx context.Context, actor Actor, itemID int64, input RecurringItemInput) (RecurringItem, error) {
+	if !canManagePayroll(actor.Role) {
+		return RecurringItem{}, ErrForbidden
+	}
+	if itemID <= 0 {
+		return RecurringItem{}, ErrInvalidInput
+	}
+
+	existing, err := s.store.GetRecurringItem(ctx, itemID)
+	if err != nil {
+		return RecurringItem{}, err
+	}
+	if input.EmployeeID != 0 && input.EmployeeID != existing.EmployeeID {
+		return RecurringItem{}, ErrInvalidInput
+	}
+	input.EmployeeID = existing.EmployeeID
+	normalized, err := s.normalizeRecurringItemInput(ctx, input)
+	if err != nil {
+		return RecurringItem{}, err
+	}
+	return s.store.UpdateRecurringItem(ctx, itemID, normalized)
+}
+
+func (s *Service) DeleteRecurringItem(ctx context.Context, actor Actor, itemID int64) error {
+	if !canManagePayroll(actor.Role) {
+		return ErrForbidden
+	}
+	if itemID <= 0 {
+		return ErrInvalidInput
+	}
+	return s.store.DeleteRecurringItem(ctx, itemID)
+}
+
+// normalizeRecurringItemInput checks the value and months and that the
+// component is an active, manually entered earning or deduction.
+func (s *Service) normalizeRecurringItemInput(ctx context.Context, input RecurringItemInput) (RecurringItemInput, error) {
+	input.StartMonth = strings.TrimSpace(input.StartMonth)
+	input.EndMonth = strings.TrimSpace(input.EndMonth)
+	input.Note = strings.TrimSpace(input.Note)
+	if input.ComponentID <= 0 || (input.Amount == nil) == (input.PercentOfBase == nil) {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+	if input.Amount != nil && !input.Amount.IsPositive() {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+	if input.PercentOfBase != nil && (*input.PercentOfBase <= 0 || *input.PercentOfBase > 100) {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+	if !isValidMonth(input.StartMonth) {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+	if input.EndMonth != "" && (!isValidMonth(input.EndMonth) || input.EndMonth < input.StartMonth) {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+
+	component, err := s.store.GetComponent(ctx, input.ComponentID)
+	if err != nil {
+		return RecurringItemInput{}, err
+	}
+	if component.IsSystem || !component.IsActive || !isRecurringComponentType(component.Type) {
+		return RecurringItemInput{}, ErrInvalidInput
+	}
+	return input, nil
+}
+
+func (s *Service) ListLoans(ctx context.Context, actor Actor, filter LoanFilter) ([]Loan, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	filter.Status = strings.TrimSpace(filter.Status)
+	if filter.EmployeeID < 0 || (filter.Status != "" && !isValidLoanStatus(filter.Status)) {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ListLoans(ctx, filter)
+}
+
+func (s *Service) GetLoan(ctx context.Context, actor Actor, loanID int64) (LoanDetail, error) {
+	if !canManagePayroll(actor.Role) {
+		return LoanDetail{}, ErrForbidden
+	}
+	if loanID <= 0 {
+		return LoanDetail{}, ErrInvalidInput
+	}
+
+	loan, err := s.store.GetLoan(ctx, loanID)
+	if err != nil {
+		return LoanDetail{}, err
+	}
+	repayments, err := s.store.ListLoanRepayments(ctx, loanID)
+	if err != nil {
+		return LoanDetail{}, err
+	}
+	return LoanDetail{Loan: loan, Repayments: repayments}, nil
+}
+
+// CreateLoan records a loan or salary advance. Its installments are deducted
+// from regular batches generated for StartMonth onwards.
+func (s *Service) CreateLoan(ctx context.Context, actor Actor, input LoanInput) (Loan, error) {
+	if !canManagePayroll(actor.Role) {
+		return Loan{}, ErrForbidden
+	}
+	input.LoanType = strings.TrimSpace(input.LoanType)
+	input.Description = strings.TrimSpace(input.Description)
+	input.StartMonth = strings.TrimSpace(input.StartMonth)
+	if input.EmployeeID <= 0 || !isValidLoanType(input.LoanType) || !input.Principal.IsPositive() {
+		return Loan{}, ErrInvalidInput
+	}
+	if input.InterestRatePercent < 0 || input.InterestRatePercent > 100 {
+		return Loan{}, ErrInvalidInput
+	}
+	if input.Installments < 1 || input.Installments > maxLoanInstallments || !isValidMonth(input.StartMonth) {
+		return Loan{}, ErrInvalidInput
+	}
+
+	total, installment, err := LoanTerms(input.Principal, input.InterestRatePercent, input.Installments)
+	if err != nil {
+		return Loan{}, ErrInvalidInput
+	}
+	return s.store.CreateLoan(ctx, Loan{
+		EmployeeID:          input.EmployeeID,
+		LoanType:            input.LoanType,
+		Description:         input.Description,
+		Principal:           input.Principal,
+		InterestRatePercent: input.InterestRatePercent,
+		TotalRepayable:      total,
+		Installments:        input.Installments,
+		InstallmentAmount:   installment,
+		StartMonth:          input.StartMonth,
+		CreatedBy:           actor.UserID,
+	})
+}
+
+// CancelLoan stops further installments on an active loan; the reason is
+// kept on the loan.
+func (s *Service) CancelLoan(ctx context.Context, actor Actor, loanID int64, input CancelLoanInput) (Loan, error) {
+	if !canManagePayroll(actor.Role) {
+		return Loan{}, ErrForbidden
+	}
+	if loanID <= 0 {
+		return Loan{}, ErrInvalidInput
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return Loan{}, ErrJustificationRequired
+	}
+
+	loan, err := s.store.GetLoan(ctx, loanID)
+	if err != nil {
+		return Loan{}, err
+	}
+	if loan.Status != LoanStatusActive {
+		return Loan{}, ErrInvalidStatusTransition
+	}
+	return s.store.CancelLoan(ctx, loanID, reason)
+}
+
+// GetLoanBalanceReport totals what each employee owes across their loans.
+func (s *Service) GetLoanBalanceReport(ctx context.Context, actor Actor) (LoanBalanceReport, error) {
+	if !canManagePayroll(actor.Role) {
+		return LoanBalanceReport{}, ErrForbidden
+	}
+	loans, err := s.store.ListLoans(ctx, LoanFilter{})
+	if err != nil {
+		return LoanBalanceReport{}, err
+	}
+	return BuildLoanBalanceReport(loans), nil
+}
+
+func (s *Service) ExportLoanBalancesCSV(ctx context.Context, actor Actor) (string, error) {
+	report, err := s.GetLoanBalanceReport(ctx, actor)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := []string{"Employee ID", "Employee Name", "Active Loans", "Total Repayable", "Repaid", "Outstanding"}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write loan balances csv header: %w", writeErr)
+	}
+	for _, row := range report.Rows {
+		record := []string{
+			strconv.FormatInt(row.EmployeeID, 10),
+			row.EmployeeName,
+			strconv.Itoa(row.ActiveLoans),
+			row.TotalRepayable.String(),
+			row.Repaid.String(),
+			row.Outstanding.String(),
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write loan balances csv row: %w", writeErr)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush loan balances csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// ListYTDTotals returns every employee's running totals for a fiscal year.
+func (s *Service) ListYTDTotals(ctx context.Context, actor Actor, fiscalYear int) ([]YTDTotals, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	if !isValidFiscalYear(fiscalYear) {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ListYTDTotals(ctx, fiscalYear, 0)
+}
+
+// RebuildYTDTotals recomputes a fiscal year's totals from its Locked batches,
+// for when the running totals are suspected to have drifted.
+func (s *Service) RebuildYTDTotals(ctx context.Context, actor Actor, fiscalYear int) ([]YTDTotals, error) {
+	if !isAdmin(actor.Role) {
+		return nil, ErrForbidden
+	}
+	if !isValidFiscalYear(fiscalYear) {
+		return nil, ErrInvalidInput
+	}
+	if err := s.store.RebuildYTDTotals(ctx, fiscalYear, actor.UserID); err != nil {
+		return nil, err
+	}
+	return s.store.ListYTDTotals(ctx, fiscalYear, 0)
+}
+
+func (s *Service) GetAnnualCertificate(ctx context.Context, actor Actor, employeeID int64, fiscalYear int) (AnnualCertificate, error) {
+	if !canManagePayroll(actor.Role) {
+		return AnnualCertificate{}, ErrForbidden
+	}
+	if employeeID <= 0 || !isValidFiscalYear(fiscalYear) {
+		return AnnualCertificate{}, ErrInvalidInput
+	}
+	return s.annualCertificate(ctx, employeeID, fiscalYear)
+}
+
+func (s *Service) RenderAnnualCertificate(ctx context.Context, actor Actor, employeeID int64, fiscalYear int) (File, error) {
+	certificate, err := s.GetAnnualCertificate(ctx, actor, employeeID, fiscalYear)
+	if err != nil {
+		return File{}, err
+	}
+	return annualCertificateFile(certificate), nil
+}
+
+// RenderMyAnnualCertificate renders the caller's own certificate.
+func (s *Service) RenderMyAnnualCertificate(ctx context.Context, actor Actor, fiscalYear int) (File, error) {
+	if !isValidFiscalYear(fiscalYear) {
+		return File{}, ErrInvalidInput
+	}
+	employeeID, err := s.store.ResolveEmployeeByUserID(ctx, actor.UserID)
+	if err != nil {
+		return File{}, ErrForbidden
+	}
+	certificate, err := s.annualCertificate(ctx, employeeID, fiscalYear)
+	if err != nil {
+		return File{}, err
+	}
+	return annualCertificateFile(certificate), nil
+}
+
+func (s *Service) ExportAnnualCertificateCSV(ctx context.Context, actor Actor, employeeID int64, fiscalYear int) (string, error) {
+	certificate, err := s.GetAnnualCertificate(ctx, actor, employeeID, fiscalYear)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	if writeErr := writer.Write(append([]string{"Month"}, taxYearAmountsHeader...)); writeErr != nil {
+		return "", fmt.Errorf("write annual certificate csv header: %w", writeErr)
+	}
+	for _, month := range certificate.Months {
+		if writeErr := writer.Write(append([]string{month.Month}, taxYearAmountsRecord(month.TaxYearAmounts)...)); writeErr != nil {
+			return "", fmt.Errorf("write annual certificate csv row: %w", writeErr)
+		}
+	}
+	if writeErr := writer.Write(append([]string{"Total"}, taxYearAmountsRecord(certificate.Totals)...)); writeErr != nil {
+		return "", fmt.Errorf("write annual certificate csv total: %w", writeErr)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush annual certificate csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (s *Service) GetAnnualReturn(ctx context.Context, actor Actor, fiscalYear int) (AnnualReturn, error) {
+	totals, err := s.ListYTDTotals(ctx, actor, fiscalYear)
+	if err != nil {
+		return AnnualReturn{}, err
+	}
+	return BuildAnnualReturn(fiscalYear, totals), nil
+}
+
+func (s *Service) ExportAnnualReturnCSV(ctx context.Context, actor Actor, fiscalYear int) (string, error) {
+	report, err := s.GetAnnualReturn(ctx, actor, fiscalYear)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := append([]string{"Employee ID", "Employee Name", "National ID"}, taxYearAmountsHeader...)
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write annual return csv header: %w", writeErr)
+	}
+	for _, row := range report.Rows {
+		record := append([]string{strconv.FormatInt(row.EmployeeID, 10), row.EmployeeName, row.NationalID}, taxYearAmountsRecord(row.TaxYearAmounts)...)
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write annual return csv row: %w", writeErr)
+		}
+	}
+	if writeErr := writer.Write(append([]string{"", "Total", ""}, taxYearAmountsRecord(report.Totals)...)); writeErr != nil {
+		return "", fmt.Errorf("write annual return csv total: %w", writeErr)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush annual return csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (s *Service) annualCertificate(ctx context.Context, employeeID int64, fiscalYear int) (AnnualCertificate, error) {
+	employee, err := s.store.GetEmployeeDetails(ctx, employeeID)
+	if err != nil {
+		return AnnualCertificate{}, err
+	}
+	first, last := FiscalYearMonths(fiscalYear)
+	months, err := s.store.ListLockedMonthlyTotals(ctx, first, last, employeeID)
+	if err != nil {
+		return AnnualCertificate{}, err
+	}
+	if len(months) == 0 {
+		return AnnualCertificate{}, ErrNoAnnualEarnings
+	}
+	return BuildAnnualCertificate(s.employer, employee, fiscalYear, months), nil
+}
+
+func annualCertificateFile(certificate AnnualCertificate) File {
+	return File{
+		Name:        AnnualCertificateFileName(certificate),
+		ContentType: "application/pdf",
+		Content:     RenderAnnualCertificatePDF(certificate),
+	}
+}
+
+var taxYearAmountsHeader = []string{"Gross Pay", "Taxable Pay", "Pensionable Pay", "PAYE", "Deductions", "Employee Contributions", "Employer Contributions", "Net Pay"}
+
+func taxYearAmountsRecord(amounts TaxYearAmounts) []string {
+	return []string{
+		amounts.GrossPay.String(),
+		amounts.TaxablePay.String(),
+		amounts.PensionablePay.String(),
+		amounts.TaxTotal.String(),
+		amounts.DeductionsTotal.String(),
+		amounts.EmployeeContributions.String(),
+		amounts.EmployerContributions.String(),
+		amounts.NetPay.String(),
+	}
+}
+
+func isValidFiscalYear(fiscalYear int) bool {
+	return fiscalYear >= 2000 && fiscalYear <= 9999
+}
+
+func (s *Service) ListProjects(ctx context.Context, actor Actor) ([]Project, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return s.store.ListProjects(ctx)
+}
+
+func (s *Service) CreateProject(ctx context.Context, actor Actor, input ProjectInput) (Project, error) {
+	if !canManagePayroll(actor.Role) {
+		return Project{}, ErrForbidden
+	}
+	normalized, err := normalizeProjectInput(input)
+	if err != nil {
+		return Project{}, err
+	}
+	return s.store.CreateProject(ctx, normalized)
+}
+
+func (s *Service) UpdateProject(ctx context.Context, actor Actor, projectID int64, input ProjectInput) (Project, error) {
+	if !canManagePayroll(actor.Role) {
+		return Project{}, ErrForbidden
+	}
+	if projectID <= 0 {
+		return Project{}, ErrInvalidInput
+	}
+	normalized, err := normalizeProjectInput(input)
+	if err != nil {
+		return Project{}, err
+	}
+
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return Project{}, err
+	}
+	existing, ok := findProjectByID(projects, projectID)
+	if !ok {
+		return Project{}, ErrProjectNotFound
+	}
+	// Allocation reports already filed quote the code, so it is fixed once created.
+	if existing.Code != normalized.Code {
+		return Project{}, ErrInvalidInput
+	}
+	return s.store.UpdateProject(ctx, projectID, normalized)
+}
+
+// ListCostAllocations returns the allocation history of one employee, or of
+// everyone when employeeID is 0.
+func (s *Service) ListCostAllocations(ctx context.Context, actor Actor, employeeID int64) ([]CostAllocation, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	if employeeID < 0 {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ListCostAllocations(ctx, employeeID)
+}
+
+// SetCostAllocation records how an employee's cost is split across projects
+// from input.EffectiveFrom, replacing any split starting in the same month.
+func (s *Service) SetCostAllocation(ctx context.Context, actor Actor, employeeID int64, input CostAllocationInput) ([]CostAllocation, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	input.EffectiveFrom = strings.TrimSpace(input.EffectiveFrom)
+	if employeeID <= 0 || !isValidMonth(input.EffectiveFrom) || len(input.Shares) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int64]struct{}, len(input.Shares))
+	for _, share := range input.Shares {
+		if share.ProjectID <= 0 || share.Percent <= 0 || share.Percent > 100 {
+			return nil, ErrInvalidInput
+		}
+		if _, duplicate := seen[share.ProjectID]; duplicate {
+			return nil, ErrInvalidInput
+		}
+		seen[share.ProjectID] = struct{}{}
+
+		project, ok := findProjectByID(projects, share.ProjectID)
+		if !ok {
+			return nil, ErrProjectNotFound
+		}
+		if !project.IsActive {
+			return nil, ErrInvalidInput
+		}
+	}
+	if !sharesAddUpToWhole(input.Shares) {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ReplaceCostAllocation(ctx, employeeID, input, actor.UserID)
+}
+
+func (s *Service) DeleteCostAllocation(ctx context.Context, actor Actor, employeeID int64, effectiveFrom string) error {
+	if !canManagePayroll(actor.Role) {
+		return ErrForbidden
+	}
+	effectiveFrom = strings.TrimSpace(effectiveFrom)
+	if employeeID <= 0 || !isValidMonth(effectiveFrom) {
+		return ErrInvalidInput
+	}
+	return s.store.DeleteCostAllocation(ctx, employeeID, effectiveFrom)
+}
+
+// GetCostAllocationReport charges a Locked batch to projects and departments.
+// Batches not yet locked may still change, so they are refused.
+func (s *Service) GetCostAllocationReport(ctx context.Context, actor Actor, batchID int64) (CostAllocationReport, error) {
+	if !canManagePayroll(actor.Role) {
+		return CostAllocationReport{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return CostAllocationReport{}, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return CostAllocationReport{}, err
+	}
+	if batch.Status != StatusLocked {
+		return CostAllocationReport{}, ErrBatchImmutable
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return CostAllocationReport{}, err
+	}
+	employees, err := s.store.ListBatchEmployeeDetails(ctx, batchID)
+	if err != nil {
+		return CostAllocationReport{}, err
+	}
+	allocations, err := s.store.ListCostAllocations(ctx, 0)
+	if err != nil {
+		return CostAllocationReport{}, err
+	}
+	return BuildCostAllocationReport(batch, entries, employees, allocations)
+}
+
+func (s *Service) ExportCostAllocationCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
+	report, err := s.GetCostAllocationReport(ctx, actor, batchID)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := []string{"Month", "Project Code", "Project Name", "Funding Source", "Department", "Employees", "Gross Pay", "Employer Contributions", "Total Cost"}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write cost allocation csv header: %w", writeErr)
+	}
+	for _, row := range report.Rows {
+		record := []string{
+			report.Batch.Month,
+			row.ProjectCode,
+			row.ProjectName,
+			row.FundingSource,
+			row.Department,
+			strconv.Itoa(row.Employees),
+			row.GrossPay.String(),
+			row.EmployerContributions.String(),
+			row.TotalCost.String(),
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write cost allocation csv row: %w", writeErr)
+		}
+	}
+	total := []string{report.Batch.Month, "", "Total", "", "", "", report.Totals.GrossPay.String(), report.Totals.EmployerContributions.String(), report.Totals.TotalCost.String()}
+	if writeErr := writer.Write(total); writeErr != nil {
+		return "", fmt.Errorf("write cost allocation csv total: %w", writeErr)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush cost allocation csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (s *Service) ListGLAccounts(ctx context.Context, actor Actor) ([]GLAccount, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	return s.store.ListGLAccounts(ctx)
+}
+
+// UpdateGLAccounts replaces the chart-of-accounts mapping. Each posting and
+// component is mapped at most once; Employer components also need the
+// expense account their cost is debited to.
+func (s *Service) UpdateGLAccounts(ctx context.Context, actor Actor, inputs []GLAccountInput) ([]GLAccount, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+
+	components, err := s.store.ListComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type mappingKey struct {
+		posting     string
+		componentID int64
+	}
+	seen := make(map[mappingKey]struct{}, len(inputs))
+	normalized := make([]GLAccountInput, 0, len(inputs))
+	for _, input := range inputs {
+		input.Posting = strings.TrimSpace(input.Posting)
+		input.AccountCode = strings.TrimSpace(input.AccountCode)
+		input.AccountName = strings.TrimSpace(input.AccountName)
+		input.ExpenseAccountCode = strings.TrimSpace(input.ExpenseAccountCode)
+		input.ExpenseAccountName = strings.TrimSpace(input.ExpenseAccountName)
+		if input.AccountCode == "" {
+			return nil, ErrInvalidInput
+		}
+
+		switch input.Posting {
+		case GLPostingBaseSalary, GLPostingNetPay:
+			if input.ComponentID != 0 || input.ExpenseAccountCode != "" {
+				return nil, ErrInvalidInput
+			}
+		case GLPostingComponent:
+			if input.ComponentID <= 0 {
+				return nil, ErrInvalidInput
+			}
+			component, ok := findComponentByID(components, input.ComponentID)
+			if !ok {
+				return nil, ErrComponentNotFound
+			}
+			if (component.Type == ComponentTypeEmployer) != (input.ExpenseAccountCode != "") {
+				return nil, ErrInvalidInput
+			}
+		default:
+			return nil, ErrInvalidInput
+		}
+		if input.ExpenseAccountCode == "" {
+			input.ExpenseAccountName = ""
+		}
+
+		key := mappingKey{input.Posting, input.ComponentID}
+		if _, duplicate := seen[key]; duplicate {
+			return nil, ErrInvalidInput
+		}
+		seen[key] = struct{}{}
+		normalized = append(normalized, input)
+	}
+	return s.store.ReplaceGLAccounts(ctx, normalized, actor.UserID)
+}
+
+func (s *Service) GetJournalLayout(ctx context.Context, actor Actor) (JournalLayout, error) {
+	if !canManagePayroll(actor.Role) {
+		return JournalLayout{}, ErrForbidden
+	}
+	return s.store.GetJournalLayout(ctx)
+}
+
+// UpdateJournalLayout sets the columns, their order and the delimiter of the
+// journal CSV to match what the accounting package imports.
+func (s *Service) UpdateJournalLayout(ctx context.Context, actor Actor, input JournalLayoutInput) (JournalLayout, error) {
+	if !canManagePayroll(actor.Role) {
+		return JournalLayout{}, ErrForbidden
+	}
+	if len(input.Columns) == 0 {
+		return JournalLayout{}, ErrInvalidInput
+	}
+	if _, ok := journalDelimiters[input.Delimiter]; !ok {
+		return JournalLayout{}, ErrInvalidInput
+	}
+	columns := make([]string, 0, len(input.Columns))
+	seen := make(map[string]struct{}, len(input.Columns))
+	for _, column := range input.Columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !isValidJournalColumn(column) {
+			return JournalLayout{}, ErrInvalidInput
+		}
+		if _, duplicate := seen[column]; duplicate {
+			return JournalLayout{}, ErrInvalidInput
+		}
+		seen[column] = struct{}{}
+		columns = append(columns, column)
+	}
+	input.Columns = columns
+	return s.store.UpdateJournalLayout(ctx, input, actor.UserID)
+}
+
+// GetJournal posts a Locked batch to the general ledger accounts. Batches not
+// yet locked may still change, so they are refused.
+func (s *Service) GetJournal(ctx context.Context, actor Actor, batchID int64) (Journal, error) {
+	if !canManagePayroll(actor.Role) {
+		return Journal{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return Journal{}, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return Journal{}, err
+	}
+	if batch.Status != StatusLocked {
+		return Journal{}, ErrBatchImmutable
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return Journal{}, err
+	}
+	components, err := s.store.ListComponents(ctx)
+	if err != nil {
+		return Journal{}, err
+	}
+	accounts, err := s.store.ListGLAccounts(ctx)
+	if err != nil {
+		return Journal{}, err
+	}
+	return BuildJournal(batch, entries, components, accounts)
+}
+
+// ExportJournalCSV writes the batch journal in the saved layout.
+func (s *Service) ExportJournalCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
+	journal, err := s.GetJournal(ctx, actor, batchID)
+	if err != nil {
+		return "", err
+	}
+	layout, err := s.store.GetJournalLayout(ctx)
+	if err != nil {
+		return "", err
+	}
+	return FormatJournalCSV(journal, layout)
+}
+
+// ListExchangeRates returns the rates of month, or of every month when month
+// is empty.
+func (s *Service) ListExchangeRates(ctx context.Context, actor Actor, month string) ([]ExchangeRate, error) {
+	if !canManagePayroll(actor.Role) {
+		return nil, ErrForbidden
+	}
+	month = strings.TrimSpace(month)
+	if month != "" && !isValidMonth(month) {
+		return nil, ErrInvalidInput
+	}
+	return s.store.ListExchangeRates(ctx, month)
+}
+
+// SetExchangeRate records how many units of the payment currency one unit of
+// input.Currency buys in input.Month. Entries keep the rate they were
+// generated with, so a Draft batch picks up a change when it is regenerated.
+func (s *Service) SetExchangeRate(ctx context.Context, actor Actor, input ExchangeRateInput) (ExchangeRate, error) {
+	if !canManagePayroll(actor.Role) {
+		return ExchangeRate{}, ErrForbidden
+	}
+	input.Month = strings.TrimSpace(input.Month)
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	if !isValidMonth(input.Month) || !employees.IsCurrencyCode(input.Currency) || input.Currency == PaymentCurrency {
+		return ExchangeRate{}, ErrInvalidInput
+	}
+	// NUMERIC(18,6) holds up to 12 integer digits.
+	if input.Rate >= 1e12 || rateUnits(input.Rate) <= 0 {
+		return ExchangeRate{}, ErrInvalidInput
+	}
+	input.Rate = float64(rateUnits(input.Rate)) / exchangeRateScale
+	return s.store.UpsertExchangeRate(ctx, input, actor.UserID)
+}
+
+func (s *Service) DeleteExchangeRate(ctx context.Context, actor Actor, month, currency string) error {
+	if !canManagePayroll(actor.Role) {
+		return ErrForbidden
+	}
+	month = strings.TrimSpace(month)
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !isValidMonth(month) || !employees.IsCurrencyCode(currency) {
+		return ErrInvalidInput
+	}
+	return s.store.DeleteExchangeRate(ctx, month, currency)
+}
+
+// GetCurrencyReport shows a batch's entries in both their contract currency
+// and the payment currency.
+func (s *Service) GetCurrencyReport(ctx context.Context, actor Actor, batchID int64) (CurrencyReport, error) {
+	if !canManagePayroll(actor.Role) {
+		return CurrencyReport{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return CurrencyReport{}, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return CurrencyReport{}, err
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return CurrencyReport{}, err
+	}
+	return BuildCurrencyReport(batch, entries)
+}
+
+func (s *Service) ExportCurrencyCSV(ctx context.Context, actor Actor, batchID int64) (string, error) {
+	report, err := s.GetCurrencyReport(ctx, actor, batchID)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	header := []string{
+		"Employee ID", "Employee Name", "Contract Currency", "Exchange Rate",
+		"Contract Monthly Salary", "Contract Gross Pay", "Contract Tax", "Contract Net Pay",
+		"Monthly Salary (" + PaymentCurrency + ")", "Gross Pay (" + PaymentCurrency + ")", "Tax (" + PaymentCurrency + ")", "Net Pay (" + PaymentCurrency + ")",
+	}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return "", fmt.Errorf("write currency csv header: %w", writeErr)
+	}
+	for _, row := range report.Rows {
+		record := append([]string{
+			strconv.FormatInt(row.EmployeeID, 10),
+			row.EmployeeName,
+			row.ContractCurrency,
+			strconv.FormatFloat(row.ExchangeRate, 'f', 6, 64),
+		}, currencyAmountsRecord(row.Contract, row.Payment)...)
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write currency csv row: %w", writeErr)
+		}
+	}
+	for _, total := range report.ByCurrency {
+		record := append([]string{"", "Total " + total.ContractCurrency, total.ContractCurrency, ""}, currencyAmountsRecord(total.Contract, total.Payment)...)
+		if writeErr := writer.Write(record); writeErr != nil {
+			return "", fmt.Errorf("write currency csv total: %w", writeErr)
+		}
+	}
+	// Contract currencies differ, so the batch total is in the payment currency only.
+	total := []string{
+		"", "Total", "", "", "", "", "", "",
+		report.PaymentTotals.MonthlySalary.String(),
+		report.PaymentTotals.GrossPay.String(),
+		report.PaymentTotals.TaxTotal.String(),
+		report.PaymentTotals.NetPay.String(),
+	}
+	if writeErr := writer.Write(total); writeErr != nil {
+		return "", fmt.Errorf("write currency csv total: %w", writeErr)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush currency csv: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func currencyAmountsRecord(contract, payment CurrencyAmounts) []string {
+	return []string{
+		contract.MonthlySalary.String(),
+		contract.GrossPay.String(),
+		contract.TaxTotal.String(),
+		contract.NetPay.String(),
+		payment.MonthlySalary.String(),
+		payment.GrossPay.String(),
+		payment.TaxTotal.String(),
+		payment.NetPay.String(),
+	}
+}
+
+// VerifyBatch recomputes the digest of a Locked or Reversed batch and
+// compares it with the one recorded when the batch was locked, so changes
+// made to its entries outside the service are detected.
+func (s *Service) VerifyBatch(ctx context.Context, actor Actor, batchID int64) (BatchVerification, error) {
+	if !canManagePayroll(actor.Role) {
+		return BatchVerification{}, ErrForbidden
+	}
+	if batchID <= 0 {
+		return BatchVerification{}, ErrInvalidInput
+	}
+
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		return BatchVerification{}, err
+	}
+	if batch.Status != StatusLocked && batch.Status != StatusReversed {
+		return BatchVerification{}, ErrBatchImmutable
+	}
+	entries, err := s.store.GetBatchEntries(ctx, batchID)
+	if err != nil {
+		return BatchVerification{}, err
+	}
+	return VerifyBatchDigest(batch, entries, time.Now().UTC()), nil
+}
+
+// SimulatePayroll runs regular batch generation for a month with raised
+// salaries and extra items, without saving anything, and compares the result
+// with the latest Locked regular batch per employee and department.
+func (s *Service) SimulatePayroll(ctx context.Context, actor Actor, input SimulationInput) (SimulationReport, error) {
+	if !canManagePayroll(actor.Role) {
+		return SimulationReport{}, ErrForbidden
+	}
+	input.Month = strings.TrimSpace(input.Month)
+	if !isValidMonth(input.Month) {
+		return SimulationReport{}, ErrInvalidInput
+	}
+	if input.SalaryIncreasePercent <= -100 || input.SalaryIncreasePercent > 1000 {
+		return SimulationReport{}, ErrInvalidInput
+	}
+	if input.Items == nil {
+		input.Items = make([]SimulationItem, 0)
+	}
+	for _, item := range input.Items {
+		if item.ComponentID <= 0 || (item.Amount == nil) == (item.PercentOfBase == nil) {
+			return SimulationReport{}, ErrInvalidInput
+		}
+		if item.Amount != nil && !item.Amount.IsPositive() {
+			return SimulationReport{}, ErrInvalidInput
+		}
+		if item.PercentOfBase != nil && (*item.PercentOfBase <= 0 || *item.PercentOfBase > 100) {
+			return SimulationReport{}, ErrInvalidInput
+		}
+		component, err := s.store.GetComponent(ctx, item.ComponentID)
+		if err != nil {
+			return SimulationReport{}, err
+		}
+		if component.IsSystem || !component.IsActive || !isRecurringComponentType(component.Type) {
+			return SimulationReport{}, ErrInvalidInput
+		}
+	}
+
+	simulated, err := s.store.SimulateEntries(ctx, input)
+	if err != nil {
+		return SimulationReport{}, err
+	}
+	locked, err := s.store.ListBatches(ctx, BatchFilter{Status: StatusLocked, BatchType: BatchTypeRegular})
+	if err != nil {
+		return SimulationReport{}, err
+	}
+	var baseline *Batch
+	for i := range locked {
+		candidate := locked[i]
+		if candidate.Status != StatusLocked || candidate.BatchType != BatchTypeRegular {
+			continue
+		}
+		if baseline == nil || candidate.Month > baseline.Month || (candidate.Month == baseline.Month && candidate.ID > baseline.ID) {
+			baseline = &candidate
+		}
+	}
+	baselineEntries := make([]Entry, 0)
+	if baseline != nil {
+		baselineEntries, err = s.store.GetBatchEntries(ctx, baseline.ID)
+		if err != nil {
+			return SimulationReport{}, err
+		}
+	}
+
+	employeeIDs := make([]int64, 0, len(simulated)+len(baselineEntries))
+	for _, entry := range simulated {
+		employeeIDs = append(employeeIDs, entry.EmployeeID)
+	}
+	for _, entry := range baselineEntries {
+		employeeIDs = append(employeeIDs, entry.EmployeeID)
+	}
+	employees, err := s.store.ListEmployeeDetails(ctx, employeeIDs)
+	if err != nil {
+		return SimulationReport{}, err
+	}
+	return BuildSimulationReport(input, simulated, baseline, baselineEntries, employees), nil
+}
+
+func canManagePayroll(role string) bool {
+	return role == auth.RoleAdmin || role == auth.RoleFinanceOfficer
+}
+
+// canViewBatches admits payroll managers and the roles that sign off batches.
+func (s *Service) canViewBatches(ctx context.Context, role string) (bool, error) {
+	if canManagePayroll(role) {
+		return true, nil
+	}
+	stages, err := s.store.ListApprovalStages(ctx)
+	if err != nil {
+		return false, err
+	}
+	return isApproverRole(stages, role), nil
+}
+
+func isAdmin(role string) bool {
+	return role == auth.RoleAdmin
+}
+
+func isMaster(role string) bool {
+	return auth.IsMasterRole(role)
+}
+
+func isValidMonth(month string) bool {
+	_, err := time.Parse("2006-01", strings.TrimSpace(month))
+	return err == nil
+}
+
+func isValidStatus(status string) bool {
+	switch strings.TrimSpace(status) {
+	case StatusDraft, StatusInApproval, StatusApproved, StatusLocked, StatusReversed:
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidComponentType(componentType string) bool {
+	switch componentType {
+	case ComponentTypeEarning, ComponentTypeDeduction, ComponentTypeTax, ComponentTypeEmployer:
+		return true
+	default:
+		return false
+	}
+}
+
+func normalizeComponentInput(input ComponentInput) (ComponentInput, error) {
+	normalized := input
+	normalized.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	normalized.Name = strings.TrimSpace(input.Name)
+	normalized.Type = strings.TrimSpace(input.Type)
+	if normalized.Code == "" || normalized.Name == "" || !isValidComponentType(normalized.Type) {
+		return ComponentInput{}, ErrInvalidInput
+	}
+	for _, char := range normalized.Code {
+		if !(char >= 'A' && char <= 'Z') && !(char >= '0' && char <= '9') && char != '_' {
+			return ComponentInput{}, ErrInvalidInput
+		}
+	}
+	return normalized, nil
+}
+
+func normalizeProjectInput(input ProjectInput) (ProjectInput, error) {
+	normalized := input
+	normalized.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	normalized.Name = strings.TrimSpace(input.Name)
+	normalized.FundingSource = strings.TrimSpace(input.FundingSource)
+	if normalized.Code == "" || normalized.Name == "" {
+		return ProjectInput{}, ErrInvalidInput
+	}
+	return normalized, nil
+}
+
+func validateLineInputs(inputs []EntryLineInput) error {
+	seen := make(map[int64]struct{}, len(inputs))
+	for _, input := range inputs {
+		if input.ComponentID <= 0 || input.Amount.IsNegative() {
+			return ErrInvalidInput
+		}
+		if _, duplicate := seen[input.ComponentID]; duplicate {
+			return ErrInvalidInput
+		}
+		seen[input.ComponentID] = struct{}{}
+	}
+	return nil
+}
+
+func buildEntryLines(entryID int64, inputs []EntryLineInput, components []Component) ([]EntryLine, error) {
+	byID := make(map[int64]Component, len(components))
+	for _, component := range components {
+		byID[component.ID] = component
+	}
+
+	lines := make([]EntryLine, 0, len(inputs))
+	for _, input := range inputs {
+		component, ok := byID[input.ComponentID]
+		if !ok {
+			return nil, ErrComponentNotFound
+		}
+		// System components (such as PAYE) are owned by the calculation engine.
+		if !component.IsActive || component.IsSystem {
+			return nil, ErrInvalidInput
+		}
+		if input.Amount.IsZero() {
+			continue
+		}
+		line := newEntryLine(component, input.Amount)
+		line.EntryID = entryID
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// exportComponents picks one CSV column per active component, plus any retired
+// component that still has amounts in the exported entries.
+func exportComponents(components []Component, entries []Entry) []Component {
+	used := make(map[int64]struct{})
+	for _, entry := range entries {
+		for _, line := range entry.Lines {
+			used[line.ComponentID] = struct{}{}
+		}
+	}
+
+	columns := make([]Component, 0, len(components))
+	for _, component := range components {
+		if _, ok := used[component.ID]; ok || component.IsActive {
+			columns = append(columns, component)
+		}
+	}
+	return columns
 }
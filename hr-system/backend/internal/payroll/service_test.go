@@ -1,14 +1,52 @@
 package payroll
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"errors"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"hr-system/backend/internal/money"
 )
 
 type fakeStore struct {
-	batches map[int64]Batch
-	entries map[int64]Entry
+	batches    map[int64]Batch
+	entries    map[int64]Entry
+	components map[int64]Component
+	taxTables  []TaxTable
+	schemes    []ContributionScheme
+	members    []ContributionMember
+	// userEmployees links user accounts to employee records (employees.user_id).
+	userEmployees map[int64]int64
+	settings      Settings
+	// bankAccounts holds each employee's bank details, keyed by employee ID.
+	bankAccounts map[int64]BankPayment
+	transitions  []BatchTransition
+	// batchEmployees holds the employees chosen for off-cycle batches.
+	batchEmployees map[int64][]int64
+	// editors holds the users who generated or edited each batch.
+	editors  map[int64][]int64
+	stages   []ApprovalStage
+	signOffs []BatchSignOff
+	// nationalIDs holds employees' national IDs, keyed by employee ID.
+	nationalIDs map[int64]string
+	loans       map[int64]Loan
+	recurring   map[int64]RecurringItem
+	repayments  []LoanRepayment
+	projects    []Project
+	allocations []CostAllocation
+	glAccounts  []GLAccount
+	// journalLayout is nil until a layout is saved.
+	journalLayout *JournalLayout
+	exchangeRates []ExchangeRate
+	// simulated is what SimulateEntries returns; simulation records its input.
+	simulated   []Entry
+	simulation  *SimulationInput
+	departments map[int64]string
 
 	generateCalls int
 	approveCalls  int
@@ -49,120 +87,2189 @@ func (f *fakeStore) GetEntry(_ context.Context, entryID int64) (Entry, error) {
 	return item, nil
 }
 
-func (f *fakeStore) CreateBatch(_ context.Context, month string, createdBy int64) (Batch, error) {
+func (f *fakeStore) CreateBatch(_ context.Context, input CreateBatchInput, createdBy int64) (Batch, error) {
 	id := int64(len(f.batches) + 1)
-	batch := Batch{ID: id, Month: month, Status: StatusDraft, CreatedBy: createdBy, CreatedAt: time.Now().UTC()}
+	batch := Batch{ID: id, Month: input.Month, Status: StatusDraft, CreatedBy: createdBy, CreatedAt: time.Now().UTC(), BatchType: input.BatchType, Description: input.Description}
 	f.batches[id] = batch
+	if f.batchEmployees == nil {
+		f.batchEmployees = make(map[int64][]int64)
+	}
+	f.batchEmployees[id] = input.EmployeeIDs
 	return batch, nil
 }
 
-func (f *fakeStore) GenerateEntriesForBatch(_ context.Context, _ int64) error {
+func (f *fakeStore) GenerateEntriesForBatch(_ context.Context, batchID int64, actorUserID int64) error {
 	f.generateCalls++
+	f.recordEditor(batchID, actorUserID)
 	return nil
 }
 
-func (f *fakeStore) UpdateEntryAmounts(_ context.Context, entryID int64, allowancesTotal, deductionsTotal, taxTotal, grossPay, netPay float64) (Entry, error) {
+func (f *fakeStore) recordEditor(batchID int64, userID int64) {
+	if f.editors == nil {
+		f.editors = make(map[int64][]int64)
+	}
+	for _, editor := range f.editors[batchID] {
+		if editor == userID {
+			return
+		}
+	}
+	f.editors[batchID] = append(f.editors[batchID], userID)
+}
+
+func (f *fakeStore) UpdateEntryAmounts(_ context.Context, entryID int64, update EntryUpdate) (Entry, error) {
 	entry, ok := f.entries[entryID]
 	if !ok {
 		return Entry{}, ErrEntryNotFound
 	}
-	entry.AllowancesTotal = allowancesTotal
-	entry.DeductionsTotal = deductionsTotal
-	entry.TaxTotal = taxTotal
-	entry.GrossPay = grossPay
-	entry.NetPay = netPay
+	amounts := update.Amounts
+	entry.Lines = update.Lines
+	entry.TaxablePay = amounts.TaxablePay
+	entry.PensionablePay = amounts.PensionablePay
+	entry.EmployerContributionsTotal = amounts.EmployerContributionsTotal
+	entry.TaxOverride = update.TaxOverride
+	entry.TaxOverrideReason = update.TaxOverrideReason
+	entry.AllowancesTotal = amounts.AllowancesTotal
+	entry.DeductionsTotal = amounts.DeductionsTotal
+	entry.TaxTotal = amounts.TaxTotal
+	entry.GrossPay = amounts.GrossPay
+	entry.NetPay = amounts.NetPay
 	f.entries[entryID] = entry
+	f.recordEditor(entry.BatchID, update.UpdatedBy)
 	return entry, nil
 }
 
-func (f *fakeStore) ApproveBatch(_ context.Context, batchID int64, approvedBy int64, approvedAt time.Time) (Batch, error) {
+func (f *fakeStore) ApplyEntryUpdates(ctx context.Context, batchID int64, updates map[int64]EntryUpdate, actorUserID int64) error {
+	if f.batches[batchID].Status != StatusDraft {
+		return ErrBatchImmutable
+	}
+	for entryID, update := range updates {
+		if _, err := f.UpdateEntryAmounts(ctx, entryID, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) SignOffBatch(_ context.Context, batchID int64, stage ApprovalStage, final bool, actorUserID int64, comment string) (Batch, error) {
+	f.signOffs = append(f.signOffs, BatchSignOff{
+		ID:            int64(len(f.signOffs) + 1),
+		BatchID:       batchID,
+		StagePosition: stage.Position,
+		StageName:     stage.Name,
+		StageRole:     stage.Role,
+		Decision:      SignOffSigned,
+		Comment:       comment,
+		ActorUserID:   actorUserID,
+		CreatedAt:     time.Now().UTC(),
+	})
+	batch := f.batches[batchID]
+	batch.Status = StatusInApproval
+	if final {
+		approvedAt := time.Now().UTC()
+		batch.Status = StatusApproved
+		batch.ApprovedBy = &actorUserID
+		batch.ApprovedAt = &approvedAt
+		f.approveCalls++
+	}
+	f.batches[batchID] = batch
+	return batch, nil
+}
+
+func (f *fakeStore) RejectBatch(_ context.Context, batchID int64, stage ApprovalStage, actorUserID int64, comment string) (Batch, error) {
+	now := time.Now().UTC()
+	f.supersedeSignOffs(batchID, now)
+	f.signOffs = append(f.signOffs, BatchSignOff{
+		ID:            int64(len(f.signOffs) + 1),
+		BatchID:       batchID,
+		StagePosition: stage.Position,
+		StageName:     stage.Name,
+		StageRole:     stage.Role,
+		Decision:      SignOffRejected,
+		Comment:       comment,
+		ActorUserID:   actorUserID,
+		CreatedAt:     now,
+		SupersededAt:  &now,
+	})
 	batch := f.batches[batchID]
-	batch.Status = StatusApproved
-	batch.ApprovedBy = &approvedBy
-	batch.ApprovedAt = &approvedAt
+	batch.Status = StatusDraft
 	f.batches[batchID] = batch
-	f.approveCalls++
+	f.transitions = append(f.transitions, BatchTransition{BatchID: batchID, FromStatus: StatusInApproval, ToStatus: StatusDraft, Justification: comment, ActorUserID: actorUserID})
 	return batch, nil
 }
 
+func (f *fakeStore) supersedeSignOffs(batchID int64, at time.Time) {
+	for i := range f.signOffs {
+		if f.signOffs[i].BatchID == batchID && f.signOffs[i].SupersededAt == nil {
+			f.signOffs[i].SupersededAt = &at
+		}
+	}
+}
+
+func (f *fakeStore) ListBatchSignOffs(_ context.Context, batchID int64) ([]BatchSignOff, error) {
+	items := make([]BatchSignOff, 0)
+	for _, signOff := range f.signOffs {
+		if signOff.BatchID == batchID {
+			items = append(items, signOff)
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) ListApprovalStages(_ context.Context) ([]ApprovalStage, error) {
+	return append([]ApprovalStage(nil), f.stages...), nil
+}
+
+func (f *fakeStore) ReplaceApprovalStages(_ context.Context, stages []ApprovalStageInput, updatedBy int64) ([]ApprovalStage, error) {
+	for _, batch := range f.batches {
+		if batch.Status == StatusInApproval {
+			return nil, ErrApprovalInProgress
+		}
+	}
+	f.stages = make([]ApprovalStage, 0, len(stages))
+	for i, stage := range stages {
+		f.stages = append(f.stages, ApprovalStage{ID: int64(i + 1), Position: i + 1, Name: stage.Name, Role: stage.Role, UpdatedBy: &updatedBy, CreatedAt: time.Now().UTC()})
+	}
+	return f.stages, nil
+}
+
 func (f *fakeStore) LockBatch(_ context.Context, batchID int64, lockedAt time.Time) (Batch, error) {
 	batch := f.batches[batchID]
+	entries := make([]Entry, 0)
+	for _, entry := range f.entries {
+		if entry.BatchID == batchID {
+			entries = append(entries, entry)
+		}
+	}
+	batch.LockDigest = ComputeBatchDigest(batch, entries)
 	batch.Status = StatusLocked
 	batch.LockedAt = &lockedAt
 	f.batches[batchID] = batch
+	for _, entry := range f.entries {
+		if entry.BatchID != batchID {
+			continue
+		}
+		for _, deduction := range entry.LoanDeductions {
+			loan := f.loans[deduction.LoanID]
+			loan.OutstandingBalance = loan.OutstandingBalance.Sub(deduction.Amount)
+			if loan.OutstandingBalance.IsZero() {
+				loan.Status = LoanStatusSettled
+			}
+			f.loans[loan.ID] = loan
+			f.repayments = append(f.repayments, LoanRepayment{ID: int64(len(f.repayments) + 1), LoanID: loan.ID, BatchID: batchID, Month: batch.Month, EntryID: entry.ID, Amount: deduction.Amount, BalanceAfter: loan.OutstandingBalance})
+		}
+	}
 	f.lockCalls++
 	return batch, nil
 }
 
-func newTestService() *Service {
-	store := &fakeStore{
-		batches: map[int64]Batch{
-			1: {ID: 1, Month: "2026-02", Status: StatusDraft, CreatedBy: 1, CreatedAt: time.Now().UTC()},
-			2: {ID: 2, Month: "2026-01", Status: StatusApproved, CreatedBy: 1, CreatedAt: time.Now().UTC()},
-		},
-		entries: map[int64]Entry{
-			10: {
-				ID:              10,
-				BatchID:         1,
-				EmployeeID:      21,
-				EmployeeName:    "Doe, Jane",
-				BaseSalary:      1000,
-				AllowancesTotal: 0,
-				DeductionsTotal: 0,
-				TaxTotal:        0,
-				GrossPay:        1000,
-				NetPay:          1000,
-			},
-			11: {
-				ID:              11,
-				BatchID:         2,
-				EmployeeID:      22,
-				EmployeeName:    "Doe, John",
-				BaseSalary:      1200,
-				AllowancesTotal: 10,
-				DeductionsTotal: 2,
-				TaxTotal:        1,
-				GrossPay:        1210,
-				NetPay:          1207,
-			},
-		},
+func (f *fakeStore) ReopenBatch(_ context.Context, batchID int64, actorUserID int64, justification string) (Batch, error) {
+	batch := f.batches[batchID]
+	batch.Status = StatusDraft
+	batch.ApprovedBy = nil
+	batch.ApprovedAt = nil
+	f.batches[batchID] = batch
+	f.supersedeSignOffs(batchID, time.Now().UTC())
+	f.transitions = append(f.transitions, BatchTransition{BatchID: batchID, FromStatus: StatusApproved, ToStatus: StatusDraft, Justification: justification, ActorUserID: actorUserID})
+	return batch, nil
+}
+
+func (f *fakeStore) ReverseBatch(_ context.Context, batchID int64, actorUserID int64, justification string) (Batch, error) {
+	original := f.batches[batchID]
+	original.Status = StatusReversed
+	f.batches[batchID] = original
+
+	correcting := Batch{ID: int64(len(f.batches) + 1), Month: original.Month, Status: StatusDraft, CreatedBy: actorUserID, CorrectsBatchID: &batchID}
+	f.batches[correcting.ID] = correcting
+	for _, entry := range f.entries {
+		if entry.BatchID == batchID {
+			entry.ID += 100
+			entry.BatchID = correcting.ID
+			f.entries[entry.ID] = entry
+		}
 	}
-	svc, _ := NewService(store)
-	return svc
+	f.transitions = append(f.transitions,
+		BatchTransition{BatchID: batchID, FromStatus: StatusLocked, ToStatus: StatusReversed, Justification: justification, RelatedBatchID: &correcting.ID, ActorUserID: actorUserID},
+		BatchTransition{BatchID: correcting.ID, ToStatus: StatusDraft, Justification: justification, RelatedBatchID: &batchID, ActorUserID: actorUserID},
+	)
+	return correcting, nil
 }
 
-func TestStatusTransitions(t *testing.T) {
-	svc := newTestService()
+func (f *fakeStore) DeleteBatch(_ context.Context, batchID int64, _ int64) error {
+	delete(f.batches, batchID)
+	for id, entry := range f.entries {
+		if entry.BatchID == batchID {
+			delete(f.entries, id)
+		}
+	}
+	return nil
+}
 
-	if _, err := svc.ApproveBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1); err != nil {
-		t.Fatalf("approve draft batch: %v", err)
+func (f *fakeStore) ListBatchTransitions(_ context.Context, batchID int64) ([]BatchTransition, error) {
+	items := make([]BatchTransition, 0)
+	for _, transition := range f.transitions {
+		if transition.BatchID == batchID {
+			items = append(items, transition)
+		}
 	}
-	if _, err := svc.LockBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1); err != nil {
-		t.Fatalf("lock approved batch: %v", err)
+	return items, nil
+}
+
+func (f *fakeStore) ListBatchEditors(_ context.Context, batchID int64) ([]int64, error) {
+	return f.editors[batchID], nil
+}
+
+func (f *fakeStore) ListComponents(_ context.Context) ([]Component, error) {
+	items := make([]Component, 0, len(f.components))
+	for id := int64(1); id <= int64(len(f.components)); id++ {
+		items = append(items, f.components[id])
 	}
-	if _, err := svc.ApproveBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2); err != ErrInvalidStatusTransition {
-		t.Fatalf("expected invalid status transition, got %v", err)
+	return items, nil
+}
+
+func (f *fakeStore) GetComponent(_ context.Context, componentID int64) (Component, error) {
+	item, ok := f.components[componentID]
+	if !ok {
+		return Component{}, ErrComponentNotFound
 	}
+	return item, nil
 }
 
-func TestUpdateEntryOnlyDraftBatch(t *testing.T) {
-	svc := newTestService()
+func (f *fakeStore) CreateComponent(_ context.Context, input ComponentInput) (Component, error) {
+	id := int64(len(f.components) + 1)
+	item := Component{ID: id, Code: input.Code, Name: input.Name, Type: input.Type, IsTaxable: input.IsTaxable, IsPensionable: input.IsPensionable, IsActive: input.IsActive}
+	f.components[id] = item
+	return item, nil
+}
 
-	_, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, UpdateEntryAmountsInput{
-		AllowancesTotal: 100,
-		DeductionsTotal: 30,
-		TaxTotal:        20,
-	})
-	if err != nil {
-		t.Fatalf("update draft entry failed: %v", err)
+func (f *fakeStore) UpdateComponent(_ context.Context, componentID int64, input ComponentInput) (Component, error) {
+	item, ok := f.components[componentID]
+	if !ok {
+		return Component{}, ErrComponentNotFound
+	}
+	item.Name = input.Name
+	item.IsTaxable = input.IsTaxable
+	item.IsPensionable = input.IsPensionable
+	item.IsActive = input.IsActive
+	f.components[componentID] = item
+	return item, nil
+}
+
+func (f *fakeStore) ListTaxTables(_ context.Context) ([]TaxTable, error) {
+	return f.taxTables, nil
+}
+
+func (f *fakeStore) CreateTaxTable(_ context.Context, table TaxTable) (TaxTable, error) {
+	table.ID = int64(len(f.taxTables) + 1)
+	table.Version = 1
+	f.taxTables = append(f.taxTables, table)
+	return table, nil
+}
+
+func (f *fakeStore) ListContributionSchemes(_ context.Context) ([]ContributionScheme, error) {
+	return f.schemes, nil
+}
+
+func (f *fakeStore) GetContributionScheme(_ context.Context, schemeID int64) (ContributionScheme, error) {
+	for _, scheme := range f.schemes {
+		if scheme.ID == schemeID {
+			return scheme, nil
+		}
+	}
+	return ContributionScheme{}, ErrContributionSchemeNotFound
+}
+
+func (f *fakeStore) UpdateContributionScheme(_ context.Context, schemeID int64, input ContributionSchemeInput, updatedBy int64) (ContributionScheme, error) {
+	for i, scheme := range f.schemes {
+		if scheme.ID != schemeID {
+			continue
+		}
+		scheme.Name = input.Name
+		scheme.EmployeeRate = input.EmployeeRate
+		scheme.EmployerRate = input.EmployerRate
+		scheme.Ceiling = input.Ceiling
+		scheme.IsMandatory = input.IsMandatory
+		scheme.IsActive = input.IsActive
+		scheme.UpdatedBy = &updatedBy
+		f.schemes[i] = scheme
+		return scheme, nil
+	}
+	return ContributionScheme{}, ErrContributionSchemeNotFound
+}
+
+func (f *fakeStore) ListContributionMembers(_ context.Context) ([]ContributionMember, error) {
+	return f.members, nil
+}
+
+func (f *fakeStore) UpsertContributionMember(_ context.Context, schemeID int64, input ContributionMemberInput) (ContributionMember, error) {
+	member := ContributionMember{SchemeID: schemeID, EmployeeID: input.EmployeeID, MemberNumber: input.MemberNumber}
+	for i, existing := range f.members {
+		if existing.SchemeID == schemeID && existing.EmployeeID == input.EmployeeID {
+			f.members[i] = member
+			return member, nil
+		}
+	}
+	f.members = append(f.members, member)
+	return member, nil
+}
+
+func (f *fakeStore) DeleteContributionMember(_ context.Context, schemeID int64, employeeID int64) error {
+	for i, existing := range f.members {
+		if existing.SchemeID == schemeID && existing.EmployeeID == employeeID {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			return nil
+		}
+	}
+	return ErrContributionMemberNotFound
+}
+
+func (f *fakeStore) ListBatchEmployeeDetails(_ context.Context, batchID int64) ([]EmployeeDetails, error) {
+	items := make([]EmployeeDetails, 0)
+	for _, entry := range f.entries {
+		if entry.BatchID == batchID {
+			items = append(items, EmployeeDetails{ID: entry.EmployeeID, Name: entry.EmployeeName, Position: "Officer", NationalID: f.nationalIDs[entry.EmployeeID], TaxResidency: entry.TaxResidency})
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) SumEntriesByEmployee(_ context.Context, fromMonth, toMonth string) (map[int64]Amounts, error) {
+	totals := make(map[int64]Amounts)
+	for _, entry := range f.entries {
+		batch := f.batches[entry.BatchID]
+		if (batch.Status != StatusApproved && batch.Status != StatusLocked) || batch.Month < fromMonth || batch.Month > toMonth {
+			continue
+		}
+		sum := totals[entry.EmployeeID]
+		sum.GrossPay = sum.GrossPay.Add(entry.GrossPay)
+		sum.TaxTotal = sum.TaxTotal.Add(entry.TaxTotal)
+		sum.NetPay = sum.NetPay.Add(entry.NetPay)
+		totals[entry.EmployeeID] = sum
+	}
+	return totals, nil
+}
+
+func (f *fakeStore) ListPriorMonthIncome(_ context.Context, month string, excludeBatchID int64) (map[int64]PriorIncome, error) {
+	income := make(map[int64]PriorIncome)
+	for _, entry := range f.entries {
+		batch := f.batches[entry.BatchID]
+		if batch.ID == excludeBatchID || batch.Month != month || (batch.Status != StatusInApproval && batch.Status != StatusApproved && batch.Status != StatusLocked) {
+			continue
+		}
+		prior := income[entry.EmployeeID]
+		prior.EmployeeID = entry.EmployeeID
+		prior.TaxablePay = prior.TaxablePay.Add(entry.TaxablePay)
+		prior.TaxTotal = prior.TaxTotal.Add(entry.TaxTotal)
+		income[entry.EmployeeID] = prior
+	}
+	return income, nil
+}
+
+func (f *fakeStore) ResolveEmployeeByUserID(_ context.Context, userID int64) (int64, error) {
+	employeeID, ok := f.userEmployees[userID]
+	if !ok {
+		return 0, ErrForbidden
+	}
+	return employeeID, nil
+}
+
+func (f *fakeStore) ListEmployeeEntries(_ context.Context, employeeID int64) ([]EmployeeEntry, error) {
+	items := make([]EmployeeEntry, 0)
+	for _, entry := range f.entries {
+		batch := f.batches[entry.BatchID]
+		if entry.EmployeeID != employeeID || (batch.Status != StatusApproved && batch.Status != StatusLocked) {
+			continue
+		}
+		items = append(items, EmployeeEntry{Entry: entry, Month: batch.Month, BatchStatus: batch.Status})
+	}
+	return items, nil
+}
+
+func (f *fakeStore) ListBatchBankPayments(_ context.Context, batchID int64) ([]BankPayment, error) {
+	items := make([]BankPayment, 0)
+	for _, entry := range f.entries {
+		if entry.BatchID != batchID {
+			continue
+		}
+		payment := f.bankAccounts[entry.EmployeeID]
+		payment.EntryID = entry.ID
+		payment.EmployeeID = entry.EmployeeID
+		payment.EmployeeName = entry.EmployeeName
+		payment.Amount = entry.NetPay
+		items = append(items, payment)
+	}
+	return items, nil
+}
+
+func (f *fakeStore) GetSettings(_ context.Context) (Settings, error) {
+	return f.settings, nil
+}
+
+func (f *fakeStore) UpdateSettings(_ context.Context, input SettingsInput, updatedBy int64) (Settings, error) {
+	f.settings = Settings{ProrationBasis: input.ProrationBasis, VarianceThresholdPercent: *input.VarianceThresholdPercent, SegregationPolicy: input.SegregationPolicy, UpdatedBy: &updatedBy, UpdatedAt: time.Now().UTC()}
+	return f.settings, nil
+}
+
+func (f *fakeStore) ListRecurringItems(_ context.Context, filter RecurringItemFilter) ([]RecurringItem, error) {
+	items := make([]RecurringItem, 0, len(f.recurring))
+	for _, item := range f.recurring {
+		if (filter.EmployeeID == 0 || item.EmployeeID == filter.EmployeeID) && (filter.Month == "" || item.AppliesTo(filter.Month)) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (f *fakeStore) GetRecurringItem(_ context.Context, itemID int64) (RecurringItem, error) {
+	item, ok := f.recurring[itemID]
+	if !ok {
+		return RecurringItem{}, ErrRecurringItemNotFound
+	}
+	return item, nil
+}
+
+func (f *fakeStore) CreateRecurringItem(_ context.Context, input RecurringItemInput, createdBy int64) (RecurringItem, error) {
+	if f.recurring == nil {
+		f.recurring = make(map[int64]RecurringItem)
+	}
+	item := f.recurringItem(int64(len(f.recurring)+1), input)
+	item.CreatedBy = createdBy
+	f.recurring[item.ID] = item
+	return item, nil
+}
+
+func (f *fakeStore) UpdateRecurringItem(_ context.Context, itemID int64, input RecurringItemInput) (RecurringItem, error) {
+	existing, ok := f.recurring[itemID]
+	if !ok {
+		return RecurringItem{}, ErrRecurringItemNotFound
+	}
+	item := f.recurringItem(itemID, input)
+	item.CreatedBy = existing.CreatedBy
+	f.recurring[itemID] = item
+	return item, nil
+}
+
+func (f *fakeStore) recurringItem(itemID int64, input RecurringItemInput) RecurringItem {
+	component := f.components[input.ComponentID]
+	return RecurringItem{
+		ID:            itemID,
+		EmployeeID:    input.EmployeeID,
+		ComponentID:   component.ID,
+		ComponentCode: component.Code,
+		ComponentName: component.Name,
+		ComponentType: component.Type,
+		Amount:        input.Amount,
+		PercentOfBase: input.PercentOfBase,
+		StartMonth:    input.StartMonth,
+		EndMonth:      input.EndMonth,
+		Note:          input.Note,
 	}
+}
+
+func (f *fakeStore) DeleteRecurringItem(_ context.Context, itemID int64) error {
+	if _, ok := f.recurring[itemID]; !ok {
+		return ErrRecurringItemNotFound
+	}
+	delete(f.recurring, itemID)
+	return nil
+}
+
+func (f *fakeStore) ListLoans(_ context.Context, filter LoanFilter) ([]Loan, error) {
+	items := make([]Loan, 0, len(f.loans))
+	for _, loan := range f.loans {
+		if (filter.EmployeeID == 0 || loan.EmployeeID == filter.EmployeeID) && (filter.Status == "" || loan.Status == filter.Status) {
+			items = append(items, loan)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (f *fakeStore) GetLoan(_ context.Context, loanID int64) (Loan, error) {
+	loan, ok := f.loans[loanID]
+	if !ok {
+		return Loan{}, ErrLoanNotFound
+	}
+	return loan, nil
+}
+
+func (f *fakeStore) CreateLoan(_ context.Context, loan Loan) (Loan, error) {
+	if f.loans == nil {
+		f.loans = make(map[int64]Loan)
+	}
+	loan.ID = int64(len(f.loans) + 1)
+	loan.OutstandingBalance = loan.TotalRepayable
+	loan.Status = LoanStatusActive
+	loan.CreatedAt = time.Now().UTC()
+	loan.UpdatedAt = loan.CreatedAt
+	f.loans[loan.ID] = loan
+	return loan, nil
+}
+
+func (f *fakeStore) CancelLoan(_ context.Context, loanID int64, reason string) (Loan, error) {
+	loan := f.loans[loanID]
+	loan.Status = LoanStatusCancelled
+	loan.CancelReason = reason
+	f.loans[loanID] = loan
+	return loan, nil
+}
+
+func (f *fakeStore) ListLoanRepayments(_ context.Context, loanID int64) ([]LoanRepayment, error) {
+	items := make([]LoanRepayment, 0)
+	for _, repayment := range f.repayments {
+		if repayment.LoanID == loanID {
+			items = append(items, repayment)
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) SimulateEntries(_ context.Context, input SimulationInput) ([]Entry, error) {
+	f.simulation = &input
+	return f.simulated, nil
+}
+
+func (f *fakeStore) ListEmployeeDetails(_ context.Context, employeeIDs []int64) ([]EmployeeDetails, error) {
+	items := make([]EmployeeDetails, 0, len(employeeIDs))
+	for _, employeeID := range employeeIDs {
+		items = append(items, EmployeeDetails{ID: employeeID, Department: f.departments[employeeID]})
+	}
+	return items, nil
+}
+
+func (f *fakeStore) GetEmployeeDetails(_ context.Context, employeeID int64) (EmployeeDetails, error) {
+	for _, entry := range f.entries {
+		if entry.EmployeeID == employeeID {
+			return EmployeeDetails{ID: employeeID, Name: entry.EmployeeName, Position: "Officer", NationalID: f.nationalIDs[employeeID], TaxResidency: entry.TaxResidency}, nil
+		}
+	}
+	return EmployeeDetails{}, ErrEmployeeNotFound
+}
 
-	_, err = svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 11, UpdateEntryAmountsInput{
-		AllowancesTotal: 100,
-		DeductionsTotal: 30,
-		TaxTotal:        20,
+// lockedEntryAmounts sums Locked entries per employee and month, which is
+// what the accumulators in payroll_ytd_totals hold.
+func (f *fakeStore) lockedEntryAmounts(fromMonth, toMonth string, employeeID int64) []MonthlyTotals {
+	type key struct {
+		employeeID int64
+		month      string
+	}
+	totals := make(map[key]MonthlyTotals)
+	for _, entry := range f.entries {
+		batch := f.batches[entry.BatchID]
+		if batch.Status != StatusLocked || batch.Month < fromMonth || batch.Month > toMonth || (employeeID != 0 && entry.EmployeeID != employeeID) {
+			continue
+		}
+		k := key{entry.EmployeeID, batch.Month}
+		row := totals[k]
+		row.EmployeeID = entry.EmployeeID
+		row.Month = batch.Month
+		row.TaxYearAmounts = row.TaxYearAmounts.Add(TaxYearAmounts{
+			GrossPay:              entry.GrossPay,
+			TaxablePay:            entry.TaxablePay,
+			PensionablePay:        entry.PensionablePay,
+			TaxTotal:              entry.TaxTotal,
+			DeductionsTotal:       entry.DeductionsTotal,
+			EmployerContributions: entry.EmployerContributionsTotal,
+			NetPay:                entry.NetPay,
+		})
+		totals[k] = row
+	}
+	items := make([]MonthlyTotals, 0, len(totals))
+	for _, row := range totals {
+		items = append(items, row)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].EmployeeID != items[j].EmployeeID {
+			return items[i].EmployeeID < items[j].EmployeeID
+		}
+		return items[i].Month < items[j].Month
 	})
-	if err != ErrBatchImmutable {
-		t.Fatalf("expected immutable error for approved batch entry, got %v", err)
+	return items
+}
+
+func (f *fakeStore) ListYTDTotals(_ context.Context, fiscalYear int, employeeID int64) ([]YTDTotals, error) {
+	first, last := FiscalYearMonths(fiscalYear)
+	items := make([]YTDTotals, 0)
+	for _, month := range f.lockedEntryAmounts(first, last, employeeID) {
+		if n := len(items); n > 0 && items[n-1].EmployeeID == month.EmployeeID {
+			items[n-1].TaxYearAmounts = items[n-1].TaxYearAmounts.Add(month.TaxYearAmounts)
+			continue
+		}
+		details, _ := f.GetEmployeeDetails(context.Background(), month.EmployeeID)
+		items = append(items, YTDTotals{EmployeeID: month.EmployeeID, EmployeeName: details.Name, NationalID: details.NationalID, FiscalYear: fiscalYear, TaxYearAmounts: month.TaxYearAmounts})
+	}
+	return items, nil
+}
+
+func (f *fakeStore) ListLockedMonthlyTotals(_ context.Context, fromMonth, toMonth string, employeeID int64) ([]MonthlyTotals, error) {
+	return f.lockedEntryAmounts(fromMonth, toMonth, employeeID), nil
+}
+
+func (f *fakeStore) RebuildYTDTotals(_ context.Context, _ int, _ int64) error {
+	return nil
+}
+
+func (f *fakeStore) ListProjects(_ context.Context) ([]Project, error) {
+	return append([]Project(nil), f.projects...), nil
+}
+
+func (f *fakeStore) CreateProject(_ context.Context, input ProjectInput) (Project, error) {
+	for _, project := range f.projects {
+		if project.Code == input.Code {
+			return Project{}, ErrProjectAlreadyExists
+		}
+	}
+	project := Project{ID: int64(len(f.projects) + 1), Code: input.Code, Name: input.Name, FundingSource: input.FundingSource, IsActive: input.IsActive}
+	f.projects = append(f.projects, project)
+	return project, nil
+}
+
+func (f *fakeStore) UpdateProject(_ context.Context, projectID int64, input ProjectInput) (Project, error) {
+	for i, project := range f.projects {
+		if project.ID == projectID {
+			project.Name = input.Name
+			project.FundingSource = input.FundingSource
+			project.IsActive = input.IsActive
+			f.projects[i] = project
+			return project, nil
+		}
+	}
+	return Project{}, ErrProjectNotFound
+}
+
+func (f *fakeStore) ListCostAllocations(_ context.Context, employeeID int64) ([]CostAllocation, error) {
+	items := make([]CostAllocation, 0)
+	for _, allocation := range f.allocations {
+		if employeeID == 0 || allocation.EmployeeID == employeeID {
+			items = append(items, allocation)
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) ReplaceCostAllocation(ctx context.Context, employeeID int64, input CostAllocationInput, createdBy int64) ([]CostAllocation, error) {
+	_ = f.DeleteCostAllocation(ctx, employeeID, input.EffectiveFrom)
+	for _, share := range input.Shares {
+		project, _ := findProjectByID(f.projects, share.ProjectID)
+		f.allocations = append(f.allocations, CostAllocation{EmployeeID: employeeID, EffectiveFrom: input.EffectiveFrom, ProjectID: project.ID, ProjectCode: project.Code, ProjectName: project.Name, FundingSource: project.FundingSource, Percent: share.Percent, CreatedBy: createdBy})
+	}
+	return f.ListCostAllocations(ctx, employeeID)
+}
+
+func (f *fakeStore) DeleteCostAllocation(_ context.Context, employeeID int64, effectiveFrom string) error {
+	kept := f.allocations[:0]
+	for _, allocation := range f.allocations {
+		if allocation.EmployeeID != employeeID || allocation.EffectiveFrom != effectiveFrom {
+			kept = append(kept, allocation)
+		}
+	}
+	if len(kept) == len(f.allocations) {
+		return ErrCostAllocationNotFound
+	}
+	f.allocations = kept
+	return nil
+}
+
+func (f *fakeStore) ListGLAccounts(_ context.Context) ([]GLAccount, error) {
+	return append([]GLAccount(nil), f.glAccounts...), nil
+}
+
+func (f *fakeStore) ReplaceGLAccounts(ctx context.Context, accounts []GLAccountInput, updatedBy int64) ([]GLAccount, error) {
+	f.glAccounts = nil
+	for i, input := range accounts {
+		account := GLAccount{ID: int64(i + 1), Posting: input.Posting, AccountCode: input.AccountCode, AccountName: input.AccountName, ExpenseAccountCode: input.ExpenseAccountCode, ExpenseAccountName: input.ExpenseAccountName, UpdatedBy: &updatedBy}
+		if input.ComponentID != 0 {
+			componentID := input.ComponentID
+			component := f.components[componentID]
+			account.ComponentID = &componentID
+			account.ComponentCode = component.Code
+			account.ComponentName = component.Name
+			account.ComponentType = component.Type
+		}
+		f.glAccounts = append(f.glAccounts, account)
+	}
+	return f.ListGLAccounts(ctx)
+}
+
+func (f *fakeStore) GetJournalLayout(_ context.Context) (JournalLayout, error) {
+	if f.journalLayout == nil {
+		return DefaultJournalLayout(), nil
+	}
+	return *f.journalLayout, nil
+}
+
+func (f *fakeStore) UpdateJournalLayout(_ context.Context, input JournalLayoutInput, updatedBy int64) (JournalLayout, error) {
+	f.journalLayout = &JournalLayout{Columns: input.Columns, Delimiter: input.Delimiter, IncludeHeader: input.IncludeHeader, UpdatedBy: &updatedBy}
+	return *f.journalLayout, nil
+}
+
+func (f *fakeStore) ListExchangeRates(_ context.Context, month string) ([]ExchangeRate, error) {
+	items := make([]ExchangeRate, 0)
+	for _, rate := range f.exchangeRates {
+		if month == "" || rate.Month == month {
+			items = append(items, rate)
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) UpsertExchangeRate(ctx context.Context, input ExchangeRateInput, updatedBy int64) (ExchangeRate, error) {
+	_ = f.DeleteExchangeRate(ctx, input.Month, input.Currency)
+	rate := ExchangeRate{Month: input.Month, Currency: input.Currency, Rate: input.Rate, UpdatedBy: &updatedBy}
+	f.exchangeRates = append(f.exchangeRates, rate)
+	return rate, nil
+}
+
+func (f *fakeStore) DeleteExchangeRate(_ context.Context, month, currency string) error {
+	for i, rate := range f.exchangeRates {
+		if rate.Month == month && rate.Currency == currency {
+			f.exchangeRates = append(f.exchangeRates[:i], f.exchangeRates[i+1:]...)
+			return nil
+		}
+	}
+	return ErrExchangeRateNotFound
+}
+
+func newTestService() *Service {
+	store := &fakeStore{
+		batches: map[int64]Batch{
+			1: {ID: 1, Month: "2026-02", Status: StatusDraft, CreatedBy: 1, CreatedAt: time.Now().UTC()},
+			2: {ID: 2, Month: "2026-01", Status: StatusApproved, CreatedBy: 1, CreatedAt: time.Now().UTC()},
+		},
+		entries: map[int64]Entry{
+			10: {
+				ID:              10,
+				BatchID:         1,
+				EmployeeID:      21,
+				EmployeeName:    "Doe, Jane",
+				TaxResidency:    TaxResidencyResident,
+				BaseSalary:      money.FromInt(1000),
+				AllowancesTotal: money.FromInt(0),
+				DeductionsTotal: money.FromInt(0),
+				TaxTotal:        money.FromInt(0),
+				GrossPay:        money.FromInt(1000),
+				NetPay:          money.FromInt(1000),
+			},
+			11: {
+				ID:              11,
+				BatchID:         2,
+				EmployeeID:      22,
+				EmployeeName:    "Doe, John",
+				BaseSalary:      money.FromInt(1200),
+				ProrationBasis:  ProrationWorkingDays,
+				ProrationFactor: 0.6,
+				AllowancesTotal: money.FromInt(10),
+				DeductionsTotal: money.FromInt(2),
+				TaxTotal:        money.FromInt(1),
+				GrossPay:        money.FromInt(1210),
+				NetPay:          money.FromInt(1207),
+				Lines: []EntryLine{
+					{EntryID: 11, ComponentID: 1, ComponentCode: "HOUSING", ComponentName: "Housing Allowance", ComponentType: ComponentTypeEarning, Amount: money.FromInt(10)},
+					{EntryID: 11, ComponentID: 3, ComponentCode: "SACCO", ComponentName: "SACCO Contribution", ComponentType: ComponentTypeDeduction, Amount: money.FromInt(2)},
+					{EntryID: 11, ComponentID: 4, ComponentCode: "PAYE", ComponentName: "PAYE", ComponentType: ComponentTypeTax, IsSystem: true, Amount: money.FromInt(1)},
+				},
+			},
+		},
+		components: map[int64]Component{
+			1: {ID: 1, Code: "HOUSING", Name: "Housing Allowance", Type: ComponentTypeEarning, IsTaxable: true, IsActive: true},
+			2: {ID: 2, Code: "TRANSPORT", Name: "Transport Allowance", Type: ComponentTypeEarning, IsTaxable: true, IsActive: true},
+			3: {ID: 3, Code: "SACCO", Name: "SACCO Contribution", Type: ComponentTypeDeduction, IsActive: true},
+			4: {ID: 4, Code: "PAYE", Name: "PAYE", Type: ComponentTypeTax, IsSystem: true, IsActive: true},
+			5: {ID: 5, Code: "AIRTIME", Name: "Airtime Allowance", Type: ComponentTypeEarning, IsTaxable: true, IsActive: false},
+			6: {ID: 6, Code: "MEALS", Name: "Meals Allowance", Type: ComponentTypeEarning, IsActive: true},
+			7: {ID: 7, Code: "NSSF_EMPLOYEE", Name: "NSSF Employee Contribution", Type: ComponentTypeDeduction, IsSystem: true, IsActive: true},
+			8: {ID: 8, Code: "NSSF_EMPLOYER", Name: "NSSF Employer Contribution", Type: ComponentTypeEmployer, IsSystem: true, IsActive: true},
+			9: {ID: 9, Code: ComponentCodeUnpaidLeave, Name: "Unpaid Leave", Type: ComponentTypeEarning, IsSystem: true, IsActive: true},
+		},
+		userEmployees: map[int64]int64{31: 21, 32: 22},
+		settings:      Settings{ProrationBasis: ProrationWorkingDays, VarianceThresholdPercent: DefaultVarianceThresholdPercent, SegregationPolicy: SegregationCreatorAndEditors},
+		stages:        []ApprovalStage{{ID: 1, Position: 1, Name: "Finance Approval", Role: "Finance Officer"}},
+		taxTables: []TaxTable{
+			{
+				ID:            1,
+				Residency:     TaxResidencyResident,
+				Version:       1,
+				EffectiveFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Brackets: []TaxBracket{
+					{LowerBound: money.FromInt(0), UpperBound: amountPtr(1000), Rate: 0},
+					{LowerBound: money.FromInt(1000), Rate: 0.1},
+				},
+			},
+		},
+	}
+	svc, _ := NewService(store, Employer{Name: "HISP Uganda", TIN: "1000000000"})
+	return svc
+}
+
+func TestStatusTransitions(t *testing.T) {
+	svc := newTestService()
+
+	if _, err := svc.ApproveBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1); err != nil {
+		t.Fatalf("approve draft batch: %v", err)
+	}
+	if _, err := svc.LockBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1); err != nil {
+		t.Fatalf("lock approved batch: %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2); err != ErrInvalidStatusTransition {
+		t.Fatalf("expected invalid status transition, got %v", err)
+	}
+}
+
+func TestApproveBatchEnforcesSegregationOfDuties(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	creator := Actor{UserID: 1, Role: "Finance Officer"}
+	editor := Actor{UserID: 9, Role: "Finance Officer"}
+	approver := Actor{UserID: 12, Role: "Admin"}
+
+	if _, err := svc.UpdateEntryAmounts(context.Background(), editor, 10, testLineInput()); err != nil {
+		t.Fatalf("edit entry: %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), creator, 1); err != ErrSegregationOfDuties {
+		t.Fatalf("expected creator to be barred, got %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), editor, 1); err != ErrSegregationOfDuties {
+		t.Fatalf("expected editor to be barred, got %v", err)
+	}
+
+	// Relaxed to the creator only, the editor may approve.
+	if _, err := svc.UpdateSettings(context.Background(), approver, SettingsInput{ProrationBasis: ProrationWorkingDays, SegregationPolicy: SegregationCreator}); err != nil {
+		t.Fatalf("relax segregation policy: %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), creator, 1); err != ErrSegregationOfDuties {
+		t.Fatalf("expected creator to stay barred, got %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), editor, 1); err != nil {
+		t.Fatalf("expected editor to approve under creator-only policy, got %v", err)
+	}
+	if store.approveCalls != 1 {
+		t.Fatalf("expected one approval, got %d", store.approveCalls)
+	}
+	if _, err := svc.UpdateSettings(context.Background(), approver, SettingsInput{ProrationBasis: ProrationWorkingDays, SegregationPolicy: "Nobody"}); err != ErrInvalidInput {
+		t.Fatalf("expected unknown policy to be rejected, got %v", err)
+	}
+}
+
+func TestSegregationOfDutiesAllowsOneSignerForSeveralStages(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	creator := Actor{UserID: 1, Role: "Admin"}
+	admin := Actor{UserID: 12, Role: "Admin"}
+
+	if _, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{
+		{Name: "Finance Review", Role: "Finance Officer"},
+		{Name: "Finance Approval", Role: "Finance Officer"},
+	}); err != nil {
+		t.Fatalf("update approval stages: %v", err)
+	}
+	// Segregation bars the creator and editors at every stage, but one
+	// approver may sign several stages, so a small team is not deadlocked.
+	if _, err := svc.ApproveBatch(context.Background(), creator, 1); err != ErrSegregationOfDuties {
+		t.Fatalf("expected creator to be barred, got %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), admin, 1); err != nil {
+		t.Fatalf("first stage: %v", err)
+	}
+	if _, err := svc.ApproveBatch(context.Background(), creator, 1); err != ErrSegregationOfDuties {
+		t.Fatalf("expected creator to stay barred at the second stage, got %v", err)
+	}
+	batch, err := svc.ApproveBatch(context.Background(), admin, 1)
+	if err != nil {
+		t.Fatalf("second stage by the same approver: %v", err)
+	}
+	if batch.Status != StatusApproved || store.approveCalls != 1 {
+		t.Fatalf("expected batch approved once, got %+v", batch)
+	}
+}
+
+func TestApprovalChainSignsStagesInOrder(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	admin := Actor{UserID: 12, Role: "Admin"}
+	hr := Actor{UserID: 13, Role: "HR Officer"}
+	finance := Actor{UserID: 14, Role: "Finance Officer"}
+	director := Actor{UserID: 15, Role: "Master Admin"}
+
+	if _, err := svc.UpdateApprovalStages(context.Background(), finance, []ApprovalStageInput{{Name: "Finance Approval", Role: "Finance Officer"}}); err != ErrForbidden {
+		t.Fatalf("expected only Admin to change the chain, got %v", err)
+	}
+	if _, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{{Name: " ", Role: "HR Officer"}}); err != ErrInvalidInput {
+		t.Fatalf("expected blank stage name to be rejected, got %v", err)
+	}
+	stages, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{
+		{Name: "HR Verification", Role: "HR Officer"},
+		{Name: "Finance Approval", Role: "Finance Officer"},
+		{Name: "Executive Authorization", Role: "Master Admin"},
+	})
+	if err != nil {
+		t.Fatalf("update approval stages: %v", err)
+	}
+	if len(stages) != 3 || stages[2].Position != 3 {
+		t.Fatalf("unexpected stages: %+v", stages)
+	}
+
+	if _, err := svc.SignOffBatch(context.Background(), finance, 1, SignOffInput{}); err != ErrForbidden {
+		t.Fatalf("expected finance to wait for HR, got %v", err)
+	}
+	batch, err := svc.SignOffBatch(context.Background(), hr, 1, SignOffInput{Comment: "headcount verified"})
+	if err != nil {
+		t.Fatalf("hr sign-off: %v", err)
+	}
+	if batch.Status != StatusInApproval {
+		t.Fatalf("expected batch in approval, got %s", batch.Status)
+	}
+	if _, err := svc.UpdateEntryAmounts(context.Background(), finance, 10, testLineInput()); err != ErrBatchImmutable {
+		t.Fatalf("expected batch in approval to be immutable, got %v", err)
+	}
+	if _, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{{Name: "Finance Approval", Role: "Finance Officer"}}); err != ErrApprovalInProgress {
+		t.Fatalf("expected chain change to be refused mid-approval, got %v", err)
+	}
+	if _, err := svc.SignOffBatch(context.Background(), finance, 1, SignOffInput{}); err != nil {
+		t.Fatalf("finance sign-off: %v", err)
+	}
+
+	// A rejection needs a reason and starts the round over from HR.
+	if _, err := svc.RejectBatch(context.Background(), director, 1, SignOffInput{Comment: " "}); err != ErrJustificationRequired {
+		t.Fatalf("expected rejection comment to be required, got %v", err)
+	}
+	batch, err = svc.RejectBatch(context.Background(), director, 1, SignOffInput{Comment: "bonus not budgeted"})
+	if err != nil {
+		t.Fatalf("reject batch: %v", err)
+	}
+	if batch.Status != StatusDraft {
+		t.Fatalf("expected rejected batch back in Draft, got %s", batch.Status)
+	}
+	detail, err := svc.GetBatch(context.Background(), director, 1)
+	if err != nil {
+		t.Fatalf("get batch as approver: %v", err)
+	}
+	if detail.NextStage == nil || detail.NextStage.Role != "HR Officer" {
+		t.Fatalf("expected approval to restart at HR, got %+v", detail.NextStage)
+	}
+	if len(detail.SignOffs) != 3 || detail.SignOffs[2].Decision != SignOffRejected {
+		t.Fatalf("expected sign-off history to be kept, got %+v", detail.SignOffs)
+	}
+
+	for _, actor := range []Actor{hr, finance} {
+		if _, err := svc.SignOffBatch(context.Background(), actor, 1, SignOffInput{}); err != nil {
+			t.Fatalf("re-sign as %s: %v", actor.Role, err)
+		}
+	}
+	batch, err = svc.SignOffBatch(context.Background(), director, 1, SignOffInput{Comment: "authorized"})
+	if err != nil {
+		t.Fatalf("executive sign-off: %v", err)
+	}
+	if batch.Status != StatusApproved || batch.ApprovedBy == nil || *batch.ApprovedBy != director.UserID {
+		t.Fatalf("expected final stage to approve, got %+v", batch)
+	}
+	if store.approveCalls != 1 {
+		t.Fatalf("expected one approval, got %d", store.approveCalls)
+	}
+}
+
+func TestApprovalChainStageRoles(t *testing.T) {
+	svc := newTestService()
+	admin := Actor{UserID: 12, Role: "Admin"}
+	hr := Actor{UserID: 13, Role: "HR Officer"}
+	master := Actor{UserID: 15, Role: "Master"}
+
+	if _, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{{Name: "Finance Approval", Role: "Finance Oficer"}}); err != ErrInvalidInput {
+		t.Fatalf("expected unknown role to be rejected, got %v", err)
+	}
+	if _, err := svc.UpdateApprovalStages(context.Background(), admin, []ApprovalStageInput{
+		{Name: "HR Verification", Role: "HR Officer"},
+		{Name: "Finance Approval", Role: "Finance Officer"},
+		{Name: "Executive Authorization", Role: "Master Admin"},
+	}); err != nil {
+		t.Fatalf("update approval stages: %v", err)
+	}
+
+	if _, err := svc.SignOffBatch(context.Background(), Actor{UserID: 16, Role: "Viewer"}, 1, SignOffInput{}); err != ErrForbidden {
+		t.Fatalf("expected viewer to be refused, got %v", err)
+	}
+	if _, err := svc.SignOffBatch(context.Background(), hr, 1, SignOffInput{}); err != nil {
+		t.Fatalf("hr sign-off: %v", err)
+	}
+	// An Admin may sign any stage, as they could approve before the chain.
+	if _, err := svc.ApproveBatch(context.Background(), admin, 1); err != nil {
+		t.Fatalf("admin sign-off of the finance stage: %v", err)
+	}
+	// "Master" signs a stage held by "Master Admin".
+	batch, err := svc.SignOffBatch(context.Background(), master, 1, SignOffInput{})
+	if err != nil {
+		t.Fatalf("master sign-off: %v", err)
+	}
+	if batch.Status != StatusApproved {
+		t.Fatalf("expected batch approved, got %s", batch.Status)
+	}
+}
+
+func TestReopenApprovedBatch(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.ReopenBatch(context.Background(), actor, 2, BatchTransitionInput{Justification: "  "}); err != ErrJustificationRequired {
+		t.Fatalf("expected justification required, got %v", err)
+	}
+	if _, err := svc.ReopenBatch(context.Background(), Actor{UserID: 5, Role: "Viewer"}, 2, BatchTransitionInput{Justification: "wrong rate"}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	if _, err := svc.ReopenBatch(context.Background(), actor, 1, BatchTransitionInput{Justification: "wrong rate"}); err != ErrInvalidStatusTransition {
+		t.Fatalf("expected draft batch to be refused, got %v", err)
+	}
+
+	batch, err := svc.ReopenBatch(context.Background(), actor, 2, BatchTransitionInput{Justification: "housing allowance keyed twice"})
+	if err != nil {
+		t.Fatalf("reopen approved batch: %v", err)
+	}
+	if batch.Status != StatusDraft || batch.ApprovedBy != nil {
+		t.Fatalf("expected draft batch without approval, got %+v", batch)
+	}
+	detail, err := svc.GetBatch(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("get batch: %v", err)
+	}
+	if len(detail.Transitions) != 1 || detail.Transitions[0].Justification != "housing allowance keyed twice" || detail.Transitions[0].ActorUserID != 9 {
+		t.Fatalf("expected reopen in batch history, got %+v", detail.Transitions)
+	}
+	store := svc.store.(*fakeStore)
+	entry := store.entries[11]
+	entry.TaxResidency = TaxResidencyResident
+	store.entries[11] = entry
+	if _, err := svc.UpdateEntryAmounts(context.Background(), actor, 11, testLineInput()); err != nil {
+		t.Fatalf("reopened batch should accept edits: %v", err)
+	}
+}
+
+func TestReopenRefusedWhileAnotherBatchOfTheMonthIsPending(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+	input := BatchTransitionInput{Justification: "housing allowance keyed twice"}
+
+	// A January bonus taxed on top of the approved regular run is in approval.
+	store.batches[3] = Batch{ID: 3, Month: "2026-01", Status: StatusInApproval, BatchType: BatchTypeBonus}
+	if _, err := svc.ReopenBatch(context.Background(), actor, 2, input); err != ErrMonthBatchPending {
+		t.Fatalf("expected reopen to be refused while the bonus is in approval, got %v", err)
+	}
+	bonus := store.batches[3]
+	bonus.Status = StatusApproved
+	store.batches[3] = bonus
+	if _, err := svc.ReopenBatch(context.Background(), actor, 2, input); err != ErrMonthBatchPending {
+		t.Fatalf("expected reopen to be refused while the bonus is approved, got %v", err)
+	}
+
+	// Once the bonus is locked its PAYE is final and the regular run may reopen.
+	bonus.Status = StatusLocked
+	store.batches[3] = bonus
+	if _, err := svc.ReopenBatch(context.Background(), actor, 2, input); err != nil {
+		t.Fatalf("reopen after bonus locked: %v", err)
+	}
+}
+
+func TestReverseLockedBatchCreatesCorrectingBatch(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	locked := store.batches[2]
+	locked.Status = StatusLocked
+	store.batches[2] = locked
+	master := Actor{UserID: 1, Role: "Master Admin"}
+	input := BatchTransitionInput{Justification: "PAYE band applied to wrong residency"}
+
+	if _, err := svc.ReverseBatch(context.Background(), Actor{UserID: 9, Role: "Admin"}, 2, input); err != ErrForbidden {
+		t.Fatalf("expected admin to be refused, got %v", err)
+	}
+	if _, err := svc.ReverseBatch(context.Background(), master, 2, BatchTransitionInput{}); err != ErrJustificationRequired {
+		t.Fatalf("expected justification required, got %v", err)
+	}
+	if _, err := svc.ReverseBatch(context.Background(), master, 1, input); err != ErrInvalidStatusTransition {
+		t.Fatalf("expected draft batch to be refused, got %v", err)
+	}
+
+	correcting, err := svc.ReverseBatch(context.Background(), master, 2, input)
+	if err != nil {
+		t.Fatalf("reverse locked batch: %v", err)
+	}
+	if correcting.Status != StatusDraft || correcting.Month != "2026-01" || correcting.CorrectsBatchID == nil || *correcting.CorrectsBatchID != 2 {
+		t.Fatalf("unexpected correcting batch: %+v", correcting)
+	}
+	if store.batches[2].Status != StatusReversed {
+		t.Fatalf("expected original batch reversed, got %s", store.batches[2].Status)
+	}
+	if _, err := svc.ExportBatchCSV(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2); err != ErrBatchImmutable {
+		t.Fatalf("expected reversed batch to be excluded from export, got %v", err)
+	}
+	entries, _ := store.GetBatchEntries(context.Background(), correcting.ID)
+	if len(entries) != 1 || entries[0].NetPay != money.FromInt(1207) {
+		t.Fatalf("expected copied entry in correcting batch, got %+v", entries)
+	}
+	if _, err := svc.ReverseBatch(context.Background(), master, 2, input); err != ErrInvalidStatusTransition {
+		t.Fatalf("expected second reversal to be refused, got %v", err)
+	}
+
+	// "Master" is the same role as "Master Admin".
+	store.batches[5] = Batch{ID: 5, Month: "2025-12", Status: StatusLocked, CreatedBy: 1}
+	if _, err := svc.ReverseBatch(context.Background(), Actor{UserID: 2, Role: "Master"}, 5, input); err != nil {
+		t.Fatalf("expected Master to reverse like Master Admin, got %v", err)
+	}
+}
+
+func TestDeleteDraftBatchMasterOnly(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	master := Actor{UserID: 1, Role: "Master Admin"}
+
+	if err := svc.DeleteBatch(context.Background(), Actor{UserID: 9, Role: "Admin"}, 1); err != ErrForbidden {
+		t.Fatalf("expected admin to be refused, got %v", err)
+	}
+	if err := svc.DeleteBatch(context.Background(), master, 2); err != ErrBatchImmutable {
+		t.Fatalf("expected approved batch to be kept, got %v", err)
+	}
+	if err := svc.DeleteBatch(context.Background(), master, 99); err != ErrBatchNotFound {
+		t.Fatalf("expected missing batch, got %v", err)
+	}
+	store.batches[3] = Batch{ID: 3, Month: "2026-03", Status: StatusDraft, CreatedBy: 1}
+	if err := svc.DeleteBatch(context.Background(), Actor{UserID: 2, Role: "Master"}, 3); err != nil {
+		t.Fatalf("expected Master to delete like Master Admin, got %v", err)
+	}
+	if err := svc.DeleteBatch(context.Background(), master, 1); err != nil {
+		t.Fatalf("delete draft batch: %v", err)
+	}
+	if _, ok := store.batches[1]; ok {
+		t.Fatalf("expected batch removed")
+	}
+	if _, ok := store.entries[10]; ok {
+		t.Fatalf("expected entries removed with batch")
+	}
+}
+
+func amountPtr(units int64) *money.Amount {
+	value := money.FromInt(units)
+	return &value
+}
+
+func testLineInput() UpdateEntryAmountsInput {
+	return UpdateEntryAmountsInput{Lines: []EntryLineInput{
+		{ComponentID: 1, Amount: money.FromInt(60)},
+		{ComponentID: 2, Amount: money.FromInt(40)},
+		{ComponentID: 3, Amount: money.FromInt(30)},
+	}}
+}
+
+func TestImportEntryAmountsPreviewsThenApplies(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+	store.nationalIDs = map[int64]string{23: "CM900"}
+	store.entries[12] = Entry{ID: 12, BatchID: 1, EmployeeID: 23, EmployeeName: "Okello, Sam", TaxResidency: TaxResidencyResident, BaseSalary: money.FromInt(500), GrossPay: money.FromInt(500), NetPay: money.FromInt(500)}
+
+	withErrors := "employee_id,national_id,employee_name,HOUSING,SACCO\n" +
+		"21,,\"Doe, Jane\",\"1,500\",30\n" +
+		",cm900,\"Okello, Sam\",200,\n" +
+		"99,,Ghost,10,\n" +
+		"21,,,5,\n" +
+		"\n" +
+		",,,abc,-5\n"
+	result, err := svc.ImportEntryAmounts(context.Background(), actor, 1, ImportEntriesInput{FileName: "amounts.csv", Content: []byte(withErrors)})
+	if err != nil {
+		t.Fatalf("import with errors: %v", err)
+	}
+	if result.Applied || result.ErrorCount != 5 || len(result.Rows) != 5 {
+		t.Fatalf("expected 5 rows with 5 errors and nothing applied, got %+v", result)
+	}
+	if got := result.Rows[2].Errors; len(got) != 1 || got[0] != "employee 99 is not in this batch" {
+		t.Fatalf("unexpected unknown employee errors: %v", got)
+	}
+	if got := result.Rows[3].Errors; len(got) != 1 || got[0] != "employee already listed on row 2" {
+		t.Fatalf("unexpected duplicate errors: %v", got)
+	}
+	if got := result.Rows[4]; got.Row != 7 || len(got.Errors) != 3 {
+		t.Fatalf("expected key, amount and sign errors on row 7, got %+v", got)
+	}
+	if store.entries[10].NetPay != money.FromInt(1000) {
+		t.Fatalf("entries changed by a rejected import")
+	}
+
+	clean := []byte("employee_id,national_id,HOUSING,SACCO\n21,,\"1,500\",30\n,cm900,200,\n")
+	preview, err := svc.ImportEntryAmounts(context.Background(), actor, 1, ImportEntriesInput{FileName: "amounts.csv", Content: clean, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if preview.Applied || preview.ErrorCount != 0 || preview.UpdatedCount != 2 {
+		t.Fatalf("unexpected dry run result: %+v", preview)
+	}
+	// 1000 base + 1500 housing, less 150 PAYE and 30 SACCO.
+	if preview.Rows[0].NetPay != money.FromInt(2320) || len(preview.Rows[0].Changes) != 2 {
+		t.Fatalf("unexpected preview row: %+v", preview.Rows[0])
+	}
+	if store.entries[10].NetPay != money.FromInt(1000) {
+		t.Fatalf("dry run changed the entry")
+	}
+
+	applied, err := svc.ImportEntryAmounts(context.Background(), actor, 1, ImportEntriesInput{FileName: "amounts.csv", Content: clean})
+	if err != nil {
+		t.Fatalf("apply import: %v", err)
+	}
+	if !applied.Applied || store.entries[10].NetPay != money.FromInt(2320) || store.entries[12].NetPay != money.FromInt(700) {
+		t.Fatalf("expected import to be applied, got %+v", applied)
+	}
+
+	unknown, err := svc.ImportEntryAmounts(context.Background(), actor, 1, ImportEntriesInput{FileName: "amounts.csv", Content: []byte("employee_id,PAYE,BICYCLE\n21,5,5\n")})
+	if err != nil {
+		t.Fatalf("import unknown columns: %v", err)
+	}
+	if unknown.Applied || len(unknown.Errors) != 2 {
+		t.Fatalf("expected system and unknown columns to be rejected, got %+v", unknown.Errors)
+	}
+	if _, err := svc.ImportEntryAmounts(context.Background(), actor, 2, ImportEntriesInput{FileName: "amounts.csv", Content: clean}); err != ErrBatchImmutable {
+		t.Fatalf("expected approved batch to be refused, got %v", err)
+	}
+}
+
+func TestLoanRepaidWhenBatchLocks(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.CreateLoan(context.Background(), Actor{UserID: 5, Role: "Employee"}, LoanInput{}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	invalid := []LoanInput{
+		{EmployeeID: 22, LoanType: "Grant", Principal: money.FromInt(600), Installments: 2, StartMonth: "2026-01"},
+		{EmployeeID: 22, LoanType: LoanTypeLoan, Installments: 2, StartMonth: "2026-01"},
+		{EmployeeID: 22, LoanType: LoanTypeLoan, Principal: money.FromInt(600), Installments: 0, StartMonth: "2026-01"},
+		{EmployeeID: 22, LoanType: LoanTypeLoan, Principal: money.FromInt(600), Installments: 2, StartMonth: "January"},
+	}
+	for _, input := range invalid {
+		if _, err := svc.CreateLoan(context.Background(), actor, input); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", input, err)
+		}
+	}
+
+	loan, err := svc.CreateLoan(context.Background(), actor, LoanInput{EmployeeID: 22, LoanType: LoanTypeAdvance, Principal: money.FromInt(600), Installments: 2, StartMonth: "2026-01"})
+	if err != nil {
+		t.Fatalf("create loan: %v", err)
+	}
+	if loan.InstallmentAmount != money.FromInt(300) || loan.OutstandingBalance != money.FromInt(600) || loan.CreatedBy != 9 {
+		t.Fatalf("unexpected loan: %+v", loan)
+	}
+
+	entry := store.entries[11]
+	entry.LoanDeductions = []LoanDeduction{{EntryID: 11, LoanID: loan.ID, Amount: money.FromInt(300)}}
+	store.entries[11] = entry
+	if _, err := svc.LockBatch(context.Background(), actor, 2); err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+
+	detail, err := svc.GetLoan(context.Background(), actor, loan.ID)
+	if err != nil {
+		t.Fatalf("get loan: %v", err)
+	}
+	if detail.Loan.OutstandingBalance != money.FromInt(300) || len(detail.Repayments) != 1 || detail.Repayments[0].Month != "2026-01" {
+		t.Fatalf("expected one repayment leaving 300, got %+v", detail)
+	}
+
+	csvText, err := svc.ExportLoanBalancesCSV(context.Background(), actor)
+	if err != nil {
+		t.Fatalf("export loan balances: %v", err)
+	}
+	if !strings.Contains(csvText, "22,,1,600.00,300.00,300.00") {
+		t.Fatalf("unexpected loan balances csv: %s", csvText)
+	}
+
+	if _, err := svc.CancelLoan(context.Background(), actor, loan.ID, CancelLoanInput{Reason: " "}); err != ErrJustificationRequired {
+		t.Fatalf("expected a reason to be required, got %v", err)
+	}
+	cancelled, err := svc.CancelLoan(context.Background(), actor, loan.ID, CancelLoanInput{Reason: "Written off"})
+	if err != nil || cancelled.Status != LoanStatusCancelled {
+		t.Fatalf("expected loan cancelled, got %+v (%v)", cancelled, err)
+	}
+	if _, err := svc.CancelLoan(context.Background(), actor, loan.ID, CancelLoanInput{Reason: "Again"}); err != ErrInvalidStatusTransition {
+		t.Fatalf("expected invalid transition for a cancelled loan, got %v", err)
+	}
+}
+
+func TestRecurringItemValidation(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+	amount := money.FromInt(300)
+	percent := 2.5
+
+	if _, err := svc.CreateRecurringItem(context.Background(), Actor{UserID: 5, Role: "Viewer"}, RecurringItemInput{}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	invalid := []RecurringItemInput{
+		// Neither or both of amount and percentage.
+		{EmployeeID: 21, ComponentID: 1, StartMonth: "2026-01"},
+		{EmployeeID: 21, ComponentID: 1, Amount: &amount, PercentOfBase: &percent, StartMonth: "2026-01"},
+		// Ends before it starts.
+		{EmployeeID: 21, ComponentID: 1, Amount: &amount, StartMonth: "2026-03", EndMonth: "2026-02"},
+		// PAYE is system-owned, AIRTIME is inactive.
+		{EmployeeID: 21, ComponentID: 4, Amount: &amount, StartMonth: "2026-01"},
+		{EmployeeID: 21, ComponentID: 5, Amount: &amount, StartMonth: "2026-01"},
+	}
+	for _, input := range invalid {
+		if _, err := svc.CreateRecurringItem(context.Background(), actor, input); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", input, err)
+		}
+	}
+
+	housing, err := svc.CreateRecurringItem(context.Background(), actor, RecurringItemInput{EmployeeID: 21, ComponentID: 1, Amount: &amount, StartMonth: " 2026-01 "})
+	if err != nil {
+		t.Fatalf("create recurring item: %v", err)
+	}
+	if housing.StartMonth != "2026-01" || housing.ComponentCode != "HOUSING" || housing.CreatedBy != 9 {
+		t.Fatalf("unexpected recurring item: %+v", housing)
+	}
+	if _, err := svc.CreateRecurringItem(context.Background(), actor, RecurringItemInput{EmployeeID: 21, ComponentID: 3, PercentOfBase: &percent, StartMonth: "2026-01", EndMonth: "2026-06"}); err != nil {
+		t.Fatalf("create percentage item: %v", err)
+	}
+
+	if _, err := svc.UpdateRecurringItem(context.Background(), actor, housing.ID, RecurringItemInput{EmployeeID: 22, ComponentID: 1, Amount: &amount, StartMonth: "2026-01"}); err != ErrInvalidInput {
+		t.Fatalf("expected the employee to be fixed, got %v", err)
+	}
+	updated, err := svc.UpdateRecurringItem(context.Background(), actor, housing.ID, RecurringItemInput{ComponentID: 1, Amount: &amount, StartMonth: "2026-01", EndMonth: "2026-03"})
+	if err != nil || updated.EmployeeID != 21 || updated.EndMonth != "2026-03" {
+		t.Fatalf("expected the item to end in March, got %+v (%v)", updated, err)
+	}
+
+	april, err := svc.ListRecurringItems(context.Background(), actor, RecurringItemFilter{EmployeeID: 21, Month: "2026-04"})
+	if err != nil || len(april) != 1 || april[0].ComponentCode != "SACCO" {
+		t.Fatalf("expected only the SACCO item in April, got %+v (%v)", april, err)
+	}
+
+	if err := svc.DeleteRecurringItem(context.Background(), actor, housing.ID); err != nil {
+		t.Fatalf("delete recurring item: %v", err)
+	}
+	if err := svc.DeleteRecurringItem(context.Background(), actor, housing.ID); err != ErrRecurringItemNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestUpdateEntryOnlyDraftBatch(t *testing.T) {
+	svc := newTestService()
+
+	entry, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, testLineInput())
+	if err != nil {
+		t.Fatalf("update draft entry failed: %v", err)
+	}
+	if len(entry.Lines) != 4 {
+		t.Fatalf("expected 3 manual lines and a PAYE line, got %d", len(entry.Lines))
+	}
+	if entry.AllowancesTotal != money.FromInt(100) || entry.TaxTotal != money.FromInt(10) || entry.GrossPay != money.FromInt(1100) || entry.NetPay != money.FromInt(1060) {
+		t.Fatalf("unexpected derived totals: %+v", entry)
+	}
+
+	_, err = svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 11, testLineInput())
+	if err != ErrBatchImmutable {
+		t.Fatalf("expected immutable error for approved batch entry, got %v", err)
+	}
+}
+
+func TestUpdateEntryRejectsInvalidLines(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	cases := []struct {
+		name  string
+		lines []EntryLineInput
+		want  error
+	}{
+		{name: "negative amount", lines: []EntryLineInput{{ComponentID: 1, Amount: money.FromInt(-5)}}, want: ErrInvalidInput},
+		{name: "duplicate component", lines: []EntryLineInput{{ComponentID: 1, Amount: money.FromInt(5)}, {ComponentID: 1, Amount: money.FromInt(6)}}, want: ErrInvalidInput},
+		{name: "unknown component", lines: []EntryLineInput{{ComponentID: 99, Amount: money.FromInt(5)}}, want: ErrComponentNotFound},
+		{name: "inactive component", lines: []EntryLineInput{{ComponentID: 5, Amount: money.FromInt(5)}}, want: ErrInvalidInput},
+		{name: "system component", lines: []EntryLineInput{{ComponentID: 4, Amount: money.FromInt(5)}}, want: ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if _, err := svc.UpdateEntryAmounts(context.Background(), actor, 10, UpdateEntryAmountsInput{Lines: tc.lines}); err != tc.want {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestExportBatchCSVHasComponentColumns(t *testing.T) {
+	svc := newTestService()
+
+	out, err := svc.ExportBatchCSV(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2)
+	if err != nil {
+		t.Fatalf("export approved batch: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %d lines", len(lines))
+	}
+	wantHeader := "Employee Name,Base Salary,Proration Factor,Housing Allowance,Transport Allowance,SACCO Contribution,PAYE,Meals Allowance," +
+		"NSSF Employee Contribution,NSSF Employer Contribution,Unpaid Leave,Allowances,Deductions,Tax,Gross Pay,Net Pay,Employer Contributions"
+	if lines[0] != wantHeader {
+		t.Fatalf("unexpected header:\n%s", lines[0])
+	}
+	wantRow := `"Doe, John",1200.00,0.6000,10.00,0.00,2.00,1.00,0.00,0.00,0.00,0.00,10.00,2.00,1.00,1210.00,1207.00,0.00`
+	if lines[1] != wantRow {
+		t.Fatalf("unexpected row:\n%s", lines[1])
+	}
+}
+
+func TestUpdateEntryComputesTaxFromTaxablePay(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	// Meals are non-taxable, so only base salary and housing count towards PAYE.
+	entry, err := svc.UpdateEntryAmounts(context.Background(), actor, 10, UpdateEntryAmountsInput{Lines: []EntryLineInput{
+		{ComponentID: 1, Amount: money.FromInt(500)},
+		{ComponentID: 6, Amount: money.FromInt(300)},
+	}})
+	if err != nil {
+		t.Fatalf("update entry: %v", err)
+	}
+	if entry.TaxablePay != money.FromInt(1500) || entry.TaxTotal != money.FromInt(50) {
+		t.Fatalf("expected taxable 1500 and tax 50, got %v and %v", entry.TaxablePay, entry.TaxTotal)
+	}
+	if entry.TaxOverride {
+		t.Fatalf("expected engine-computed tax")
+	}
+}
+
+func TestUpdateEntryTaxOverrideRequiresReason(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	_, err := svc.UpdateEntryAmounts(context.Background(), actor, 10, UpdateEntryAmountsInput{TaxOverride: amountPtr(75)})
+	if err != ErrTaxOverrideReason {
+		t.Fatalf("expected missing reason error, got %v", err)
+	}
+
+	entry, err := svc.UpdateEntryAmounts(context.Background(), actor, 10, UpdateEntryAmountsInput{
+		TaxOverride:       amountPtr(75),
+		TaxOverrideReason: "URA ruling for secondment",
+	})
+	if err != nil {
+		t.Fatalf("override tax: %v", err)
+	}
+	if !entry.TaxOverride || entry.TaxTotal != money.FromInt(75) || entry.NetPay != money.FromInt(925) {
+		t.Fatalf("expected overridden tax 75 and net 925, got %+v", entry)
+	}
+}
+
+func nssfScheme() ContributionScheme {
+	return ContributionScheme{
+		ID:                  1,
+		Code:                ContributionSchemeNSSF,
+		Name:                "National Social Security Fund",
+		EmployeeRate:        0.05,
+		EmployerRate:        0.10,
+		IsMandatory:         true,
+		IsActive:            true,
+		EmployeeComponentID: 7,
+		EmployerComponentID: 8,
+	}
+}
+
+func TestUpdateEntryPostsContributionLines(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	store.schemes = []ContributionScheme{nssfScheme()}
+	store.components[1] = Component{ID: 1, Code: "HOUSING", Name: "Housing Allowance", Type: ComponentTypeEarning, IsTaxable: true, IsPensionable: true, IsActive: true}
+
+	entry, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, UpdateEntryAmountsInput{Lines: []EntryLineInput{
+		{ComponentID: 1, Amount: money.FromInt(200)},
+		{ComponentID: 6, Amount: money.FromInt(100)},
+	}})
+	if err != nil {
+		t.Fatalf("update entry: %v", err)
+	}
+	// Pensionable pay is base salary plus housing; meals are not pensionable.
+	if entry.DeductionsTotal != money.FromInt(60) || entry.EmployerContributionsTotal != money.FromInt(120) {
+		t.Fatalf("expected employee 60 and employer 120 contributions, got %v and %v", entry.DeductionsTotal, entry.EmployerContributionsTotal)
+	}
+	if entry.GrossPay != money.FromInt(1300) || entry.NetPay != money.FromInt(1300-60-20) {
+		t.Fatalf("employer cost must not reduce net pay, got %+v", entry)
+	}
+}
+
+func TestUpdateEntryKeepsUnpaidLeaveDeduction(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	entry := store.entries[10]
+	entry.LeaveDeductions = []LeaveDeduction{{EntryID: 10, LeaveRequestID: 5, UnpaidDays: 2, DailyRate: money.FromInt(50), Amount: money.FromInt(100)}}
+	entry.Lines = []EntryLine{{EntryID: 10, ComponentID: 9, ComponentCode: ComponentCodeUnpaidLeave, ComponentType: ComponentTypeEarning, IsSystem: true, Amount: money.FromInt(100)}}
+	store.entries[10] = entry
+
+	updated, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, testLineInput())
+	if err != nil {
+		t.Fatalf("update entry: %v", err)
+	}
+	// Gross and taxable pay are 1000 + 100 allowances - 100 unpaid leave,
+	// inside the zero band.
+	if updated.GrossPay != money.FromInt(1000) || updated.DeductionsTotal != money.FromInt(30) || updated.TaxTotal != money.FromInt(0) || updated.NetPay != money.FromInt(970) {
+		t.Fatalf("expected unpaid leave to survive the edit, got %+v", updated)
+	}
+
+	if _, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, UpdateEntryAmountsInput{Lines: []EntryLineInput{{ComponentID: 9, Amount: money.FromInt(1)}}}); err != ErrInvalidInput {
+		t.Fatalf("expected unpaid leave line to be system-owned, got %v", err)
+	}
+}
+
+func TestCreateOffCycleBatchRequiresEmployees(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.CreateBatch(context.Background(), actor, CreateBatchInput{Month: "2026-02", BatchType: BatchTypeBonus}); err != ErrInvalidInput {
+		t.Fatalf("expected off-cycle batch without employees to be rejected, got %v", err)
+	}
+	if _, err := svc.CreateBatch(context.Background(), actor, CreateBatchInput{Month: "2026-02", BatchType: "Thirteenth", EmployeeIDs: []int64{21}}); err != ErrInvalidInput {
+		t.Fatalf("expected unknown batch type to be rejected, got %v", err)
+	}
+
+	bonus, err := svc.CreateBatch(context.Background(), actor, CreateBatchInput{Month: "2026-02", BatchType: BatchTypeBonus, Description: " Annual bonus ", EmployeeIDs: []int64{21}})
+	if err != nil {
+		t.Fatalf("create bonus batch: %v", err)
+	}
+	if bonus.BatchType != BatchTypeBonus || bonus.Description != "Annual bonus" || len(store.batchEmployees[bonus.ID]) != 1 {
+		t.Fatalf("expected bonus batch for one employee, got %+v", bonus)
+	}
+
+	regular, err := svc.CreateBatch(context.Background(), actor, CreateBatchInput{Month: "2026-03", EmployeeIDs: []int64{21}})
+	if err != nil {
+		t.Fatalf("create regular batch: %v", err)
+	}
+	if regular.BatchType != BatchTypeRegular || len(store.batchEmployees[regular.ID]) != 0 {
+		t.Fatalf("expected regular batch to pay everyone, got %+v", regular)
+	}
+}
+
+func TestUpdateOffCycleEntryTaxesCombinedMonthlyIncome(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+
+	// The regular February run is approved: 1500 taxable, 50 PAYE withheld.
+	regular := store.batches[1]
+	regular.Status = StatusApproved
+	store.batches[1] = regular
+	paid := store.entries[10]
+	paid.TaxablePay = money.FromInt(1500)
+	paid.TaxTotal = money.FromInt(50)
+	store.entries[10] = paid
+
+	store.batches[3] = Batch{ID: 3, Month: "2026-02", Status: StatusDraft, BatchType: BatchTypeBonus}
+	store.entries[12] = Entry{ID: 12, BatchID: 3, EmployeeID: 21, EmployeeName: "Doe, Jane", TaxResidency: TaxResidencyResident}
+
+	updated, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 12, UpdateEntryAmountsInput{Lines: []EntryLineInput{{ComponentID: 1, Amount: money.FromInt(500)}}})
+	if err != nil {
+		t.Fatalf("update bonus entry: %v", err)
+	}
+	// On its own 500 is inside the zero band; on top of 1500 it is taxed at 10%.
+	if updated.TaxablePay != money.FromInt(500) || updated.TaxTotal != money.FromInt(50) {
+		t.Fatalf("expected 50 PAYE on the combined 2000, got taxable %v and tax %v", updated.TaxablePay, updated.TaxTotal)
+	}
+}
+
+func TestUpdateEntryTaxesIncomeFromBatchInApproval(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+
+	// A February bonus run is part-way through approval: 1500 taxable, 50 PAYE.
+	store.batches[3] = Batch{ID: 3, Month: "2026-02", Status: StatusInApproval, BatchType: BatchTypeBonus}
+	store.entries[12] = Entry{ID: 12, BatchID: 3, EmployeeID: 21, EmployeeName: "Doe, Jane", TaxResidency: TaxResidencyResident, TaxablePay: money.FromInt(1500), TaxTotal: money.FromInt(50)}
+	regular := store.entries[10]
+	regular.TaxResidency = TaxResidencyResident
+	store.entries[10] = regular
+
+	updated, err := svc.UpdateEntryAmounts(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 10, UpdateEntryAmountsInput{Lines: []EntryLineInput{{ComponentID: 1, Amount: money.FromInt(500)}}})
+	if err != nil {
+		t.Fatalf("update regular entry: %v", err)
+	}
+	// On its own 1500 owes 50; the combined 3000 owes 200, less the bonus's 50.
+	if updated.TaxablePay != money.FromInt(1500) || updated.TaxTotal != money.FromInt(150) {
+		t.Fatalf("expected 150 PAYE on the combined 3000, got taxable %v and tax %v", updated.TaxablePay, updated.TaxTotal)
+	}
+}
+
+func TestExportVarianceCSVAgainstPreviousLockedBatch(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	current := store.batches[1]
+	current.BatchType = BatchTypeRegular
+	store.batches[1] = current
+	previous := store.batches[2]
+	previous.Status = StatusLocked
+	previous.BatchType = BatchTypeRegular
+	store.batches[2] = previous
+
+	if _, err := svc.ExportVarianceCSV(context.Background(), Actor{UserID: 9, Role: "HR Officer"}, 1); err != ErrForbidden {
+		t.Fatalf("expected HR officer to be forbidden, got %v", err)
+	}
+	csvText, err := svc.ExportVarianceCSV(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1)
+	if err != nil {
+		t.Fatalf("export variance csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvText), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and two rows, got %q", csvText)
+	}
+	if !strings.HasPrefix(lines[1], "2026-02,2026-01,21,\"Doe, Jane\",New Joiner,") || !strings.HasSuffix(lines[1], ",Yes") {
+		t.Fatalf("expected Jane as a flagged new joiner, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "Leaver") || !strings.Contains(lines[2], "-100.00") {
+		t.Fatalf("expected John as a leaver at -100%%, got %q", lines[2])
+	}
+}
+
+func TestRemittanceScheduleListsContributions(t *testing.T) {
+	svc := newTestService()
+	store := svc.store.(*fakeStore)
+	store.schemes = []ContributionScheme{nssfScheme()}
+	store.members = []ContributionMember{{SchemeID: 1, EmployeeID: 22, MemberNumber: "NS-0042"}}
+	entry := store.entries[11]
+	entry.Lines = append(entry.Lines,
+		EntryLine{EntryID: 11, ComponentID: 7, ComponentType: ComponentTypeDeduction, IsSystem: true, Amount: money.FromInt(60)},
+		EntryLine{EntryID: 11, ComponentID: 8, ComponentType: ComponentTypeEmployer, IsSystem: true, Amount: money.FromInt(120)},
+	)
+	store.entries[11] = entry
+
+	schedule, err := svc.GetRemittanceSchedule(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2)
+	if err != nil {
+		t.Fatalf("remittance schedule: %v", err)
+	}
+	if len(schedule.Schemes) != 1 || len(schedule.Schemes[0].Rows) != 1 {
+		t.Fatalf("expected one NSSF row, got %+v", schedule.Schemes)
+	}
+	if nssf := schedule.Schemes[0]; nssf.Total != money.FromInt(180) || nssf.Rows[0].MemberNumber != "NS-0042" {
+		t.Fatalf("unexpected NSSF remittance: %+v", nssf)
+	}
+
+	out, err := svc.ExportRemittanceCSV(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 2)
+	if err != nil {
+		t.Fatalf("export remittance: %v", err)
+	}
+	if !strings.Contains(out, `NSSF,2026-01,"Doe, John",NS-0042,0.00,60.00,120.00,180.00`) {
+		t.Fatalf("unexpected remittance csv:\n%s", out)
+	}
+	if _, err := svc.ExportRemittanceCSV(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, 1); err != ErrBatchImmutable {
+		t.Fatalf("expected draft remittance export to be refused, got %v", err)
+	}
+}
+
+func TestRenderPayslipForApprovedEntry(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	file, err := svc.RenderPayslip(context.Background(), actor, 11)
+	if err != nil {
+		t.Fatalf("render payslip: %v", err)
+	}
+	if file.ContentType != "application/pdf" || file.Name != "payslip-2026-01-doe-john-11.pdf" {
+		t.Fatalf("unexpected payslip file: %s (%s)", file.Name, file.ContentType)
+	}
+	for _, want := range []string{"%PDF-1.4", "(HISP Uganda)", "(Doe, John)", "(Housing Allowance)", "(PAYE)", "(1,207.00)"} {
+		if !bytes.Contains(file.Content, []byte(want)) {
+			t.Fatalf("expected payslip to contain %q", want)
+		}
+	}
+
+	if _, err := svc.RenderPayslip(context.Background(), actor, 10); err != ErrBatchImmutable {
+		t.Fatalf("expected draft batch payslip to be refused, got %v", err)
+	}
+}
+
+func TestRenderBatchPayslipsAsZipAndMergedPDF(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	archive, err := svc.RenderBatchPayslips(context.Background(), actor, 2, "zip")
+	if err != nil {
+		t.Fatalf("render batch zip: %v", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive.Content), int64(len(archive.Content)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "payslip-2026-01-doe-john-11.pdf" {
+		t.Fatalf("unexpected zip contents: %+v", reader.File)
+	}
+
+	merged, err := svc.RenderBatchPayslips(context.Background(), actor, 2, "pdf")
+	if err != nil {
+		t.Fatalf("render merged pdf: %v", err)
+	}
+	if merged.ContentType != "application/pdf" || !bytes.Contains(merged.Content, []byte("/Count 1")) {
+		t.Fatalf("expected a one-page merged pdf")
+	}
+
+	if _, err := svc.RenderBatchPayslips(context.Background(), actor, 2, "docx"); err != ErrInvalidInput {
+		t.Fatalf("expected invalid format error, got %v", err)
+	}
+	if archive.Name != "payslips-2026-01-batch-2.zip" {
+		t.Fatalf("unexpected archive name %q", archive.Name)
+	}
+
+	store := svc.store.(*fakeStore)
+	store.batches[3] = Batch{ID: 3, Month: "2026-03", Status: StatusApproved, CreatedBy: 1}
+	if _, err := svc.RenderBatchPayslips(context.Background(), actor, 3, "zip"); err != ErrInvalidInput {
+		t.Fatalf("expected batch without entries to be refused, got %v", err)
+	}
+}
+
+func TestSelfServiceSeesOnlyOwnReleasedEntries(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+
+	john := Actor{UserID: 32, Role: "Viewer"}
+	entries, err := svc.ListMyEntries(ctx, john)
+	if err != nil {
+		t.Fatalf("list own entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != 11 || entries[0].Month != "2026-01" {
+		t.Fatalf("expected only the approved January entry, got %+v", entries)
+	}
+	if _, err := svc.RenderMyPayslip(ctx, john, 11); err != nil {
+		t.Fatalf("render own payslip: %v", err)
+	}
+
+	jane := Actor{UserID: 31, Role: "Finance Officer"}
+	entries, err = svc.ListMyEntries(ctx, jane)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected draft entry to stay hidden, got %+v (%v)", entries, err)
+	}
+	if _, err := svc.RenderMyPayslip(ctx, jane, 10); err != ErrEntryNotFound {
+		t.Fatalf("expected draft payslip to be not found, got %v", err)
+	}
+	if _, err := svc.RenderMyPayslip(ctx, jane, 11); err != ErrForbidden {
+		t.Fatalf("expected another employee's payslip to be forbidden, got %v", err)
+	}
+
+	if _, err := svc.ListMyEntries(ctx, Actor{UserID: 99, Role: "Viewer"}); err != ErrForbidden {
+		t.Fatalf("expected unlinked user to be forbidden, got %v", err)
+	}
+}
+
+func TestExportBankFileValidatesAccountsFirst(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+	store := svc.store.(*fakeStore)
+
+	_, err := svc.ExportBankFile(context.Background(), actor, 2, BankFormatCSV)
+	var validationErr *BankValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, ErrBankDetailsInvalid) {
+		t.Fatalf("expected bank validation error, got %v", err)
+	}
+	if len(validationErr.Issues) != 2 || validationErr.Issues[0].EmployeeID != 22 {
+		t.Fatalf("expected missing bank name and account number for employee 22, got %+v", validationErr.Issues)
+	}
+
+	store.bankAccounts = map[int64]BankPayment{22: {BankName: "Stanbic Bank", BankCode: "SBICUGKX", BankAccountNumber: "9030001234567"}}
+	file, err := svc.ExportBankFile(context.Background(), actor, 2, BankFormatCSV)
+	if err != nil {
+		t.Fatalf("export bank csv: %v", err)
+	}
+	if file.Name != "bank-payments-2026-01-batch-2.csv" || !strings.Contains(string(file.Content), `"Doe, John",Stanbic Bank,SBICUGKX,,9030001234567,1207.00,UGX,SALARY 2026-01`) {
+		t.Fatalf("unexpected bank csv %s:\n%s", file.Name, file.Content)
+	}
+
+	issues, err := svc.ValidateBankFile(context.Background(), actor, 2, BankFormatPain001)
+	if err != nil {
+		t.Fatalf("validate pain.001: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Field != "debtor_account" || issues[1].Field != "debtor_bic" {
+		t.Fatalf("expected missing employer account details, got %+v", issues)
+	}
+
+	if _, err := svc.ExportBankFile(context.Background(), actor, 1, BankFormatCSV); err != ErrBatchImmutable {
+		t.Fatalf("expected draft batch to be refused, got %v", err)
+	}
+	if _, err := svc.ExportBankFile(context.Background(), actor, 2, "mt940"); err != ErrInvalidInput {
+		t.Fatalf("expected unknown format to be rejected, got %v", err)
+	}
+}
+
+func TestUpdateSettingsValidatesProrationBasis(t *testing.T) {
+	svc := newTestService()
+
+	if _, err := svc.UpdateSettings(context.Background(), Actor{UserID: 9, Role: "Finance Officer"}, SettingsInput{ProrationBasis: ProrationCalendarDays}); err != ErrForbidden {
+		t.Fatalf("expected finance officer to be forbidden, got %v", err)
+	}
+	if _, err := svc.UpdateSettings(context.Background(), Actor{UserID: 1, Role: "Admin"}, SettingsInput{ProrationBasis: "Hours"}); err != ErrInvalidInput {
+		t.Fatalf("expected invalid basis to be rejected, got %v", err)
+	}
+	threshold := 15.0
+	if _, err := svc.UpdateSettings(context.Background(), Actor{UserID: 1, Role: "Admin"}, SettingsInput{ProrationBasis: ProrationWorkingDays, VarianceThresholdPercent: &threshold}); err != nil {
+		t.Fatalf("set variance threshold: %v", err)
+	}
+	settings, err := svc.UpdateSettings(context.Background(), Actor{UserID: 1, Role: "Admin"}, SettingsInput{ProrationBasis: " CalendarDays "})
+	if err != nil || settings.ProrationBasis != ProrationCalendarDays || settings.VarianceThresholdPercent != 15 {
+		t.Fatalf("expected calendar-day proration keeping the threshold, got %+v (%v)", settings, err)
+	}
+	settings, err = svc.UpdateSettings(context.Background(), Actor{UserID: 1, Role: "Admin"}, SettingsInput{SegregationPolicy: SegregationCreator})
+	if err != nil || settings.ProrationBasis != ProrationCalendarDays || settings.SegregationPolicy != SegregationCreator {
+		t.Fatalf("expected an omitted basis to keep calendar days, got %+v (%v)", settings, err)
+	}
+	negative := -1.0
+	if _, err := svc.UpdateSettings(context.Background(), Actor{UserID: 1, Role: "Admin"}, SettingsInput{ProrationBasis: ProrationWorkingDays, VarianceThresholdPercent: &negative}); err != ErrInvalidInput {
+		t.Fatalf("expected negative threshold to be rejected, got %v", err)
+	}
+}
+
+func TestAnnualCertificateAndReturnFromLockedBatches(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.ListYTDTotals(context.Background(), Actor{UserID: 31, Role: "Employee"}, 2025); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	if _, err := svc.ListYTDTotals(context.Background(), actor, 25); err != ErrInvalidInput {
+		t.Fatalf("expected invalid fiscal year, got %v", err)
+	}
+	// Batch 2 (2026-01, fiscal year 2025/26) is only Approved so far.
+	if _, err := svc.RenderAnnualCertificate(context.Background(), actor, 22, 2025); err != ErrNoAnnualEarnings {
+		t.Fatalf("expected no annual earnings before lock, got %v", err)
+	}
+	if _, err := svc.LockBatch(context.Background(), actor, 2); err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+
+	certificate, err := svc.GetAnnualCertificate(context.Background(), actor, 22, 2025)
+	if err != nil {
+		t.Fatalf("get annual certificate: %v", err)
+	}
+	if len(certificate.Months) != 1 || certificate.Totals.GrossPay != money.FromInt(1210) || certificate.Totals.TaxTotal != money.FromInt(1) {
+		t.Fatalf("unexpected certificate: %+v", certificate)
+	}
+	file, err := svc.RenderAnnualCertificate(context.Background(), actor, 22, 2025)
+	if err != nil || file.ContentType != "application/pdf" || !bytes.HasPrefix(file.Content, []byte("%PDF-")) {
+		t.Fatalf("expected a pdf certificate, got %q (%v)", file.Name, err)
+	}
+	if _, err := svc.RenderMyAnnualCertificate(context.Background(), Actor{UserID: 31, Role: "Employee"}, 2025); err != ErrNoAnnualEarnings {
+		t.Fatalf("expected no earnings for Jane, got %v", err)
+	}
+	if mine, err := svc.RenderMyAnnualCertificate(context.Background(), Actor{UserID: 32, Role: "Employee"}, 2025); err != nil || mine.Name != file.Name {
+		t.Fatalf("expected John's own certificate, got %q (%v)", mine.Name, err)
+	}
+
+	csvText, err := svc.ExportAnnualReturnCSV(context.Background(), actor, 2025)
+	if err != nil {
+		t.Fatalf("export annual return: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvText), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "22,\"Doe, John\",,1210.00,") || !strings.HasPrefix(lines[2], ",Total,,1210.00,") {
+		t.Fatalf("unexpected annual return csv: %s", csvText)
+	}
+	if _, err := svc.RebuildYTDTotals(context.Background(), actor, 2025); err != ErrForbidden {
+		t.Fatalf("expected rebuild to be admin only, got %v", err)
+	}
+}
+
+func TestCostAllocationReportForLockedBatch(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.CreateProject(context.Background(), Actor{UserID: 31, Role: "Employee"}, ProjectInput{Code: "P1", Name: "Clinic"}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	clinic, err := svc.CreateProject(context.Background(), actor, ProjectInput{Code: " grant-a ", Name: "Clinic", FundingSource: "USAID", IsActive: true})
+	if err != nil || clinic.Code != "GRANT-A" {
+		t.Fatalf("create project: %+v (%v)", clinic, err)
+	}
+	schools, err := svc.CreateProject(context.Background(), actor, ProjectInput{Code: "GRANT-B", Name: "Schools", IsActive: true})
+	if err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	if _, err := svc.UpdateProject(context.Background(), actor, schools.ID, ProjectInput{Code: "GRANT-C", Name: "Schools"}); err != ErrInvalidInput {
+		t.Fatalf("expected the project code to be fixed, got %v", err)
+	}
+
+	invalid := []CostAllocationInput{
+		{EffectiveFrom: "2026-01", Shares: []CostAllocationShare{{ProjectID: clinic.ID, Percent: 60}, {ProjectID: schools.ID, Percent: 30}}},
+		{EffectiveFrom: "2026-01", Shares: []CostAllocationShare{{ProjectID: clinic.ID, Percent: 50}, {ProjectID: clinic.ID, Percent: 50}}},
+		{EffectiveFrom: "January", Shares: []CostAllocationShare{{ProjectID: clinic.ID, Percent: 100}}},
+		{EffectiveFrom: "2026-01"},
+	}
+	for _, input := range invalid {
+		if _, err := svc.SetCostAllocation(context.Background(), actor, 22, input); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", input, err)
+		}
+	}
+	if _, err := svc.SetCostAllocation(context.Background(), actor, 22, CostAllocationInput{EffectiveFrom: "2026-01", Shares: []CostAllocationShare{{ProjectID: 99, Percent: 100}}}); err != ErrProjectNotFound {
+		t.Fatalf("expected unknown project, got %v", err)
+	}
+	if _, err := svc.SetCostAllocation(context.Background(), actor, 22, CostAllocationInput{EffectiveFrom: "2025-07", Shares: []CostAllocationShare{{ProjectID: schools.ID, Percent: 100}}}); err != nil {
+		t.Fatalf("set earlier allocation: %v", err)
+	}
+	if _, err := svc.SetCostAllocation(context.Background(), actor, 22, CostAllocationInput{EffectiveFrom: "2026-01", Shares: []CostAllocationShare{{ProjectID: clinic.ID, Percent: 60}, {ProjectID: schools.ID, Percent: 40}}}); err != nil {
+		t.Fatalf("set allocation: %v", err)
+	}
+	// Another allocation that only starts after the batch month.
+	if _, err := svc.SetCostAllocation(context.Background(), actor, 22, CostAllocationInput{EffectiveFrom: "2026-03", Shares: []CostAllocationShare{{ProjectID: clinic.ID, Percent: 100}}}); err != nil {
+		t.Fatalf("set later allocation: %v", err)
+	}
+
+	if _, err := svc.GetCostAllocationReport(context.Background(), actor, 2); err != ErrBatchImmutable {
+		t.Fatalf("expected the report to need a locked batch, got %v", err)
+	}
+	if _, err := svc.LockBatch(context.Background(), actor, 2); err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+	report, err := svc.GetCostAllocationReport(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("cost allocation report: %v", err)
+	}
+	if len(report.ByProject) != 2 || report.ByProject[0].ProjectCode != "GRANT-A" || report.ByProject[0].GrossPay != money.FromInt(726) || report.ByProject[1].GrossPay != money.FromInt(484) {
+		t.Fatalf("expected 1210 gross split 60/40, got %+v", report.ByProject)
+	}
+	if report.Totals.GrossPay != money.FromInt(1210) {
+		t.Fatalf("unexpected report totals: %+v", report.Totals)
+	}
+
+	csvText, err := svc.ExportCostAllocationCSV(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("export cost allocation: %v", err)
+	}
+	if !strings.Contains(csvText, "2026-01,GRANT-A,Clinic,USAID,,1,726.00,0.00,726.00") {
+		t.Fatalf("unexpected cost allocation csv: %s", csvText)
+	}
+}
+
+func TestJournalExportForLockedBatch(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.UpdateGLAccounts(context.Background(), Actor{UserID: 31, Role: "Employee"}, nil); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	invalid := [][]GLAccountInput{
+		{{Posting: GLPostingBaseSalary, AccountCode: ""}},
+		{{Posting: "Bonus", AccountCode: "6000"}},
+		{{Posting: GLPostingNetPay, ComponentID: 1, AccountCode: "2100"}},
+		{{Posting: GLPostingComponent, AccountCode: "6010"}},
+		{{Posting: GLPostingComponent, ComponentID: 8, AccountCode: "2210"}},
+		{{Posting: GLPostingComponent, ComponentID: 3, AccountCode: "2300", ExpenseAccountCode: "6200"}},
+		{{Posting: GLPostingNetPay, AccountCode: "2100"}, {Posting: GLPostingNetPay, AccountCode: "2101"}},
+	}
+	for _, inputs := range invalid {
+		if _, err := svc.UpdateGLAccounts(context.Background(), actor, inputs); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", inputs, err)
+		}
+	}
+	if _, err := svc.UpdateGLAccounts(context.Background(), actor, []GLAccountInput{{Posting: GLPostingComponent, ComponentID: 99, AccountCode: "6010"}}); err != ErrComponentNotFound {
+		t.Fatalf("expected unknown component, got %v", err)
+	}
+
+	accounts := []GLAccountInput{
+		{Posting: GLPostingBaseSalary, AccountCode: "6000", AccountName: "Salaries"},
+		{Posting: GLPostingNetPay, AccountCode: "2100", AccountName: "Net Pay Clearing"},
+		{Posting: GLPostingComponent, ComponentID: 1, AccountCode: "6010", AccountName: "Allowances"},
+		{Posting: GLPostingComponent, ComponentID: 4, AccountCode: "2200", AccountName: "PAYE Payable"},
+	}
+	if _, err := svc.UpdateGLAccounts(context.Background(), actor, accounts); err != nil {
+		t.Fatalf("update gl accounts: %v", err)
+	}
+
+	if _, err := svc.GetJournal(context.Background(), actor, 2); err != ErrBatchImmutable {
+		t.Fatalf("expected the journal to need a locked batch, got %v", err)
+	}
+	if _, err := svc.LockBatch(context.Background(), actor, 2); err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+	_, err := svc.GetJournal(context.Background(), actor, 2)
+	var accountErr *GLAccountError
+	if !errors.As(err, &accountErr) || !errors.Is(err, ErrGLAccountMissing) || len(accountErr.Missing) != 1 || accountErr.Missing[0] != "SACCO" {
+		t.Fatalf("expected SACCO to be reported unmapped, got %v", err)
+	}
+
+	accounts = append(accounts, GLAccountInput{Posting: GLPostingComponent, ComponentID: 3, AccountCode: "2300", AccountName: "SACCO Payable"})
+	if _, err := svc.UpdateGLAccounts(context.Background(), actor, accounts); err != nil {
+		t.Fatalf("update gl accounts: %v", err)
+	}
+	journal, err := svc.GetJournal(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if len(journal.Lines) != 5 || journal.TotalDebit != money.FromInt(1210) || journal.TotalCredit != money.FromInt(1210) {
+		t.Fatalf("unexpected journal: %+v", journal)
+	}
+
+	if _, err := svc.UpdateJournalLayout(context.Background(), actor, JournalLayoutInput{Columns: []string{"account_code", "account_code"}, Delimiter: ","}); err != ErrInvalidInput {
+		t.Fatalf("expected duplicate columns to be refused, got %v", err)
+	}
+	if _, err := svc.UpdateJournalLayout(context.Background(), actor, JournalLayoutInput{Columns: []string{"account_code"}, Delimiter: ":"}); err != ErrInvalidInput {
+		t.Fatalf("expected an unsupported delimiter to be refused, got %v", err)
+	}
+	if _, err := svc.UpdateJournalLayout(context.Background(), actor, JournalLayoutInput{Columns: []string{" Account_Code ", "amount", "date"}, Delimiter: ";"}); err != nil {
+		t.Fatalf("update journal layout: %v", err)
+	}
+	csvText, err := svc.ExportJournalCSV(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("export journal: %v", err)
+	}
+	want := "6000;1200.00;2026-01-31\n6010;10.00;2026-01-31\n2300;-2.00;2026-01-31\n2200;-1.00;2026-01-31\n2100;-1207.00;2026-01-31\n"
+	if csvText != want {
+		t.Fatalf("unexpected journal csv:\n%s", csvText)
+	}
+}
+
+func TestExchangeRatesAndCurrencyReport(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.SetExchangeRate(context.Background(), Actor{UserID: 31, Role: "Employee"}, ExchangeRateInput{Month: "2026-01", Currency: "USD", Rate: 3700}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	invalid := []ExchangeRateInput{
+		{Month: "2026-01", Currency: PaymentCurrency, Rate: 1},
+		{Month: "2026-01", Currency: "US", Rate: 3700},
+		{Month: "January", Currency: "USD", Rate: 3700},
+		{Month: "2026-01", Currency: "USD", Rate: 0},
+		{Month: "2026-01", Currency: "USD", Rate: 0.0000001},
+		{Month: "2026-01", Currency: "USD", Rate: -3700},
+	}
+	for _, input := range invalid {
+		if _, err := svc.SetExchangeRate(context.Background(), actor, input); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", input, err)
+		}
+	}
+	rate, err := svc.SetExchangeRate(context.Background(), actor, ExchangeRateInput{Month: "2026-01", Currency: " usd ", Rate: 3712.3456789})
+	if err != nil || rate.Currency != "USD" || rate.Rate != 3712.345679 {
+		t.Fatalf("set exchange rate: %+v (%v)", rate, err)
+	}
+	if rates, err := svc.ListExchangeRates(context.Background(), actor, "2026-01"); err != nil || len(rates) != 1 {
+		t.Fatalf("expected one rate for January, got %+v (%v)", rates, err)
+	}
+
+	store := svc.store.(*fakeStore)
+	entry := store.entries[11]
+	entry.ContractCurrency = "USD"
+	entry.ContractMonthlySalary = money.FromInt(1)
+	entry.ExchangeRate = 1200
+	entry.MonthlyBaseSalary = money.FromInt(1200)
+	store.entries[11] = entry
+
+	report, err := svc.GetCurrencyReport(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("currency report: %v", err)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].Contract.NetPay != money.FromCents(101) || report.Rows[0].Payment.NetPay != money.FromInt(1207) {
+		t.Fatalf("unexpected currency report: %+v", report.Rows)
+	}
+	csvText, err := svc.ExportCurrencyCSV(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("export currency report: %v", err)
+	}
+	if !strings.Contains(csvText, "22,\"Doe, John\",USD,1200.000000,1.00,1.01,0.00,1.01,1200.00,1210.00,1.00,1207.00") || !strings.Contains(csvText, ",Total,,,,,,,1200.00,1210.00,1.00,1207.00") {
+		t.Fatalf("unexpected currency csv: %s", csvText)
+	}
+
+	if err := svc.DeleteExchangeRate(context.Background(), actor, "2026-01", "USD"); err != nil {
+		t.Fatalf("delete exchange rate: %v", err)
+	}
+	if err := svc.DeleteExchangeRate(context.Background(), actor, "2026-01", "USD"); err != ErrExchangeRateNotFound {
+		t.Fatalf("expected the rate to be gone, got %v", err)
+	}
+}
+
+func TestVerifyBatchDetectsChangesAfterLock(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+
+	if _, err := svc.VerifyBatch(context.Background(), actor, 2); err != ErrBatchImmutable {
+		t.Fatalf("expected an approved batch to be refused, got %v", err)
+	}
+	locked, err := svc.LockBatch(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+	if len(locked.LockDigest) != 64 {
+		t.Fatalf("expected a digest on the locked batch, got %q", locked.LockDigest)
+	}
+	if _, err := svc.VerifyBatch(context.Background(), Actor{UserID: 31, Role: "Employee"}, 2); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	result, err := svc.VerifyBatch(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("verify batch: %v", err)
+	}
+	if !result.Sealed || !result.Intact || result.ComputedDigest != locked.LockDigest || result.Entries != 1 {
+		t.Fatalf("expected the untouched batch to verify, got %+v", result)
+	}
+
+	store := svc.store.(*fakeStore)
+	entry := store.entries[11]
+	entry.NetPay = entry.NetPay.Add(money.FromInt(100))
+	store.entries[11] = entry
+	result, err = svc.VerifyBatch(context.Background(), actor, 2)
+	if err != nil {
+		t.Fatalf("verify batch: %v", err)
+	}
+	if result.Intact || result.RecordedDigest != locked.LockDigest || result.ComputedDigest == locked.LockDigest {
+		t.Fatalf("expected the changed net pay to be detected, got %+v", result)
+	}
+
+	// Batches locked before digests were recorded cannot be verified.
+	batch := store.batches[2]
+	batch.LockDigest = ""
+	store.batches[2] = batch
+	result, err = svc.VerifyBatch(context.Background(), actor, 2)
+	if err != nil || result.Sealed || result.Intact {
+		t.Fatalf("expected an unsealed result, got %+v (%v)", result, err)
+	}
+}
+
+func TestSimulatePayrollComparesWithLatestLockedBatch(t *testing.T) {
+	svc := newTestService()
+	actor := Actor{UserID: 9, Role: "Finance Officer"}
+	percent := func(value float64) *float64 { return &value }
+	amount := func(units int64) *money.Amount {
+		value := money.FromInt(units)
+		return &value
+	}
+
+	if _, err := svc.SimulatePayroll(context.Background(), Actor{UserID: 31, Role: "Employee"}, SimulationInput{Month: "2026-02"}); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	invalid := []SimulationInput{
+		{Month: "February"},
+		{Month: "2026-02", SalaryIncreasePercent: -100},
+		{Month: "2026-02", Items: []SimulationItem{{ComponentID: 2}}},
+		{Month: "2026-02", Items: []SimulationItem{{ComponentID: 2, Amount: amount(50), PercentOfBase: percent(5)}}},
+		{Month: "2026-02", Items: []SimulationItem{{ComponentID: 2, Amount: amount(-50)}}},
+		{Month: "2026-02", Items: []SimulationItem{{ComponentID: 4, Amount: amount(50)}}},
+		{Month: "2026-02", Items: []SimulationItem{{ComponentID: 5, PercentOfBase: percent(5)}}},
+	}
+	for _, input := range invalid {
+		if _, err := svc.SimulatePayroll(context.Background(), actor, input); err != ErrInvalidInput {
+			t.Fatalf("expected invalid input for %+v, got %v", input, err)
+		}
+	}
+
+	store := svc.store.(*fakeStore)
+	batch := store.batches[2]
+	batch.BatchType = BatchTypeRegular
+	store.batches[2] = batch
+	if _, err := svc.LockBatch(context.Background(), actor, 2); err != nil {
+		t.Fatalf("lock batch: %v", err)
+	}
+	store.departments = map[int64]string{22: "Finance", 23: "Programs"}
+	store.simulated = []Entry{
+		{EmployeeID: 22, EmployeeName: "Doe, John", BaseSalary: money.FromInt(1260), GrossPay: money.FromInt(1320), TaxTotal: money.FromInt(12), NetPay: money.FromInt(1306)},
+		{EmployeeID: 23, EmployeeName: "Okello, Grace", BaseSalary: money.FromInt(800), GrossPay: money.FromInt(850), NetPay: money.FromInt(850), EmployerContributionsTotal: money.FromInt(85)},
+	}
+	report, err := svc.SimulatePayroll(context.Background(), actor, SimulationInput{Month: " 2026-02 ", SalaryIncreasePercent: 5, Items: []SimulationItem{{ComponentID: 2, Amount: amount(50)}}})
+	if err != nil {
+		t.Fatalf("simulate payroll: %v", err)
+	}
+	if store.simulation == nil || store.simulation.Month != "2026-02" || len(store.simulation.Items) != 1 {
+		t.Fatalf("unexpected simulation input: %+v", store.simulation)
+	}
+	if report.Baseline == nil || report.Baseline.ID != 2 {
+		t.Fatalf("expected batch 2 as the baseline, got %+v", report.Baseline)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Department != "Finance" || report.Rows[0].Difference.GrossPay != money.FromInt(110) {
+		t.Fatalf("unexpected rows: %+v", report.Rows)
+	}
+	if report.Totals.Employees != 2 || report.Totals.Difference.TotalCost != money.FromInt(1045) {
+		t.Fatalf("unexpected totals: %+v", report.Totals)
+	}
+	if len(report.Departments) != 2 || report.Departments[1].Department != "Programs" || report.Departments[1].TotalCostChangePercent != nil {
+		t.Fatalf("unexpected departments: %+v", report.Departments)
 	}
 }
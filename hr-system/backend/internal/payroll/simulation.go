@@ -0,0 +1,221 @@
+package payroll
+
+import (
+	"math"
+	"sort"
+
+	"hr-system/backend/internal/money"
+)
+
+// SimulationInput is a what-if payroll run: the regular batch for Month is
+// generated as usual, with every monthly salary raised by
+// SalaryIncreasePercent (negative for a cut) and Items paid to every employee
+// as if they were recurring items. Nothing is saved.
+type SimulationInput struct {
+	Month                 string           `json:"month"`
+	SalaryIncreasePercent float64          `json:"salary_increase_percent"`
+	Items                 []SimulationItem `json:"items"`
+}
+
+// SimulationItem sets exactly one of Amount and PercentOfBase, like a
+// recurring item.
+type SimulationItem struct {
+	ComponentID   int64         `json:"component_id"`
+	Amount        *money.Amount `json:"amount"`
+	PercentOfBase *float64      `json:"percent_of_base"`
+}
+
+// AdjustSalary applies the salary increase to a monthly salary, rounding the
+// increase half-up to the cent.
+func (input SimulationInput) AdjustSalary(salary money.Amount) (money.Amount, error) {
+	if input.SalaryIncreasePercent == 0 {
+		return salary, nil
+	}
+	increase, err := salary.MulRate(input.SalaryIncreasePercent / 100)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return salary.Add(increase), nil
+}
+
+// RecurringItems returns the simulated items as recurring items of the
+// employee covering month.
+func (input SimulationInput) RecurringItems(employeeID int64, month string) []RecurringItem {
+	items := make([]RecurringItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		items = append(items, RecurringItem{
+			EmployeeID:    employeeID,
+			ComponentID:   item.ComponentID,
+			Amount:        item.Amount,
+			PercentOfBase: item.PercentOfBase,
+			StartMonth:    month,
+			EndMonth:      month,
+		})
+	}
+	return items
+}
+
+// SimulationAmounts are the figures compared for one employee or group.
+// TotalCost is gross pay plus employer contributions.
+type SimulationAmounts struct {
+	BaseSalary            money.Amount `json:"base_salary"`
+	GrossPay              money.Amount `json:"gross_pay"`
+	TaxTotal              money.Amount `json:"tax_total"`
+	NetPay                money.Amount `json:"net_pay"`
+	EmployerContributions money.Amount `json:"employer_contributions"`
+	TotalCost             money.Amount `json:"total_cost"`
+}
+
+func simulationAmountsOf(entry Entry) SimulationAmounts {
+	return SimulationAmounts{
+		BaseSalary:            entry.BaseSalary,
+		GrossPay:              entry.GrossPay,
+		TaxTotal:              entry.TaxTotal,
+		NetPay:                entry.NetPay,
+		EmployerContributions: entry.EmployerContributionsTotal,
+		TotalCost:             entry.GrossPay.Add(entry.EmployerContributionsTotal),
+	}
+}
+
+func (a SimulationAmounts) add(b SimulationAmounts) SimulationAmounts {
+	return SimulationAmounts{
+		BaseSalary:            a.BaseSalary.Add(b.BaseSalary),
+		GrossPay:              a.GrossPay.Add(b.GrossPay),
+		TaxTotal:              a.TaxTotal.Add(b.TaxTotal),
+		NetPay:                a.NetPay.Add(b.NetPay),
+		EmployerContributions: a.EmployerContributions.Add(b.EmployerContributions),
+		TotalCost:             a.TotalCost.Add(b.TotalCost),
+	}
+}
+
+func (a SimulationAmounts) sub(b SimulationAmounts) SimulationAmounts {
+	return SimulationAmounts{
+		BaseSalary:            a.BaseSalary.Sub(b.BaseSalary),
+		GrossPay:              a.GrossPay.Sub(b.GrossPay),
+		TaxTotal:              a.TaxTotal.Sub(b.TaxTotal),
+		NetPay:                a.NetPay.Sub(b.NetPay),
+		EmployerContributions: a.EmployerContributions.Sub(b.EmployerContributions),
+		TotalCost:             a.TotalCost.Sub(b.TotalCost),
+	}
+}
+
+// SimulationRow compares an employee's simulated entry with their entry in
+// the baseline batch. Either side is zero when the employee is paid in only
+// one of them.
+type SimulationRow struct {
+	EmployeeID   int64             `json:"employee_id"`
+	EmployeeName string            `json:"employee_name"`
+	Department   string            `json:"department"`
+	Baseline     SimulationAmounts `json:"baseline"`
+	Simulated    SimulationAmounts `json:"simulated"`
+	Difference   SimulationAmounts `json:"difference"`
+}
+
+// SimulationTotals sums the rows of a department, or of the whole run when
+// Department is empty in SimulationReport.Totals. Employees counts each
+// employee paid on either side once.
+type SimulationTotals struct {
+	Department string            `json:"department"`
+	Employees  int               `json:"employees"`
+	Baseline   SimulationAmounts `json:"baseline"`
+	Simulated  SimulationAmounts `json:"simulated"`
+	Difference SimulationAmounts `json:"difference"`
+	// TotalCostChangePercent is nil when the baseline cost is zero.
+	TotalCostChangePercent *float64 `json:"total_cost_change_percent"`
+}
+
+func (t *SimulationTotals) addRow(row SimulationRow) {
+	t.Employees++
+	t.Baseline = t.Baseline.add(row.Baseline)
+	t.Simulated = t.Simulated.add(row.Simulated)
+	t.Difference = t.Difference.add(row.Difference)
+}
+
+func (t *SimulationTotals) finish() {
+	if !t.Baseline.TotalCost.IsZero() {
+		percent := math.Round(t.Difference.TotalCost.Float64()/t.Baseline.TotalCost.Float64()*10000) / 100
+		t.TotalCostChangePercent = &percent
+	}
+}
+
+// SimulationReport compares a simulated regular batch with the latest Locked
+// regular batch.
+type SimulationReport struct {
+	Input SimulationInput `json:"input"`
+	// Baseline is nil when no regular batch is locked yet; every employee is
+	// then compared with zero.
+	Baseline    *Batch             `json:"baseline"`
+	Rows        []SimulationRow    `json:"rows"`
+	Departments []SimulationTotals `json:"departments"`
+	Totals      SimulationTotals   `json:"totals"`
+}
+
+// BuildSimulationReport matches simulated and baseline entries by employee
+// and groups them under the employee's department. Rows are ordered by
+// department then employee name.
+func BuildSimulationReport(input SimulationInput, simulated []Entry, baseline *Batch, baselineEntries []Entry, employees []EmployeeDetails) SimulationReport {
+	departments := make(map[int64]string, len(employees))
+	for _, employee := range employees {
+		departments[employee.ID] = employee.Department
+	}
+
+	rows := make(map[int64]*SimulationRow, len(simulated))
+	order := make([]int64, 0, len(simulated))
+	rowFor := func(entry Entry) *SimulationRow {
+		row, ok := rows[entry.EmployeeID]
+		if !ok {
+			row = &SimulationRow{EmployeeID: entry.EmployeeID, EmployeeName: entry.EmployeeName, Department: departments[entry.EmployeeID]}
+			rows[entry.EmployeeID] = row
+			order = append(order, entry.EmployeeID)
+		}
+		return row
+	}
+	for _, entry := range baselineEntries {
+		row := rowFor(entry)
+		row.Baseline = row.Baseline.add(simulationAmountsOf(entry))
+	}
+	for _, entry := range simulated {
+		row := rowFor(entry)
+		row.Simulated = row.Simulated.add(simulationAmountsOf(entry))
+	}
+
+	report := SimulationReport{
+		Input:       input,
+		Baseline:    baseline,
+		Rows:        make([]SimulationRow, 0, len(order)),
+		Departments: make([]SimulationTotals, 0),
+	}
+	byDepartment := make(map[string]*SimulationTotals)
+	for _, employeeID := range order {
+		row := rows[employeeID]
+		row.Difference = row.Simulated.sub(row.Baseline)
+		report.Rows = append(report.Rows, *row)
+
+		total, ok := byDepartment[row.Department]
+		if !ok {
+			total = &SimulationTotals{Department: row.Department}
+			byDepartment[row.Department] = total
+		}
+		total.addRow(*row)
+		report.Totals.addRow(*row)
+	}
+	report.Totals.finish()
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Department != report.Rows[j].Department {
+			return report.Rows[i].Department < report.Rows[j].Department
+		}
+		if report.Rows[i].EmployeeName != report.Rows[j].EmployeeName {
+			return report.Rows[i].EmployeeName < report.Rows[j].EmployeeName
+		}
+		return report.Rows[i].EmployeeID < report.Rows[j].EmployeeID
+	})
+	for _, total := range byDepartment {
+		total.finish()
+		report.Departments = append(report.Departments, *total)
+	}
+	sort.Slice(report.Departments, func(i, j int) bool {
+		return report.Departments[i].Department < report.Departments[j].Department
+	})
+	return report
+}
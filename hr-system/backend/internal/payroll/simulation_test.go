@@ -0,0 +1,95 @@
+package payroll
+
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestSimulationAdjustSalaryRoundsIncreaseHalfUp(t *testing.T) {
+	input := SimulationInput{SalaryIncreasePercent: 5}
+	if got, err := input.AdjustSalary(money.MustParse("1000.10")); err != nil || got != money.MustParse("1050.11") {
+		t.Fatalf("expected 1050.11, got %s (%v)", got, err)
+	}
+	input.SalaryIncreasePercent = -10
+	if got, err := input.AdjustSalary(money.FromInt(2000)); err != nil || got != money.FromInt(1800) {
+		t.Fatalf("expected 1800.00 after a cut, got %s (%v)", got, err)
+	}
+	input.SalaryIncreasePercent = 0
+	if got, err := input.AdjustSalary(money.MustParse("999.99")); err != nil || got != money.MustParse("999.99") {
+		t.Fatalf("expected the salary unchanged, got %s (%v)", got, err)
+	}
+}
+
+func TestSimulationItemsAddToRecurringLines(t *testing.T) {
+	components := []Component{
+		{ID: 1, Code: "HOUSING", Name: "Housing Allowance", Type: ComponentTypeEarning, IsActive: true},
+		{ID: 2, Code: "TRANSPORT", Name: "Transport Allowance", Type: ComponentTypeEarning, IsActive: true},
+	}
+	fixed := money.FromInt(50)
+	percent := 10.0
+	input := SimulationInput{Items: []SimulationItem{
+		{ComponentID: 1, Amount: &fixed},
+		{ComponentID: 2, PercentOfBase: &percent},
+	}}
+	existing := money.FromInt(100)
+	items := append([]RecurringItem{{EmployeeID: 3, ComponentID: 1, Amount: &existing, StartMonth: "2026-01"}}, input.RecurringItems(3, "2026-02")...)
+
+	lines, err := RecurringLines(items, components, "2026-02", money.FromInt(800))
+	if err != nil {
+		t.Fatalf("recurring lines: %v", err)
+	}
+	if len(lines) != 2 || lines[0].Amount != money.FromInt(150) || lines[1].Amount != money.FromInt(80) {
+		t.Fatalf("expected housing 150.00 and transport 80.00, got %+v", lines)
+	}
+	if lines, err = RecurringLines(input.RecurringItems(3, "2026-02"), components, "2026-03", money.FromInt(800)); err != nil || len(lines) != 0 {
+		t.Fatalf("expected simulated items to cover the simulated month only, got %+v (%v)", lines, err)
+	}
+}
+
+func TestBuildSimulationReportGroupsByDepartment(t *testing.T) {
+	baseline := &Batch{ID: 6, Month: "2026-01", Status: StatusLocked, BatchType: BatchTypeRegular}
+	baselineEntries := []Entry{
+		{EmployeeID: 1, EmployeeName: "Akello, Ruth", BaseSalary: money.FromInt(1000), GrossPay: money.FromInt(1000), NetPay: money.FromInt(900), TaxTotal: money.FromInt(100), EmployerContributionsTotal: money.FromInt(100)},
+		{EmployeeID: 2, EmployeeName: "Byaruhanga, Paul", BaseSalary: money.FromInt(500), GrossPay: money.FromInt(500), NetPay: money.FromInt(500), EmployerContributionsTotal: money.FromInt(50)},
+	}
+	simulated := []Entry{
+		{EmployeeID: 3, EmployeeName: "Nakato, Sarah", BaseSalary: money.FromInt(700), GrossPay: money.FromInt(700), NetPay: money.FromInt(700), EmployerContributionsTotal: money.FromInt(70)},
+		{EmployeeID: 1, EmployeeName: "Akello, Ruth", BaseSalary: money.FromInt(1050), GrossPay: money.FromInt(1100), NetPay: money.FromInt(980), TaxTotal: money.FromInt(120), EmployerContributionsTotal: money.FromInt(110)},
+	}
+	employees := []EmployeeDetails{{ID: 1, Department: "Finance"}, {ID: 2, Department: "Finance"}, {ID: 3, Department: "Programs"}}
+
+	report := BuildSimulationReport(SimulationInput{Month: "2026-02", SalaryIncreasePercent: 5}, simulated, baseline, baselineEntries, employees)
+	if len(report.Rows) != 3 {
+		t.Fatalf("expected three rows, got %+v", report.Rows)
+	}
+	wantOrder := []int64{1, 2, 3}
+	for i, employeeID := range wantOrder {
+		if report.Rows[i].EmployeeID != employeeID {
+			t.Fatalf("expected rows by department then name, got %+v", report.Rows)
+		}
+	}
+	if report.Rows[0].Difference.TotalCost != money.FromInt(110) || report.Rows[0].Difference.TaxTotal != money.FromInt(20) {
+		t.Fatalf("unexpected difference for the raised employee: %+v", report.Rows[0].Difference)
+	}
+	if report.Rows[1].Simulated.TotalCost.IsPositive() || report.Rows[1].Difference.TotalCost != money.FromInt(-550) {
+		t.Fatalf("expected the leaver to be compared with zero, got %+v", report.Rows[1])
+	}
+
+	if len(report.Departments) != 2 {
+		t.Fatalf("expected two departments, got %+v", report.Departments)
+	}
+	finance := report.Departments[0]
+	if finance.Department != "Finance" || finance.Employees != 2 || finance.Baseline.TotalCost != money.FromInt(1650) || finance.Simulated.TotalCost != money.FromInt(1210) {
+		t.Fatalf("unexpected finance totals: %+v", finance)
+	}
+	if finance.TotalCostChangePercent == nil || *finance.TotalCostChangePercent != -26.67 {
+		t.Fatalf("expected a -26.67%% change for finance, got %v", finance.TotalCostChangePercent)
+	}
+	if report.Departments[1].TotalCostChangePercent != nil {
+		t.Fatalf("expected no percentage without a baseline cost, got %v", *report.Departments[1].TotalCostChangePercent)
+	}
+	if report.Totals.Employees != 3 || report.Totals.Difference.TotalCost != money.FromInt(330) {
+		t.Fatalf("unexpected totals: %+v", report.Totals)
+	}
+}
@@ -0,0 +1,147 @@
+package payroll
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"hr-system/backend/internal/employees"
+	"hr-system/backend/internal/money"
+)
+
+// Tax tables are matched to employees.tax_residency, so the residencies are
+// the employee record's own.
+const (
+	TaxResidencyResident    = employees.TaxResidencyResident
+	TaxResidencyNonResident = employees.TaxResidencyNonResident
+)
+
+const ComponentCodePAYE = "PAYE"
+
+type TaxTable struct {
+	ID            int64        `db:"id" json:"id"`
+	Name          string       `db:"name" json:"name"`
+	Residency     string       `db:"residency" json:"residency"`
+	Version       int          `db:"version" json:"version"`
+	EffectiveFrom time.Time    `db:"effective_from" json:"effective_from"`
+	CreatedBy     *int64       `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt     time.Time    `db:"created_at" json:"created_at"`
+	Brackets      []TaxBracket `db:"-" json:"brackets"`
+}
+
+type TaxBracket struct {
+	ID         int64         `db:"id" json:"id"`
+	TableID    int64         `db:"table_id" json:"table_id"`
+	LowerBound money.Amount  `db:"lower_bound" json:"lower_bound"`
+	UpperBound *money.Amount `db:"upper_bound" json:"upper_bound,omitempty"`
+	Rate       float64       `db:"rate" json:"rate"`
+}
+
+type TaxTableInput struct {
+	Name          string            `json:"name"`
+	Residency     string            `json:"residency"`
+	EffectiveFrom string            `json:"effective_from"`
+	Brackets      []TaxBracketInput `json:"brackets"`
+}
+
+type TaxBracketInput struct {
+	LowerBound money.Amount  `json:"lower_bound"`
+	UpperBound *money.Amount `json:"upper_bound"`
+	Rate       float64       `json:"rate"`
+}
+
+// Compute applies the table's bands marginally: each band taxes only the slice
+// of taxable pay that falls between its bounds. Each band's tax is rounded
+// half-up to cents before the bands are summed.
+func (t TaxTable) Compute(taxablePay money.Amount) (money.Amount, error) {
+	var tax money.Amount
+	for _, bracket := range t.Brackets {
+		if !taxablePay.GreaterThan(bracket.LowerBound) {
+			continue
+		}
+		upper := taxablePay
+		if bracket.UpperBound != nil {
+			upper = money.Min(upper, *bracket.UpperBound)
+		}
+		bandTax, err := upper.Sub(bracket.LowerBound).MulRate(bracket.Rate)
+		if err != nil {
+			return money.Amount{}, fmt.Errorf("tax band from %s: %w", bracket.LowerBound, err)
+		}
+		tax = tax.Add(bandTax)
+	}
+	return tax, nil
+}
+
+// SelectTaxTable returns the table in force for the residency at the end of the
+// payroll month. Later effective dates win; the higher version breaks ties.
+func SelectTaxTable(tables []TaxTable, residency string, month string) (TaxTable, bool) {
+	periodEnd, err := monthEnd(month)
+	if err != nil {
+		return TaxTable{}, false
+	}
+
+	var (
+		selected TaxTable
+		found    bool
+	)
+	for _, table := range tables {
+		if table.Residency != residency || table.EffectiveFrom.After(periodEnd) {
+			continue
+		}
+		if !found ||
+			table.EffectiveFrom.After(selected.EffectiveFrom) ||
+			(table.EffectiveFrom.Equal(selected.EffectiveFrom) && table.Version > selected.Version) {
+			selected = table
+			found = true
+		}
+	}
+	return selected, found
+}
+
+func isValidTaxResidency(residency string) bool {
+	return residency == TaxResidencyResident || residency == TaxResidencyNonResident
+}
+
+// validateTaxBrackets requires contiguous bands starting at zero, with only the
+// last band left open-ended.
+func validateTaxBrackets(inputs []TaxBracketInput) ([]TaxBracket, error) {
+	if len(inputs) == 0 {
+		return nil, ErrInvalidInput
+	}
+	brackets := make([]TaxBracket, 0, len(inputs))
+	for _, input := range inputs {
+		brackets = append(brackets, TaxBracket{LowerBound: input.LowerBound, UpperBound: input.UpperBound, Rate: input.Rate})
+	}
+	sort.Slice(brackets, func(i, j int) bool { return brackets[i].LowerBound.LessThan(brackets[j].LowerBound) })
+
+	if !brackets[0].LowerBound.IsZero() {
+		return nil, ErrInvalidInput
+	}
+	for i, bracket := range brackets {
+		if !isValidRate(bracket.Rate) {
+			return nil, ErrInvalidInput
+		}
+		last := i == len(brackets)-1
+		if bracket.UpperBound == nil {
+			if !last {
+				return nil, ErrInvalidInput
+			}
+			continue
+		}
+		if !bracket.UpperBound.GreaterThan(bracket.LowerBound) {
+			return nil, ErrInvalidInput
+		}
+		if !last && brackets[i+1].LowerBound != *bracket.UpperBound {
+			return nil, ErrInvalidInput
+		}
+	}
+	return brackets, nil
+}
+
+func monthEnd(month string) (time.Time, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.AddDate(0, 1, -1), nil
+}
@@ -0,0 +1,116 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+func ugandaResidentTable() TaxTable {
+	return TaxTable{
+		ID:            1,
+		Residency:     TaxResidencyResident,
+		Version:       1,
+		EffectiveFrom: time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC),
+		Brackets: []TaxBracket{
+			{LowerBound: money.FromInt(0), UpperBound: amountPtr(235000), Rate: 0},
+			{LowerBound: money.FromInt(235000), UpperBound: amountPtr(335000), Rate: 0.1},
+			{LowerBound: money.FromInt(335000), UpperBound: amountPtr(410000), Rate: 0.2},
+			{LowerBound: money.FromInt(410000), UpperBound: amountPtr(10000000), Rate: 0.3},
+			{LowerBound: money.FromInt(10000000), Rate: 0.4},
+		},
+	}
+}
+
+func ugandaNonResidentTable() TaxTable {
+	return TaxTable{
+		ID:            2,
+		Residency:     TaxResidencyNonResident,
+		Version:       1,
+		EffectiveFrom: time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC),
+		Brackets: []TaxBracket{
+			{LowerBound: money.FromInt(0), UpperBound: amountPtr(335000), Rate: 0.1},
+			{LowerBound: money.FromInt(335000), UpperBound: amountPtr(410000), Rate: 0.2},
+			{LowerBound: money.FromInt(410000), UpperBound: amountPtr(10000000), Rate: 0.3},
+			{LowerBound: money.FromInt(10000000), Rate: 0.4},
+		},
+	}
+}
+
+func TestTaxTableComputeUgandaBands(t *testing.T) {
+	resident := ugandaResidentTable()
+	nonResident := ugandaNonResidentTable()
+
+	cases := []struct {
+		table   TaxTable
+		taxable int64
+		want    int64
+	}{
+		{table: resident, taxable: 200000, want: 0},
+		{table: resident, taxable: 235000, want: 0},
+		{table: resident, taxable: 300000, want: 6500},
+		{table: resident, taxable: 400000, want: 23000},
+		{table: resident, taxable: 1000000, want: 202000},
+		{table: resident, taxable: 12000000, want: 3702000},
+		{table: nonResident, taxable: 300000, want: 30000},
+		{table: nonResident, taxable: 400000, want: 46500},
+		{table: nonResident, taxable: 1000000, want: 225500},
+	}
+	for _, tc := range cases {
+		if got, err := tc.table.Compute(money.FromInt(tc.taxable)); err != nil || got != money.FromInt(tc.want) {
+			t.Fatalf("%s on %v: expected %v, got %v (%v)", tc.table.Residency, tc.taxable, tc.want, got, err)
+		}
+	}
+}
+
+func TestSelectTaxTableByEffectiveDate(t *testing.T) {
+	original := ugandaResidentTable()
+	revised := ugandaResidentTable()
+	revised.ID = 3
+	revised.Version = 2
+	revised.EffectiveFrom = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	tables := []TaxTable{original, revised, ugandaNonResidentTable()}
+
+	selected, ok := SelectTaxTable(tables, TaxResidencyResident, "2026-06")
+	if !ok || selected.ID != original.ID {
+		t.Fatalf("expected original table for June 2026, got %+v", selected)
+	}
+	selected, ok = SelectTaxTable(tables, TaxResidencyResident, "2026-07")
+	if !ok || selected.ID != revised.ID {
+		t.Fatalf("expected revised table for July 2026, got %+v", selected)
+	}
+	if _, ok := SelectTaxTable(tables, TaxResidencyResident, "2011-01"); ok {
+		t.Fatalf("expected no table before the first effective date")
+	}
+}
+
+func TestValidateTaxBrackets(t *testing.T) {
+	valid := []TaxBracketInput{
+		{LowerBound: money.FromInt(100), UpperBound: amountPtr(200), Rate: 0.2},
+		{LowerBound: money.FromInt(0), UpperBound: amountPtr(100), Rate: 0},
+		{LowerBound: money.FromInt(200), Rate: 0.3},
+	}
+	brackets, err := validateTaxBrackets(valid)
+	if err != nil {
+		t.Fatalf("expected valid brackets, got %v", err)
+	}
+	if brackets[0].LowerBound != money.FromInt(0) || brackets[2].UpperBound != nil {
+		t.Fatalf("expected brackets sorted by lower bound, got %+v", brackets)
+	}
+
+	gap := []TaxBracketInput{
+		{LowerBound: money.FromInt(0), UpperBound: amountPtr(100), Rate: 0},
+		{LowerBound: money.FromInt(150), Rate: 0.3},
+	}
+	if _, err := validateTaxBrackets(gap); err != ErrInvalidInput {
+		t.Fatalf("expected gap to be rejected, got %v", err)
+	}
+	openMiddle := []TaxBracketInput{
+		{LowerBound: money.FromInt(0), Rate: 0},
+		{LowerBound: money.FromInt(100), Rate: 0.3},
+	}
+	if _, err := validateTaxBrackets(openMiddle); err != ErrInvalidInput {
+		t.Fatalf("expected open-ended middle band to be rejected, got %v", err)
+	}
+}
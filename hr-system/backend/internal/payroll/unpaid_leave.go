@@ -0,0 +1,116 @@
+package payroll
+
+import (
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+// ComponentCodeUnpaidLeave is the system earning line carrying the total of
+// an entry's LeaveDeductions. It is pay that was never earned, so its amount
+// is taken off earnings rather than added.
+const ComponentCodeUnpaidLeave = "UNPAID_LEAVE"
+
+// reducesEarnings reports whether line is an earning that lowers pay instead
+// of adding to it.
+func reducesEarnings(line EntryLine) bool {
+	return line.ComponentCode == ComponentCodeUnpaidLeave
+}
+
+// UnpaidLeave is an approved leave request of an unpaid leave type.
+type UnpaidLeave struct {
+	LeaveRequestID int64     `db:"leave_request_id"`
+	EmployeeID     int64     `db:"employee_id"`
+	LeaveType      string    `db:"leave_type"`
+	StartDate      time.Time `db:"start_date"`
+	EndDate        time.Time `db:"end_date"`
+}
+
+// LeaveDeduction is the part of an entry's UNPAID_LEAVE line that comes from
+// one leave request.
+type LeaveDeduction struct {
+	EntryID        int64        `db:"entry_id" json:"entry_id"`
+	LeaveRequestID int64        `db:"leave_request_id" json:"leave_request_id"`
+	LeaveType      string       `db:"leave_type" json:"leave_type"`
+	StartDate      time.Time    `db:"start_date" json:"start_date"`
+	EndDate        time.Time    `db:"end_date" json:"end_date"`
+	UnpaidDays     int          `db:"unpaid_days" json:"unpaid_days"`
+	DailyRate      money.Amount `db:"daily_rate" json:"daily_rate"`
+	Amount         money.Amount `db:"amount" json:"amount"`
+}
+
+// ComputeLeaveDeductions prices the unpaid leave days of month that fall
+// within employment at the daily rate of the monthly salary. Days are counted
+// on the proration basis so a day of leave is worth the same as a day paid,
+// and a date covered by more than one request is only deducted once.
+func ComputeLeaveDeductions(basis, month string, monthlySalary money.Amount, hireDate time.Time, terminationDate *time.Time, leaves []UnpaidLeave) ([]LeaveDeduction, error) {
+	if !isValidProrationBasis(basis) {
+		return nil, ErrInvalidInput
+	}
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	periodDays := int64(countDays(basis, monthStart, monthEnd))
+
+	start, end := monthStart, monthEnd
+	if hire := dateOnly(hireDate); hire.After(start) {
+		start = hire
+	}
+	if terminationDate != nil {
+		if exit := dateOnly(*terminationDate); exit.Before(end) {
+			end = exit
+		}
+	}
+
+	counted := make(map[time.Time]bool)
+	deductions := make([]LeaveDeduction, 0, len(leaves))
+	for _, leave := range leaves {
+		from, to := dateOnly(leave.StartDate), dateOnly(leave.EndDate)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		days := 0
+		for current := from; !current.After(to); current = current.AddDate(0, 0, 1) {
+			if counted[current] || countDays(basis, current, current) == 0 {
+				continue
+			}
+			counted[current] = true
+			days++
+		}
+		if days == 0 {
+			continue
+		}
+		dailyRate, err := monthlySalary.MulFrac(1, periodDays)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := monthlySalary.MulFrac(int64(days), periodDays)
+		if err != nil {
+			return nil, err
+		}
+		deductions = append(deductions, LeaveDeduction{
+			LeaveRequestID: leave.LeaveRequestID,
+			LeaveType:      leave.LeaveType,
+			StartDate:      dateOnly(leave.StartDate),
+			EndDate:        dateOnly(leave.EndDate),
+			UnpaidDays:     days,
+			DailyRate:      dailyRate,
+			Amount:         amount,
+		})
+	}
+	return deductions, nil
+}
+
+// TotalLeaveDeductions is the amount of the UNPAID_LEAVE line.
+func TotalLeaveDeductions(deductions []LeaveDeduction) money.Amount {
+	var total money.Amount
+	for _, deduction := range deductions {
+		total = total.Add(deduction.Amount)
+	}
+	return total
+}
@@ -0,0 +1,99 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestComputeLeaveDeductions(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		return parsed
+	}
+	exit := date("2026-03-27")
+	leaves := []UnpaidLeave{
+		// Starts in February; only 2-4 March (Mon-Wed) fall in the batch month.
+		{LeaveRequestID: 1, LeaveType: "Unpaid Leave", StartDate: date("2026-02-26"), EndDate: date("2026-03-04")},
+		// Overlaps the first request on 3-4 March, which are not deducted twice.
+		{LeaveRequestID: 2, LeaveType: "Unpaid Leave", StartDate: date("2026-03-03"), EndDate: date("2026-03-06")},
+		// Falls after the termination date.
+		{LeaveRequestID: 3, LeaveType: "Unpaid Leave", StartDate: date("2026-03-30"), EndDate: date("2026-04-03")},
+	}
+
+	// March 2026 has 22 working days, so the daily rate of 22,000 is 1,000.
+	got, err := ComputeLeaveDeductions(ProrationWorkingDays, "2026-03", money.FromInt(22000), date("2020-01-15"), &exit, leaves)
+	if err != nil {
+		t.Fatalf("compute leave deductions: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected two deductions, got %+v", got)
+	}
+	if got[0].LeaveRequestID != 1 || got[0].UnpaidDays != 3 || got[0].DailyRate != money.FromInt(1000) || got[0].Amount != money.FromInt(3000) {
+		t.Fatalf("unexpected first deduction: %+v", got[0])
+	}
+	if got[1].LeaveRequestID != 2 || got[1].UnpaidDays != 2 || got[1].Amount != money.FromInt(2000) {
+		t.Fatalf("unexpected second deduction: %+v", got[1])
+	}
+	if total := TotalLeaveDeductions(got); total != money.FromInt(5000) {
+		t.Fatalf("expected total 5000, got %s", total)
+	}
+
+	calendar, err := ComputeLeaveDeductions(ProrationCalendarDays, "2026-03", money.FromInt(3100), date("2020-01-15"), nil, leaves[:1])
+	if err != nil {
+		t.Fatalf("compute calendar leave deductions: %v", err)
+	}
+	if len(calendar) != 1 || calendar[0].UnpaidDays != 4 || calendar[0].Amount != money.FromInt(400) {
+		t.Fatalf("expected 4 calendar days at 100, got %+v", calendar)
+	}
+
+	if _, err := ComputeLeaveDeductions("Hours", "2026-03", money.FromInt(22000), date("2020-01-15"), nil, leaves); err != ErrInvalidInput {
+		t.Fatalf("expected invalid basis error, got %v", err)
+	}
+}
+
+func TestCalculatePostsUnpaidLeaveBeforeTax(t *testing.T) {
+	components := []Component{
+		{ID: 4, Code: ComponentCodePAYE, Type: ComponentTypeTax, IsSystem: true, IsActive: true},
+		{ID: 9, Code: ComponentCodeUnpaidLeave, Type: ComponentTypeEarning, IsSystem: true, IsActive: true},
+	}
+	table := TaxTable{Brackets: []TaxBracket{
+		{LowerBound: money.FromInt(0), UpperBound: amountPtr(1000), Rate: 0},
+		{LowerBound: money.FromInt(1000), Rate: 0.1},
+	}}
+
+	result, err := Calculate(CalculationInput{
+		BaseSalary:  money.FromInt(2200),
+		Components:  components,
+		TaxTable:    &table,
+		UnpaidLeave: money.FromInt(200),
+		// A stale system line from an earlier run is replaced, not kept.
+		Lines: []EntryLine{{ComponentID: 9, ComponentCode: ComponentCodeUnpaidLeave, ComponentType: ComponentTypeEarning, IsSystem: true, Amount: money.FromInt(999)}},
+	})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+	amounts := result.Amounts
+	if amounts.TaxablePay != money.FromInt(2000) || amounts.PensionablePay != money.FromInt(2000) {
+		t.Fatalf("unpaid leave must reduce taxable and pensionable pay, got %+v", amounts)
+	}
+	// Unpaid leave is taken off earnings, so gross excludes it and it is not
+	// a deduction.
+	if amounts.AllowancesTotal != money.FromInt(-200) || amounts.DeductionsTotal != money.FromInt(0) || amounts.TaxTotal != money.FromInt(100) {
+		t.Fatalf("expected earnings -200, no deductions and tax 100, got %+v", amounts)
+	}
+	if amounts.GrossPay != money.FromInt(2000) || amounts.NetPay != money.FromInt(1900) {
+		t.Fatalf("expected gross 2000 and net 1900, got %v and %v", amounts.GrossPay, amounts.NetPay)
+	}
+	if len(result.Lines) != 2 {
+		t.Fatalf("expected unpaid leave and PAYE lines, got %+v", result.Lines)
+	}
+
+	if _, err := Calculate(CalculationInput{BaseSalary: money.FromInt(2200), Components: components[:1], TaxTable: &table, UnpaidLeave: money.FromInt(200)}); err != ErrComponentNotFound {
+		t.Fatalf("expected missing component error, got %v", err)
+	}
+}
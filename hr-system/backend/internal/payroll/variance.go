@@ -0,0 +1,177 @@
+package payroll
+
+import (
+	"math"
+	"sort"
+
+	"hr-system/backend/internal/money"
+)
+
+// DefaultVarianceThresholdPercent applies until an Admin changes the setting.
+const DefaultVarianceThresholdPercent = 10.0
+
+const (
+	VarianceNewJoiner = "New Joiner"
+	VarianceLeaver    = "Leaver"
+	VarianceChanged   = "Changed"
+	VarianceUnchanged = "Unchanged"
+)
+
+// ComponentVariance is a component whose amount differs between the two
+// batches for one employee.
+type ComponentVariance struct {
+	ComponentCode string       `json:"component_code"`
+	ComponentName string       `json:"component_name"`
+	Previous      money.Amount `json:"previous"`
+	Current       money.Amount `json:"current"`
+	Delta         money.Amount `json:"delta"`
+}
+
+type VarianceRow struct {
+	EmployeeID         int64               `json:"employee_id"`
+	EmployeeName       string              `json:"employee_name"`
+	Change             string              `json:"change"`
+	PreviousBaseSalary money.Amount        `json:"previous_base_salary"`
+	CurrentBaseSalary  money.Amount        `json:"current_base_salary"`
+	BaseSalaryChanged  bool                `json:"base_salary_changed"`
+	Components         []ComponentVariance `json:"components"`
+	PreviousNetPay     money.Amount        `json:"previous_net_pay"`
+	CurrentNetPay      money.Amount        `json:"current_net_pay"`
+	NetPayDelta        money.Amount        `json:"net_pay_delta"`
+	// NetPayDeltaPercent is nil when there was no previous net pay to compare.
+	NetPayDeltaPercent *float64 `json:"net_pay_delta_percent"`
+	Flagged            bool     `json:"flagged"`
+}
+
+type VarianceReport struct {
+	Batch Batch `json:"batch"`
+	// PreviousBatch is nil when no earlier batch of the same type is locked;
+	// every employee is then reported as a new joiner.
+	PreviousBatch    *Batch        `json:"previous_batch"`
+	ThresholdPercent float64       `json:"threshold_percent"`
+	Rows             []VarianceRow `json:"rows"`
+	PreviousNetPay   money.Amount  `json:"previous_net_pay"`
+	CurrentNetPay    money.Amount  `json:"current_net_pay"`
+	NetPayDelta      money.Amount  `json:"net_pay_delta"`
+	FlaggedCount     int           `json:"flagged_count"`
+}
+
+// BuildVarianceReport compares each employee's entry in batch with their
+// entry in the previous batch. Base salary is compared on the monthly salary
+// so a prorated month is not mistaken for a pay change. A row is flagged when
+// net pay moves by more than thresholdPercent, or appears from nothing.
+func BuildVarianceReport(batch Batch, previous *Batch, current []Entry, prior []Entry, thresholdPercent float64) VarianceReport {
+	report := VarianceReport{Batch: batch, PreviousBatch: previous, ThresholdPercent: thresholdPercent, Rows: make([]VarianceRow, 0, len(current))}
+
+	priorByEmployee := make(map[int64]Entry, len(prior))
+	for _, entry := range prior {
+		priorByEmployee[entry.EmployeeID] = entry
+	}
+	seen := make(map[int64]bool, len(current))
+	for _, entry := range current {
+		seen[entry.EmployeeID] = true
+		before, ok := priorByEmployee[entry.EmployeeID]
+		row := compareEntries(before, entry, thresholdPercent)
+		if !ok {
+			row.Change = VarianceNewJoiner
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	for _, entry := range prior {
+		if seen[entry.EmployeeID] {
+			continue
+		}
+		row := compareEntries(entry, Entry{EmployeeID: entry.EmployeeID, EmployeeName: entry.EmployeeName}, thresholdPercent)
+		row.Change = VarianceLeaver
+		report.Rows = append(report.Rows, row)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		if report.Rows[i].EmployeeName != report.Rows[j].EmployeeName {
+			return report.Rows[i].EmployeeName < report.Rows[j].EmployeeName
+		}
+		return report.Rows[i].EmployeeID < report.Rows[j].EmployeeID
+	})
+	for _, row := range report.Rows {
+		report.PreviousNetPay = report.PreviousNetPay.Add(row.PreviousNetPay)
+		report.CurrentNetPay = report.CurrentNetPay.Add(row.CurrentNetPay)
+		if row.Flagged {
+			report.FlaggedCount++
+		}
+	}
+	report.NetPayDelta = report.CurrentNetPay.Sub(report.PreviousNetPay)
+	return report
+}
+
+func compareEntries(before, after Entry, thresholdPercent float64) VarianceRow {
+	row := VarianceRow{
+		EmployeeID:         after.EmployeeID,
+		EmployeeName:       after.EmployeeName,
+		PreviousBaseSalary: before.MonthlyBaseSalary,
+		CurrentBaseSalary:  after.MonthlyBaseSalary,
+		BaseSalaryChanged:  before.MonthlyBaseSalary != after.MonthlyBaseSalary,
+		Components:         componentVariances(before.Lines, after.Lines),
+		PreviousNetPay:     before.NetPay,
+		CurrentNetPay:      after.NetPay,
+		NetPayDelta:        after.NetPay.Sub(before.NetPay),
+	}
+	if !before.NetPay.IsZero() {
+		percent := math.Round(float64(row.NetPayDelta.Cents())/float64(before.NetPay.Cents())*1e4) / 1e2
+		row.NetPayDeltaPercent = &percent
+		row.Flagged = math.Abs(percent) > thresholdPercent
+	} else {
+		row.Flagged = !row.NetPayDelta.IsZero()
+	}
+
+	row.Change = VarianceUnchanged
+	if row.BaseSalaryChanged || len(row.Components) > 0 || !row.NetPayDelta.IsZero() {
+		row.Change = VarianceChanged
+	}
+	return row
+}
+
+// componentVariances lists the components whose per-employee total differs,
+// in the order they first appear (current lines first).
+func componentVariances(before, after []EntryLine) []ComponentVariance {
+	type total struct {
+		name             string
+		previous, latest money.Amount
+	}
+	totals := make(map[string]*total)
+	order := make([]string, 0, len(before)+len(after))
+	add := func(line EntryLine, current bool) {
+		item, ok := totals[line.ComponentCode]
+		if !ok {
+			item = &total{name: line.ComponentName}
+			totals[line.ComponentCode] = item
+			order = append(order, line.ComponentCode)
+		}
+		if current {
+			item.latest = item.latest.Add(line.Amount)
+		} else {
+			item.previous = item.previous.Add(line.Amount)
+		}
+	}
+	for _, line := range after {
+		add(line, true)
+	}
+	for _, line := range before {
+		add(line, false)
+	}
+
+	variances := make([]ComponentVariance, 0)
+	for _, code := range order {
+		item := totals[code]
+		if item.previous == item.latest {
+			continue
+		}
+		variances = append(variances, ComponentVariance{
+			ComponentCode: code,
+			ComponentName: item.name,
+			Previous:      item.previous,
+			Current:       item.latest,
+			Delta:         item.latest.Sub(item.previous),
+		})
+	}
+	return variances
+}
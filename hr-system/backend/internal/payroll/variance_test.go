@@ -0,0 +1,60 @@
+package payroll
+
+import (
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestBuildVarianceReport(t *testing.T) {
+	housing := func(amount int64) EntryLine {
+		return EntryLine{ComponentCode: "HOUSING", ComponentName: "Housing Allowance", ComponentType: ComponentTypeEarning, Amount: money.FromInt(amount)}
+	}
+	previous := Batch{ID: 1, Month: "2026-01", Status: StatusLocked, BatchType: BatchTypeRegular}
+	current := Batch{ID: 2, Month: "2026-02", Status: StatusDraft, BatchType: BatchTypeRegular}
+	prior := []Entry{
+		{EmployeeID: 1, EmployeeName: "Akello, Grace", MonthlyBaseSalary: money.FromInt(1000), NetPay: money.FromInt(1100), Lines: []EntryLine{housing(100)}},
+		{EmployeeID: 2, EmployeeName: "Okello, Peter", MonthlyBaseSalary: money.FromInt(2000), NetPay: money.FromInt(2000)},
+		{EmployeeID: 3, EmployeeName: "Mugisha, Ann", MonthlyBaseSalary: money.FromInt(800), NetPay: money.FromInt(800)},
+	}
+	entries := []Entry{
+		// Housing up by 5: +0.45%, under the 10% threshold.
+		{EmployeeID: 1, EmployeeName: "Akello, Grace", MonthlyBaseSalary: money.FromInt(1000), NetPay: money.FromInt(1105), Lines: []EntryLine{housing(105)}},
+		// Pay rise of 25%.
+		{EmployeeID: 2, EmployeeName: "Okello, Peter", MonthlyBaseSalary: money.FromInt(2500), NetPay: money.FromInt(2500)},
+		{EmployeeID: 4, EmployeeName: "Nansubuga, Joy", MonthlyBaseSalary: money.FromInt(900), NetPay: money.FromInt(900)},
+	}
+
+	report := BuildVarianceReport(current, &previous, entries, prior, 10)
+	if len(report.Rows) != 4 || report.FlaggedCount != 3 {
+		t.Fatalf("expected 4 rows with 3 flagged, got %d rows and %d flagged", len(report.Rows), report.FlaggedCount)
+	}
+
+	byEmployee := make(map[int64]VarianceRow, len(report.Rows))
+	for _, row := range report.Rows {
+		byEmployee[row.EmployeeID] = row
+	}
+	grace := byEmployee[1]
+	if grace.Change != VarianceChanged || grace.Flagged || grace.BaseSalaryChanged || len(grace.Components) != 1 || grace.Components[0].Delta != money.FromInt(5) {
+		t.Fatalf("expected unflagged housing change, got %+v", grace)
+	}
+	if grace.NetPayDeltaPercent == nil || *grace.NetPayDeltaPercent != 0.45 {
+		t.Fatalf("expected 0.45%% change, got %v", grace.NetPayDeltaPercent)
+	}
+	peter := byEmployee[2]
+	if !peter.BaseSalaryChanged || !peter.Flagged || peter.NetPayDelta != money.FromInt(500) || *peter.NetPayDeltaPercent != 25 {
+		t.Fatalf("expected flagged 25%% pay rise, got %+v", peter)
+	}
+	if ann := byEmployee[3]; ann.Change != VarianceLeaver || !ann.Flagged || ann.NetPayDelta != money.FromInt(-800) {
+		t.Fatalf("expected flagged leaver, got %+v", ann)
+	}
+	if joy := byEmployee[4]; joy.Change != VarianceNewJoiner || !joy.Flagged || joy.NetPayDeltaPercent != nil {
+		t.Fatalf("expected flagged new joiner without a percentage, got %+v", joy)
+	}
+	if report.NetPayDelta != money.FromInt(605) {
+		t.Fatalf("expected net pay delta 605, got %v", report.NetPayDelta)
+	}
+	if report.Rows[0].EmployeeName != "Akello, Grace" {
+		t.Fatalf("expected rows sorted by name, got %s first", report.Rows[0].EmployeeName)
+	}
+}
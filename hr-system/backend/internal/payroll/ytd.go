@@ -0,0 +1,205 @@
+package payroll
+
+import (
+	"fmt"
+	"time"
+
+	"hr-system/backend/internal/money"
+	"hr-system/backend/internal/pdf"
+)
+
+// TaxYearAmounts are the figures reported for a fiscal year: what was paid,
+// taxed and contributed.
+type TaxYearAmounts struct {
+	GrossPay              money.Amount `db:"gross_pay" json:"gross_pay"`
+	TaxablePay            money.Amount `db:"taxable_pay" json:"taxable_pay"`
+	PensionablePay        money.Amount `db:"pensionable_pay" json:"pensionable_pay"`
+	TaxTotal              money.Amount `db:"tax_total" json:"tax_total"`
+	DeductionsTotal       money.Amount `db:"deductions_total" json:"deductions_total"`
+	EmployeeContributions money.Amount `db:"employee_contributions" json:"employee_contributions"`
+	EmployerContributions money.Amount `db:"employer_contributions" json:"employer_contributions"`
+	NetPay                money.Amount `db:"net_pay" json:"net_pay"`
+}
+
+func (a TaxYearAmounts) Add(other TaxYearAmounts) TaxYearAmounts {
+	return TaxYearAmounts{
+		GrossPay:              a.GrossPay.Add(other.GrossPay),
+		TaxablePay:            a.TaxablePay.Add(other.TaxablePay),
+		PensionablePay:        a.PensionablePay.Add(other.PensionablePay),
+		TaxTotal:              a.TaxTotal.Add(other.TaxTotal),
+		DeductionsTotal:       a.DeductionsTotal.Add(other.DeductionsTotal),
+		EmployeeContributions: a.EmployeeContributions.Add(other.EmployeeContributions),
+		EmployerContributions: a.EmployerContributions.Add(other.EmployerContributions),
+		NetPay:                a.NetPay.Add(other.NetPay),
+	}
+}
+
+// YTDTotals is an employee's running total for a fiscal year, kept from
+// Locked batches.
+type YTDTotals struct {
+	EmployeeID   int64  `db:"employee_id" json:"employee_id"`
+	EmployeeName string `db:"employee_name" json:"employee_name"`
+	NationalID   string `db:"national_id" json:"national_id"`
+	FiscalYear   int    `db:"fiscal_year" json:"fiscal_year"`
+	TaxYearAmounts
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// MonthlyTotals sums an employee's Locked entries for one month, regular and
+// off-cycle batches together.
+type MonthlyTotals struct {
+	EmployeeID int64  `db:"employee_id" json:"employee_id"`
+	Month      string `db:"month" json:"month"`
+	TaxYearAmounts
+}
+
+// AnnualCertificate is an employee's statement of earnings and tax for a
+// fiscal year.
+type AnnualCertificate struct {
+	Employer    Employer        `json:"employer"`
+	Employee    EmployeeDetails `json:"employee"`
+	FiscalYear  int             `json:"fiscal_year"`
+	Period      string          `json:"period"`
+	Months      []MonthlyTotals `json:"months"`
+	Totals      TaxYearAmounts  `json:"totals"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// AnnualReturn lists every employee paid in a fiscal year, for the company's
+// annual PAYE return.
+type AnnualReturn struct {
+	FiscalYear int            `json:"fiscal_year"`
+	Period     string         `json:"period"`
+	Rows       []YTDTotals    `json:"rows"`
+	Totals     TaxYearAmounts `json:"totals"`
+}
+
+// FiscalYearOf returns the fiscal year, named by the calendar year it starts
+// in, that contains month.
+func FiscalYearOf(month string) (int, error) {
+	start, err := FiscalYearStart(month)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := time.Parse("2006-01", start)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Year(), nil
+}
+
+// FiscalYearMonths returns the first and last months (YYYY-MM) of a fiscal
+// year.
+func FiscalYearMonths(fiscalYear int) (string, string) {
+	start := time.Date(fiscalYear, FiscalYearStartMonth, 1, 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01"), start.AddDate(0, 11, 0).Format("2006-01")
+}
+
+// FiscalYearLabel names a fiscal year the way returns do, e.g. "2025/26".
+func FiscalYearLabel(fiscalYear int) string {
+	return fmt.Sprintf("%d/%02d", fiscalYear, (fiscalYear+1)%100)
+}
+
+// BuildAnnualCertificate totals the certificate from its months, so the
+// figures always add up on the page.
+func BuildAnnualCertificate(employer Employer, employee EmployeeDetails, fiscalYear int, months []MonthlyTotals) AnnualCertificate {
+	first, last := FiscalYearMonths(fiscalYear)
+	certificate := AnnualCertificate{
+		Employer:    employer,
+		Employee:    employee,
+		FiscalYear:  fiscalYear,
+		Period:      fmt.Sprintf("%s (%s to %s)", FiscalYearLabel(fiscalYear), monthLabel(first), monthLabel(last)),
+		Months:      make([]MonthlyTotals, 0, len(months)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, month := range months {
+		certificate.Months = append(certificate.Months, month)
+		certificate.Totals = certificate.Totals.Add(month.TaxYearAmounts)
+	}
+	return certificate
+}
+
+func BuildAnnualReturn(fiscalYear int, totals []YTDTotals) AnnualReturn {
+	report := AnnualReturn{FiscalYear: fiscalYear, Period: FiscalYearLabel(fiscalYear), Rows: make([]YTDTotals, 0, len(totals))}
+	for _, row := range totals {
+		report.Rows = append(report.Rows, row)
+		report.Totals = report.Totals.Add(row.TaxYearAmounts)
+	}
+	return report
+}
+
+func AnnualCertificateFileName(certificate AnnualCertificate) string {
+	return fmt.Sprintf("tax-certificate-%d-%s-%d.pdf", certificate.FiscalYear, fileNamePart(certificate.Employee.Name), certificate.Employee.ID)
+}
+
+// RenderAnnualCertificatePDF lays the certificate out on one A4 page: employer
+// and employee details, a row per month and the year's totals.
+func RenderAnnualCertificatePDF(certificate AnnualCertificate) []byte {
+	doc := pdf.New()
+	page := doc.AddPage()
+
+	y := pdf.A4Height - 60
+	page.Text(payslipLeft, y, pdf.Bold, 16, certificate.Employer.Name)
+	page.TextRight(payslipRight, y, pdf.Bold, 12, "ANNUAL EARNINGS AND TAX CERTIFICATE")
+	y -= 16
+	if certificate.Employer.Address != "" {
+		page.Text(payslipLeft, y, pdf.Regular, 9, certificate.Employer.Address)
+	}
+	page.TextRight(payslipRight, y, pdf.Regular, 10, "Fiscal year: "+certificate.Period)
+	y -= 12
+	if certificate.Employer.TIN != "" {
+		page.Text(payslipLeft, y, pdf.Regular, 9, "Employer TIN: "+certificate.Employer.TIN)
+	}
+	y -= 12
+	page.Line(payslipLeft, y, payslipRight, y, 1)
+
+	y -= 20
+	details := [][2]string{
+		{"Employee", certificate.Employee.Name},
+		{"Employee No.", fmt.Sprintf("%d", certificate.Employee.ID)},
+		{"National ID", certificate.Employee.NationalID},
+		{"Tax Residency", certificate.Employee.TaxResidency},
+	}
+	for i, detail := range details {
+		x := payslipLeft
+		if i%2 == 1 {
+			x = payslipMiddle
+		}
+		page.Text(x, y, pdf.Bold, 9, detail[0])
+		page.Text(x+80, y, pdf.Regular, 9, detail[1])
+		if i%2 == 1 || i == len(details)-1 {
+			y -= payslipLeading
+		}
+	}
+
+	// Month, then six amount columns right-aligned at these x positions.
+	columns := []float64{185, 255, 325, 395, 465, payslipRight}
+	headings := []string{"Gross Pay", "Taxable Pay", "PAYE", "Employee Contr.", "Employer Contr.", "Net Pay"}
+	row := func(y float64, font pdf.Font, label string, amounts TaxYearAmounts) {
+		values := []money.Amount{amounts.GrossPay, amounts.TaxablePay, amounts.TaxTotal, amounts.EmployeeContributions, amounts.EmployerContributions, amounts.NetPay}
+		page.Text(payslipLeft, y, font, 9, label)
+		for i, value := range values {
+			page.TextRight(columns[i], y, font, 9, value.FormatThousands())
+		}
+	}
+
+	y -= 14
+	page.Text(payslipLeft, y, pdf.Bold, 9, "Month")
+	for i, heading := range headings {
+		page.TextRight(columns[i], y, pdf.Bold, 8, heading)
+	}
+	y -= 4
+	page.Line(payslipLeft, y, payslipRight, y, 0.5)
+	y -= payslipLeading
+	for _, month := range certificate.Months {
+		row(y, pdf.Regular, monthLabel(month.Month), month.TaxYearAmounts)
+		y -= payslipLeading
+	}
+	page.Line(payslipLeft, y+payslipLeading-4, payslipRight, y+payslipLeading-4, 0.5)
+	row(y, pdf.Bold, "Total", certificate.Totals)
+
+	page.Line(payslipLeft, 60, payslipRight, 60, 0.5)
+	page.Text(payslipLeft, 46, pdf.Regular, 8, "Figures are taken from locked payroll batches. This is a computer-generated certificate and requires no signature.")
+	page.TextRight(payslipRight, 34, pdf.Regular, 8, "Generated "+certificate.GeneratedAt.Format("2006-01-02 15:04 MST"))
+	return doc.Bytes()
+}
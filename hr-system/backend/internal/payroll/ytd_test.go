@@ -0,0 +1,57 @@
+package payroll
+
+import (
+	"bytes"
+	"testing"
+
+	"hr-system/backend/internal/money"
+)
+
+func TestFiscalYearOf(t *testing.T) {
+	cases := map[string]int{"2025-07": 2025, "2026-06": 2025, "2026-07": 2026, "2026-01": 2025}
+	for month, want := range cases {
+		got, err := FiscalYearOf(month)
+		if err != nil || got != want {
+			t.Fatalf("FiscalYearOf(%s) = %d, %v; want %d", month, got, err, want)
+		}
+	}
+	if _, err := FiscalYearOf("2026"); err == nil {
+		t.Fatal("expected an invalid month to fail")
+	}
+
+	first, last := FiscalYearMonths(2025)
+	if first != "2025-07" || last != "2026-06" || FiscalYearLabel(2025) != "2025/26" || FiscalYearLabel(2099) != "2099/00" {
+		t.Fatalf("unexpected fiscal year bounds %s..%s (%s)", first, last, FiscalYearLabel(2025))
+	}
+}
+
+func TestBuildAnnualCertificateTotalsMonths(t *testing.T) {
+	months := []MonthlyTotals{
+		{EmployeeID: 22, Month: "2025-07", TaxYearAmounts: TaxYearAmounts{GrossPay: money.FromInt(1000), TaxTotal: money.FromInt(100), EmployeeContributions: money.FromInt(50), NetPay: money.FromInt(850)}},
+		{EmployeeID: 22, Month: "2025-08", TaxYearAmounts: TaxYearAmounts{GrossPay: money.FromInt(1200), TaxTotal: money.FromInt(140), EmployeeContributions: money.FromInt(60), NetPay: money.FromInt(1000)}},
+	}
+	certificate := BuildAnnualCertificate(Employer{Name: "Acme"}, EmployeeDetails{ID: 22, Name: "John Doe"}, 2025, months)
+	totals := certificate.Totals
+	if totals.GrossPay != money.FromInt(2200) || totals.TaxTotal != money.FromInt(240) || totals.EmployeeContributions != money.FromInt(110) || totals.NetPay != money.FromInt(1850) {
+		t.Fatalf("unexpected certificate totals: %+v", totals)
+	}
+	if certificate.Period != "2025/26 (July 2025 to June 2026)" {
+		t.Fatalf("unexpected period %q", certificate.Period)
+	}
+	if name := AnnualCertificateFileName(certificate); name != "tax-certificate-2025-john-doe-22.pdf" {
+		t.Fatalf("unexpected file name %q", name)
+	}
+	if content := RenderAnnualCertificatePDF(certificate); !bytes.HasPrefix(content, []byte("%PDF-")) {
+		t.Fatal("expected a pdf document")
+	}
+}
+
+func TestBuildAnnualReturn(t *testing.T) {
+	report := BuildAnnualReturn(2025, []YTDTotals{
+		{EmployeeID: 21, TaxYearAmounts: TaxYearAmounts{GrossPay: money.FromInt(500), TaxTotal: money.FromInt(10)}},
+		{EmployeeID: 22, TaxYearAmounts: TaxYearAmounts{GrossPay: money.FromInt(700), TaxTotal: money.FromInt(30)}},
+	})
+	if len(report.Rows) != 2 || report.Period != "2025/26" || report.Totals.GrossPay != money.FromInt(1200) || report.Totals.TaxTotal != money.FromInt(40) {
+		t.Fatalf("unexpected annual return: %+v", report)
+	}
+}
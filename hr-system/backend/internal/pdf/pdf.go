@@ -0,0 +1,187 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page sizes in PDF points (1/72 inch).
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// Document is a minimal text-and-rules PDF writer using the built-in
+// Helvetica fonts, which every PDF reader ships, so nothing is embedded.
+type Document struct {
+	pages []*Page
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Text draws text with its baseline starting at (x, y), measured from the
+// bottom-left corner of the page.
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		fontResource(font), number(size), number(x), number(y), escape(encode(text)))
+}
+
+// TextRight draws text so that it ends at x.
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		number(width), number(x1), number(y1), number(x2), number(y2))
+}
+
+// Rect strokes a rectangle whose bottom-left corner is (x, y).
+func (p *Page) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n",
+		number(width), number(x), number(y), number(w), number(h))
+}
+
+// TextWidth returns the rendered width of text in points.
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := helveticaWidths
+	if font == Bold {
+		widths = helveticaBoldWidths
+	}
+	total := 0
+	for _, char := range encode(text) {
+		if char >= 32 && int(char-32) < len(widths) {
+			total += widths[char-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Bytes serialises the document.
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := make([]int, 0, 4+2*len(d.pages))
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are fixed; each page then takes a page object and a content stream.
+	pageIDs := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		pageIDs = append(pageIDs, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			number(A4Width), number(A4Height), 6+2*i,
+		))
+		content := page.content.Bytes()
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+func fontResource(font Font) string {
+	if font == Bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+func number(value float64) string {
+	formatted := fmt.Sprintf("%.2f", value)
+	formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	if formatted == "" || formatted == "-" {
+		return "0"
+	}
+	return formatted
+}
+
+// encode maps text to WinAnsi bytes; Latin-1 characters carry over directly
+// and anything outside it is replaced with '?'.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range text {
+		switch {
+		case char == '\t':
+			encoded = append(encoded, ' ')
+		case char >= 32 && char <= 126, char >= 160 && char <= 255:
+			encoded = append(encoded, byte(char))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func escape(text []byte) string {
+	var sb strings.Builder
+	for _, char := range text {
+		switch char {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(char)
+	}
+	return sb.String()
+}
+
+// Glyph widths (per 1000 em) for characters 32-126 from the standard Adobe
+// font metrics.
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = []int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
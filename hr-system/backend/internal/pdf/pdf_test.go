@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocumentXrefPointsAtObjects(t *testing.T) {
+	doc := New()
+	first := doc.AddPage()
+	first.Text(40, 800, Bold, 16, "Payslip (January)")
+	first.Line(40, 790, 555, 790, 0.5)
+	second := doc.AddPage()
+	second.TextRight(555, 700, Regular, 10, "1,250.00")
+
+	out := doc.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte(`(Payslip \(January\)) Tj`)) {
+		t.Fatalf("expected escaped text in content stream")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Fatalf("expected two pages")
+	}
+
+	text := string(out)
+	startxref := strings.LastIndex(text, "startxref\n")
+	xrefOffset, err := strconv.Atoi(strings.Fields(text[startxref+len("startxref\n"):])[0])
+	if err != nil {
+		t.Fatalf("parse startxref: %v", err)
+	}
+	rows := strings.Split(text[xrefOffset:], "\n")
+	if rows[0] != "xref" {
+		t.Fatalf("startxref does not point at xref table")
+	}
+	// rows[1] is the subsection header, rows[2] the free entry; objects start at 1.
+	for object := 1; object <= 8; object++ {
+		offset, err := strconv.Atoi(rows[2+object][:10])
+		if err != nil {
+			t.Fatalf("parse xref row %d: %v", object, err)
+		}
+		if want := fmt.Sprintf("%d 0 obj", object); !strings.HasPrefix(text[offset:], want) {
+			t.Fatalf("xref entry %d points at %q", object, text[offset:offset+10])
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := TextWidth(Regular, 10, "100"); got != 16.68 {
+		t.Fatalf("expected three Helvetica digits at 10pt to be 16.68pt, got %v", got)
+	}
+	if TextWidth(Bold, 10, "Net Pay") <= TextWidth(Regular, 10, "Net Pay") {
+		t.Fatalf("expected bold text to be wider")
+	}
+}
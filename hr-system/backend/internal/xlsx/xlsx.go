@@ -0,0 +1,209 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidWorkbook = errors.New("invalid xlsx workbook")
+
+// ReadFirstSheet returns the text of every cell on the workbook's first
+// worksheet, one slice per row. Rows and cells the sheet omits come back as
+// empty, so positions match the spreadsheet. Numbers are returned as stored
+// (e.g. "1250.5"), without the cell's display format.
+func ReadFirstSheet(content []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sharedStrings, err := readSharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Index int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+					Runs []struct {
+						Text string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeFile(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		index := row.Index
+		if index <= 0 {
+			index = len(rows) + 1
+		}
+		for len(rows) < index-1 {
+			rows = append(rows, nil)
+		}
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			column := len(cells)
+			if cell.Ref != "" {
+				column, err = columnIndex(cell.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for len(cells) < column {
+				cells = append(cells, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				position, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || position < 0 || position >= len(sharedStrings) {
+					return nil, fmt.Errorf("%w: bad shared string in cell %s", ErrInvalidWorkbook, cell.Ref)
+				}
+				value = sharedStrings[position]
+			case "inlineStr":
+				value = cell.Inline.Text
+				for _, run := range cell.Inline.Runs {
+					value += run.Text
+				}
+			case "b":
+				if value == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			}
+			cells = append(cells, value)
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// firstSheetPath follows the workbook's first <sheet> through its relationship
+// to the worksheet part, which need not be named sheet1.xml.
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrInvalidWorkbook)
+	}
+
+	var relationships struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return "", err
+	}
+	for _, relationship := range relationships.Items {
+		if relationship.ID != workbook.Sheets[0].RelationshipID {
+			continue
+		}
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/"), nil
+		}
+		return path.Join("xl", relationship.Target), nil
+	}
+	return "", fmt.Errorf("%w: first sheet has no worksheet part", ErrInvalidWorkbook)
+}
+
+// readSharedStrings loads the workbook's string table; a workbook without
+// text cells may leave it out.
+func readSharedStrings(files map[string]*zip.File) ([]string, error) {
+	if _, ok := files["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodeFile(files, "xl/sharedStrings.xml", &table); err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(table.Items))
+	for _, item := range table.Items {
+		value := item.Text
+		for _, run := range item.Runs {
+			value += run.Text
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func decodeFile(files map[string]*zip.File, name string, target any) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %s", ErrInvalidWorkbook, name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: open %s: %v", ErrInvalidWorkbook, name, err)
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(io.LimitReader(reader, maxPartSize)).Decode(target); err != nil {
+		return fmt.Errorf("%w: read %s: %v", ErrInvalidWorkbook, name, err)
+	}
+	return nil
+}
+
+// maxPartSize bounds how much of one workbook part is read, so a crafted
+// archive cannot expand without limit.
+const maxPartSize = 64 << 20
+
+// columnIndex turns a cell reference such as "AB12" into its zero-based
+// column, 27.
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, char := range strings.ToUpper(ref) {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+		column = column*26 + int(char-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("%w: bad cell reference %q", ErrInvalidWorkbook, ref)
+	}
+	return column - 1, nil
+}
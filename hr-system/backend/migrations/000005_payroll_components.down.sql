@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payroll_entry_lines_component_id;
+DROP INDEX IF EXISTS idx_payroll_entry_lines_entry_id;
+DROP TABLE IF EXISTS payroll_entry_lines;
+
+DROP TABLE IF EXISTS payroll_components;
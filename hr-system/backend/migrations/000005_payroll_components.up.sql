@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS payroll_components (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    component_type TEXT NOT NULL,
+    is_taxable BOOLEAN NOT NULL DEFAULT FALSE,
+    is_pensionable BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_components_code UNIQUE (code),
+    CONSTRAINT chk_payroll_components_type CHECK (component_type IN ('Earning', 'Deduction', 'Tax'))
+);
+
+INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable)
+VALUES
+    ('HOUSING', 'Housing Allowance', 'Earning', TRUE, FALSE),
+    ('TRANSPORT', 'Transport Allowance', 'Earning', TRUE, FALSE),
+    ('AIRTIME', 'Airtime Allowance', 'Earning', TRUE, FALSE),
+    ('OTHER_ALLOWANCE', 'Other Allowances', 'Earning', TRUE, FALSE),
+    ('SACCO', 'SACCO Contribution', 'Deduction', FALSE, FALSE),
+    ('LOAN_REPAYMENT', 'Loan Repayment', 'Deduction', FALSE, FALSE),
+    ('OTHER_DEDUCTION', 'Other Deductions', 'Deduction', FALSE, FALSE),
+    ('PAYE', 'PAYE', 'Tax', FALSE, FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS payroll_entry_lines (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE,
+    component_id BIGINT NOT NULL REFERENCES payroll_components(id),
+    amount NUMERIC(14,2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_entry_lines_entry_component UNIQUE (entry_id, component_id),
+    CONSTRAINT chk_payroll_entry_lines_amount_nonnegative CHECK (amount >= 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_entry_lines_entry_id ON payroll_entry_lines(entry_id);
+CREATE INDEX IF NOT EXISTS idx_payroll_entry_lines_component_id ON payroll_entry_lines(component_id);
+
+-- Carry the legacy flat totals over as line items so existing entries stay explainable.
+INSERT INTO payroll_entry_lines (entry_id, component_id, amount)
+SELECT pe.id, pc.id, pe.allowances_total
+FROM payroll_entries pe
+JOIN payroll_components pc ON pc.code = 'OTHER_ALLOWANCE'
+WHERE pe.allowances_total > 0;
+
+INSERT INTO payroll_entry_lines (entry_id, component_id, amount)
+SELECT pe.id, pc.id, pe.deductions_total
+FROM payroll_entries pe
+JOIN payroll_components pc ON pc.code = 'OTHER_DEDUCTION'
+WHERE pe.deductions_total > 0;
+
+INSERT INTO payroll_entry_lines (entry_id, component_id, amount)
+SELECT pe.id, pc.id, pe.tax_total
+FROM payroll_entries pe
+JOIN payroll_components pc ON pc.code = 'PAYE'
+WHERE pe.tax_total > 0;
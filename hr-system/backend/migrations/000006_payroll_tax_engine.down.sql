@@ -0,0 +1,21 @@
+ALTER TABLE payroll_entries
+    DROP COLUMN IF EXISTS tax_override_by,
+    DROP COLUMN IF EXISTS tax_override_reason,
+    DROP COLUMN IF EXISTS tax_override,
+    DROP COLUMN IF EXISTS tax_table_id,
+    DROP COLUMN IF EXISTS taxable_pay;
+
+DROP INDEX IF EXISTS idx_payroll_tax_brackets_table_id;
+DROP TABLE IF EXISTS payroll_tax_brackets;
+
+DROP INDEX IF EXISTS idx_payroll_tax_tables_residency_effective;
+DROP TABLE IF EXISTS payroll_tax_tables;
+
+ALTER TABLE payroll_components
+    DROP COLUMN IF EXISTS is_system;
+
+ALTER TABLE employees
+    DROP CONSTRAINT IF EXISTS chk_employees_tax_residency;
+
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS tax_residency;
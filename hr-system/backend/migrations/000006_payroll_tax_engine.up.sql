@@ -0,0 +1,84 @@
+ALTER TABLE employees
+    ADD COLUMN IF NOT EXISTS tax_residency TEXT NOT NULL DEFAULT 'Resident';
+
+ALTER TABLE employees
+    ADD CONSTRAINT chk_employees_tax_residency CHECK (tax_residency IN ('Resident', 'Non-Resident'));
+
+ALTER TABLE payroll_components
+    ADD COLUMN IF NOT EXISTS is_system BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE payroll_components
+SET is_system = TRUE
+WHERE code = 'PAYE';
+
+CREATE TABLE IF NOT EXISTS payroll_tax_tables (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    residency TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    effective_from DATE NOT NULL,
+    created_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_tax_tables_residency_version UNIQUE (residency, version),
+    CONSTRAINT chk_payroll_tax_tables_residency CHECK (residency IN ('Resident', 'Non-Resident')),
+    CONSTRAINT chk_payroll_tax_tables_version_positive CHECK (version > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_tax_tables_residency_effective ON payroll_tax_tables(residency, effective_from);
+
+CREATE TABLE IF NOT EXISTS payroll_tax_brackets (
+    id BIGSERIAL PRIMARY KEY,
+    table_id BIGINT NOT NULL REFERENCES payroll_tax_tables(id) ON DELETE CASCADE,
+    lower_bound NUMERIC(14,2) NOT NULL,
+    upper_bound NUMERIC(14,2),
+    rate NUMERIC(7,4) NOT NULL,
+    CONSTRAINT chk_payroll_tax_brackets_lower_nonnegative CHECK (lower_bound >= 0),
+    CONSTRAINT chk_payroll_tax_brackets_bounds CHECK (upper_bound IS NULL OR upper_bound > lower_bound),
+    CONSTRAINT chk_payroll_tax_brackets_rate CHECK (rate >= 0 AND rate <= 1)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_tax_brackets_table_id ON payroll_tax_brackets(table_id);
+
+-- Uganda monthly PAYE bands (Income Tax Act, Third Schedule) expressed as marginal rates.
+-- The 10% surcharge on income above UGX 10,000,000 is folded into the top band.
+WITH resident AS (
+    INSERT INTO payroll_tax_tables (name, residency, version, effective_from)
+    VALUES ('Uganda PAYE (Resident)', 'Resident', 1, DATE '2012-07-01')
+    RETURNING id
+)
+INSERT INTO payroll_tax_brackets (table_id, lower_bound, upper_bound, rate)
+SELECT resident.id, bands.lower_bound, bands.upper_bound, bands.rate
+FROM resident
+CROSS JOIN (VALUES
+    (0::NUMERIC, 235000::NUMERIC, 0.0000::NUMERIC),
+    (235000, 335000, 0.1000),
+    (335000, 410000, 0.2000),
+    (410000, 10000000, 0.3000),
+    (10000000, NULL, 0.4000)
+) AS bands(lower_bound, upper_bound, rate);
+
+WITH non_resident AS (
+    INSERT INTO payroll_tax_tables (name, residency, version, effective_from)
+    VALUES ('Uganda PAYE (Non-Resident)', 'Non-Resident', 1, DATE '2012-07-01')
+    RETURNING id
+)
+INSERT INTO payroll_tax_brackets (table_id, lower_bound, upper_bound, rate)
+SELECT non_resident.id, bands.lower_bound, bands.upper_bound, bands.rate
+FROM non_resident
+CROSS JOIN (VALUES
+    (0::NUMERIC, 335000::NUMERIC, 0.1000::NUMERIC),
+    (335000, 410000, 0.2000),
+    (410000, 10000000, 0.3000),
+    (10000000, NULL, 0.4000)
+) AS bands(lower_bound, upper_bound, rate);
+
+ALTER TABLE payroll_entries
+    ADD COLUMN IF NOT EXISTS taxable_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_table_id BIGINT REFERENCES payroll_tax_tables(id),
+    ADD COLUMN IF NOT EXISTS tax_override BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS tax_override_reason TEXT,
+    ADD COLUMN IF NOT EXISTS tax_override_by BIGINT REFERENCES users(id);
+
+-- Existing entries carried manually typed tax; keep those figures as recorded overrides.
+UPDATE payroll_entries
+SET taxable_pay = gross_pay,
+    tax_override = tax_total > 0,
+    tax_override_reason = CASE WHEN tax_total > 0 THEN 'Manual tax entered before the PAYE engine' ELSE NULL END;
@@ -0,0 +1,28 @@
+ALTER TABLE payroll_entries
+    DROP COLUMN IF EXISTS employer_contributions_total,
+    DROP COLUMN IF EXISTS pensionable_pay;
+
+DROP INDEX IF EXISTS idx_payroll_contribution_members_employee_id;
+DROP TABLE IF EXISTS payroll_contribution_members;
+DROP TABLE IF EXISTS payroll_contribution_schemes;
+
+DELETE FROM payroll_entry_lines
+WHERE component_id IN (
+    SELECT id FROM payroll_components
+    WHERE code IN ('NSSF_EMPLOYEE', 'NSSF_EMPLOYER', 'PENSION_EMPLOYEE', 'PENSION_EMPLOYER')
+       OR component_type = 'Employer'
+);
+
+DELETE FROM payroll_components
+WHERE code IN ('NSSF_EMPLOYEE', 'NSSF_EMPLOYER', 'PENSION_EMPLOYEE', 'PENSION_EMPLOYER')
+   OR component_type = 'Employer';
+
+UPDATE payroll_components
+SET is_pensionable = FALSE
+WHERE code IN ('HOUSING', 'TRANSPORT', 'OTHER_ALLOWANCE');
+
+ALTER TABLE payroll_components
+    DROP CONSTRAINT IF EXISTS chk_payroll_components_type;
+
+ALTER TABLE payroll_components
+    ADD CONSTRAINT chk_payroll_components_type CHECK (component_type IN ('Earning', 'Deduction', 'Tax'));
@@ -0,0 +1,71 @@
+ALTER TABLE payroll_components
+    DROP CONSTRAINT IF EXISTS chk_payroll_components_type;
+
+ALTER TABLE payroll_components
+    ADD CONSTRAINT chk_payroll_components_type CHECK (component_type IN ('Earning', 'Deduction', 'Tax', 'Employer'));
+
+INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable, is_system)
+VALUES
+    ('NSSF_EMPLOYEE', 'NSSF Employee Contribution', 'Deduction', FALSE, FALSE, TRUE),
+    ('NSSF_EMPLOYER', 'NSSF Employer Contribution', 'Employer', FALSE, FALSE, TRUE),
+    ('PENSION_EMPLOYEE', 'Pension Employee Contribution', 'Deduction', FALSE, FALSE, TRUE),
+    ('PENSION_EMPLOYER', 'Pension Employer Contribution', 'Employer', FALSE, FALSE, TRUE)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS payroll_contribution_schemes (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    employee_rate NUMERIC(7,4) NOT NULL DEFAULT 0,
+    employer_rate NUMERIC(7,4) NOT NULL DEFAULT 0,
+    ceiling NUMERIC(14,2),
+    is_mandatory BOOLEAN NOT NULL DEFAULT TRUE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    employee_component_id BIGINT NOT NULL REFERENCES payroll_components(id),
+    employer_component_id BIGINT NOT NULL REFERENCES payroll_components(id),
+    updated_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_contribution_schemes_code UNIQUE (code),
+    CONSTRAINT chk_payroll_contribution_schemes_employee_rate CHECK (employee_rate >= 0 AND employee_rate <= 1),
+    CONSTRAINT chk_payroll_contribution_schemes_employer_rate CHECK (employer_rate >= 0 AND employer_rate <= 1),
+    CONSTRAINT chk_payroll_contribution_schemes_ceiling CHECK (ceiling IS NULL OR ceiling > 0)
+);
+
+-- NSSF: 5% employee and 10% employer on gross pay, no ceiling. The private pension
+-- scheme is opt-in and starts disabled until rates are configured.
+INSERT INTO payroll_contribution_schemes (code, name, employee_rate, employer_rate, is_mandatory, is_active, employee_component_id, employer_component_id)
+SELECT 'NSSF', 'National Social Security Fund', 0.0500, 0.1000, TRUE, TRUE, ee.id, er.id
+FROM payroll_components ee
+JOIN payroll_components er ON er.code = 'NSSF_EMPLOYER'
+WHERE ee.code = 'NSSF_EMPLOYEE'
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO payroll_contribution_schemes (code, name, employee_rate, employer_rate, is_mandatory, is_active, employee_component_id, employer_component_id)
+SELECT 'PENSION', 'Private Pension Scheme', 0, 0, FALSE, FALSE, ee.id, er.id
+FROM payroll_components ee
+JOIN payroll_components er ON er.code = 'PENSION_EMPLOYER'
+WHERE ee.code = 'PENSION_EMPLOYEE'
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS payroll_contribution_members (
+    scheme_id BIGINT NOT NULL REFERENCES payroll_contribution_schemes(id) ON DELETE CASCADE,
+    employee_id BIGINT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    member_number TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scheme_id, employee_id)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_contribution_members_employee_id ON payroll_contribution_members(employee_id);
+
+-- Allowances that count as NSSF wages.
+UPDATE payroll_components
+SET is_pensionable = TRUE
+WHERE code IN ('HOUSING', 'TRANSPORT', 'OTHER_ALLOWANCE');
+
+ALTER TABLE payroll_entries
+    ADD COLUMN IF NOT EXISTS pensionable_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS employer_contributions_total NUMERIC(14,2) NOT NULL DEFAULT 0;
+
+UPDATE payroll_entries
+SET pensionable_pay = gross_pay;
@@ -0,0 +1,6 @@
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS bank_account_number,
+    DROP COLUMN IF EXISTS bank_account_name,
+    DROP COLUMN IF EXISTS bank_code,
+    DROP COLUMN IF EXISTS bank_branch,
+    DROP COLUMN IF EXISTS bank_name;
@@ -0,0 +1,6 @@
+ALTER TABLE employees
+    ADD COLUMN IF NOT EXISTS bank_name TEXT,
+    ADD COLUMN IF NOT EXISTS bank_branch TEXT,
+    ADD COLUMN IF NOT EXISTS bank_code TEXT,
+    ADD COLUMN IF NOT EXISTS bank_account_name TEXT,
+    ADD COLUMN IF NOT EXISTS bank_account_number TEXT;
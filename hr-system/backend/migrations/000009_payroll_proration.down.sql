@@ -0,0 +1,17 @@
+ALTER TABLE payroll_entries
+    DROP CONSTRAINT IF EXISTS chk_payroll_entries_proration_factor;
+
+ALTER TABLE payroll_entries
+    DROP COLUMN IF EXISTS proration_factor,
+    DROP COLUMN IF EXISTS proration_period_days,
+    DROP COLUMN IF EXISTS proration_days_paid,
+    DROP COLUMN IF EXISTS proration_basis,
+    DROP COLUMN IF EXISTS monthly_base_salary;
+
+DROP TABLE IF EXISTS payroll_settings;
+
+ALTER TABLE employees
+    DROP CONSTRAINT IF EXISTS chk_employees_termination_after_hire;
+
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS termination_date;
@@ -0,0 +1,36 @@
+ALTER TABLE employees
+    ADD COLUMN IF NOT EXISTS termination_date DATE;
+
+ALTER TABLE employees
+    ADD CONSTRAINT chk_employees_termination_after_hire CHECK (termination_date IS NULL OR termination_date >= hire_date);
+
+-- Single-row table of organisation-wide payroll settings.
+CREATE TABLE IF NOT EXISTS payroll_settings (
+    id SMALLINT PRIMARY KEY DEFAULT 1,
+    proration_basis TEXT NOT NULL DEFAULT 'WorkingDays',
+    updated_by BIGINT REFERENCES users(id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_settings_singleton CHECK (id = 1),
+    CONSTRAINT chk_payroll_settings_proration_basis CHECK (proration_basis IN ('WorkingDays', 'CalendarDays'))
+);
+
+INSERT INTO payroll_settings (id) VALUES (1)
+ON CONFLICT (id) DO NOTHING;
+
+-- Existing entries were paid for the full month.
+ALTER TABLE payroll_entries
+    ADD COLUMN IF NOT EXISTS monthly_base_salary NUMERIC(14,2),
+    ADD COLUMN IF NOT EXISTS proration_basis TEXT NOT NULL DEFAULT 'WorkingDays',
+    ADD COLUMN IF NOT EXISTS proration_days_paid INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS proration_period_days INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS proration_factor NUMERIC(7,6) NOT NULL DEFAULT 1;
+
+UPDATE payroll_entries
+SET monthly_base_salary = base_salary
+WHERE monthly_base_salary IS NULL;
+
+ALTER TABLE payroll_entries
+    ALTER COLUMN monthly_base_salary SET NOT NULL;
+
+ALTER TABLE payroll_entries
+    ADD CONSTRAINT chk_payroll_entries_proration_factor CHECK (proration_factor >= 0 AND proration_factor <= 1);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS payroll_entry_leave_deductions;
+
+DELETE FROM payroll_entry_lines
+WHERE component_id IN (SELECT id FROM payroll_components WHERE code = 'UNPAID_LEAVE');
+
+DELETE FROM payroll_components
+WHERE code = 'UNPAID_LEAVE';
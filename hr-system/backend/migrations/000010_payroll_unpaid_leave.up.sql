@@ -0,0 +1,20 @@
+-- Unpaid leave is an earning that lowers pay: its lines are taken off
+-- earnings, so gross, taxable and pensionable pay all exclude it.
+INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable, is_system)
+VALUES ('UNPAID_LEAVE', 'Unpaid Leave', 'Earning', FALSE, FALSE, TRUE)
+ON CONFLICT (code) DO NOTHING;
+
+-- One row per approved unpaid leave request that contributed to an entry's
+-- UNPAID_LEAVE line, so the deduction can be traced back to the leave module.
+CREATE TABLE IF NOT EXISTS payroll_entry_leave_deductions (
+    entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE,
+    leave_request_id BIGINT NOT NULL REFERENCES leave_requests(id),
+    unpaid_days INTEGER NOT NULL,
+    daily_rate NUMERIC(14,2) NOT NULL,
+    amount NUMERIC(14,2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entry_id, leave_request_id),
+    CONSTRAINT chk_payroll_entry_leave_deductions_days_positive CHECK (unpaid_days > 0),
+    CONSTRAINT chk_payroll_entry_leave_deductions_amount_nonnegative CHECK (amount >= 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_entry_leave_deductions_leave_request_id ON payroll_entry_leave_deductions(leave_request_id);
@@ -0,0 +1,22 @@
+-- Append-only record of base salary changes. The row with the latest
+-- effective_from on or before a date is the salary in force on that date;
+-- rows sharing a date are ordered by id so a same-day correction wins.
+CREATE TABLE IF NOT EXISTS employee_salary_history (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    base_salary NUMERIC(14,2) NOT NULL,
+    effective_from DATE NOT NULL,
+    reason TEXT NOT NULL,
+    approved_by BIGINT REFERENCES users(id),
+    recorded_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_employee_salary_history_base_salary_nonnegative CHECK (base_salary >= 0),
+    CONSTRAINT chk_employee_salary_history_reason_present CHECK (BTRIM(reason) <> '')
+);
+CREATE INDEX IF NOT EXISTS idx_employee_salary_history_employee_effective ON employee_salary_history(employee_id, effective_from DESC, id DESC);
+
+-- Seed each employee's current salary from their hire date.
+INSERT INTO employee_salary_history (employee_id, base_salary, effective_from, reason)
+SELECT e.id, e.base_salary, e.hire_date, 'Salary on record when history tracking began'
+FROM employees e
+WHERE NOT EXISTS (SELECT 1 FROM employee_salary_history h WHERE h.employee_id = e.id);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS payroll_batch_transitions;
+
+-- Fails while a month still holds a reversed batch beside its correction.
+DROP INDEX IF EXISTS uq_payroll_batches_month;
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT uq_payroll_batches_month UNIQUE (month);
+
+ALTER TABLE payroll_batches
+    DROP COLUMN IF EXISTS corrects_batch_id;
+
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_status;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_status CHECK (status IN ('Draft', 'Approved', 'Locked'));
@@ -0,0 +1,27 @@
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_status;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_status CHECK (status IN ('Draft', 'Approved', 'Locked', 'Reversed'));
+
+-- A correcting batch points at the locked batch it replaces.
+ALTER TABLE payroll_batches
+    ADD COLUMN IF NOT EXISTS corrects_batch_id BIGINT REFERENCES payroll_batches(id);
+
+-- A reversed batch stays on record beside its correcting batch for the same month.
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS uq_payroll_batches_month;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payroll_batches_month ON payroll_batches(month) WHERE status <> 'Reversed';
+
+CREATE TABLE IF NOT EXISTS payroll_batch_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES payroll_batches(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    justification TEXT NOT NULL,
+    related_batch_id BIGINT REFERENCES payroll_batches(id) ON DELETE SET NULL,
+    actor_user_id BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_batch_transitions_justification_present CHECK (BTRIM(justification) <> '')
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_batch_transitions_batch_id ON payroll_batch_transitions(batch_id);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS payroll_batch_employees;
+
+-- Fails while a month still holds more than one non-reversed batch.
+DROP INDEX IF EXISTS uq_payroll_batches_month;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payroll_batches_month ON payroll_batches(month) WHERE status <> 'Reversed';
+
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_batch_type;
+
+ALTER TABLE payroll_batches
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS batch_type;
+
+DELETE FROM payroll_components c
+WHERE c.code IN ('BONUS', 'ARREARS')
+    AND NOT EXISTS (SELECT 1 FROM payroll_entry_lines l WHERE l.component_id = c.id);
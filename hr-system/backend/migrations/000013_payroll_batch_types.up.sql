@@ -0,0 +1,23 @@
+ALTER TABLE payroll_batches
+    ADD COLUMN IF NOT EXISTS batch_type TEXT NOT NULL DEFAULT 'Regular',
+    ADD COLUMN IF NOT EXISTS description TEXT;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_batch_type CHECK (batch_type IN ('Regular', 'Supplementary', 'Bonus', 'Arrears'));
+
+-- Only the regular run is limited to one (non-reversed) batch per month.
+DROP INDEX IF EXISTS uq_payroll_batches_month;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payroll_batches_month ON payroll_batches(month) WHERE status <> 'Reversed' AND batch_type = 'Regular';
+
+-- Employees chosen for an off-cycle batch; regular batches pay everyone.
+CREATE TABLE IF NOT EXISTS payroll_batch_employees (
+    batch_id BIGINT NOT NULL REFERENCES payroll_batches(id) ON DELETE CASCADE,
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    PRIMARY KEY (batch_id, employee_id)
+);
+
+INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable)
+VALUES
+    ('BONUS', 'Bonus', 'Earning', TRUE, FALSE),
+    ('ARREARS', 'Salary Arrears', 'Earning', TRUE, TRUE)
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE payroll_settings
+    DROP CONSTRAINT IF EXISTS chk_payroll_settings_variance_threshold;
+
+ALTER TABLE payroll_settings
+    DROP COLUMN IF EXISTS variance_threshold_percent;
@@ -0,0 +1,7 @@
+-- Net pay changes against the previous locked batch larger than this
+-- percentage are flagged on the variance report.
+ALTER TABLE payroll_settings
+    ADD COLUMN IF NOT EXISTS variance_threshold_percent NUMERIC(7,2) NOT NULL DEFAULT 10;
+
+ALTER TABLE payroll_settings
+    ADD CONSTRAINT chk_payroll_settings_variance_threshold CHECK (variance_threshold_percent >= 0);
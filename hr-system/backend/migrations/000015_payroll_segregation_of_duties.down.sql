@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS payroll_batch_editors;
+
+ALTER TABLE payroll_settings
+    DROP CONSTRAINT IF EXISTS chk_payroll_settings_segregation_policy;
+
+ALTER TABLE payroll_settings
+    DROP COLUMN IF EXISTS segregation_policy;
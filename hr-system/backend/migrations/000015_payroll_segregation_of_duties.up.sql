@@ -0,0 +1,24 @@
+-- Who may approve a batch: Off (anyone), Creator (not the batch creator) or
+-- CreatorAndEditors (neither the creator nor anyone who generated or edited
+-- its entries).
+ALTER TABLE payroll_settings
+    ADD COLUMN IF NOT EXISTS segregation_policy TEXT NOT NULL DEFAULT 'CreatorAndEditors';
+
+ALTER TABLE payroll_settings
+    ADD CONSTRAINT chk_payroll_settings_segregation_policy CHECK (segregation_policy IN ('Off', 'Creator', 'CreatorAndEditors'));
+
+-- Users who generated or edited a batch's entries.
+CREATE TABLE IF NOT EXISTS payroll_batch_editors (
+    batch_id BIGINT NOT NULL REFERENCES payroll_batches(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    first_edited_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_edited_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (batch_id, user_id)
+);
+
+-- Tax overrides are the only edits recorded per user before this migration.
+INSERT INTO payroll_batch_editors (batch_id, user_id)
+SELECT DISTINCT batch_id, tax_override_by
+FROM payroll_entries
+WHERE tax_override_by IS NOT NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS payroll_batch_signoffs;
+DROP TABLE IF EXISTS payroll_approval_stages;
+
+-- Batches part-way through approval go back to Draft.
+UPDATE payroll_batches SET status = 'Draft' WHERE status = 'In Approval';
+
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_status;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_status CHECK (status IN ('Draft', 'Approved', 'Locked', 'Reversed'));
@@ -0,0 +1,47 @@
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_status;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_status CHECK (status IN ('Draft', 'In Approval', 'Approved', 'Locked', 'Reversed'));
+
+-- Ordered sign-off stages a batch passes through on its way to Approved.
+CREATE TABLE IF NOT EXISTS payroll_approval_stages (
+    id BIGSERIAL PRIMARY KEY,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL,
+    updated_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_approval_stages_position UNIQUE (position),
+    CONSTRAINT chk_payroll_approval_stages_position CHECK (position > 0),
+    CONSTRAINT chk_payroll_approval_stages_name_present CHECK (BTRIM(name) <> ''),
+    CONSTRAINT chk_payroll_approval_stages_role_present CHECK (BTRIM(role) <> '')
+);
+
+INSERT INTO payroll_approval_stages (position, name, role)
+VALUES
+    (1, 'HR Verification', 'HR Officer'),
+    (2, 'Finance Approval', 'Finance Officer'),
+    (3, 'Executive Authorization', 'Master Admin')
+ON CONFLICT (position) DO NOTHING;
+
+-- Each sign-off or rejection, with the stage as it stood at the time. Rows of
+-- an approval round that ended in rejection or reopening are superseded.
+CREATE TABLE IF NOT EXISTS payroll_batch_signoffs (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES payroll_batches(id) ON DELETE CASCADE,
+    stage_position INTEGER NOT NULL,
+    stage_name TEXT NOT NULL,
+    stage_role TEXT NOT NULL,
+    decision TEXT NOT NULL,
+    comment TEXT,
+    actor_user_id BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    superseded_at TIMESTAMPTZ,
+    CONSTRAINT chk_payroll_batch_signoffs_decision CHECK (decision IN ('Signed', 'Rejected')),
+    CONSTRAINT chk_payroll_batch_signoffs_rejection_comment CHECK (decision <> 'Rejected' OR BTRIM(COALESCE(comment, '')) <> '')
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_batch_signoffs_batch_id ON payroll_batch_signoffs(batch_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payroll_batch_signoffs_current_stage
+    ON payroll_batch_signoffs(batch_id, stage_position)
+    WHERE superseded_at IS NULL AND decision = 'Signed';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS payroll_loan_repayments;
+DROP TABLE IF EXISTS payroll_entry_loan_deductions;
+DROP TABLE IF EXISTS payroll_loans;
+
+DELETE FROM payroll_entry_lines
+WHERE component_id IN (SELECT id FROM payroll_components WHERE code = 'LOAN_INSTALLMENT');
+
+DELETE FROM payroll_components
+WHERE code = 'LOAN_INSTALLMENT';
@@ -0,0 +1,62 @@
+INSERT INTO payroll_components (code, name, component_type, is_taxable, is_pensionable, is_system)
+VALUES ('LOAN_INSTALLMENT', 'Loan Installment', 'Deduction', FALSE, FALSE, TRUE)
+ON CONFLICT (code) DO NOTHING;
+
+-- Staff loans and salary advances, repaid by monthly installments deducted in
+-- regular payroll batches from start_month. Interest is a flat percentage of
+-- the principal, added once.
+CREATE TABLE IF NOT EXISTS payroll_loans (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    loan_type TEXT NOT NULL,
+    description TEXT,
+    principal NUMERIC(14,2) NOT NULL,
+    interest_rate_percent NUMERIC(7,4) NOT NULL DEFAULT 0,
+    total_repayable NUMERIC(14,2) NOT NULL,
+    installments INTEGER NOT NULL,
+    installment_amount NUMERIC(14,2) NOT NULL,
+    start_month TEXT NOT NULL,
+    outstanding_balance NUMERIC(14,2) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'Active',
+    cancel_reason TEXT,
+    created_by BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_loans_type CHECK (loan_type IN ('Advance', 'Loan')),
+    CONSTRAINT chk_payroll_loans_status CHECK (status IN ('Active', 'Settled', 'Cancelled')),
+    CONSTRAINT chk_payroll_loans_principal_positive CHECK (principal > 0),
+    CONSTRAINT chk_payroll_loans_interest_nonnegative CHECK (interest_rate_percent >= 0),
+    CONSTRAINT chk_payroll_loans_installments_positive CHECK (installments > 0),
+    CONSTRAINT chk_payroll_loans_installment_positive CHECK (installment_amount > 0),
+    CONSTRAINT chk_payroll_loans_start_month_format CHECK (start_month ~ '^[0-9]{4}-(0[1-9]|1[0-2])$'),
+    CONSTRAINT chk_payroll_loans_outstanding_range CHECK (outstanding_balance >= 0 AND outstanding_balance <= total_repayable),
+    CONSTRAINT chk_payroll_loans_cancel_reason CHECK (status <> 'Cancelled' OR BTRIM(COALESCE(cancel_reason, '')) <> '')
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_loans_employee_id ON payroll_loans(employee_id);
+
+-- The loans behind an entry's LOAN_INSTALLMENT line.
+CREATE TABLE IF NOT EXISTS payroll_entry_loan_deductions (
+    entry_id BIGINT NOT NULL REFERENCES payroll_entries(id) ON DELETE CASCADE,
+    loan_id BIGINT NOT NULL REFERENCES payroll_loans(id),
+    amount NUMERIC(14,2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entry_id, loan_id),
+    CONSTRAINT chk_payroll_entry_loan_deductions_amount_positive CHECK (amount > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_entry_loan_deductions_loan_id ON payroll_entry_loan_deductions(loan_id);
+
+-- Installments taken when a batch is locked. Reversing the batch marks them
+-- reversed and restores the balance.
+CREATE TABLE IF NOT EXISTS payroll_loan_repayments (
+    id BIGSERIAL PRIMARY KEY,
+    loan_id BIGINT NOT NULL REFERENCES payroll_loans(id),
+    batch_id BIGINT NOT NULL REFERENCES payroll_batches(id),
+    entry_id BIGINT NOT NULL REFERENCES payroll_entries(id),
+    amount NUMERIC(14,2) NOT NULL,
+    balance_after NUMERIC(14,2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reversed_at TIMESTAMPTZ,
+    CONSTRAINT chk_payroll_loan_repayments_amount_positive CHECK (amount > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_loan_repayments_loan_id ON payroll_loan_repayments(loan_id);
+CREATE INDEX IF NOT EXISTS idx_payroll_loan_repayments_batch_id ON payroll_loan_repayments(batch_id);
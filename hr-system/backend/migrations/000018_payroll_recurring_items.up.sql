@@ -0,0 +1,26 @@
+-- Standing per-employee earnings and deductions, posted as lines by regular
+-- batch generation for every month from start_month to end_month (open-ended
+-- when NULL). Each item is either a fixed amount or a percentage of the base
+-- salary paid for the month.
+CREATE TABLE IF NOT EXISTS payroll_recurring_items (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    component_id BIGINT NOT NULL REFERENCES payroll_components(id),
+    amount NUMERIC(14,2),
+    percent_of_base NUMERIC(7,4),
+    start_month TEXT NOT NULL,
+    end_month TEXT,
+    note TEXT,
+    created_by BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_recurring_items_value CHECK (
+        (amount IS NOT NULL AND percent_of_base IS NULL AND amount > 0)
+        OR (amount IS NULL AND percent_of_base IS NOT NULL AND percent_of_base > 0 AND percent_of_base <= 100)
+    ),
+    CONSTRAINT chk_payroll_recurring_items_start_month_format CHECK (start_month ~ '^[0-9]{4}-(0[1-9]|1[0-2])$'),
+    CONSTRAINT chk_payroll_recurring_items_end_month CHECK (
+        end_month IS NULL OR (end_month ~ '^[0-9]{4}-(0[1-9]|1[0-2])$' AND end_month >= start_month)
+    )
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_recurring_items_employee_id ON payroll_recurring_items(employee_id);
@@ -0,0 +1,56 @@
+-- Year-to-date totals per employee and fiscal year (July-June, named by the
+-- year it starts in), accumulated from Locked batches: a batch's entries are
+-- added when it is locked and taken off again if it is reversed.
+CREATE TABLE IF NOT EXISTS payroll_ytd_totals (
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    fiscal_year INTEGER NOT NULL,
+    gross_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    taxable_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    pensionable_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    tax_total NUMERIC(14,2) NOT NULL DEFAULT 0,
+    deductions_total NUMERIC(14,2) NOT NULL DEFAULT 0,
+    employee_contributions NUMERIC(14,2) NOT NULL DEFAULT 0,
+    employer_contributions NUMERIC(14,2) NOT NULL DEFAULT 0,
+    net_pay NUMERIC(14,2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (employee_id, fiscal_year)
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_ytd_totals_fiscal_year ON payroll_ytd_totals(fiscal_year);
+
+-- Backfill from the batches already locked.
+INSERT INTO payroll_ytd_totals (
+    employee_id,
+    fiscal_year,
+    gross_pay,
+    taxable_pay,
+    pensionable_pay,
+    tax_total,
+    deductions_total,
+    employee_contributions,
+    employer_contributions,
+    net_pay
+)
+SELECT
+    pe.employee_id,
+    CASE
+        WHEN SUBSTRING(b.month FROM 6 FOR 2)::INTEGER >= 7 THEN SUBSTRING(b.month FROM 1 FOR 4)::INTEGER
+        ELSE SUBSTRING(b.month FROM 1 FOR 4)::INTEGER - 1
+    END AS fiscal_year,
+    SUM(pe.gross_pay),
+    SUM(pe.taxable_pay),
+    SUM(pe.pensionable_pay),
+    SUM(pe.tax_total),
+    SUM(pe.deductions_total),
+    SUM(COALESCE((
+        SELECT SUM(l.amount)
+        FROM payroll_entry_lines l
+        WHERE l.entry_id = pe.id
+            AND l.component_id IN (SELECT employee_component_id FROM payroll_contribution_schemes)
+    ), 0)),
+    SUM(pe.employer_contributions_total),
+    SUM(pe.net_pay)
+FROM payroll_entries pe
+JOIN payroll_batches b ON b.id = pe.batch_id
+WHERE b.status = 'Locked'
+GROUP BY pe.employee_id, 2
+ON CONFLICT (employee_id, fiscal_year) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payroll_cost_allocations;
+DROP TABLE IF EXISTS payroll_projects;
@@ -0,0 +1,26 @@
+-- Projects and grants that salaries are charged to, and each employee's
+-- percentage split across them. A split applies from effective_from (YYYY-MM)
+-- until the employee's next one; its percentages add up to 100.
+CREATE TABLE IF NOT EXISTS payroll_projects (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    funding_source TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payroll_projects_code UNIQUE (code)
+);
+
+CREATE TABLE IF NOT EXISTS payroll_cost_allocations (
+    employee_id BIGINT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    effective_from TEXT NOT NULL,
+    project_id BIGINT NOT NULL REFERENCES payroll_projects(id),
+    percent NUMERIC(7,4) NOT NULL,
+    created_by BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (employee_id, effective_from, project_id),
+    CONSTRAINT chk_payroll_cost_allocations_percent CHECK (percent > 0 AND percent <= 100),
+    CONSTRAINT chk_payroll_cost_allocations_effective_from_format CHECK (effective_from ~ '^[0-9]{4}-(0[1-9]|1[0-2])$')
+);
+CREATE INDEX IF NOT EXISTS idx_payroll_cost_allocations_project_id ON payroll_cost_allocations(project_id);
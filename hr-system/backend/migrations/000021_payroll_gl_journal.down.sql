@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payroll_gl_journal_layout;
+DROP TABLE IF EXISTS payroll_gl_accounts;
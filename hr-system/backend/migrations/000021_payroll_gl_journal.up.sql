@@ -0,0 +1,29 @@
+-- Chart-of-accounts mapping for the payroll journal. BaseSalary and NetPay
+-- are single accounts; every component a Locked batch uses needs a Component
+-- row. Employer contribution components also name the expense account debited
+-- against their payable.
+CREATE TABLE IF NOT EXISTS payroll_gl_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    posting TEXT NOT NULL,
+    component_id BIGINT REFERENCES payroll_components(id) ON DELETE CASCADE,
+    account_code TEXT NOT NULL,
+    account_name TEXT,
+    expense_account_code TEXT,
+    expense_account_name TEXT,
+    updated_by BIGINT REFERENCES users(id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_gl_accounts_posting CHECK (posting IN ('BaseSalary', 'NetPay', 'Component')),
+    CONSTRAINT chk_payroll_gl_accounts_component CHECK ((posting = 'Component') = (component_id IS NOT NULL))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payroll_gl_accounts_posting ON payroll_gl_accounts(posting, COALESCE(component_id, 0));
+
+-- Column layout of the journal CSV; a single row, defaults apply until saved.
+CREATE TABLE IF NOT EXISTS payroll_gl_journal_layout (
+    id SMALLINT PRIMARY KEY,
+    columns TEXT NOT NULL,
+    delimiter TEXT NOT NULL DEFAULT ',',
+    include_header BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by BIGINT REFERENCES users(id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_payroll_gl_journal_layout_singleton CHECK (id = 1)
+);
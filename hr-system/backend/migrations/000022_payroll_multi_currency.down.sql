@@ -0,0 +1,21 @@
+ALTER TABLE payroll_entries
+    DROP CONSTRAINT IF EXISTS chk_payroll_entries_exchange_rate_positive;
+
+ALTER TABLE payroll_entries
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS contract_monthly_salary,
+    DROP COLUMN IF EXISTS contract_currency;
+
+DROP TABLE IF EXISTS payroll_exchange_rates;
+
+ALTER TABLE employee_salary_history
+    DROP CONSTRAINT IF EXISTS chk_employee_salary_history_currency;
+
+ALTER TABLE employee_salary_history
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE employees
+    DROP CONSTRAINT IF EXISTS chk_employees_salary_currency;
+
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS salary_currency;
@@ -0,0 +1,43 @@
+-- Salaries may be contracted in a foreign currency. Payroll is paid in UGX:
+-- generation converts the monthly salary at the rate for the payroll month
+-- and keeps the contract salary and rate on the entry.
+ALTER TABLE employees
+    ADD COLUMN IF NOT EXISTS salary_currency TEXT NOT NULL DEFAULT 'UGX';
+
+ALTER TABLE employees
+    ADD CONSTRAINT chk_employees_salary_currency CHECK (salary_currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE employee_salary_history
+    ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'UGX';
+
+ALTER TABLE employee_salary_history
+    ADD CONSTRAINT chk_employee_salary_history_currency CHECK (currency ~ '^[A-Z]{3}$');
+
+-- UGX per unit of a foreign currency, one rate per month.
+CREATE TABLE IF NOT EXISTS payroll_exchange_rates (
+    month TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    rate NUMERIC(18,6) NOT NULL,
+    updated_by BIGINT REFERENCES users(id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (month, currency),
+    CONSTRAINT chk_payroll_exchange_rates_month_format CHECK (month ~ '^[0-9]{4}-(0[1-9]|1[0-2])$'),
+    CONSTRAINT chk_payroll_exchange_rates_currency CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'UGX'),
+    CONSTRAINT chk_payroll_exchange_rates_rate_positive CHECK (rate > 0)
+);
+
+-- Existing entries were contracted and paid in UGX.
+ALTER TABLE payroll_entries
+    ADD COLUMN IF NOT EXISTS contract_currency TEXT NOT NULL DEFAULT 'UGX',
+    ADD COLUMN IF NOT EXISTS contract_monthly_salary NUMERIC(14,2),
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18,6) NOT NULL DEFAULT 1;
+
+UPDATE payroll_entries
+SET contract_monthly_salary = monthly_base_salary
+WHERE contract_monthly_salary IS NULL;
+
+ALTER TABLE payroll_entries
+    ALTER COLUMN contract_monthly_salary SET NOT NULL;
+
+ALTER TABLE payroll_entries
+    ADD CONSTRAINT chk_payroll_entries_exchange_rate_positive CHECK (exchange_rate > 0);
@@ -0,0 +1,17 @@
+DROP TRIGGER IF EXISTS trg_payroll_entry_loan_deductions_final ON payroll_entry_loan_deductions;
+DROP TRIGGER IF EXISTS trg_payroll_entry_leave_deductions_final ON payroll_entry_leave_deductions;
+DROP TRIGGER IF EXISTS trg_payroll_entry_lines_final ON payroll_entry_lines;
+DROP TRIGGER IF EXISTS trg_payroll_entries_final ON payroll_entries;
+DROP TRIGGER IF EXISTS trg_payroll_batches_final ON payroll_batches;
+
+DROP FUNCTION IF EXISTS payroll_guard_final_entry_detail();
+DROP FUNCTION IF EXISTS payroll_guard_final_entry();
+DROP FUNCTION IF EXISTS payroll_guard_final_batch();
+DROP FUNCTION IF EXISTS payroll_entry_is_final(BIGINT);
+DROP FUNCTION IF EXISTS payroll_batch_is_final(BIGINT);
+
+ALTER TABLE payroll_batches
+    DROP CONSTRAINT IF EXISTS chk_payroll_batches_lock_digest;
+
+ALTER TABLE payroll_batches
+    DROP COLUMN IF EXISTS lock_digest;
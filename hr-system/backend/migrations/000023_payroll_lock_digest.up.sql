@@ -0,0 +1,107 @@
+-- SHA-256 over the batch and its entries and lines, taken when the batch is
+-- locked. Batches locked before this migration have none.
+ALTER TABLE payroll_batches
+    ADD COLUMN IF NOT EXISTS lock_digest TEXT;
+
+ALTER TABLE payroll_batches
+    ADD CONSTRAINT chk_payroll_batches_lock_digest CHECK (lock_digest IS NULL OR lock_digest ~ '^[0-9a-f]{64}$');
+
+-- Locked and Reversed batches are final. The only change allowed to one is
+-- marking a Locked batch Reversed.
+CREATE OR REPLACE FUNCTION payroll_batch_is_final(target_batch_id BIGINT) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1
+        FROM payroll_batches
+        WHERE id = target_batch_id
+            AND status IN ('Locked', 'Reversed')
+    );
+$$ LANGUAGE SQL STABLE;
+
+CREATE OR REPLACE FUNCTION payroll_entry_is_final(target_entry_id BIGINT) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1
+        FROM payroll_entries pe
+        JOIN payroll_batches b ON b.id = pe.batch_id
+        WHERE pe.id = target_entry_id
+            AND b.status IN ('Locked', 'Reversed')
+    );
+$$ LANGUAGE SQL STABLE;
+
+CREATE OR REPLACE FUNCTION payroll_guard_final_batch() RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status IN ('Locked', 'Reversed') THEN
+        IF TG_OP = 'DELETE' THEN
+            RAISE EXCEPTION 'payroll batch % is %', OLD.id, OLD.status USING ERRCODE = 'check_violation';
+        END IF;
+        IF NOT (NEW.status = OLD.status OR (OLD.status = 'Locked' AND NEW.status = 'Reversed'))
+            OR ROW(NEW.id, NEW.month, NEW.batch_type, NEW.description, NEW.corrects_batch_id, NEW.created_by, NEW.approved_by, NEW.approved_at, NEW.locked_at, NEW.lock_digest)
+                IS DISTINCT FROM ROW(OLD.id, OLD.month, OLD.batch_type, OLD.description, OLD.corrects_batch_id, OLD.created_by, OLD.approved_by, OLD.approved_at, OLD.locked_at, OLD.lock_digest) THEN
+            RAISE EXCEPTION 'payroll batch % is %', OLD.id, OLD.status USING ERRCODE = 'check_violation';
+        END IF;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION payroll_guard_final_entry() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF payroll_batch_is_final(OLD.batch_id) THEN
+            RAISE EXCEPTION 'payroll entry % belongs to locked batch %', OLD.id, OLD.batch_id USING ERRCODE = 'check_violation';
+        END IF;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    IF payroll_batch_is_final(NEW.batch_id) THEN
+        RAISE EXCEPTION 'payroll batch % is locked', NEW.batch_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Lines and the leave and loan deductions behind them belong to an entry.
+CREATE OR REPLACE FUNCTION payroll_guard_final_entry_detail() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF payroll_entry_is_final(OLD.entry_id) THEN
+            RAISE EXCEPTION 'payroll entry % belongs to a locked batch', OLD.entry_id USING ERRCODE = 'check_violation';
+        END IF;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    IF payroll_entry_is_final(NEW.entry_id) THEN
+        RAISE EXCEPTION 'payroll entry % belongs to a locked batch', NEW.entry_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_payroll_batches_final ON payroll_batches;
+CREATE TRIGGER trg_payroll_batches_final
+    BEFORE UPDATE OR DELETE ON payroll_batches
+    FOR EACH ROW EXECUTE FUNCTION payroll_guard_final_batch();
+
+DROP TRIGGER IF EXISTS trg_payroll_entries_final ON payroll_entries;
+CREATE TRIGGER trg_payroll_entries_final
+    BEFORE INSERT OR UPDATE OR DELETE ON payroll_entries
+    FOR EACH ROW EXECUTE FUNCTION payroll_guard_final_entry();
+
+DROP TRIGGER IF EXISTS trg_payroll_entry_lines_final ON payroll_entry_lines;
+CREATE TRIGGER trg_payroll_entry_lines_final
+    BEFORE INSERT OR UPDATE OR DELETE ON payroll_entry_lines
+    FOR EACH ROW EXECUTE FUNCTION payroll_guard_final_entry_detail();
+
+DROP TRIGGER IF EXISTS trg_payroll_entry_leave_deductions_final ON payroll_entry_leave_deductions;
+CREATE TRIGGER trg_payroll_entry_leave_deductions_final
+    BEFORE INSERT OR UPDATE OR DELETE ON payroll_entry_leave_deductions
+    FOR EACH ROW EXECUTE FUNCTION payroll_guard_final_entry_detail();
+
+DROP TRIGGER IF EXISTS trg_payroll_entry_loan_deductions_final ON payroll_entry_loan_deductions;
+CREATE TRIGGER trg_payroll_entry_loan_deductions_final
+    BEFORE INSERT OR UPDATE OR DELETE ON payroll_entry_loan_deductions
+    FOR EACH ROW EXECUTE FUNCTION payroll_guard_final_entry_detail();